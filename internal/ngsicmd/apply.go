@@ -0,0 +1,472 @@
+/*
+MIT License
+
+Copyright (c) 2020 Kazuhito Suda
+
+This file is part of NGSI Go
+
+https://github.com/lets-fiware/ngsi-go
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+package ngsicmd
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+
+	"github.com/lets-fiware/ngsi-go/internal/ngsilib"
+	"github.com/urfave/cli/v2"
+)
+
+type applyResource struct {
+	name  string
+	path  string
+	patch bool
+}
+
+type applyDocument struct {
+	Subscriptions []map[string]interface{} `json:"subscriptions"`
+	Registrations []map[string]interface{} `json:"registrations"`
+}
+
+type applyAction struct {
+	action   string
+	resource *applyResource
+	key      string
+	id       string
+	data     map[string]interface{}
+}
+
+const (
+	applyCreate    = "create"
+	applyUpdate    = "update"
+	applyReplace   = "replace"
+	applyDelete    = "delete"
+	applyUnchanged = "unchanged"
+)
+
+func apply(c *cli.Context) error {
+	const funcName = "apply"
+
+	ngsi, err := initCmd(c, funcName, true)
+	if err != nil {
+		return &ngsiCmdError{funcName, 1, err.Error(), err}
+	}
+
+	client, err := newClient(ngsi, c, false)
+	if err != nil {
+		return &ngsiCmdError{funcName, 2, err.Error(), err}
+	}
+
+	doc, err := applyReadDocument(ngsi, c.String("file"))
+	if err != nil {
+		return &ngsiCmdError{funcName, 3, err.Error(), err}
+	}
+
+	key := c.String("key")
+	if key == "" {
+		key = "description"
+	}
+	prune := c.Bool("prune")
+
+	subscriptions := &applyResource{name: "subscription", path: "/subscriptions", patch: true}
+	registrations := &applyResource{name: "registration", path: "/registrations"}
+	if client.IsNgsiLd() {
+		registrations = &applyResource{name: "registration", path: "/csourceRegistrations", patch: true}
+	}
+
+	var actions []*applyAction
+
+	for _, r := range []struct {
+		resource *applyResource
+		desired  []map[string]interface{}
+	}{{subscriptions, doc.Subscriptions}, {registrations, doc.Registrations}} {
+		// a resource which is not in the file is left as it is even if --prune is specified
+		if r.desired == nil {
+			continue
+		}
+		existing, err := applyList(client, r.resource.path)
+		if err != nil {
+			return &ngsiCmdError{funcName, 4, err.Error(), err}
+		}
+		a, err := applyPlan(r.resource, r.desired, existing, key, prune)
+		if err != nil {
+			return &ngsiCmdError{funcName, 5, err.Error(), err}
+		}
+		actions = append(actions, a...)
+	}
+
+	dryRun := c.Bool("dryRun")
+
+	for _, a := range actions {
+		if a.id == "" {
+			fmt.Fprintf(ngsi.StdWriter, "%s %s %q\n", a.action, a.resource.name, a.key)
+		} else {
+			fmt.Fprintf(ngsi.StdWriter, "%s %s %q (%s)\n", a.action, a.resource.name, a.key, a.id)
+		}
+		if dryRun || a.action == applyUnchanged {
+			continue
+		}
+		if err := applyRun(c, ngsi, client, a); err != nil {
+			return &ngsiCmdError{funcName, 6, err.Error(), err}
+		}
+	}
+
+	return nil
+}
+
+// applyReadDocument reads desired subscriptions and registrations. The file is an object which
+// has "subscriptions" and "registrations", or an array of subscriptions.
+func applyReadDocument(ngsi *ngsilib.NGSI, file string) (*applyDocument, error) {
+	const funcName = "applyReadDocument"
+
+	var b []byte
+	var err error
+
+	if file == "-" || file == "stdin" {
+		b, err = ngsi.FileReader.ReadAll(ngsi.StdReader)
+		if err != nil {
+			return nil, &ngsiCmdError{funcName, 1, err.Error(), err}
+		}
+	} else {
+		path, err := ngsi.FileReader.FilePathAbs(file)
+		if err != nil {
+			return nil, &ngsiCmdError{funcName, 2, err.Error(), err}
+		}
+		b, err = ngsi.FileReader.ReadFile(path)
+		if err != nil {
+			return nil, &ngsiCmdError{funcName, 3, err.Error(), err}
+		}
+	}
+
+	doc := &applyDocument{}
+
+	if strings.HasPrefix(strings.TrimSpace(string(b)), "[") {
+		if err := ngsilib.JSONUnmarshal(b, &doc.Subscriptions); err != nil {
+			return nil, &ngsiCmdError{funcName, 4, err.Error(), err}
+		}
+		return doc, nil
+	}
+
+	if err := ngsilib.JSONUnmarshal(b, doc); err != nil {
+		return nil, &ngsiCmdError{funcName, 5, err.Error(), err}
+	}
+
+	return doc, nil
+}
+
+func applyList(client *ngsilib.Client, path string) ([]map[string]interface{}, error) {
+	const funcName = "applyList"
+
+	limit := 100
+	var items []map[string]interface{}
+
+	for offset, count := 0, 1; offset < count; offset += limit {
+		client.SetPath(path)
+
+		v := url.Values{}
+		if client.IsNgsiLd() {
+			v.Set("count", "true")
+		} else {
+			v.Set("options", "count")
+		}
+		v.Set("limit", fmt.Sprintf("%d", limit))
+		v.Set("offset", fmt.Sprintf("%d", offset))
+		client.SetQuery(&v)
+
+		res, body, err := client.HTTPGet()
+		if err != nil {
+			return nil, &ngsiCmdError{funcName, 1, err.Error(), err}
+		}
+		if res.StatusCode != http.StatusOK {
+			return nil, &ngsiCmdError{funcName, 2, fmt.Sprintf("%s %s", res.Status, string(body)), nil}
+		}
+		count, err = client.ResultsCount(res)
+		if err != nil {
+			return nil, &ngsiCmdError{funcName, 3, "ResultsCount error", err}
+		}
+		if count == 0 {
+			break
+		}
+
+		var page []map[string]interface{}
+		if err := ngsilib.JSONUnmarshalDecode(body, &page, client.IsSafeString()); err != nil {
+			return nil, &ngsiCmdError{funcName, 4, err.Error(), err}
+		}
+		items = append(items, page...)
+	}
+
+	return items, nil
+}
+
+// applyPlan matches desired items with existing items by the value of key. When existing items have
+// the same value, the first one is matched and the others are extras.
+func applyPlan(resource *applyResource, desired, existing []map[string]interface{}, key string, prune bool) ([]*applyAction, error) {
+	const funcName = "applyPlan"
+
+	index := make(map[string][]map[string]interface{})
+	for _, e := range existing {
+		if k, ok := e[key].(string); ok {
+			index[k] = append(index[k], e)
+		}
+	}
+
+	var actions []*applyAction
+	matched := make(map[string]bool)
+
+	for i, d := range desired {
+		k, ok := d[key].(string)
+		if !ok || k == "" {
+			return nil, &ngsiCmdError{funcName, 1, fmt.Sprintf("%s not found in %s #%d", key, resource.name, i+1), nil}
+		}
+		for _, prev := range desired[:i] {
+			if prev[key] == k {
+				return nil, &ngsiCmdError{funcName, 2, fmt.Sprintf("duplicate %s in %ss: %s", key, resource.name, k), nil}
+			}
+		}
+
+		candidates := index[k]
+		if len(candidates) == 0 {
+			actions = append(actions, &applyAction{action: applyCreate, resource: resource, key: k, data: d})
+			continue
+		}
+
+		e := candidates[0]
+		id, _ := e["id"].(string)
+		matched[id] = true
+
+		switch {
+		case applyContains(e, d):
+			actions = append(actions, &applyAction{action: applyUnchanged, resource: resource, key: k, id: id})
+		case resource.patch:
+			actions = append(actions, &applyAction{action: applyUpdate, resource: resource, key: k, id: id, data: d})
+		default:
+			actions = append(actions, &applyAction{action: applyReplace, resource: resource, key: k, id: id, data: d})
+		}
+	}
+
+	if prune {
+		for _, e := range existing {
+			id, _ := e["id"].(string)
+			if !matched[id] {
+				k, _ := e[key].(string)
+				actions = append(actions, &applyAction{action: applyDelete, resource: resource, key: k, id: id})
+			}
+		}
+	}
+
+	return actions, nil
+}
+
+// applyContains returns true if existing has all fields of desired except id and @context.
+func applyContains(existing, desired map[string]interface{}) bool {
+	for k, v := range desired {
+		if k == "id" || k == "@context" {
+			continue
+		}
+		if !applyEqual(v, existing[k]) {
+			return false
+		}
+	}
+	return true
+}
+
+func applyEqual(desired, existing interface{}) bool {
+	switch d := desired.(type) {
+	case map[string]interface{}:
+		e, ok := existing.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		for k, v := range d {
+			if !applyEqual(v, e[k]) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		e, ok := existing.([]interface{})
+		if !ok || len(d) != len(e) {
+			return false
+		}
+		for i := range d {
+			if !applyEqual(d[i], e[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	return reflect.DeepEqual(desired, existing)
+}
+
+func applyRun(c *cli.Context, ngsi *ngsilib.NGSI, client *ngsilib.Client, a *applyAction) error {
+	const funcName = "applyRun"
+
+	switch a.action {
+	case applyCreate:
+		id, err := applyPost(client, a.resource, a.data)
+		if err != nil {
+			return &ngsiCmdError{funcName, 1, err.Error(), err}
+		}
+		ngsi.Logging(ngsilib.LogInfo, fmt.Sprintf("%s %s is created\n", a.resource.name, id))
+	case applyUpdate:
+		var err error
+		if a.resource.name == "subscription" {
+			err = applyUpdateSubscription(c, ngsi, client, a.id, a.data)
+		} else {
+			err = applyPatch(client, a.resource, a.id, a.data)
+		}
+		if err != nil {
+			return &ngsiCmdError{funcName, 2, err.Error(), err}
+		}
+		ngsi.Logging(ngsilib.LogInfo, fmt.Sprintf("%s %s is updated\n", a.resource.name, a.id))
+	case applyReplace:
+		// the new one is created first so that nothing is lost when the creation fails
+		id, err := applyPost(client, a.resource, a.data)
+		if err != nil {
+			return &ngsiCmdError{funcName, 3, err.Error(), err}
+		}
+		if err := applyDeleteItem(client, a.resource, a.id); err != nil {
+			msg := fmt.Sprintf("%s %s is created but %s is not deleted: %s", a.resource.name, id, a.id, err.Error())
+			return &ngsiCmdError{funcName, 4, msg, err}
+		}
+		ngsi.Logging(ngsilib.LogInfo, fmt.Sprintf("%s %s is replaced by %s\n", a.resource.name, a.id, id))
+	case applyDelete:
+		if err := applyDeleteItem(client, a.resource, a.id); err != nil {
+			return &ngsiCmdError{funcName, 5, err.Error(), err}
+		}
+		ngsi.Logging(ngsilib.LogInfo, fmt.Sprintf("%s %s is deleted\n", a.resource.name, a.id))
+	}
+
+	return nil
+}
+
+// applyUpdateSubscription updates a subscription in the same way as the update subscription command.
+func applyUpdateSubscription(c *cli.Context, ngsi *ngsilib.NGSI, client *ngsilib.Client, id string, data map[string]interface{}) error {
+	const funcName = "applyUpdateSubscription"
+
+	b, err := applyBody(client, data, false)
+	if err != nil {
+		return &ngsiCmdError{funcName, 1, err.Error(), err}
+	}
+
+	set := flag.NewFlagSet("update", flag.ContinueOnError)
+	set.String("id", "", "")
+	set.String("data", "", "")
+	if err := set.Parse([]string{"--id=" + id, "--data=" + strings.TrimSpace(string(b))}); err != nil {
+		return &ngsiCmdError{funcName, 2, err.Error(), err}
+	}
+
+	client.SetQuery(&url.Values{})
+
+	ctx := cli.NewContext(c.App, set, nil)
+	if client.IsNgsiV2() {
+		return subscriptionsUpdateV2(ctx, ngsi, client)
+	}
+	return subscriptionsUpdateLd(ctx, ngsi, client)
+}
+
+// applyBody returns a payload of an item. The id is removed except for NGSI-LD creation,
+// where the id may be chosen by the client.
+func applyBody(client *ngsilib.Client, data map[string]interface{}, keepID bool) ([]byte, error) {
+	body := make(map[string]interface{}, len(data))
+	for k, v := range data {
+		if k == "id" && !(keepID && client.IsNgsiLd()) {
+			continue
+		}
+		body[k] = v
+	}
+	return ngsilib.JSONMarshalEncode(body, client.IsSafeString())
+}
+
+func applyPost(client *ngsilib.Client, resource *applyResource, data map[string]interface{}) (string, error) {
+	const funcName = "applyPost"
+
+	b, err := applyBody(client, data, true)
+	if err != nil {
+		return "", &ngsiCmdError{funcName, 1, err.Error(), err}
+	}
+
+	client.SetPath(resource.path)
+	client.SetQuery(&url.Values{})
+	client.SetContentType()
+
+	res, body, err := client.HTTPPost(b)
+	if err != nil {
+		return "", &ngsiCmdError{funcName, 2, err.Error(), err}
+	}
+	if res.StatusCode != http.StatusCreated {
+		return "", &ngsiCmdError{funcName, 3, fmt.Sprintf("%s %s", res.Status, string(body)), nil}
+	}
+
+	location := res.Header.Get("Location")
+	if i := strings.LastIndex(location, "/"); i >= 0 {
+		location = location[i+1:]
+	}
+
+	return location, nil
+}
+
+// applyPatch updates a registration. NGSI-LD registrations have no update command to go through.
+func applyPatch(client *ngsilib.Client, resource *applyResource, id string, data map[string]interface{}) error {
+	const funcName = "applyPatch"
+
+	b, err := applyBody(client, data, false)
+	if err != nil {
+		return &ngsiCmdError{funcName, 1, err.Error(), err}
+	}
+
+	client.SetPath(resource.path + "/" + id)
+	client.SetQuery(&url.Values{})
+	client.SetContentType()
+
+	res, body, err := client.HTTPPatch(b)
+	if err != nil {
+		return &ngsiCmdError{funcName, 2, err.Error(), err}
+	}
+	if res.StatusCode != http.StatusNoContent {
+		return &ngsiCmdError{funcName, 3, fmt.Sprintf("%s %s %s", res.Status, string(body), id), nil}
+	}
+
+	return nil
+}
+
+func applyDeleteItem(client *ngsilib.Client, resource *applyResource, id string) error {
+	const funcName = "applyDeleteItem"
+
+	client.SetPath(resource.path + "/" + id)
+	client.SetQuery(&url.Values{})
+
+	res, body, err := client.HTTPDelete()
+	if err != nil {
+		return &ngsiCmdError{funcName, 1, err.Error(), err}
+	}
+	if res.StatusCode != http.StatusNoContent {
+		return &ngsiCmdError{funcName, 2, fmt.Sprintf("%s %s %s", res.Status, string(body), id), nil}
+	}
+
+	return nil
+}
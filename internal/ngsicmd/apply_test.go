@@ -0,0 +1,780 @@
+/*
+MIT License
+
+Copyright (c) 2020 Kazuhito Suda
+
+This file is part of NGSI Go
+
+https://github.com/lets-fiware/ngsi-go
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+package ngsicmd
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/lets-fiware/ngsi-go/internal/ngsilib"
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/cli/v2"
+)
+
+var testApplyDesired = `{"subscriptions":[` +
+	`{"description":"sub1","subject":{"entities":[{"idPattern":".*"}]},"notification":{"http":{"url":"http://receiver/1"}}},` +
+	`{"description":"sub2","subject":{"entities":[{"idPattern":".*"}]},"notification":{"http":{"url":"http://receiver/2"}}},` +
+	`{"description":"sub3","notification":{"http":{"url":"http://receiver/3"}}}]}`
+
+var testApplyExisting = `[` +
+	`{"id":"5f0a","description":"sub1","status":"active","subject":{"entities":[{"idPattern":".*"}],"condition":{"attrs":[]}},"notification":{"timesSent":1,"http":{"url":"http://receiver/1"}}},` +
+	`{"id":"5f0b","description":"sub2","status":"active","subject":{"entities":[{"idPattern":".*"}],"condition":{"attrs":[]}},"notification":{"http":{"url":"http://receiver/old"}}},` +
+	`{"id":"5f0c","description":"sub4","status":"active","notification":{"http":{"url":"http://receiver/4"}}}]`
+
+func setupApplyList(body string, count string) MockHTTPReqRes {
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusOK
+	reqRes.ResBody = []byte(body)
+	reqRes.ResHeader = http.Header{"Fiware-Total-Count": []string{count}}
+	reqRes.Path = "/v2/subscriptions"
+	return reqRes
+}
+
+func TestApply(t *testing.T) {
+	ngsi, set, app, buf := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+
+	setupFlagString(set, "host,file")
+	setupFlagBool(set, "prune")
+	ngsi.FileReader = &MockFileLib{filePathAbs: "subscriptions.json", readFile: []byte(testApplyDesired)}
+
+	reqRes2 := MockHTTPReqRes{}
+	reqRes2.Res.StatusCode = http.StatusNoContent
+	reqRes2.ReqData = []byte(`{"description":"sub2","subject":{"entities":[{"idPattern":".*"}]},"notification":{"http":{"url":"http://receiver/2"}}}`)
+	reqRes2.Path = "/v2/subscriptions/5f0b"
+	reqRes3 := MockHTTPReqRes{}
+	reqRes3.Res.StatusCode = http.StatusCreated
+	reqRes3.ReqData = []byte(`{"description":"sub3","notification":{"http":{"url":"http://receiver/3"}}}`)
+	reqRes3.ResHeader = http.Header{"Location": []string{"/v2/subscriptions/5f0d"}}
+	reqRes3.Path = "/v2/subscriptions"
+	reqRes4 := MockHTTPReqRes{}
+	reqRes4.Res.StatusCode = http.StatusNoContent
+	reqRes4.Path = "/v2/subscriptions/5f0c"
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, setupApplyList(testApplyExisting, "3"), reqRes2, reqRes3, reqRes4)
+	ngsi.HTTP = mock
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", "--file=subscriptions.json", "--prune"})
+
+	err := apply(c)
+
+	if assert.NoError(t, err) {
+		expected := "unchanged subscription \"sub1\" (5f0a)\n" +
+			"update subscription \"sub2\" (5f0b)\n" +
+			"create subscription \"sub3\"\n" +
+			"delete subscription \"sub4\" (5f0c)\n"
+		assert.Equal(t, expected, buf.String())
+		assert.Equal(t, 4, mock.index)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestApplyDryRun(t *testing.T) {
+	ngsi, set, app, buf := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+
+	setupFlagString(set, "host,file")
+	setupFlagBool(set, "dryRun")
+	ngsi.FileReader = &MockFileLib{filePathAbs: "subscriptions.json", readFile: []byte(testApplyDesired)}
+
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, setupApplyList(testApplyExisting, "3"))
+	ngsi.HTTP = mock
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", "--file=subscriptions.json", "--dryRun"})
+
+	err := apply(c)
+
+	if assert.NoError(t, err) {
+		expected := "unchanged subscription \"sub1\" (5f0a)\n" +
+			"update subscription \"sub2\" (5f0b)\n" +
+			"create subscription \"sub3\"\n"
+		assert.Equal(t, expected, buf.String())
+		assert.Equal(t, 1, mock.index)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestApplyStdinArray(t *testing.T) {
+	ngsi, set, app, buf := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+
+	setupFlagString(set, "host,file,key")
+	setupFlagBool(set, "dryRun")
+	ngsi.FileReader = &MockFileLib{readall: []byte(`[{"name":"sub1","description":"first"}]`)}
+
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, setupApplyList(`[{"id":"5f0a","name":"sub1","description":"first"}]`, "1"))
+	ngsi.HTTP = mock
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", "--file=-", "--key=name", "--dryRun"})
+
+	err := apply(c)
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, "unchanged subscription \"sub1\" (5f0a)\n", buf.String())
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestApplyRegistrationsV2(t *testing.T) {
+	ngsi, set, app, buf := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+
+	setupFlagString(set, "host,file")
+	ngsi.FileReader = &MockFileLib{filePathAbs: "registrations.json", readFile: []byte(`{"registrations":[{"description":"reg1","provider":{"http":{"url":"http://provider/new"}}}]}`)}
+
+	reqRes1 := MockHTTPReqRes{}
+	reqRes1.Res.StatusCode = http.StatusOK
+	reqRes1.ResBody = []byte(`[{"id":"5f1a","description":"reg1","provider":{"http":{"url":"http://provider/old"}}}]`)
+	reqRes1.ResHeader = http.Header{"Fiware-Total-Count": []string{"1"}}
+	reqRes1.Path = "/v2/registrations"
+	reqRes2 := MockHTTPReqRes{}
+	reqRes2.Res.StatusCode = http.StatusCreated
+	reqRes2.ResHeader = http.Header{"Location": []string{"/v2/registrations/5f1b"}}
+	reqRes2.Path = "/v2/registrations"
+	reqRes3 := MockHTTPReqRes{}
+	reqRes3.Res.StatusCode = http.StatusNoContent
+	reqRes3.Path = "/v2/registrations/5f1a"
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes1, reqRes2, reqRes3)
+	ngsi.HTTP = mock
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", "--file=registrations.json"})
+
+	err := apply(c)
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, "replace registration \"reg1\" (5f1a)\n", buf.String())
+		assert.Equal(t, 3, mock.index)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestApplyLd(t *testing.T) {
+	ngsi, set, app, buf := setupTest()
+
+	setupAddBroker(t, ngsi, "orion-ld", "https://orion-ld", "ld")
+
+	setupFlagString(set, "host,file")
+	ngsi.FileReader = &MockFileLib{filePathAbs: "subscriptions.json", readFile: []byte(`[{"id":"urn:ngsi-ld:Subscription:001","type":"Subscription","description":"sub1","@context":"https://context"}]`)}
+
+	reqRes1 := MockHTTPReqRes{}
+	reqRes1.Res.StatusCode = http.StatusOK
+	reqRes1.ResBody = []byte(`[]`)
+	reqRes1.ResHeader = http.Header{"Ngsild-Results-Count": []string{"0"}}
+	reqRes1.Path = "/ngsi-ld/v1/subscriptions"
+	reqRes2 := MockHTTPReqRes{}
+	reqRes2.Res.StatusCode = http.StatusCreated
+	reqRes2.ReqData = []byte(`{"@context":"https://context","description":"sub1","id":"urn:ngsi-ld:Subscription:001","type":"Subscription"}`)
+	reqRes2.ResHeader = http.Header{"Location": []string{"/ngsi-ld/v1/subscriptions/urn:ngsi-ld:Subscription:001"}}
+	reqRes2.Path = "/ngsi-ld/v1/subscriptions"
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes1, reqRes2)
+	ngsi.HTTP = mock
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion-ld", "--file=subscriptions.json"})
+
+	err := apply(c)
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, "create subscription \"sub1\"\n", buf.String())
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestApplyErrorInitCmd(t *testing.T) {
+	_, set, app, _ := setupTest()
+
+	c := cli.NewContext(app, set, nil)
+
+	err := apply(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 1, ngsiErr.ErrNo)
+		assert.Equal(t, "Required host not found", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestApplyErrorNewClient(t *testing.T) {
+	_, set, app, _ := setupTest()
+
+	setupFlagString(set, "host,link")
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", "--link=abc"})
+
+	err := apply(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 2, ngsiErr.ErrNo)
+		assert.Equal(t, "abc not found", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestApplyErrorReadFile(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+
+	setupFlagString(set, "host,file")
+	ngsi.FileReader = &MockFileLib{filePathAbs: "subscriptions.json", readFileError: errors.New("read error")}
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", "--file=subscriptions.json"})
+
+	err := apply(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 3, ngsiErr.ErrNo)
+		assert.Equal(t, "read error", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestApplyErrorList(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+
+	setupFlagString(set, "host,file")
+	ngsi.FileReader = &MockFileLib{filePathAbs: "subscriptions.json", readFile: []byte(testApplyDesired)}
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Err = errors.New("http error")
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", "--file=subscriptions.json"})
+
+	err := apply(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 4, ngsiErr.ErrNo)
+		assert.Equal(t, "http error", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestApplyErrorPlan(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+
+	setupFlagString(set, "host,file")
+	ngsi.FileReader = &MockFileLib{filePathAbs: "subscriptions.json", readFile: []byte(`[{"subject":{}}]`)}
+
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, setupApplyList(`[]`, "0"))
+	ngsi.HTTP = mock
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", "--file=subscriptions.json"})
+
+	err := apply(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 5, ngsiErr.ErrNo)
+		assert.Equal(t, "description not found in subscription #1", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestApplyErrorRun(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+
+	setupFlagString(set, "host,file")
+	ngsi.FileReader = &MockFileLib{filePathAbs: "subscriptions.json", readFile: []byte(`[{"description":"sub1"}]`)}
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusBadRequest
+	reqRes.Res.Status = "400 Bad Request"
+	reqRes.ResBody = []byte("error")
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, setupApplyList(`[]`, "0"), reqRes)
+	ngsi.HTTP = mock
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", "--file=subscriptions.json"})
+
+	err := apply(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 6, ngsiErr.ErrNo)
+		assert.Equal(t, "400 Bad Request error", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestApplyReadDocumentErrorReadAll(t *testing.T) {
+	ngsi, _, _, _ := setupTest()
+
+	ngsi.FileReader = &MockFileLib{readallError: errors.New("readall error")}
+
+	_, err := applyReadDocument(ngsi, "stdin")
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 1, ngsiErr.ErrNo)
+		assert.Equal(t, "readall error", ngsiErr.Message)
+	}
+}
+
+func TestApplyReadDocumentErrorFilePathAbs(t *testing.T) {
+	ngsi, _, _, _ := setupTest()
+
+	ngsi.FileReader = &MockFileLib{filePathAbsError: errors.New("path error")}
+
+	_, err := applyReadDocument(ngsi, "file")
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 2, ngsiErr.ErrNo)
+		assert.Equal(t, "path error", ngsiErr.Message)
+	}
+}
+
+func TestApplyReadDocumentErrorReadFile(t *testing.T) {
+	ngsi, _, _, _ := setupTest()
+
+	ngsi.FileReader = &MockFileLib{filePathAbs: "file", readFileError: errors.New("read error")}
+
+	_, err := applyReadDocument(ngsi, "file")
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 3, ngsiErr.ErrNo)
+		assert.Equal(t, "read error", ngsiErr.Message)
+	}
+}
+
+func TestApplyReadDocumentErrorArray(t *testing.T) {
+	ngsi, _, _, _ := setupTest()
+
+	ngsi.FileReader = &MockFileLib{filePathAbs: "file", readFile: []byte(`[{"description":}]`)}
+
+	_, err := applyReadDocument(ngsi, "file")
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 4, ngsiErr.ErrNo)
+		assert.Equal(t, "invalid character '}' looking for beginning of value (17) \"description\":}]", ngsiErr.Message)
+	}
+}
+
+func TestApplyReadDocumentErrorObject(t *testing.T) {
+	ngsi, _, _, _ := setupTest()
+
+	ngsi.FileReader = &MockFileLib{filePathAbs: "file", readFile: []byte(`{"subscriptions":{}}`)}
+
+	_, err := applyReadDocument(ngsi, "file")
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 5, ngsiErr.ErrNo)
+	}
+}
+
+func TestApplyListErrorHTTPStatus(t *testing.T) {
+	ngsi, _, _, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusBadRequest
+	reqRes.Res.Status = "400 Bad Request"
+	reqRes.ResBody = []byte("error")
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	client, _ := ngsi.NewClient("orion", &ngsilib.CmdFlags{}, false)
+
+	_, err := applyList(client, "/subscriptions")
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 2, ngsiErr.ErrNo)
+		assert.Equal(t, "400 Bad Request error", ngsiErr.Message)
+	}
+}
+
+func TestApplyListErrorResultsCount(t *testing.T) {
+	ngsi, _, _, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusOK
+	reqRes.ResBody = []byte(`[]`)
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	client, _ := ngsi.NewClient("orion", &ngsilib.CmdFlags{}, false)
+
+	_, err := applyList(client, "/subscriptions")
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 3, ngsiErr.ErrNo)
+		assert.Equal(t, "ResultsCount error", ngsiErr.Message)
+	}
+}
+
+func TestApplyListErrorUnmarshal(t *testing.T) {
+	ngsi, _, _, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, setupApplyList(`[{"id":}]`, "1"))
+	ngsi.HTTP = mock
+	client, _ := ngsi.NewClient("orion", &ngsilib.CmdFlags{}, false)
+
+	_, err := applyList(client, "/subscriptions")
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 4, ngsiErr.ErrNo)
+	}
+}
+
+func TestApplyPlanDuplicateExisting(t *testing.T) {
+	resource := &applyResource{name: "subscription", path: "/subscriptions", patch: true}
+	desired := []map[string]interface{}{{"description": "sub1"}}
+	existing := []map[string]interface{}{{"id": "1", "description": "sub1"}, {"id": "2", "description": "sub1"}}
+
+	actions, err := applyPlan(resource, desired, existing, "description", true)
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, 2, len(actions))
+		assert.Equal(t, applyUnchanged, actions[0].action)
+		assert.Equal(t, "1", actions[0].id)
+		assert.Equal(t, applyDelete, actions[1].action)
+		assert.Equal(t, "2", actions[1].id)
+	}
+}
+
+func TestApplyPlanErrorDuplicate(t *testing.T) {
+	resource := &applyResource{name: "subscription", path: "/subscriptions", patch: true}
+	desired := []map[string]interface{}{{"description": "sub1"}, {"description": "sub1"}}
+
+	_, err := applyPlan(resource, desired, nil, "description", false)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 2, ngsiErr.ErrNo)
+		assert.Equal(t, "duplicate description in subscriptions: sub1", ngsiErr.Message)
+	}
+}
+
+func TestApplyContains(t *testing.T) {
+	existing := map[string]interface{}{"id": "1", "a": []interface{}{"x", map[string]interface{}{"b": 1.0, "c": 2.0}}}
+
+	assert.True(t, applyContains(existing, map[string]interface{}{"id": "2", "@context": "ctx", "a": []interface{}{"x", map[string]interface{}{"b": 1.0}}}))
+	assert.False(t, applyContains(existing, map[string]interface{}{"a": []interface{}{"x"}}))
+	assert.False(t, applyContains(existing, map[string]interface{}{"a": []interface{}{"x", "y"}}))
+	assert.False(t, applyContains(existing, map[string]interface{}{"a": "x"}))
+	assert.False(t, applyContains(existing, map[string]interface{}{"a": []interface{}{"x", map[string]interface{}{"b": 2.0}}}))
+	assert.False(t, applyContains(map[string]interface{}{"a": "x"}, map[string]interface{}{"a": map[string]interface{}{}}))
+}
+
+func TestApplyRunError(t *testing.T) {
+	cases := []struct {
+		action  string
+		errNo   int
+		n       int
+		message string
+	}{
+		{applyCreate, 1, 1, "http error"},
+		{applyUpdate, 2, 1, "http error"},
+		{applyReplace, 3, 1, "http error"},
+		{applyReplace, 4, 2, "registration 5f1b is created but 5f1a is not deleted: http error"},
+		{applyDelete, 5, 1, "http error"},
+	}
+
+	for _, c := range cases {
+		ngsi, set, app, _ := setupTest()
+
+		setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+
+		reqRes1 := MockHTTPReqRes{}
+		reqRes1.Res.StatusCode = http.StatusCreated
+		reqRes1.ResHeader = http.Header{"Location": []string{"/v2/registrations/5f1b"}}
+		reqRes1.Path = "/v2/registrations"
+		reqRes2 := MockHTTPReqRes{}
+		reqRes2.Err = errors.New("http error")
+		mock := NewMockHTTP()
+		if c.n == 2 {
+			mock.ReqRes = append(mock.ReqRes, reqRes1)
+		}
+		mock.ReqRes = append(mock.ReqRes, reqRes2)
+		ngsi.HTTP = mock
+		client, _ := ngsi.NewClient("orion", &ngsilib.CmdFlags{}, false)
+
+		resource := &applyResource{name: "registration", path: "/registrations"}
+		a := &applyAction{action: c.action, resource: resource, key: "reg1", id: "5f1a", data: map[string]interface{}{"description": "reg1"}}
+
+		err := applyRun(cli.NewContext(app, set, nil), ngsi, client, a)
+
+		if assert.Error(t, err) {
+			ngsiErr := err.(*ngsiCmdError)
+			assert.Equal(t, c.errNo, ngsiErr.ErrNo)
+			assert.Equal(t, c.message, ngsiErr.Message)
+		}
+	}
+}
+
+func TestApplyRunReplace(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+
+	reqRes1 := MockHTTPReqRes{}
+	reqRes1.Res.StatusCode = http.StatusCreated
+	reqRes1.ReqData = []byte(`{"description":"reg1"}`)
+	reqRes1.ResHeader = http.Header{"Location": []string{"/v2/registrations/5f1b"}}
+	reqRes1.Path = "/v2/registrations"
+	reqRes2 := MockHTTPReqRes{}
+	reqRes2.Res.StatusCode = http.StatusNoContent
+	reqRes2.Path = "/v2/registrations/5f1a"
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes1, reqRes2)
+	ngsi.HTTP = mock
+	client, _ := ngsi.NewClient("orion", &ngsilib.CmdFlags{}, false)
+
+	resource := &applyResource{name: "registration", path: "/registrations"}
+	a := &applyAction{action: applyReplace, resource: resource, key: "reg1", id: "5f1a", data: map[string]interface{}{"description": "reg1"}}
+
+	err := applyRun(cli.NewContext(app, set, nil), ngsi, client, a)
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, 2, mock.index)
+	}
+}
+
+func TestApplyRunUpdateSubscriptionLd(t *testing.T) {
+	ngsi, set, app, buf := setupTest()
+
+	setupAddBroker(t, ngsi, "orion-ld", "https://orion-ld", "ld")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusNoContent
+	reqRes.ReqData = []byte(`{"description":"sub1"}`)
+	reqRes.Path = "/ngsi-ld/v1/subscriptions/urn:ngsi-ld:Subscription:001"
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	client, _ := ngsi.NewClient("orion-ld", &ngsilib.CmdFlags{}, false)
+
+	resource := &applyResource{name: "subscription", path: "/subscriptions", patch: true}
+	data := map[string]interface{}{"id": "urn:ngsi-ld:Subscription:001", "description": "sub1"}
+	a := &applyAction{action: applyUpdate, resource: resource, key: "sub1", id: "urn:ngsi-ld:Subscription:001", data: data}
+
+	err := applyRun(cli.NewContext(app, set, nil), ngsi, client, a)
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, "", buf.String())
+	}
+}
+
+func TestApplyUpdateSubscriptionErrorJSONMarshal(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+	client, _ := ngsi.NewClient("orion", &ngsilib.CmdFlags{}, false)
+	ngsi.JSONConverter = &MockJSONLib{EncodeErr: errors.New("json error"), Jsonlib: ngsi.JSONConverter}
+
+	err := applyUpdateSubscription(cli.NewContext(app, set, nil), ngsi, client, "5f0a", map[string]interface{}{"description": "sub1"})
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 1, ngsiErr.ErrNo)
+	}
+}
+
+func TestApplyUpdateSubscriptionErrorHTTPStatus(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusNotFound
+	reqRes.Res.Status = "404 Not Found"
+	reqRes.ResBody = []byte("error")
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	client, _ := ngsi.NewClient("orion", &ngsilib.CmdFlags{}, false)
+
+	err := applyUpdateSubscription(cli.NewContext(app, set, nil), ngsi, client, "5f0a", map[string]interface{}{"description": "sub1"})
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, "subscriptionsUpdateV2", ngsiErr.Function)
+		assert.Equal(t, 4, ngsiErr.ErrNo)
+		assert.Equal(t, "404 Not Found error 5f0a", ngsiErr.Message)
+	}
+}
+
+func TestApplyPostErrorJSONMarshal(t *testing.T) {
+	ngsi, _, _, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+	client, _ := ngsi.NewClient("orion", &ngsilib.CmdFlags{}, false)
+	ngsi.JSONConverter = &MockJSONLib{EncodeErr: errors.New("json error"), Jsonlib: ngsi.JSONConverter}
+
+	resource := &applyResource{name: "subscription", path: "/subscriptions", patch: true}
+	_, err := applyPost(client, resource, map[string]interface{}{"description": "sub1"})
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 1, ngsiErr.ErrNo)
+	}
+}
+
+func TestApplyPostErrorHTTPStatus(t *testing.T) {
+	ngsi, _, _, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusBadRequest
+	reqRes.Res.Status = "400 Bad Request"
+	reqRes.ResBody = []byte("error")
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	client, _ := ngsi.NewClient("orion", &ngsilib.CmdFlags{}, false)
+
+	resource := &applyResource{name: "subscription", path: "/subscriptions", patch: true}
+	_, err := applyPost(client, resource, map[string]interface{}{"description": "sub1"})
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 3, ngsiErr.ErrNo)
+		assert.Equal(t, "400 Bad Request error", ngsiErr.Message)
+	}
+}
+
+func TestApplyPatchErrorJSONMarshal(t *testing.T) {
+	ngsi, _, _, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+	client, _ := ngsi.NewClient("orion", &ngsilib.CmdFlags{}, false)
+	ngsi.JSONConverter = &MockJSONLib{EncodeErr: errors.New("json error"), Jsonlib: ngsi.JSONConverter}
+
+	resource := &applyResource{name: "registration", path: "/csourceRegistrations", patch: true}
+	err := applyPatch(client, resource, "5f0a", map[string]interface{}{"description": "sub1"})
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 1, ngsiErr.ErrNo)
+	}
+}
+
+func TestApplyPatchErrorHTTPStatus(t *testing.T) {
+	ngsi, _, _, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusNotFound
+	reqRes.Res.Status = "404 Not Found"
+	reqRes.ResBody = []byte("error")
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	client, _ := ngsi.NewClient("orion", &ngsilib.CmdFlags{}, false)
+
+	resource := &applyResource{name: "registration", path: "/csourceRegistrations", patch: true}
+	err := applyPatch(client, resource, "5f0a", map[string]interface{}{"description": "sub1"})
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 3, ngsiErr.ErrNo)
+		assert.Equal(t, "404 Not Found error 5f0a", ngsiErr.Message)
+	}
+}
+
+func TestApplyDeleteItemErrorHTTPStatus(t *testing.T) {
+	ngsi, _, _, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusNotFound
+	reqRes.Res.Status = "404 Not Found"
+	reqRes.ResBody = []byte("error")
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	client, _ := ngsi.NewClient("orion", &ngsilib.CmdFlags{}, false)
+
+	resource := &applyResource{name: "subscription", path: "/subscriptions", patch: true}
+	err := applyDeleteItem(client, resource, "5f0a")
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 2, ngsiErr.ErrNo)
+		assert.Equal(t, "404 Not Found error 5f0a", ngsiErr.Message)
+	}
+}
@@ -76,7 +76,7 @@ func attrRead(c *cli.Context) error {
 			return &ngsiCmdError{funcName, 5, err.Error(), err}
 		}
 	}
-	fmt.Fprintln(ngsi.StdWriter, string(body))
+	printJSON(ngsi, body)
 
 	return nil
 }
@@ -50,29 +50,78 @@ func attrsRead(c *cli.Context) error {
 		return &ngsiCmdError{funcName, 2, err.Error(), err}
 	}
 
+	watch := c.Bool("watch")
+	if watch && c.Bool("values") {
+		return &ngsiCmdError{funcName, 6, "--watch cannot be used with --values", nil}
+	}
+
 	id := c.String("id")
-	client.SetPath("/entities/" + id + "/attrs")
 
-	args := []string{"type", "attrs", "metadata"}
-	opts := []string{"keyValues", "values", "unique"}
-	v := parseOptions(c, args, opts)
-	client.SetQuery(v)
+	// fetch returns nil without an error when watching and the entity is not found, so that
+	// the deletion of the entity is printed as a change.
+	fetch := func(watching bool) ([]byte, error) {
+		client.SetPath("/entities/" + id + "/attrs")
 
-	res, body, err := client.HTTPGet()
-	if err != nil {
-		return &ngsiCmdError{funcName, 3, err.Error(), err}
-	}
-	if res.StatusCode != http.StatusOK {
-		return &ngsiCmdError{funcName, 4, fmt.Sprintf("%s %s", res.Status, string(body)), nil}
+		args := []string{"type", "attrs", "metadata"}
+		opts := []string{"keyValues", "values", "unique"}
+		v := parseOptions(c, args, opts)
+		client.SetQuery(v)
+
+		res, body, err := client.HTTPGet()
+		if err != nil {
+			return nil, &ngsiCmdError{funcName, 3, err.Error(), err}
+		}
+		if watching && res.StatusCode == http.StatusNotFound {
+			return nil, nil
+		}
+		if res.StatusCode != http.StatusOK {
+			return nil, &ngsiCmdError{funcName, 4, fmt.Sprintf("%s %s", res.Status, string(body)), nil}
+		}
+
+		if client.IsSafeString() {
+			body, err = ngsilib.JSONSafeStringDecode(body)
+			if err != nil {
+				return nil, &ngsiCmdError{funcName, 5, err.Error(), err}
+			}
+		}
+		return body, nil
 	}
 
-	if client.IsSafeString() {
-		body, err = ngsilib.JSONSafeStringDecode(body)
+	body, err := fetch(false)
+	if err != nil {
+		return err
+	}
+	printJSON(ngsi, body)
+
+	if watch {
+		// attributes are handled as an entity so that changes are printed in the same way as get entity
+		entity := func(body []byte) (entitiesRespose, error) {
+			e := make(map[string]interface{})
+			if err := ngsilib.JSONUnmarshal(body, &e); err != nil {
+				return nil, &ngsiCmdError{funcName, 7, err.Error(), err}
+			}
+			e["id"] = id
+			e["type"] = c.String("type")
+			return entitiesRespose{e}, nil
+		}
+		prev, err := entity(body)
+		if err != nil {
+			return err
+		}
+		err = watchRun(c, ngsi, prev, func() (entitiesRespose, error) {
+			body, err := fetch(true)
+			if err != nil {
+				return nil, err
+			}
+			if body == nil {
+				return entitiesRespose{}, nil
+			}
+			return entity(body)
+		})
 		if err != nil {
-			return &ngsiCmdError{funcName, 5, err.Error(), err}
+			return &ngsiCmdError{funcName, 8, err.Error(), err}
 		}
 	}
-	fmt.Fprintln(ngsi.StdWriter, string(body))
 
 	return nil
 }
@@ -805,3 +805,137 @@ func TestAttrsReplaceErrorHTTPStatus(t *testing.T) {
 		t.FailNow()
 	}
 }
+
+func TestAttrsReadWatch(t *testing.T) {
+	ngsi, set, app, buf := setupTest()
+	defer setupWatch(2)()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+
+	setupFlagString(set, "host,id,type")
+	setupFlagBool(set, "watch,keyValues")
+	reqRes1 := MockHTTPReqRes{}
+	reqRes1.Res.StatusCode = http.StatusOK
+	reqRes1.ResBody = []byte(`{"temperature":21}`)
+	reqRes2 := MockHTTPReqRes{}
+	reqRes2.Err = errors.New("http error")
+	reqRes3 := MockHTTPReqRes{}
+	reqRes3.Res.StatusCode = http.StatusOK
+	reqRes3.ResBody = []byte(`{"temperature":21,"humidity":40}`)
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes1, reqRes2, reqRes3)
+	ngsi.HTTP = mock
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", "--id=E1", "--type=T", "--keyValues", "--watch"})
+
+	err := attrsRead(c)
+
+	if assert.NoError(t, err) {
+		expected := `{"temperature":21}` + "\n" +
+			"2021-01-02T03:04:10Z ~ E1 (T)\n" +
+			"    + humidity\n"
+		assert.Equal(t, expected, buf.String())
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestAttrsReadWatchNotFound(t *testing.T) {
+	ngsi, set, app, buf := setupTest()
+	defer setupWatch(2)()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+
+	setupFlagString(set, "host,id,type")
+	setupFlagBool(set, "watch,keyValues")
+	reqRes1 := MockHTTPReqRes{}
+	reqRes1.Res.StatusCode = http.StatusOK
+	reqRes1.ResBody = []byte(`{"temperature":21}`)
+	reqRes2 := MockHTTPReqRes{}
+	reqRes2.Res.StatusCode = http.StatusNotFound
+	reqRes2.ResBody = []byte(`{"error":"NotFound","description":"The requested entity has not been found. Check type and id"}`)
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes1, reqRes2, reqRes1)
+	ngsi.HTTP = mock
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", "--id=E1", "--type=T", "--keyValues", "--watch"})
+
+	err := attrsRead(c)
+
+	if assert.NoError(t, err) {
+		expected := `{"temperature":21}` + "\n" +
+			"2021-01-02T03:04:05Z - E1 (T)\n" +
+			"2021-01-02T03:04:10Z + E1 (T)\n"
+		assert.Equal(t, expected, buf.String())
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestAttrsReadWatchErrorValues(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+
+	setupFlagString(set, "host,id")
+	setupFlagBool(set, "watch,values")
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", "--id=E1", "--watch", "--values"})
+
+	err := attrsRead(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 6, ngsiErr.ErrNo)
+		assert.Equal(t, "--watch cannot be used with --values", ngsiErr.Message)
+	}
+}
+
+func TestAttrsReadWatchErrorJSON(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+
+	setupFlagString(set, "host,id")
+	setupFlagBool(set, "watch")
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusOK
+	reqRes.ResBody = []byte(`[1]`)
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", "--id=E1", "--watch"})
+
+	err := attrsRead(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 7, ngsiErr.ErrNo)
+	}
+}
+
+func TestAttrsReadWatchErrorWatch(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+
+	setupFlagString(set, "host,id,interval")
+	setupFlagBool(set, "watch")
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusOK
+	reqRes.ResBody = []byte(`{}`)
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", "--id=E1", "--watch", "--interval=x"})
+
+	err := attrsRead(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 8, ngsiErr.ErrNo)
+		assert.Equal(t, "interval error: x", ngsiErr.Message)
+	}
+}
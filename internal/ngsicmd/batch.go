@@ -32,6 +32,7 @@ package ngsicmd
 import (
 	"fmt"
 	"net/http"
+	"strings"
 
 	"github.com/lets-fiware/ngsi-go/internal/ngsilib"
 	"github.com/urfave/cli/v2"
@@ -50,6 +51,14 @@ func batch(c *cli.Context, mode string) error {
 		return &ngsiCmdError{funcName, 2, err.Error(), err}
 	}
 
+	switch strings.ToLower(c.String("format")) {
+	case "", "json":
+	case "csv":
+		return batchCSV(c, ngsi, client, mode)
+	default:
+		return &ngsiCmdError{funcName, 3, "format error: " + c.String("format"), nil}
+	}
+
 	if client.IsNgsiLd() {
 		switch mode {
 		case "create":
@@ -75,7 +84,7 @@ func batch(c *cli.Context, mode string) error {
 			return opUpdate(c, ngsi, client, "delete")
 		}
 	}
-	return &ngsiCmdError{funcName, 3, "error: " + mode, nil}
+	return &ngsiCmdError{funcName, 4, "error: " + mode, nil}
 }
 
 func batchCreate(c *cli.Context, ngsi *ngsilib.NGSI, client *ngsilib.Client) error {
@@ -90,15 +99,17 @@ func batchCreate(c *cli.Context, ngsi *ngsilib.NGSI, client *ngsilib.Client) err
 		return &ngsiCmdError{funcName, 1, err.Error(), err}
 	}
 
-	res, body, err := client.HTTPPost(b)
+	bodies, err := batchPost(c, ngsi, client, b, false, http.StatusOK)
 	if err != nil {
 		return &ngsiCmdError{funcName, 2, err.Error(), err}
 	}
-	if res.StatusCode != http.StatusOK {
-		return &ngsiCmdError{funcName, 3, fmt.Sprintf("%s %s", res.Status, string(body)), nil}
+
+	body, err := batchMergeResults(bodies)
+	if err != nil {
+		return &ngsiCmdError{funcName, 3, err.Error(), err}
 	}
 
-	fmt.Fprintln(ngsi.StdWriter, string(body))
+	printJSON(ngsi, body)
 
 	return nil
 }
@@ -119,16 +130,14 @@ func batchUpdate(c *cli.Context, ngsi *ngsilib.NGSI, client *ngsilib.Client) err
 		return &ngsiCmdError{funcName, 1, err.Error(), err}
 	}
 
-	res, body, err := client.HTTPPost(b)
+	_, err = batchPost(c, ngsi, client, b, true, http.StatusNoContent)
 	if err != nil {
 		return &ngsiCmdError{funcName, 2, err.Error(), err}
 	}
-	if res.StatusCode != http.StatusNoContent {
-		return &ngsiCmdError{funcName, 3, fmt.Sprintf("%s %s", res.Status, string(body)), nil}
-	}
 
 	return nil
 }
+
 func batchUpsert(c *cli.Context, ngsi *ngsilib.NGSI, client *ngsilib.Client) error {
 	const funcName = "batchUpsert"
 
@@ -145,13 +154,10 @@ func batchUpsert(c *cli.Context, ngsi *ngsilib.NGSI, client *ngsilib.Client) err
 		return &ngsiCmdError{funcName, 1, err.Error(), err}
 	}
 
-	res, body, err := client.HTTPPost(b)
+	_, err = batchPost(c, ngsi, client, b, true, http.StatusNoContent)
 	if err != nil {
 		return &ngsiCmdError{funcName, 2, err.Error(), err}
 	}
-	if res.StatusCode != http.StatusNoContent {
-		return &ngsiCmdError{funcName, 3, fmt.Sprintf("%s %s", res.Status, string(body)), nil}
-	}
 
 	return nil
 }
@@ -168,15 +174,96 @@ func batchDelete(c *cli.Context, ngsi *ngsilib.NGSI, client *ngsilib.Client) err
 		return &ngsiCmdError{funcName, 1, err.Error(), err}
 	}
 
-	res, body, err := client.HTTPPost(b)
+	bodies, err := batchPost(c, ngsi, client, b, false, http.StatusOK)
 	if err != nil {
 		return &ngsiCmdError{funcName, 2, err.Error(), err}
 	}
-	if res.StatusCode != http.StatusOK {
-		return &ngsiCmdError{funcName, 3, fmt.Sprintf("%s %s", res.Status, string(body)), nil}
+
+	body, err := batchMergeResults(bodies)
+	if err != nil {
+		return &ngsiCmdError{funcName, 3, err.Error(), err}
 	}
 
-	fmt.Fprintln(ngsi.StdWriter, string(body))
+	printJSON(ngsi, body)
 
 	return nil
 }
+
+// batchPost posts a JSON array of NGSI-LD entity operations in batches of --batchSize elements
+// and returns the response bodies. A batch is split when the payload is too large. Data which is
+// not a JSON array is posted as it is.
+func batchPost(c *cli.Context, ngsi *ngsilib.NGSI, client *ngsilib.Client, b []byte, idempotent bool, status int) ([][]byte, error) {
+	const funcName = "batchPost"
+
+	bodies := [][]byte{}
+
+	post := func(b []byte) (bool, error) {
+		var res *http.Response
+		var body []byte
+		var err error
+
+		if idempotent {
+			res, body, err = client.HTTPPostIdempotent(b)
+		} else {
+			res, body, err = client.HTTPPost(b)
+		}
+		if err != nil {
+			return false, &ngsiCmdError{funcName, 1, err.Error(), err}
+		}
+		if res.StatusCode == http.StatusRequestEntityTooLarge {
+			ngsi.Logging(ngsilib.LogInfo, fmt.Sprintf("%s, split entities\n", res.Status))
+			return true, nil
+		}
+		if res.StatusCode != status {
+			return false, &ngsiCmdError{funcName, 2, fmt.Sprintf("%s %s", res.Status, string(body)), nil}
+		}
+		bodies = append(bodies, body)
+		return false, nil
+	}
+
+	var items []interface{}
+	if err := ngsilib.JSONUnmarshal(b, &items); err != nil {
+		if _, err := post(b); err != nil {
+			return nil, err
+		}
+		return bodies, nil
+	}
+
+	err := batchRun(len(items), batchSize(c, ngsi, client), func(start, end int) (bool, error) {
+		b, err := ngsilib.JSONMarshal(items[start:end])
+		if err != nil {
+			return false, &ngsiCmdError{funcName, 3, err.Error(), err}
+		}
+		return post(b)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return bodies, nil
+}
+
+// batchMergeResults merges JSON arrays returned by batch requests into one array.
+// A single response body is returned as it is.
+func batchMergeResults(bodies [][]byte) ([]byte, error) {
+	const funcName = "batchMergeResults"
+
+	if len(bodies) == 1 {
+		return bodies[0], nil
+	}
+
+	results := []interface{}{}
+	for _, body := range bodies {
+		var r []interface{}
+		if err := ngsilib.JSONUnmarshal(body, &r); err != nil {
+			return nil, &ngsiCmdError{funcName, 1, err.Error(), err}
+		}
+		results = append(results, r...)
+	}
+
+	b, err := ngsilib.JSONMarshal(results)
+	if err != nil {
+		return nil, &ngsiCmdError{funcName, 2, err.Error(), err}
+	}
+	return b, nil
+}
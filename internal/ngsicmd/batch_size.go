@@ -0,0 +1,102 @@
+/*
+MIT License
+
+Copyright (c) 2020 Kazuhito Suda
+
+This file is part of NGSI Go
+
+https://github.com/lets-fiware/ngsi-go
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+package ngsicmd
+
+import (
+	"fmt"
+
+	"github.com/lets-fiware/ngsi-go/internal/ngsilib"
+	"github.com/urfave/cli/v2"
+)
+
+const defaultBatchSize = 100
+
+// pageSize returns the number of entities read per request. --pageSize takes precedence
+// over the pageSize of the broker, which takes precedence over --maxCount.
+func pageSize(c *cli.Context, ngsi *ngsilib.NGSI, client *ngsilib.Client) int {
+	return batchSizeValue(c, "pageSize", client.PageSize(ngsi.Maxsize))
+}
+
+// batchSize returns the number of entities per batch request. --batchSize takes precedence
+// over the batchSize of the broker, which takes precedence over --maxCount.
+func batchSize(c *cli.Context, ngsi *ngsilib.NGSI, client *ngsilib.Client) int {
+	return batchSizeValue(c, "batchSize", client.BatchSize(ngsi.Maxsize))
+}
+
+func batchSizeValue(c *cli.Context, name string, size int) int {
+	if c.IsSet(name) {
+		if n := c.Int(name); n >= 1 && n <= ngsilib.MaxSize {
+			return n
+		}
+	}
+	if size < 1 || size > ngsilib.MaxSize {
+		size = defaultBatchSize
+	}
+	return size
+}
+
+// batchRun calls post for each batch of up to size items out of n items. post returns true
+// when the broker answers 413 Payload Too Large, then the batch is split in half and posted again.
+func batchRun(n, size int, post func(start, end int) (bool, error)) error {
+	if size < 1 {
+		size = defaultBatchSize
+	}
+	for start := 0; start < n; start += size {
+		end := start + size
+		if end > n {
+			end = n
+		}
+		if err := batchSplit(start, end, post); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func batchSplit(start, end int, post func(start, end int) (bool, error)) error {
+	const funcName = "batchSplit"
+
+	tooLarge, err := post(start, end)
+	if err != nil {
+		return err
+	}
+	if !tooLarge {
+		return nil
+	}
+	if end-start < 2 {
+		return &ngsiCmdError{funcName, 1, fmt.Sprintf("payload too large: an entity (index %d) cannot be split", start), nil}
+	}
+
+	mid := start + (end-start)/2
+	if err := batchSplit(start, mid, post); err != nil {
+		return err
+	}
+	return batchSplit(mid, end, post)
+}
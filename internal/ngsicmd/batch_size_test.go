@@ -0,0 +1,138 @@
+/*
+MIT License
+
+Copyright (c) 2020 Kazuhito Suda
+
+This file is part of NGSI Go
+
+https://github.com/lets-fiware/ngsi-go
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+package ngsicmd
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/lets-fiware/ngsi-go/internal/ngsilib"
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/cli/v2"
+)
+
+func TestPageSize(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+	setupFlagString(set, "pageSize")
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--pageSize=1000"})
+	client, _ := ngsi.NewClient("orion", &ngsilib.CmdFlags{}, false)
+
+	assert.Equal(t, 1000, pageSize(c, ngsi, client))
+}
+
+func TestPageSizeBroker(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+	setupFlagString(set, "pageSize")
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--pageSize=0"})
+	client, _ := ngsi.NewClient("orion", &ngsilib.CmdFlags{}, false)
+	client.Broker.PageSize = "500"
+
+	assert.Equal(t, 500, pageSize(c, ngsi, client))
+}
+
+func TestBatchSize(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+	setupFlagString(set, "batchSize")
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--batchSize=20"})
+	client, _ := ngsi.NewClient("orion", &ngsilib.CmdFlags{}, false)
+	client.Broker.BatchSize = "50"
+
+	assert.Equal(t, 20, batchSize(c, ngsi, client))
+}
+
+func TestBatchSizeMaxCount(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+	c := cli.NewContext(app, set, nil)
+	client, _ := ngsi.NewClient("orion", &ngsilib.CmdFlags{}, false)
+	ngsi.Maxsize = 200
+
+	assert.Equal(t, 200, batchSize(c, ngsi, client))
+
+	ngsi.Maxsize = 100
+}
+
+func TestBatchRun(t *testing.T) {
+	var batches [][2]int
+
+	err := batchRun(5, 2, func(start, end int) (bool, error) {
+		batches = append(batches, [2]int{start, end})
+		return false, nil
+	})
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, [][2]int{{0, 2}, {2, 4}, {4, 5}}, batches)
+	}
+}
+
+func TestBatchRunSplit(t *testing.T) {
+	var batches [][2]int
+
+	err := batchRun(5, 5, func(start, end int) (bool, error) {
+		batches = append(batches, [2]int{start, end})
+		return end-start > 2, nil
+	})
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, [][2]int{{0, 5}, {0, 2}, {2, 5}, {2, 3}, {3, 5}}, batches)
+	}
+}
+
+func TestBatchRunError(t *testing.T) {
+	err := batchRun(5, 2, func(start, end int) (bool, error) {
+		return false, errors.New("post error")
+	})
+
+	if assert.Error(t, err) {
+		assert.Equal(t, "post error", err.Error())
+	}
+}
+
+func TestBatchRunErrorTooLarge(t *testing.T) {
+	err := batchRun(2, 2, func(start, end int) (bool, error) {
+		return true, nil
+	})
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 1, ngsiErr.ErrNo)
+		assert.Equal(t, "payload too large: an entity (index 0) cannot be split", ngsiErr.Message)
+	}
+}
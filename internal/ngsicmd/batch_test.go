@@ -265,7 +265,7 @@ func TestBatchErrorModeV2(t *testing.T) {
 
 	if assert.Error(t, err) {
 		ngsiErr := err.(*ngsiCmdError)
-		assert.Equal(t, 3, ngsiErr.ErrNo)
+		assert.Equal(t, 4, ngsiErr.ErrNo)
 		assert.Equal(t, "error: get", ngsiErr.Message)
 	} else {
 		t.FailNow()
@@ -284,7 +284,7 @@ func TestBatchErrorModeLD(t *testing.T) {
 
 	if assert.Error(t, err) {
 		ngsiErr := err.(*ngsiCmdError)
-		assert.Equal(t, 3, ngsiErr.ErrNo)
+		assert.Equal(t, 4, ngsiErr.ErrNo)
 		assert.Equal(t, "error: get", ngsiErr.Message)
 	} else {
 		t.FailNow()
@@ -373,7 +373,7 @@ func TestBatchCreateErrorHTTPStatus(t *testing.T) {
 
 	if assert.Error(t, err) {
 		ngsiErr := err.(*ngsiCmdError)
-		assert.Equal(t, 3, ngsiErr.ErrNo)
+		assert.Equal(t, 2, ngsiErr.ErrNo)
 	} else {
 		t.FailNow()
 	}
@@ -461,7 +461,7 @@ func TestBatchUpdateErrorHTTPStatus(t *testing.T) {
 
 	if assert.Error(t, err) {
 		ngsiErr := err.(*ngsiCmdError)
-		assert.Equal(t, 3, ngsiErr.ErrNo)
+		assert.Equal(t, 2, ngsiErr.ErrNo)
 	} else {
 		t.FailNow()
 	}
@@ -549,7 +549,7 @@ func TestBatchUpsertErrorHTTPStatus(t *testing.T) {
 
 	if assert.Error(t, err) {
 		ngsiErr := err.(*ngsiCmdError)
-		assert.Equal(t, 3, ngsiErr.ErrNo)
+		assert.Equal(t, 2, ngsiErr.ErrNo)
 	} else {
 		t.FailNow()
 	}
@@ -635,6 +635,61 @@ func TestBatchDeleteErrorHTTPStatus(t *testing.T) {
 	client, _ := newClient(ngsi, c, false)
 	err := batchDelete(c, ngsi, client)
 
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 2, ngsiErr.ErrNo)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestBatchCreateBatchSize(t *testing.T) {
+	ngsi, set, app, buf := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "LD")
+	setupFlagString(set, "host,data,link,batchSize")
+	reqRes1 := MockHTTPReqRes{}
+	reqRes1.Res.StatusCode = http.StatusOK
+	reqRes1.ReqData = []byte(`[{"id":"E1","type":"T"},{"id":"E2","type":"T"}]`)
+	reqRes1.ResBody = []byte(`["E1","E2"]`)
+	reqRes1.Path = "/ngsi-ld/v1/entityOperations/create"
+	reqRes2 := MockHTTPReqRes{}
+	reqRes2.Res.StatusCode = http.StatusOK
+	reqRes2.ReqData = []byte(`[{"id":"E3","type":"T"}]`)
+	reqRes2.ResBody = []byte(`["E3"]`)
+	reqRes2.Path = "/ngsi-ld/v1/entityOperations/create"
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes1)
+	mock.ReqRes = append(mock.ReqRes, reqRes2)
+	ngsi.HTTP = mock
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", "--batchSize=2", `--data=[{"id":"E1","type":"T"},{"id":"E2","type":"T"},{"id":"E3","type":"T"}]`})
+	client, _ := newClient(ngsi, c, false)
+	err := batchCreate(c, ngsi, client)
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, "[\"E1\",\"E2\",\"E3\"]\n", buf.String())
+	}
+}
+
+func TestBatchCreateErrorMergeResults(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "LD")
+	setupFlagString(set, "host,data,link,batchSize")
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusOK
+	reqRes.ResBody = []byte(`{}`)
+	reqRes.Path = "/ngsi-ld/v1/entityOperations/create"
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", "--batchSize=1", `--data=[{"id":"E1","type":"T"},{"id":"E2","type":"T"}]`})
+	client, _ := newClient(ngsi, c, false)
+	err := batchCreate(c, ngsi, client)
+
 	if assert.Error(t, err) {
 		ngsiErr := err.(*ngsiCmdError)
 		assert.Equal(t, 3, ngsiErr.ErrNo)
@@ -643,6 +698,36 @@ func TestBatchDeleteErrorHTTPStatus(t *testing.T) {
 	}
 }
 
+func TestBatchUpsertTooLarge(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "LD")
+	setupFlagString(set, "host,data,link")
+	reqRes1 := MockHTTPReqRes{}
+	reqRes1.Res.StatusCode = http.StatusRequestEntityTooLarge
+	reqRes1.Res.Status = "413 Request Entity Too Large"
+	reqRes1.Path = "/ngsi-ld/v1/entityOperations/upsert"
+	reqRes2 := MockHTTPReqRes{}
+	reqRes2.Res.StatusCode = http.StatusNoContent
+	reqRes2.ReqData = []byte(`[{"id":"E1","type":"T"}]`)
+	reqRes2.Path = "/ngsi-ld/v1/entityOperations/upsert"
+	reqRes3 := MockHTTPReqRes{}
+	reqRes3.Res.StatusCode = http.StatusNoContent
+	reqRes3.ReqData = []byte(`[{"id":"E2","type":"T"}]`)
+	reqRes3.Path = "/ngsi-ld/v1/entityOperations/upsert"
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes1)
+	mock.ReqRes = append(mock.ReqRes, reqRes2)
+	mock.ReqRes = append(mock.ReqRes, reqRes3)
+	ngsi.HTTP = mock
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", `--data=[{"id":"E1","type":"T"},{"id":"E2","type":"T"}]`})
+	client, _ := newClient(ngsi, c, false)
+	err := batchUpsert(c, ngsi, client)
+
+	assert.NoError(t, err)
+}
+
 var testData = `[
     {
       "id": "urn:ngsi-ld:TemperatureSensor:002",
@@ -89,7 +89,7 @@ func brokersGet(c *cli.Context) error {
 		if err != nil {
 			return &ngsiCmdError{funcName, 5, err.Error(), err}
 		}
-		fmt.Fprintln(ngsi.StdWriter, string(b))
+		printJSON(ngsi, b)
 	}
 
 	return nil
@@ -111,7 +111,8 @@ func brokersAdd(c *cli.Context) error {
 		return &ngsiCmdError{funcName, 3, host + " already exists", err}
 	}
 
-	if !c.IsSet("ngsiType") {
+	serverType := strings.ToLower(c.String("serverType"))
+	if !c.IsSet("ngsiType") && (serverType == "" || serverType == "broker") {
 		return &ngsiCmdError{funcName, 4, "ngsiType is missing", err}
 	}
 
@@ -120,7 +121,7 @@ func brokersAdd(c *cli.Context) error {
 	for i := 0; i < len(args); i++ {
 		key := args[i]
 		if c.IsSet(key) {
-			value := c.String(key)
+			value := brokerParamValue(c, key)
 			if value != "" {
 				param[key] = value
 			}
@@ -152,7 +153,7 @@ func brokersUpdate(c *cli.Context) error {
 	for i := 0; i < len(args); i++ {
 		key := args[i]
 		if c.IsSet(key) {
-			value := c.String(key)
+			value := brokerParamValue(c, key)
 			if value != "" {
 				param[key] = value
 			}
@@ -166,6 +167,15 @@ func brokersUpdate(c *cli.Context) error {
 	return nil
 }
 
+// brokerParamValue returns the value of a broker parameter flag. Headers may be
+// given more than once, so they are joined with newlines for CreateBroker and UpdateBroker.
+func brokerParamValue(c *cli.Context, key string) string {
+	if key == "header" {
+		return strings.Join(c.StringSlice(key), "\n")
+	}
+	return c.String(key)
+}
+
 func brokersDelete(c *cli.Context) error {
 	const funcName = "brokerDelete"
 
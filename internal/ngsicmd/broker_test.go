@@ -263,6 +263,22 @@ func TestBrokersAdd(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestBrokersAddOpenIDConnect(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupFlagString(set, "host,ngsiType,brokerHost,idmType,idmHost,clientId,clientSecret,idmScope,audience")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", "--brokerHost=http://orion", "--ngsiType=v2", "--idmType=oidc", "--idmHost=http://keycloak/realms/fiware", "--clientId=ngsi-go", "--clientSecret=1111", "--idmScope=openid", "--audience=orion"})
+	err := brokersAdd(c)
+
+	if assert.NoError(t, err) {
+		list := ngsi.BrokerList()
+		assert.Equal(t, "openid", (*list)["orion"].IdmScope)
+		assert.Equal(t, "orion", (*list)["orion"].Audience)
+	}
+}
+
 func TestBrokersAddLD(t *testing.T) {
 	_, set, app, _ := setupTest()
 
@@ -275,6 +291,123 @@ func TestBrokersAddLD(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestBrokersAddQuantumLeap(t *testing.T) {
+	_, set, app, _ := setupTest()
+
+	setupFlagString(set, "host,serverType,brokerHost")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=ql", "--brokerHost=http://quantumleap:8668", "--serverType=quantumleap"})
+	err := brokersAdd(c)
+
+	assert.NoError(t, err)
+}
+
+func TestBrokersAddProxy(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupFlagString(set, "host,ngsiType,brokerHost,proxy")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", "--brokerHost=http://orion", "--ngsiType=v2", "--proxy=http://proxy:8080"})
+	err := brokersAdd(c)
+
+	if assert.NoError(t, err) {
+		list := ngsi.BrokerList()
+		assert.Equal(t, "http://proxy:8080", (*list)["orion"].Proxy)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestBrokersAddTLS(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupFlagString(set, "host,ngsiType,brokerHost,insecureSkipVerify")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", "--brokerHost=https://orion", "--ngsiType=v2", "--insecureSkipVerify=on"})
+	err := brokersAdd(c)
+
+	if assert.NoError(t, err) {
+		list := ngsi.BrokerList()
+		assert.Equal(t, "on", (*list)["orion"].InsecureSkipVerify)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestBrokersAddErrorTLS(t *testing.T) {
+	_, set, app, _ := setupTest()
+
+	setupFlagString(set, "host,ngsiType,brokerHost,clientCert")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", "--brokerHost=https://orion", "--ngsiType=v2", "--clientCert=/cert/client.pem"})
+	err := brokersAdd(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 5, ngsiErr.ErrNo)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestBrokersAddRetry(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupFlagString(set, "host,ngsiType,brokerHost,retry,retryWait")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", "--brokerHost=https://orion", "--ngsiType=v2", "--retry=3", "--retryWait=2"})
+	err := brokersAdd(c)
+
+	if assert.NoError(t, err) {
+		list := ngsi.BrokerList()
+		assert.Equal(t, "3", (*list)["orion"].Retry)
+		assert.Equal(t, "2", (*list)["orion"].RetryWait)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestBrokersAddSize(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupFlagString(set, "host,ngsiType,brokerHost,pageSize,batchSize")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", "--brokerHost=https://orion", "--ngsiType=v2", "--pageSize=1000", "--batchSize=50"})
+	err := brokersAdd(c)
+
+	if assert.NoError(t, err) {
+		list := ngsi.BrokerList()
+		assert.Equal(t, "1000", (*list)["orion"].PageSize)
+		assert.Equal(t, "50", (*list)["orion"].BatchSize)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestBrokersAddErrorRetry(t *testing.T) {
+	_, set, app, _ := setupTest()
+
+	setupFlagString(set, "host,ngsiType,brokerHost,retry")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", "--brokerHost=https://orion", "--ngsiType=v2", "--retry=many"})
+	err := brokersAdd(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 5, ngsiErr.ErrNo)
+		assert.Equal(t, "retry error: many", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
 func TestBrokersAddLDSafeString(t *testing.T) {
 	_, set, app, _ := setupTest()
 
@@ -390,6 +523,24 @@ func TestBrokersUpdate(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestBrokersUpdateHeader(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+
+	setupFlagString(set, "host")
+	set.Var(&cli.StringSlice{}, "header", "")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", "--header=apikey=b2a1c3d4", "--header=Accept=application/json, text/plain"})
+	err := brokersUpdate(c)
+
+	if assert.NoError(t, err) {
+		broker, _ := ngsi.BrokerList().BrokerInfo("orion")
+		assert.Equal(t, map[string]string{"apikey": "b2a1c3d4", "Accept": "application/json, text/plain"}, broker.Headers)
+	}
+}
+
 func TestBrokersUpdateErrorInitCmd(t *testing.T) {
 	_, set, app, _ := setupTest()
 
@@ -0,0 +1,222 @@
+/*
+MIT License
+
+Copyright (c) 2020 Kazuhito Suda
+
+This file is part of NGSI Go
+
+https://github.com/lets-fiware/ngsi-go
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+package ngsicmd
+
+import (
+	"strings"
+)
+
+const ldCoreContext = "https://uri.etsi.org/ngsi-ld/v1/ngsi-ld-core-context.jsonld"
+
+// convertEntitiesV2ToLd converts NGSIv2 entities in normalized representation to NGSI-LD entities.
+func convertEntitiesV2ToLd(entities entitiesRespose) entitiesRespose {
+	ldEntities := entitiesRespose{}
+
+	for _, e := range entities {
+		entity := map[string]interface{}{}
+		entityType, _ := e["type"].(string)
+
+		for k, v := range e {
+			switch k {
+			case "id":
+				id, _ := v.(string)
+				if !strings.Contains(id, ":") {
+					id = "urn:ngsi-ld:" + entityType + ":" + id
+				}
+				entity["id"] = id
+			case "type":
+				entity["type"] = v
+			default:
+				if attr, ok := v.(map[string]interface{}); ok {
+					entity[k] = convertAttrV2ToLd(attr, true)
+				}
+			}
+		}
+		ldEntities = append(ldEntities, entity)
+	}
+
+	return ldEntities
+}
+
+// convertAttrV2ToLd converts an attribute to a Property, Relationship or GeoProperty.
+// Metadata are converted to sub-properties. TimeInstant and unitCode become observedAt and unitCode.
+func convertAttrV2ToLd(attr map[string]interface{}, metadata bool) map[string]interface{} {
+	property := map[string]interface{}{}
+
+	attrType, _ := attr["type"].(string)
+	value := attr["value"]
+
+	switch attrType {
+	case "Relationship":
+		property["type"] = "Relationship"
+		property["object"] = value
+	case "geo:json":
+		property["type"] = "GeoProperty"
+		property["value"] = value
+	case "DateTime", "ISO8601":
+		property["type"] = "Property"
+		property["value"] = map[string]interface{}{"@type": "DateTime", "@value": value}
+	default:
+		property["type"] = "Property"
+		property["value"] = value
+	}
+
+	if !metadata {
+		return property
+	}
+
+	if md, ok := attr["metadata"].(map[string]interface{}); ok {
+		for k, v := range md {
+			m, ok := v.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			switch k {
+			case "TimeInstant":
+				property["observedAt"] = m["value"]
+			case "unitCode":
+				property["unitCode"] = m["value"]
+			default:
+				property[k] = convertAttrV2ToLd(m, false)
+			}
+		}
+	}
+
+	return property
+}
+
+// convertEntitiesLdToV2 converts NGSI-LD entities to NGSIv2 entities in normalized representation.
+func convertEntitiesLdToV2(entities entitiesRespose) entitiesRespose {
+	v2Entities := entitiesRespose{}
+
+	for _, e := range entities {
+		entity := map[string]interface{}{}
+
+		for k, v := range e {
+			switch k {
+			case "id", "type":
+				entity[k] = v
+			case "@context", "createdAt", "modifiedAt", "scope":
+			default:
+				if attr := ldAttr(v); attr != nil {
+					entity[k] = convertAttrLdToV2(attr, true)
+				}
+			}
+		}
+		v2Entities = append(v2Entities, entity)
+	}
+
+	return v2Entities
+}
+
+// ldAttr returns an attribute. Only the first instance of a multi-attribute is used.
+func ldAttr(v interface{}) map[string]interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		return v
+	case []interface{}:
+		if len(v) > 0 {
+			if attr, ok := v[0].(map[string]interface{}); ok {
+				return attr
+			}
+		}
+	}
+	return nil
+}
+
+// convertAttrLdToV2 converts a Property, Relationship or GeoProperty to an attribute.
+// Sub-properties are converted to metadata. observedAt and unitCode become TimeInstant and unitCode.
+func convertAttrLdToV2(property map[string]interface{}, metadata bool) map[string]interface{} {
+	attr := map[string]interface{}{}
+
+	switch property["type"] {
+	case "Relationship":
+		attr["type"] = "Relationship"
+		attr["value"] = property["object"]
+	case "GeoProperty":
+		attr["type"] = "geo:json"
+		attr["value"] = property["value"]
+	default:
+		attr["type"], attr["value"] = convertValueLdToV2(property["value"])
+	}
+
+	if !metadata {
+		return attr
+	}
+
+	md := map[string]interface{}{}
+	for k, v := range property {
+		switch k {
+		case "type", "value", "object", "createdAt", "modifiedAt", "datasetId", "instanceId":
+		case "observedAt":
+			md["TimeInstant"] = map[string]interface{}{"type": "DateTime", "value": v}
+		case "unitCode":
+			md["unitCode"] = map[string]interface{}{"type": "Text", "value": v}
+		default:
+			if p, ok := v.(map[string]interface{}); ok {
+				md[k] = convertAttrLdToV2(p, false)
+			}
+		}
+	}
+	if len(md) > 0 {
+		attr["metadata"] = md
+	}
+
+	return attr
+}
+
+func convertValueLdToV2(value interface{}) (string, interface{}) {
+	switch v := value.(type) {
+	case string:
+		return "Text", v
+	case float64:
+		return "Number", v
+	case bool:
+		return "Boolean", v
+	case map[string]interface{}:
+		if v["@type"] == "DateTime" {
+			return "DateTime", v["@value"]
+		}
+	case nil:
+		return "None", nil
+	}
+	return "StructuredValue", value
+}
+
+// setLdContext adds @context to entities when context is not empty. Otherwise it removes @context.
+func setLdContext(entities entitiesRespose, context string) {
+	for _, e := range entities {
+		if context == "" {
+			delete(e, "@context")
+		} else if _, ok := e["@context"]; !ok {
+			e["@context"] = context
+		}
+	}
+}
@@ -0,0 +1,96 @@
+/*
+MIT License
+
+Copyright (c) 2020 Kazuhito Suda
+
+This file is part of NGSI Go
+
+https://github.com/lets-fiware/ngsi-go
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+package ngsicmd
+
+import (
+	"testing"
+
+	"github.com/lets-fiware/ngsi-go/internal/ngsilib"
+	"github.com/stretchr/testify/assert"
+)
+
+func testConvert(t *testing.T, f func(entitiesRespose) entitiesRespose, data string) string {
+	var entities entitiesRespose
+	err := ngsilib.JSONUnmarshal([]byte(data), &entities)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	b, err := ngsilib.JSONMarshal(f(entities))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	return string(b)
+}
+
+func TestConvertEntitiesV2ToLd(t *testing.T) {
+	_, _, _, _ = setupTest()
+
+	data := `[{"id":"Room1","type":"Room","temperature":{"type":"Number","value":23.5,"metadata":{"TimeInstant":{"type":"DateTime","value":"2021-01-01T00:00:00.000Z"},"unitCode":{"type":"Text","value":"CEL"},"accuracy":{"type":"Number","value":0.5,"metadata":{}}}},"refBuilding":{"type":"Relationship","value":"urn:ngsi-ld:Building:001","metadata":{}},"location":{"type":"geo:json","value":{"type":"Point","coordinates":[139.7,35.6]},"metadata":{}},"lastCleaned":{"type":"DateTime","value":"2021-01-01T00:00:00.000Z","metadata":{}},"text":"invalid"}]`
+
+	actual := testConvert(t, convertEntitiesV2ToLd, data)
+
+	expected := `[{"id":"urn:ngsi-ld:Room:Room1","lastCleaned":{"type":"Property","value":{"@type":"DateTime","@value":"2021-01-01T00:00:00.000Z"}},"location":{"type":"GeoProperty","value":{"coordinates":[139.7,35.6],"type":"Point"}},"refBuilding":{"object":"urn:ngsi-ld:Building:001","type":"Relationship"},"temperature":{"accuracy":{"type":"Property","value":0.5},"observedAt":"2021-01-01T00:00:00.000Z","type":"Property","unitCode":"CEL","value":23.5},"type":"Room"}]`
+	assert.Equal(t, expected, actual)
+}
+
+func TestConvertEntitiesV2ToLdURN(t *testing.T) {
+	_, _, _, _ = setupTest()
+
+	data := `[{"id":"urn:ngsi-ld:Room:001","type":"Room","name":{"type":"Text","value":"room","metadata":{"invalid":"data"}}}]`
+
+	actual := testConvert(t, convertEntitiesV2ToLd, data)
+
+	expected := `[{"id":"urn:ngsi-ld:Room:001","name":{"type":"Property","value":"room"},"type":"Room"}]`
+	assert.Equal(t, expected, actual)
+}
+
+func TestConvertEntitiesLdToV2(t *testing.T) {
+	_, _, _, _ = setupTest()
+
+	data := `[{"@context":"http://context","id":"urn:ngsi-ld:Room:001","type":"Room","createdAt":"2021-01-01T00:00:00.000Z","temperature":{"type":"Property","value":23.5,"observedAt":"2021-01-01T00:00:00.000Z","unitCode":"CEL","createdAt":"2021-01-01T00:00:00.000Z","accuracy":{"type":"Property","value":0.5,"unitCode":"CEL"}},"refBuilding":{"type":"Relationship","object":"urn:ngsi-ld:Building:001"},"location":{"type":"GeoProperty","value":{"type":"Point","coordinates":[139.7,35.6]}},"lastCleaned":{"type":"Property","value":{"@type":"DateTime","@value":"2021-01-01T00:00:00.000Z"}},"open":[{"type":"Property","value":true,"datasetId":"urn:ngsi-ld:Dataset:001"},{"type":"Property","value":false}],"note":{"type":"Property","value":null},"size":{"type":"Property","value":{"width":10,"depth":5}},"empty":[],"text":"invalid"}]`
+
+	actual := testConvert(t, convertEntitiesLdToV2, data)
+
+	expected := `[{"id":"urn:ngsi-ld:Room:001","lastCleaned":{"type":"DateTime","value":"2021-01-01T00:00:00.000Z"},"location":{"type":"geo:json","value":{"coordinates":[139.7,35.6],"type":"Point"}},"note":{"type":"None","value":null},"open":{"type":"Boolean","value":true},"refBuilding":{"type":"Relationship","value":"urn:ngsi-ld:Building:001"},"size":{"type":"StructuredValue","value":{"depth":5,"width":10}},"temperature":{"metadata":{"TimeInstant":{"type":"DateTime","value":"2021-01-01T00:00:00.000Z"},"accuracy":{"type":"Number","value":0.5},"unitCode":{"type":"Text","value":"CEL"}},"type":"Number","value":23.5},"type":"Room"}]`
+	assert.Equal(t, expected, actual)
+}
+
+func TestSetLdContext(t *testing.T) {
+	entities := entitiesRespose{{"id": "E1"}, {"id": "E2", "@context": "http://context"}}
+
+	setLdContext(entities, ldCoreContext)
+
+	assert.Equal(t, ldCoreContext, entities[0]["@context"])
+	assert.Equal(t, "http://context", entities[1]["@context"])
+
+	setLdContext(entities, "")
+
+	assert.Equal(t, entitiesRespose{{"id": "E1"}, {"id": "E2"}}, entities)
+}
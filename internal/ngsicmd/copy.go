@@ -31,13 +31,38 @@ package ngsicmd
 
 import (
 	"fmt"
+	"io/ioutil"
 	"net/http"
 	"net/url"
+	"os"
+	"strings"
 
 	"github.com/lets-fiware/ngsi-go/internal/ngsilib"
 	"github.com/urfave/cli/v2"
 )
 
+// copyCheckpoint records the progress of cp so that it can be resumed. The ids of failed entities
+// are appended to a separate file so that the checkpoint stays small.
+type copyCheckpoint struct {
+	Host        string `json:"host"`
+	Destination string `json:"destination"`
+	Type        string `json:"type,omitempty"`
+	Tenant      string `json:"service,omitempty"`
+	Scope       string `json:"path,omitempty"`
+	Tenant2     string `json:"service2,omitempty"`
+	Scope2      string `json:"path2,omitempty"`
+	Offset      int    `json:"offset"`
+	Created     int    `json:"created"`
+	Failed      int    `json:"failed"`
+}
+
+type copyPage struct {
+	entities  entitiesRespose
+	count     int
+	failedIDs []string
+	output    []byte
+}
+
 func copy(c *cli.Context) error {
 	const funcName = "copy"
 
@@ -63,64 +88,358 @@ func copy(c *cli.Context) error {
 		return &ngsiCmdError{funcName, 4, err.Error() + " (destination)", err}
 	}
 
-	if source.IsNgsiLd() || destination.IsNgsiLd() {
-		return &ngsiCmdError{funcName, 5, "only available on NGSIv2", err}
+	dryRun := c.Bool("dryRun")
+
+	if !c.IsSet("run") && !dryRun {
+		return &ngsiCmdError{funcName, 5, "run copy with --run option", err}
 	}
 
-	if !c.IsSet("run") {
-		return &ngsiCmdError{funcName, 6, "run copy with --run option", err}
+	checkpointFile := ""
+	if !dryRun {
+		checkpointFile = c.String("checkpoint")
+	}
+	cp := &copyCheckpoint{
+		Host:        c.String("host"),
+		Destination: ngsi.Destination,
+		Type:        entityType,
+		Tenant:      source.Tenant,
+		Scope:       source.Scope,
+		Tenant2:     destination.Tenant,
+		Scope2:      destination.Scope,
 	}
 
-	page := 0
-	count := 0
-	limit := 100
-	total := 0
-	for {
-		source.SetPath("/entities")
+	if c.Bool("resume") {
+		cp, err = copyLoadCheckpoint(checkpointFile, cp)
+		if err != nil {
+			return &ngsiCmdError{funcName, 6, err.Error(), err}
+		}
+		ngsi.Logging(ngsilib.LogInfo, fmt.Sprintf("resume from offset %d\n", cp.Offset))
+	}
 
-		v := url.Values{}
-		v.Set("type", entityType)
-		v.Set("options", "count")
-		v.Set("limit", fmt.Sprintf("%d", limit))
-		v.Set("offset", fmt.Sprintf("%d", page*limit))
-		source.SetQuery(&v)
+	limit := pageSize(c, ngsi, source)
+	size := batchSize(c, ngsi, destination)
 
-		res, body, err := source.HTTPGet()
+	process := func(source, destination *ngsilib.Client, offset int) (*copyPage, error) {
+		entities, count, err := copyReadEntities(source, entityType, limit, offset)
 		if err != nil {
-			return &ngsiCmdError{funcName, 7, err.Error(), err}
+			return nil, &ngsiCmdError{funcName, 7, err.Error(), err}
 		}
-		if res.StatusCode != http.StatusOK {
-			return &ngsiCmdError{funcName, 8, fmt.Sprintf("%s %s", res.Status, string(body)), nil}
+		if count == 0 {
+			return &copyPage{}, nil
 		}
-		count, err = source.ResultsCount(res)
-		if err != nil {
-			return &ngsiCmdError{funcName, 9, err.Error(), err}
+
+		page := &copyPage{entities: copyConvertEntities(source, destination, entities), count: count}
+
+		if dryRun {
+			page.output, err = ngsilib.JSONMarshal(page.entities)
+			if err != nil {
+				return nil, &ngsiCmdError{funcName, 8, err.Error(), err}
+			}
+		} else {
+			page.failedIDs, err = copyWriteEntities(ngsi, destination, page.entities, offset, size)
+			if err != nil {
+				return nil, &ngsiCmdError{funcName, 9, err.Error(), err}
+			}
 		}
-		if count == 0 {
-			break
+		return page, nil
+	}
+
+	// record is called in the order of pages so that the checkpoint never skips a page
+	record := func(page *copyPage) error {
+		if dryRun {
+			printJSON(ngsi, page.output)
+		} else {
+			cp.Created += len(page.entities) - len(page.failedIDs)
+			cp.Failed += len(page.failedIDs)
 		}
 
-		var entities entitiesRespose
-		err = ngsilib.JSONUnmarshal(body, &entities)
-		if err != nil {
-			return &ngsiCmdError{funcName, 10, err.Error(), err}
+		cp.Offset += limit
+
+		if checkpointFile != "" {
+			// the ids are written before the checkpoint so that a crash never loses them
+			if err := copyAppendFailedIDs(checkpointFile+".failed", page.failedIDs); err != nil {
+				return &ngsiCmdError{funcName, 10, err.Error(), err}
+			}
+			if err := copySaveCheckpoint(checkpointFile, cp); err != nil {
+				return &ngsiCmdError{funcName, 11, err.Error(), err}
+			}
+		}
+		return nil
+	}
+
+	first, err := process(source, destination, cp.Offset)
+	if err != nil {
+		return err
+	}
+	if first.count > 0 {
+		if err := record(first); err != nil {
+			return err
 		}
 
-		res, _, err = destination.OpUpdate(&entities, "append", false, false)
+		offset := cp.Offset
+		next := func() (interface{}, bool, error) {
+			if offset >= first.count {
+				return nil, false, nil
+			}
+			offset += limit
+			return offset - limit, true, nil
+		}
+		job := func(v interface{}) (interface{}, error) {
+			return process(source.Clone(), destination.Clone(), v.(int))
+		}
+		done := func(v interface{}) error {
+			return record(v.(*copyPage))
+		}
+		if err := parallelRun(parallelWorkers(c), next, job, done); err != nil {
+			return err
+		}
+	}
+
+	if dryRun {
+		return nil
+	}
+
+	ngsi.Logging(ngsilib.LogInfo, fmt.Sprintf("created: %d, failed: %d\n", cp.Created, cp.Failed))
+
+	if cp.Failed > 0 {
+		return &ngsiCmdError{funcName, 12, fmt.Sprintf("created: %d, failed: %d", cp.Created, cp.Failed), nil}
+	}
+
+	if checkpointFile != "" {
+		if err := os.Remove(checkpointFile); err != nil && !os.IsNotExist(err) {
+			return &ngsiCmdError{funcName, 13, err.Error(), err}
+		}
+	}
+
+	fmt.Fprintln(ngsi.StdWriter, cp.Created)
+
+	return nil
+}
+
+func copyReadEntities(source *ngsilib.Client, entityType string, limit, offset int) (entitiesRespose, int, error) {
+	const funcName = "copyReadEntities"
+
+	source.SetPath("/entities")
+
+	v := url.Values{}
+	if entityType != "" {
+		v.Set("type", entityType)
+	}
+	if source.IsNgsiLd() {
+		v.Set("count", "true")
+		source.SetHeader("Accept", "application/ld+json")
+	} else {
+		v.Set("options", "count")
+	}
+	v.Set("limit", fmt.Sprintf("%d", limit))
+	v.Set("offset", fmt.Sprintf("%d", offset))
+	source.SetQuery(&v)
+
+	res, body, err := source.HTTPGet()
+	if err != nil {
+		return nil, 0, &ngsiCmdError{funcName, 1, err.Error(), err}
+	}
+	if res.StatusCode != http.StatusOK {
+		return nil, 0, &ngsiCmdError{funcName, 2, fmt.Sprintf("%s %s", res.Status, string(body)), nil}
+	}
+	count, err := source.ResultsCount(res)
+	if err != nil {
+		return nil, 0, &ngsiCmdError{funcName, 3, err.Error(), err}
+	}
+	if count == 0 {
+		return nil, 0, nil
+	}
+
+	var entities entitiesRespose
+	err = ngsilib.JSONUnmarshal(body, &entities)
+	if err != nil {
+		return nil, 0, &ngsiCmdError{funcName, 4, err.Error(), err}
+	}
+
+	return entities, count, nil
+}
+
+// copyConvertEntities converts entities when the NGSI types of source and destination differ.
+// NGSI-LD entities have @context in the payload unless --link2 is specified.
+func copyConvertEntities(source, destination *ngsilib.Client, entities entitiesRespose) entitiesRespose {
+	switch {
+	case !source.IsNgsiLd() && destination.IsNgsiLd():
+		entities = convertEntitiesV2ToLd(entities)
+	case source.IsNgsiLd() && !destination.IsNgsiLd():
+		entities = convertEntitiesLdToV2(entities)
+	}
+
+	if destination.IsNgsiLd() {
+		context := ldCoreContext
+		if destination.Link != nil {
+			context = ""
+		}
+		setLdContext(entities, context)
+	}
+
+	return entities
+}
+
+// copyWriteEntities writes entities to destination in batches of size entities and returns ids of entities that failed.
+func copyWriteEntities(ngsi *ngsilib.NGSI, destination *ngsilib.Client, entities entitiesRespose, offset, size int) ([]string, error) {
+	const funcName = "copyWriteEntities"
+
+	failedIDs := []string{}
+
+	err := batchRun(len(entities), size, func(start, end int) (bool, error) {
+		ids, tooLarge, err := copyWriteBatch(ngsi, destination, entities[start:end], offset+start)
+		if tooLarge && end-start == 1 {
+			// an entity which cannot be split fails so that copying goes on
+			ngsi.Logging(ngsilib.LogErr, fmt.Sprintf("offset %d: payload too large\n", offset+start))
+			ids = copyEntityIDs(entities[start:end])
+			tooLarge = false
+		}
+		failedIDs = append(failedIDs, ids...)
+		return tooLarge, err
+	})
+	if err != nil {
+		return nil, &ngsiCmdError{funcName, 1, err.Error(), err}
+	}
+
+	return failedIDs, nil
+}
+
+// copyWriteBatch writes entities with a batch request. It returns true when the payload is too large.
+func copyWriteBatch(ngsi *ngsilib.NGSI, destination *ngsilib.Client, entities entitiesRespose, offset int) ([]string, bool, error) {
+	const funcName = "copyWriteBatch"
+
+	var res *http.Response
+	var body []byte
+	var err error
+
+	if destination.IsNgsiLd() {
+		destination.SetPath("/entityOperations/upsert")
+		destination.SetContentType()
+
+		b, err := ngsilib.JSONMarshal(entities)
 		if err != nil {
-			return &ngsiCmdError{funcName, 11, err.Error(), err}
+			return nil, false, &ngsiCmdError{funcName, 1, err.Error(), err}
 		}
 
-		total += len(entities)
+		res, body, err = destination.HTTPPostIdempotent(b)
+		if err != nil {
+			return nil, false, &ngsiCmdError{funcName, 2, err.Error(), err}
+		}
+	} else {
+		res, body, err = destination.OpUpdate(&entities, "append", false, false)
+		if err != nil {
+			return nil, false, &ngsiCmdError{funcName, 3, err.Error(), err}
+		}
+	}
 
-		if (page+1)*limit < count {
-			page = page + 1
-		} else {
-			break
+	if res.StatusCode == http.StatusMultiStatus {
+		var result struct {
+			Errors []struct {
+				EntityID string `json:"entityId"`
+			} `json:"errors"`
+		}
+		if err := ngsilib.JSONUnmarshal(body, &result); err != nil {
+			return nil, false, &ngsiCmdError{funcName, 4, err.Error(), err}
 		}
+		ids := []string{}
+		for _, e := range result.Errors {
+			ids = append(ids, e.EntityID)
+		}
+		ngsi.Logging(ngsilib.LogErr, fmt.Sprintf("offset %d: %s %s\n", offset, res.Status, string(body)))
+		return ids, false, nil
+	}
+
+	if res.StatusCode == http.StatusRequestEntityTooLarge {
+		ngsi.Logging(ngsilib.LogInfo, fmt.Sprintf("offset %d: %s, split %d entities\n", offset, res.Status, len(entities)))
+		return nil, true, nil
+	}
+
+	if res.StatusCode < http.StatusOK || res.StatusCode >= http.StatusMultipleChoices {
+		ngsi.Logging(ngsilib.LogErr, fmt.Sprintf("offset %d: %s %s\n", offset, res.Status, string(body)))
+		return copyEntityIDs(entities), false, nil
+	}
+
+	return []string{}, false, nil
+}
+
+func copyLoadCheckpoint(path string, expected *copyCheckpoint) (*copyCheckpoint, error) {
+	const funcName = "copyLoadCheckpoint"
+
+	if path == "" {
+		return nil, &ngsiCmdError{funcName, 1, "specify checkpoint file with --checkpoint", nil}
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, &ngsiCmdError{funcName, 2, err.Error(), err}
+	}
+
+	cp := &copyCheckpoint{}
+	if err := ngsilib.JSONUnmarshal(b, cp); err != nil {
+		return nil, &ngsiCmdError{funcName, 3, err.Error(), err}
+	}
+
+	// the offset is meaningful only for the same selection of entities
+	if cp.Host != expected.Host || cp.Destination != expected.Destination || cp.Type != expected.Type ||
+		cp.Tenant != expected.Tenant || cp.Scope != expected.Scope || cp.Tenant2 != expected.Tenant2 || cp.Scope2 != expected.Scope2 {
+		return nil, &ngsiCmdError{funcName, 4, fmt.Sprintf("checkpoint mismatch: %s -> %s (type: %s, service: %s, path: %s, service2: %s, path2: %s)",
+			cp.Host, cp.Destination, cp.Type, cp.Tenant, cp.Scope, cp.Tenant2, cp.Scope2), nil}
+	}
+
+	return cp, nil
+}
+
+func copySaveCheckpoint(path string, cp *copyCheckpoint) error {
+	const funcName = "copySaveCheckpoint"
+
+	b, err := ngsilib.JSONMarshal(cp)
+	if err != nil {
+		return &ngsiCmdError{funcName, 1, err.Error(), err}
+	}
+
+	// write a temporary file first so that an interrupted run leaves a valid checkpoint
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, b, 0600); err != nil {
+		return &ngsiCmdError{funcName, 2, err.Error(), err}
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return &ngsiCmdError{funcName, 3, err.Error(), err}
+	}
+
+	return nil
+}
+
+// copyAppendFailedIDs appends ids to the file, one per line.
+func copyAppendFailedIDs(path string, ids []string) error {
+	const funcName = "copyAppendFailedIDs"
+
+	if len(ids) == 0 {
+		return nil
 	}
 
-	fmt.Fprintln(ngsi.StdWriter, total)
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return &ngsiCmdError{funcName, 1, err.Error(), err}
+	}
+
+	if _, err := f.WriteString(strings.Join(ids, "\n") + "\n"); err != nil {
+		_ = f.Close()
+		return &ngsiCmdError{funcName, 2, err.Error(), err}
+	}
+
+	if err := f.Close(); err != nil {
+		return &ngsiCmdError{funcName, 3, err.Error(), err}
+	}
 
 	return nil
 }
+
+func copyEntityIDs(entities entitiesRespose) []string {
+	ids := []string{}
+	for _, e := range entities {
+		if id, ok := e["id"].(string); ok {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
@@ -31,9 +31,13 @@ package ngsicmd
 
 import (
 	"errors"
+	"io/ioutil"
 	"net/http"
+	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/lets-fiware/ngsi-go/internal/ngsilib"
 	"github.com/stretchr/testify/assert"
 	"github.com/urfave/cli/v2"
 )
@@ -110,45 +114,6 @@ func TestCopyErrorNewClientDestination(t *testing.T) {
 	}
 }
 
-func TestCopyErrorIsNgsiLdSrc(t *testing.T) {
-	ngsi, set, app, _ := setupTest()
-
-	setupAddBroker(t, ngsi, "orion-ld", "https://orion-ld", "ld")
-
-	setupFlagString(set, "host,destination")
-	c := cli.NewContext(app, set, nil)
-	_ = set.Parse([]string{"--host=orion-ld", "--destination=orion-ld"})
-	err := copy(c)
-
-	if assert.Error(t, err) {
-		ngsiErr := err.(*ngsiCmdError)
-		assert.Equal(t, 5, ngsiErr.ErrNo)
-		assert.Equal(t, "only available on NGSIv2", ngsiErr.Message)
-	} else {
-		t.FailNow()
-	}
-}
-
-func TestCopyErrorIsNgsiLdDest(t *testing.T) {
-	ngsi, set, app, _ := setupTest()
-
-	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
-	setupAddBroker(t, ngsi, "orion-ld", "https://orion-ld", "ld")
-
-	setupFlagString(set, "host,destination")
-	c := cli.NewContext(app, set, nil)
-	_ = set.Parse([]string{"--host=orion", "--destination=orion-ld"})
-	err := copy(c)
-
-	if assert.Error(t, err) {
-		ngsiErr := err.(*ngsiCmdError)
-		assert.Equal(t, 5, ngsiErr.ErrNo)
-		assert.Equal(t, "only available on NGSIv2", ngsiErr.Message)
-	} else {
-		t.FailNow()
-	}
-}
-
 func TestCopyErrorRunFlag(t *testing.T) {
 	ngsi, set, app, _ := setupTest()
 
@@ -162,7 +127,7 @@ func TestCopyErrorRunFlag(t *testing.T) {
 
 	if assert.Error(t, err) {
 		ngsiErr := err.(*ngsiCmdError)
-		assert.Equal(t, 6, ngsiErr.ErrNo)
+		assert.Equal(t, 5, ngsiErr.ErrNo)
 		assert.Equal(t, "run copy with --run option", ngsiErr.Message)
 	} else {
 		t.FailNow()
@@ -216,7 +181,7 @@ func TestCopyErrorHTTPStatus(t *testing.T) {
 
 	if assert.Error(t, err) {
 		ngsiErr := err.(*ngsiCmdError)
-		assert.Equal(t, 8, ngsiErr.ErrNo)
+		assert.Equal(t, 7, ngsiErr.ErrNo)
 	} else {
 		t.FailNow()
 	}
@@ -242,7 +207,7 @@ func TestCopyErrorResultCount(t *testing.T) {
 
 	if assert.Error(t, err) {
 		ngsiErr := err.(*ngsiCmdError)
-		assert.Equal(t, 9, ngsiErr.ErrNo)
+		assert.Equal(t, 7, ngsiErr.ErrNo)
 		assert.Equal(t, "strconv.Atoi: parsing \"\": invalid syntax", ngsiErr.Message)
 	} else {
 		t.FailNow()
@@ -299,7 +264,7 @@ func TestCopyErrorJSONUnmarshal(t *testing.T) {
 
 	if assert.Error(t, err) {
 		ngsiErr := err.(*ngsiCmdError)
-		assert.Equal(t, 10, ngsiErr.ErrNo)
+		assert.Equal(t, 7, ngsiErr.ErrNo)
 		assert.Equal(t, "json: cannot unmarshal object into Go value of type ngsicmd.entitiesRespose", ngsiErr.Message)
 	} else {
 		t.FailNow()
@@ -332,7 +297,7 @@ func TestCopyErrorOpUpdate(t *testing.T) {
 
 	if assert.Error(t, err) {
 		ngsiErr := err.(*ngsiCmdError)
-		assert.Equal(t, 11, ngsiErr.ErrNo)
+		assert.Equal(t, 9, ngsiErr.ErrNo)
 		assert.Equal(t, "opupdate error", ngsiErr.Message)
 	} else {
 		t.FailNow()
@@ -379,3 +344,634 @@ func TestCopyPage(t *testing.T) {
 		t.FailNow()
 	}
 }
+
+func testCopyPages(pages int, status int) *MockHTTP {
+	mock := NewMockHTTP()
+	for i := 0; i < pages; i++ {
+		reqRes1 := MockHTTPReqRes{}
+		reqRes1.Res.StatusCode = http.StatusOK
+		reqRes1.ResBody = []byte(`[{"id":"device001"}]`)
+		reqRes1.ResHeader = http.Header{"Fiware-Total-Count": []string{"250"}}
+		reqRes1.Path = "/v2/entities"
+		reqRes2 := MockHTTPReqRes{}
+		reqRes2.Res.StatusCode = status
+		reqRes2.Res.Status = http.StatusText(status)
+		reqRes2.Path = "/v2/op/update"
+		mock.ReqRes = append(mock.ReqRes, reqRes1)
+		mock.ReqRes = append(mock.ReqRes, reqRes2)
+	}
+	return mock
+}
+
+func TestCopyCheckpoint(t *testing.T) {
+	ngsi, set, app, buf := setupTest()
+
+	setupAddBroker(t, ngsi, "orion-src", "https://orion-src", "v2")
+	setupAddBroker(t, ngsi, "orion-dest", "https://orion-dest", "v2")
+
+	file := filepath.Join(t.TempDir(), "cp.json")
+
+	setupFlagString(set, "host,destination,checkpoint")
+	setupFlagBool(set, "run")
+	ngsi.HTTP = testCopyPages(3, http.StatusNoContent)
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion-src", "--destination=orion-dest", "--run", "--checkpoint=" + file})
+	err := copy(c)
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, "3\n", buf.String())
+		_, err = os.Stat(file)
+		assert.True(t, os.IsNotExist(err))
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestCopyResume(t *testing.T) {
+	ngsi, set, app, buf := setupTest()
+
+	setupAddBroker(t, ngsi, "orion-src", "https://orion-src", "v2")
+	setupAddBroker(t, ngsi, "orion-dest", "https://orion-dest", "v2")
+
+	file := filepath.Join(t.TempDir(), "cp.json")
+	cp := `{"host":"orion-src","destination":"orion-dest","offset":200,"created":200,"failed":0}`
+	_ = ioutil.WriteFile(file, []byte(cp), 0600)
+
+	setupFlagString(set, "host,destination,checkpoint")
+	setupFlagBool(set, "run,resume")
+	mock := testCopyPages(1, http.StatusNoContent)
+	ngsi.HTTP = mock
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion-src", "--destination=orion-dest", "--run", "--checkpoint=" + file, "--resume"})
+	err := copy(c)
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, "201\n", buf.String())
+		assert.Equal(t, 2, mock.index)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestCopyErrorResume(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion-src", "https://orion-src", "v2")
+	setupAddBroker(t, ngsi, "orion-dest", "https://orion-dest", "v2")
+
+	setupFlagString(set, "host,destination")
+	setupFlagBool(set, "run,resume")
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion-src", "--destination=orion-dest", "--run", "--resume"})
+	err := copy(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 6, ngsiErr.ErrNo)
+		assert.Equal(t, "specify checkpoint file with --checkpoint", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestCopyErrorSaveCheckpoint(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion-src", "https://orion-src", "v2")
+	setupAddBroker(t, ngsi, "orion-dest", "https://orion-dest", "v2")
+
+	file := filepath.Join(t.TempDir(), "none", "cp.json")
+
+	setupFlagString(set, "host,destination,checkpoint")
+	setupFlagBool(set, "run")
+	ngsi.HTTP = testCopyPages(1, http.StatusNoContent)
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion-src", "--destination=orion-dest", "--run", "--checkpoint=" + file})
+	err := copy(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 11, ngsiErr.ErrNo)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestCopyErrorAppendFailedIDs(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion-src", "https://orion-src", "v2")
+	setupAddBroker(t, ngsi, "orion-dest", "https://orion-dest", "v2")
+
+	file := filepath.Join(t.TempDir(), "cp.json")
+	_ = os.Mkdir(file+".failed", 0700)
+
+	setupFlagString(set, "host,destination,checkpoint")
+	setupFlagBool(set, "run")
+	mock := testCopyPages(1, http.StatusNoContent)
+	mock.ReqRes[1].Res.StatusCode = http.StatusUnprocessableEntity
+	mock.ReqRes[1].Res.Status = "422 Unprocessable Entity"
+	ngsi.HTTP = mock
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion-src", "--destination=orion-dest", "--run", "--checkpoint=" + file})
+	err := copy(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 10, ngsiErr.ErrNo)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestCopyErrorFailed(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion-src", "https://orion-src", "v2")
+	setupAddBroker(t, ngsi, "orion-dest", "https://orion-dest", "v2")
+
+	file := filepath.Join(t.TempDir(), "cp.json")
+
+	setupFlagString(set, "host,destination,checkpoint")
+	setupFlagBool(set, "run")
+	mock := testCopyPages(3, http.StatusNoContent)
+	mock.ReqRes[3].Res.StatusCode = http.StatusUnprocessableEntity
+	mock.ReqRes[3].Res.Status = "422 Unprocessable Entity"
+	ngsi.HTTP = mock
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion-src", "--destination=orion-dest", "--run", "--checkpoint=" + file})
+	err := copy(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 12, ngsiErr.ErrNo)
+		assert.Equal(t, "created: 2, failed: 1", ngsiErr.Message)
+		b, _ := ioutil.ReadFile(file)
+		expected := `{"host":"orion-src","destination":"orion-dest","offset":300,"created":2,"failed":1}`
+		assert.Equal(t, expected, string(b))
+		b, _ = ioutil.ReadFile(file + ".failed")
+		assert.Equal(t, "device001\n", string(b))
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestCopyErrorRemoveCheckpoint(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion-src", "https://orion-src", "v2")
+	setupAddBroker(t, ngsi, "orion-dest", "https://orion-dest", "v2")
+
+	dir := t.TempDir()
+	_ = ioutil.WriteFile(filepath.Join(dir, "file"), []byte{}, 0600)
+
+	setupFlagString(set, "host,destination,checkpoint")
+	setupFlagBool(set, "run")
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusOK
+	reqRes.ResBody = []byte(`[]`)
+	reqRes.ResHeader = http.Header{"Fiware-Total-Count": []string{"0"}}
+	reqRes.Path = "/v2/entities"
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion-src", "--destination=orion-dest", "--run", "--checkpoint=" + dir})
+	err := copy(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 13, ngsiErr.ErrNo)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestCopyLoadCheckpoint(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "cp.json")
+	_ = ioutil.WriteFile(file, []byte(`{"host":"orion","destination":"orion-ld","type":"Device","offset":100}`), 0600)
+
+	cp, err := copyLoadCheckpoint(file, &copyCheckpoint{Host: "orion", Destination: "orion-ld", Type: "Device"})
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, 100, cp.Offset)
+	}
+}
+
+func TestCopyLoadCheckpointErrorReadFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "cp.json")
+
+	_, err := copyLoadCheckpoint(file, &copyCheckpoint{})
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 2, ngsiErr.ErrNo)
+	}
+}
+
+func TestCopyLoadCheckpointErrorJSONUnmarshal(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "cp.json")
+	_ = ioutil.WriteFile(file, []byte(`{"offset":"100"}`), 0600)
+
+	_, err := copyLoadCheckpoint(file, &copyCheckpoint{})
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 3, ngsiErr.ErrNo)
+	}
+}
+
+func TestCopyLoadCheckpointErrorMismatch(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "cp.json")
+	_ = ioutil.WriteFile(file, []byte(`{"host":"orion","destination":"orion-ld","type":"Device","offset":100}`), 0600)
+
+	_, err := copyLoadCheckpoint(file, &copyCheckpoint{Host: "orion", Destination: "orion-ld", Type: "Room"})
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 4, ngsiErr.ErrNo)
+		assert.Equal(t, "checkpoint mismatch: orion -> orion-ld (type: Device, service: , path: , service2: , path2: )", ngsiErr.Message)
+	}
+}
+
+func TestCopyLoadCheckpointErrorMismatchTenant(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "cp.json")
+	_ = ioutil.WriteFile(file, []byte(`{"host":"orion","destination":"orion-ld","type":"Device","service":"openiot","path":"/iot","offset":100}`), 0600)
+
+	_, err := copyLoadCheckpoint(file, &copyCheckpoint{Host: "orion", Destination: "orion-ld", Type: "Device", Tenant: "openiot", Scope: "/"})
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 4, ngsiErr.ErrNo)
+		assert.Equal(t, "checkpoint mismatch: orion -> orion-ld (type: Device, service: openiot, path: /iot, service2: , path2: )", ngsiErr.Message)
+	}
+}
+
+func TestCopySaveCheckpointErrorRename(t *testing.T) {
+	dir := t.TempDir()
+	_ = ioutil.WriteFile(filepath.Join(dir, "file"), []byte{}, 0600)
+
+	err := copySaveCheckpoint(dir, &copyCheckpoint{})
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 3, ngsiErr.ErrNo)
+	}
+}
+
+func TestCopyAppendFailedIDs(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "cp.json.failed")
+
+	err := copyAppendFailedIDs(file, []string{"device001"})
+	assert.NoError(t, err)
+	err = copyAppendFailedIDs(file, []string{})
+	assert.NoError(t, err)
+	err = copyAppendFailedIDs(file, []string{"device002", "device003"})
+	assert.NoError(t, err)
+
+	b, _ := ioutil.ReadFile(file)
+	assert.Equal(t, "device001\ndevice002\ndevice003\n", string(b))
+}
+
+func TestCopyAppendFailedIDsErrorOpen(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "none", "cp.json.failed")
+
+	err := copyAppendFailedIDs(file, []string{"device001"})
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 1, ngsiErr.ErrNo)
+	}
+}
+
+func TestCopyEntityIDs(t *testing.T) {
+	entities := entitiesRespose{{"id": "E1"}, {"type": "T"}, {"id": "E2"}}
+
+	assert.Equal(t, []string{"E1", "E2"}, copyEntityIDs(entities))
+}
+
+func TestCopyLdToLd(t *testing.T) {
+	ngsi, set, app, buf := setupTest()
+
+	setupAddBroker(t, ngsi, "orion-ld", "https://orion-ld", "ld")
+	setupAddBroker(t, ngsi, "orion-ld2", "https://orion-ld2", "ld")
+
+	setupFlagString(set, "host,destination")
+	setupFlagBool(set, "run")
+	reqRes1 := MockHTTPReqRes{}
+	reqRes1.Res.StatusCode = http.StatusOK
+	reqRes1.ResBody = []byte(`[{"@context":"http://context","id":"urn:ngsi-ld:Device:001","type":"Device"}]`)
+	reqRes1.ResHeader = http.Header{"Ngsild-Results-Count": []string{"1"}}
+	reqRes1.Path = "/ngsi-ld/v1/entities"
+	reqRes2 := MockHTTPReqRes{}
+	reqRes2.Res.StatusCode = http.StatusNoContent
+	reqRes2.ReqData = []byte(`[{"@context":"http://context","id":"urn:ngsi-ld:Device:001","type":"Device"}]`)
+	reqRes2.Path = "/ngsi-ld/v1/entityOperations/upsert"
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes1)
+	mock.ReqRes = append(mock.ReqRes, reqRes2)
+	ngsi.HTTP = mock
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion-ld", "--destination=orion-ld2", "--run"})
+	err := copy(c)
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, "1\n", buf.String())
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestCopyV2ToLd(t *testing.T) {
+	ngsi, set, app, buf := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+	setupAddBroker(t, ngsi, "orion-ld", "https://orion-ld", "ld")
+
+	setupFlagString(set, "host,destination,link2")
+	setupFlagBool(set, "run")
+	reqRes1 := MockHTTPReqRes{}
+	reqRes1.Res.StatusCode = http.StatusOK
+	reqRes1.ResBody = []byte(`[{"id":"device001","type":"Device","temperature":{"type":"Number","value":25,"metadata":{}}}]`)
+	reqRes1.ResHeader = http.Header{"Fiware-Total-Count": []string{"1"}}
+	reqRes1.Path = "/v2/entities"
+	reqRes2 := MockHTTPReqRes{}
+	reqRes2.Res.StatusCode = http.StatusNoContent
+	reqRes2.ReqData = []byte(`[{"id":"urn:ngsi-ld:Device:device001","temperature":{"type":"Property","value":25},"type":"Device"}]`)
+	reqRes2.Path = "/ngsi-ld/v1/entityOperations/upsert"
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes1)
+	mock.ReqRes = append(mock.ReqRes, reqRes2)
+	ngsi.HTTP = mock
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", "--destination=orion-ld", "--link2=http://context", "--run"})
+	err := copy(c)
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, "1\n", buf.String())
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestCopyLdToV2(t *testing.T) {
+	ngsi, set, app, buf := setupTest()
+
+	setupAddBroker(t, ngsi, "orion-ld", "https://orion-ld", "ld")
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+
+	setupFlagString(set, "host,destination")
+	setupFlagBool(set, "run")
+	reqRes1 := MockHTTPReqRes{}
+	reqRes1.Res.StatusCode = http.StatusOK
+	reqRes1.ResBody = []byte(`[{"@context":"http://context","id":"urn:ngsi-ld:Device:001","type":"Device","name":{"type":"Property","value":"device"}}]`)
+	reqRes1.ResHeader = http.Header{"Ngsild-Results-Count": []string{"1"}}
+	reqRes1.Path = "/ngsi-ld/v1/entities"
+	reqRes2 := MockHTTPReqRes{}
+	reqRes2.Res.StatusCode = http.StatusNoContent
+	reqRes2.ReqData = []byte(`{"actionType":"append","entities":[{"id":"urn:ngsi-ld:Device:001","name":{"type":"Text","value":"device"},"type":"Device"}]}`)
+	reqRes2.Path = "/v2/op/update"
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes1)
+	mock.ReqRes = append(mock.ReqRes, reqRes2)
+	ngsi.HTTP = mock
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion-ld", "--destination=orion", "--run"})
+	err := copy(c)
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, "1\n", buf.String())
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestCopyDryRun(t *testing.T) {
+	ngsi, set, app, buf := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+	setupAddBroker(t, ngsi, "orion-ld", "https://orion-ld", "ld")
+
+	setupFlagString(set, "host,destination,checkpoint")
+	setupFlagBool(set, "dryRun")
+	reqRes1 := MockHTTPReqRes{}
+	reqRes1.Res.StatusCode = http.StatusOK
+	reqRes1.ResBody = []byte(`[{"id":"urn:ngsi-ld:Device:001","type":"Device","refRoom":{"type":"Relationship","value":"urn:ngsi-ld:Room:001","metadata":{}}}]`)
+	reqRes1.ResHeader = http.Header{"Fiware-Total-Count": []string{"1"}}
+	reqRes1.Path = "/v2/entities"
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes1)
+	ngsi.HTTP = mock
+	file := filepath.Join(t.TempDir(), "cp.json")
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", "--destination=orion-ld", "--dryRun", "--checkpoint=" + file})
+	err := copy(c)
+
+	if assert.NoError(t, err) {
+		expected := `[{"@context":"https://uri.etsi.org/ngsi-ld/v1/ngsi-ld-core-context.jsonld","id":"urn:ngsi-ld:Device:001","refRoom":{"object":"urn:ngsi-ld:Room:001","type":"Relationship"},"type":"Device"}]` + "\n"
+		assert.Equal(t, expected, buf.String())
+		assert.Equal(t, 1, mock.index)
+		_, err = os.Stat(file)
+		assert.True(t, os.IsNotExist(err))
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestCopyErrorDryRun(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+	setupAddBroker(t, ngsi, "orion-ld", "https://orion-ld", "ld")
+
+	setupFlagString(set, "host,destination")
+	setupFlagBool(set, "dryRun")
+	reqRes1 := MockHTTPReqRes{}
+	reqRes1.Res.StatusCode = http.StatusOK
+	reqRes1.ResBody = []byte(`[{"id":"device001","type":"Device"}]`)
+	reqRes1.ResHeader = http.Header{"Fiware-Total-Count": []string{"1"}}
+	reqRes1.Path = "/v2/entities"
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes1)
+	ngsi.HTTP = mock
+	ngsi.JSONConverter = &MockJSONLib{EncodeErr: errors.New("json error"), Jsonlib: ngsi.JSONConverter}
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", "--destination=orion-ld", "--dryRun"})
+	err := copy(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 8, ngsiErr.ErrNo)
+		assert.Equal(t, "json error", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestCopyMultiStatus(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion-ld", "https://orion-ld", "ld")
+	setupAddBroker(t, ngsi, "orion-ld2", "https://orion-ld2", "ld")
+
+	setupFlagString(set, "host,destination")
+	setupFlagBool(set, "run")
+	reqRes1 := MockHTTPReqRes{}
+	reqRes1.Res.StatusCode = http.StatusOK
+	reqRes1.ResBody = []byte(`[{"id":"urn:ngsi-ld:Device:001","type":"Device"},{"id":"urn:ngsi-ld:Device:002","type":"Device"}]`)
+	reqRes1.ResHeader = http.Header{"Ngsild-Results-Count": []string{"2"}}
+	reqRes1.Path = "/ngsi-ld/v1/entities"
+	reqRes2 := MockHTTPReqRes{}
+	reqRes2.Res.StatusCode = http.StatusMultiStatus
+	reqRes2.ResBody = []byte(`{"success":["urn:ngsi-ld:Device:001"],"errors":[{"entityId":"urn:ngsi-ld:Device:002","error":{"type":"BadRequestData"}}]}`)
+	reqRes2.Path = "/ngsi-ld/v1/entityOperations/upsert"
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes1)
+	mock.ReqRes = append(mock.ReqRes, reqRes2)
+	ngsi.HTTP = mock
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion-ld", "--destination=orion-ld2", "--run"})
+	err := copy(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 12, ngsiErr.ErrNo)
+		assert.Equal(t, "created: 1, failed: 1", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestCopyWriteBatchErrorJSONMarshal(t *testing.T) {
+	ngsi, _, _, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion-ld", "https://orion-ld", "ld")
+	client, _ := ngsi.NewClient("orion-ld", &ngsilib.CmdFlags{}, false)
+	ngsi.JSONConverter = &MockJSONLib{EncodeErr: errors.New("json error"), Jsonlib: ngsi.JSONConverter}
+
+	_, _, err := copyWriteBatch(ngsi, client, entitiesRespose{}, 0)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 1, ngsiErr.ErrNo)
+		assert.Equal(t, "json error", ngsiErr.Message)
+	}
+}
+
+func TestCopyWriteBatchErrorHTTP(t *testing.T) {
+	ngsi, _, _, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion-ld", "https://orion-ld", "ld")
+	reqRes := MockHTTPReqRes{}
+	reqRes.Err = errors.New("http error")
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	client, _ := ngsi.NewClient("orion-ld", &ngsilib.CmdFlags{}, false)
+
+	_, _, err := copyWriteBatch(ngsi, client, entitiesRespose{}, 0)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 2, ngsiErr.ErrNo)
+		assert.Equal(t, "http error", ngsiErr.Message)
+	}
+}
+
+func TestCopyWriteBatchErrorJSONUnmarshal(t *testing.T) {
+	ngsi, _, _, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion-ld", "https://orion-ld", "ld")
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusMultiStatus
+	reqRes.ResBody = []byte(`{"errors":{}}`)
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	client, _ := ngsi.NewClient("orion-ld", &ngsilib.CmdFlags{}, false)
+
+	_, _, err := copyWriteBatch(ngsi, client, entitiesRespose{}, 0)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 4, ngsiErr.ErrNo)
+	}
+}
+
+func TestCopyWriteEntitiesTooLarge(t *testing.T) {
+	ngsi, _, _, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+	reqRes1 := MockHTTPReqRes{}
+	reqRes1.Res.StatusCode = http.StatusRequestEntityTooLarge
+	reqRes2 := MockHTTPReqRes{}
+	reqRes2.Res.StatusCode = http.StatusRequestEntityTooLarge
+	reqRes3 := MockHTTPReqRes{}
+	reqRes3.Res.StatusCode = http.StatusNoContent
+	reqRes3.ReqData = []byte(`{"actionType":"append","entities":[{"id":"device002"}]}`)
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes1)
+	mock.ReqRes = append(mock.ReqRes, reqRes2)
+	mock.ReqRes = append(mock.ReqRes, reqRes3)
+	ngsi.HTTP = mock
+	client, _ := ngsi.NewClient("orion", &ngsilib.CmdFlags{}, false)
+
+	entities := entitiesRespose{{"id": "device001"}, {"id": "device002"}}
+	failedIDs, err := copyWriteEntities(ngsi, client, entities, 0, 2)
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, []string{"device001"}, failedIDs)
+		assert.Equal(t, 3, mock.index)
+	}
+}
+
+func TestCopyWriteEntitiesError(t *testing.T) {
+	ngsi, _, _, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+	reqRes := MockHTTPReqRes{}
+	reqRes.Err = errors.New("http error")
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	client, _ := ngsi.NewClient("orion", &ngsilib.CmdFlags{}, false)
+
+	_, err := copyWriteEntities(ngsi, client, entitiesRespose{{"id": "device001"}}, 0, 100)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 1, ngsiErr.ErrNo)
+		assert.Equal(t, "http error", ngsiErr.Message)
+	}
+}
+
+func TestCopyParallel(t *testing.T) {
+	ngsi, set, app, buf := setupTest()
+
+	setupAddBroker(t, ngsi, "orion-src", "https://orion-src", "v2")
+	setupAddBroker(t, ngsi, "orion-dest", "https://orion-dest", "v2")
+
+	file := filepath.Join(t.TempDir(), "cp.json")
+
+	setupFlagString(set, "host,destination,checkpoint,parallel")
+	setupFlagBool(set, "run")
+	mock := NewMockHTTP()
+	for i := 0; i < 6; i++ {
+		reqRes := MockHTTPReqRes{}
+		reqRes.Res.StatusCode = http.StatusOK
+		reqRes.ResBody = []byte(`[{"id":"device001"}]`)
+		reqRes.ResHeader = http.Header{"Fiware-Total-Count": []string{"250"}}
+		mock.ReqRes = append(mock.ReqRes, reqRes)
+	}
+	ngsi.HTTP = mock
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion-src", "--destination=orion-dest", "--run", "--parallel=3", "--checkpoint=" + file})
+	err := copy(c)
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, "3\n", buf.String())
+		assert.Equal(t, 6, mock.index)
+	} else {
+		t.FailNow()
+	}
+}
@@ -0,0 +1,121 @@
+/*
+MIT License
+
+Copyright (c) 2020 Kazuhito Suda
+
+This file is part of NGSI Go
+
+https://github.com/lets-fiware/ngsi-go
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+package ngsicmd
+
+import (
+	"fmt"
+
+	"github.com/urfave/cli/v2"
+)
+
+func credentialsList(c *cli.Context) error {
+	const funcName = "credentialsList"
+
+	ngsi, err := initCmd(c, funcName, false)
+	if err != nil {
+		return &ngsiCmdError{funcName, 1, err.Error(), err}
+	}
+
+	keys, err := ngsi.CredentialList()
+	if err != nil {
+		return &ngsiCmdError{funcName, 2, err.Error(), err}
+	}
+
+	for _, key := range keys {
+		fmt.Fprintln(ngsi.StdWriter, key)
+	}
+
+	return nil
+}
+
+func credentialsSet(c *cli.Context) error {
+	const funcName = "credentialsSet"
+
+	ngsi, err := initCmd(c, funcName, true)
+	if err != nil {
+		return &ngsiCmdError{funcName, 1, err.Error(), err}
+	}
+
+	client, err := ngsi.BrokerClient(ngsi.Host)
+	if err != nil {
+		return &ngsiCmdError{funcName, 2, err.Error(), err}
+	}
+
+	password := c.String("password")
+	if password == "" {
+		password = client.Broker.Password
+	}
+	if password == "" && ngsi.TermLib.IsTerminal() {
+		password, err = ngsi.TermLib.ReadPassword(fmt.Sprintf("Password for %s: ", client.Broker.Username))
+		if err != nil {
+			return &ngsiCmdError{funcName, 3, err.Error(), err}
+		}
+	}
+	if password == "" {
+		return &ngsiCmdError{funcName, 4, "password is required", nil}
+	}
+
+	err = ngsi.SetCredential(client, password)
+	if err != nil {
+		return &ngsiCmdError{funcName, 5, err.Error(), err}
+	}
+
+	if client.Broker.Password != "" && ngsi.ExistsBrokerHost(ngsi.Host) {
+		if err := ngsi.DeleteItem(ngsi.Host, "password"); err != nil {
+			return &ngsiCmdError{funcName, 6, err.Error(), err}
+		}
+		if err := ngsi.UpdateBroker(ngsi.Host, nil); err != nil {
+			return &ngsiCmdError{funcName, 7, err.Error(), err}
+		}
+	}
+
+	return nil
+}
+
+func credentialsDelete(c *cli.Context) error {
+	const funcName = "credentialsDelete"
+
+	ngsi, err := initCmd(c, funcName, true)
+	if err != nil {
+		return &ngsiCmdError{funcName, 1, err.Error(), err}
+	}
+
+	client, err := ngsi.BrokerClient(ngsi.Host)
+	if err != nil {
+		return &ngsiCmdError{funcName, 2, err.Error(), err}
+	}
+
+	err = ngsi.DeleteCredential(client)
+	if err != nil {
+		return &ngsiCmdError{funcName, 3, err.Error(), err}
+	}
+
+	return nil
+}
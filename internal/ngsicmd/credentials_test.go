@@ -0,0 +1,283 @@
+/*
+MIT License
+
+Copyright (c) 2020 Kazuhito Suda
+
+This file is part of NGSI Go
+
+https://github.com/lets-fiware/ngsi-go
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+package ngsicmd
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/lets-fiware/ngsi-go/internal/ngsilib"
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/cli/v2"
+)
+
+func setupCredentials(ngsi *ngsilib.NGSI, io *MockIoLib, password ...string) {
+	filename := "ngsi-go-credentials.json"
+	io.SetFileName(&filename)
+	ngsi.CredFile = io
+	ngsi.TermLib = &MockTermLib{Terminal: true, Password: password}
+}
+
+func TestCredentialsList(t *testing.T) {
+	ngsi, set, app, buf := setupTest()
+	setupCredentials(ngsi, &MockIoLib{})
+
+	c := cli.NewContext(app, set, nil)
+	err := credentialsList(c)
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, "", buf.String())
+	}
+}
+
+func TestCredentialsListErrorInitCmd(t *testing.T) {
+	_, set, app, _ := setupTest()
+
+	setupFlagString(set, "syslog")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--syslog="})
+	err := credentialsList(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 1, ngsiErr.ErrNo)
+		assert.Equal(t, "syslog logLevel error", ngsiErr.Message)
+	}
+}
+
+func TestCredentialsListErrorList(t *testing.T) {
+	_, set, app, _ := setupTest()
+
+	c := cli.NewContext(app, set, nil)
+	err := credentialsList(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 2, ngsiErr.ErrNo)
+		assert.Equal(t, "credential store not available without config file", ngsiErr.Message)
+	}
+}
+
+func TestCredentialsSet(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+	setupAddBroker2(t, ngsi, "orion", "https://orion", "v2", "keyrock", "https://keyrock", "fiware", "")
+	setupCredentials(ngsi, &MockIoLib{}, "secret")
+
+	setupFlagString(set, "host,password")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", "--password=1234"})
+	err := credentialsSet(c)
+
+	assert.NoError(t, err)
+}
+
+func TestCredentialsSetPrompt(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+	setupAddBroker2(t, ngsi, "orion", "https://orion", "v2", "keyrock", "https://keyrock", "fiware", "")
+	setupCredentials(ngsi, &MockIoLib{}, "1234", "secret")
+
+	setupFlagString(set, "host")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion"})
+	err := credentialsSet(c)
+
+	assert.NoError(t, err)
+}
+
+func TestCredentialsSetMigrate(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+	setupAddBroker2(t, ngsi, "orion", "https://orion", "v2", "tokenproxy", "https://keyrock", "fiware", "1234")
+	setupCredentials(ngsi, &MockIoLib{}, "secret")
+
+	setupFlagString(set, "host")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion"})
+	err := credentialsSet(c)
+
+	if assert.NoError(t, err) {
+		list := ngsi.BrokerList()
+		assert.Equal(t, "", (*list)["orion"].Password)
+	}
+}
+
+func TestCredentialsSetErrorInitCmd(t *testing.T) {
+	_, set, app, _ := setupTest()
+
+	c := cli.NewContext(app, set, nil)
+	err := credentialsSet(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 1, ngsiErr.ErrNo)
+		assert.Equal(t, "Required host not found", ngsiErr.Message)
+	}
+}
+
+func TestCredentialsSetErrorNewClient(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+	setupAddBroker2(t, ngsi, "orion", "https://orion", "v2", "keyrock", "https://keyrock", "fiware", "")
+
+	setupFlagString(set, "host")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion-ld"})
+	err := credentialsSet(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 2, ngsiErr.ErrNo)
+		assert.Equal(t, "orion-ld not found", ngsiErr.Message)
+	}
+}
+
+func TestCredentialsSetErrorReadPassword(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+	setupAddBroker2(t, ngsi, "orion", "https://orion", "v2", "keyrock", "https://keyrock", "fiware", "")
+	ngsi.TermLib = &MockTermLib{Terminal: true, Err: errors.New("read error")}
+
+	setupFlagString(set, "host")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion"})
+	err := credentialsSet(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 3, ngsiErr.ErrNo)
+		assert.Equal(t, "read error", ngsiErr.Message)
+	}
+}
+
+func TestCredentialsSetErrorPassword(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+	setupAddBroker2(t, ngsi, "orion", "https://orion", "v2", "keyrock", "https://keyrock", "fiware", "")
+
+	setupFlagString(set, "host")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion"})
+	err := credentialsSet(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 4, ngsiErr.ErrNo)
+		assert.Equal(t, "password is required", ngsiErr.Message)
+	}
+}
+
+func TestCredentialsSetErrorSetCredential(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+	setupAddBroker2(t, ngsi, "orion", "https://orion", "v2", "keyrock", "https://keyrock", "fiware", "")
+	setupCredentials(ngsi, &MockIoLib{})
+
+	setupFlagString(set, "host,password")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", "--password=1234"})
+	err := credentialsSet(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 5, ngsiErr.ErrNo)
+		assert.Equal(t, "passphrase is required (set NGSI_GO_PASSPHRASE)", ngsiErr.Message)
+	}
+}
+
+func TestCredentialsSetErrorUpdateBroker(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+	setupAddBroker2(t, ngsi, "orion", "https://orion", "v2", "tokenproxy", "https://keyrock", "fiware", "1234")
+	setupCredentials(ngsi, &MockIoLib{}, "secret")
+	list := ngsi.BrokerList()
+	(*list)["orion"].NgsiType = "v3"
+
+	setupFlagString(set, "host")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion"})
+	err := credentialsSet(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 7, ngsiErr.ErrNo)
+		assert.Equal(t, "v3 not found", ngsiErr.Message)
+	}
+}
+
+func TestCredentialsDelete(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+	setupAddBroker2(t, ngsi, "orion", "https://orion", "v2", "keyrock", "https://keyrock", "fiware", "")
+	setupCredentials(ngsi, &MockIoLib{})
+
+	setupFlagString(set, "host")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion"})
+	err := credentialsDelete(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 3, ngsiErr.ErrNo)
+		assert.Equal(t, "fiware@https://keyrock not found", ngsiErr.Message)
+	}
+}
+
+func TestCredentialsDeleteErrorInitCmd(t *testing.T) {
+	_, set, app, _ := setupTest()
+
+	c := cli.NewContext(app, set, nil)
+	err := credentialsDelete(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 1, ngsiErr.ErrNo)
+		assert.Equal(t, "Required host not found", ngsiErr.Message)
+	}
+}
+
+func TestCredentialsDeleteErrorNewClient(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+	setupAddBroker2(t, ngsi, "orion", "https://orion", "v2", "keyrock", "https://keyrock", "fiware", "")
+
+	setupFlagString(set, "host")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion-ld"})
+	err := credentialsDelete(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 2, ngsiErr.ErrNo)
+		assert.Equal(t, "orion-ld not found", ngsiErr.Message)
+	}
+}
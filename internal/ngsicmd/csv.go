@@ -0,0 +1,461 @@
+/*
+MIT License
+
+Copyright (c) 2020 Kazuhito Suda
+
+This file is part of NGSI Go
+
+https://github.com/lets-fiware/ngsi-go
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+package ngsicmd
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/lets-fiware/ngsi-go/internal/ngsilib"
+	"github.com/urfave/cli/v2"
+)
+
+type csvColumn struct {
+	name     string
+	attrType string
+}
+
+type csvEntityReader struct {
+	reader  *csv.Reader
+	columns []csvColumn
+	ngsiLd  bool
+	line    int
+}
+
+type csvEntityWriter struct {
+	writer  *csv.Writer
+	columns []csvColumn
+	ngsiLd  bool
+	types   map[string]string
+	typed   map[string]bool
+}
+
+func batchCSV(c *cli.Context, ngsi *ngsilib.NGSI, client *ngsilib.Client, mode string) error {
+	const funcName = "batchCSV"
+
+	actionTypes := map[string]string{"create": "append_strict", "update": "update", "upsert": "append"}
+	if _, ok := actionTypes[mode]; !ok {
+		return &ngsiCmdError{funcName, 1, "csv format is not supported: " + mode, nil}
+	}
+
+	fileReader, err := getReader(c, ngsi)
+	if err != nil {
+		return &ngsiCmdError{funcName, 2, err.Error(), err}
+	}
+	defer fileReader.Close()
+
+	reader, err := newCSVEntityReader(fileReader.File(), client.IsNgsiLd())
+	if err != nil {
+		return &ngsiCmdError{funcName, 3, err.Error(), err}
+	}
+
+	size := batchSize(c, ngsi, client)
+
+	var entities []interface{}
+
+	for {
+		entity, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return &ngsiCmdError{funcName, 4, err.Error(), err}
+		}
+		entities = append(entities, entity)
+
+		if len(entities) >= size {
+			if err := batchCSVPost(c, ngsi, client, mode, actionTypes[mode], entities); err != nil {
+				return &ngsiCmdError{funcName, 5, err.Error(), err}
+			}
+			entities = nil
+		}
+	}
+
+	if len(entities) > 0 {
+		if err := batchCSVPost(c, ngsi, client, mode, actionTypes[mode], entities); err != nil {
+			return &ngsiCmdError{funcName, 6, err.Error(), err}
+		}
+	}
+
+	return nil
+}
+
+func batchCSVPost(c *cli.Context, ngsi *ngsilib.NGSI, client *ngsilib.Client, mode, actionType string, entities []interface{}) error {
+	const funcName = "batchCSVPost"
+
+	if client.IsNgsiV2() {
+		res, body, err := client.OpUpdate(entities, actionType, false, client.IsSafeString())
+		if err != nil {
+			return &ngsiCmdError{funcName, 1, err.Error(), err}
+		}
+		if res.StatusCode != http.StatusNoContent {
+			return &ngsiCmdError{funcName, 2, fmt.Sprintf("%s %s", res.Status, string(body)), nil}
+		}
+		return nil
+	}
+
+	client.SetPath("/entityOperations/" + mode)
+
+	var opts []string
+	switch mode {
+	case "update":
+		opts = []string{"noOverwrite", "replace"}
+	case "upsert":
+		opts = []string{"replace", "update"}
+	}
+	v := parseOptions(c, nil, opts)
+	client.SetQuery(v)
+
+	client.SetHeader("Content-Type", "application/json")
+
+	b, err := ngsilib.JSONMarshalEncode(&entities, client.IsSafeString())
+	if err != nil {
+		return &ngsiCmdError{funcName, 3, err.Error(), err}
+	}
+
+	res, body, err := client.HTTPPost(b)
+	if err != nil {
+		return &ngsiCmdError{funcName, 4, err.Error(), err}
+	}
+	switch res.StatusCode {
+	default:
+		return &ngsiCmdError{funcName, 5, fmt.Sprintf("%s %s", res.Status, string(body)), nil}
+	case http.StatusOK, http.StatusCreated:
+		if mode == "create" && len(body) > 0 {
+			printJSON(ngsi, body)
+		}
+	case http.StatusNoContent:
+	}
+
+	return nil
+}
+
+func newCSVEntityReader(r io.Reader, ngsiLd bool) (*csvEntityReader, error) {
+	const funcName = "newCSVEntityReader"
+
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		if err == io.EOF {
+			return nil, &ngsiCmdError{funcName, 1, "csv header not found", nil}
+		}
+		return nil, &ngsiCmdError{funcName, 2, err.Error(), err}
+	}
+
+	columns := make([]csvColumn, len(header))
+	hasID := false
+	for i, h := range header {
+		h = strings.TrimSpace(h)
+		if i == 0 {
+			h = strings.TrimPrefix(h, "\ufeff")
+		}
+		if pos := strings.Index(h, ":"); pos != -1 {
+			columns[i] = csvColumn{name: h[:pos], attrType: h[pos+1:]}
+		} else {
+			columns[i] = csvColumn{name: h}
+		}
+		if columns[i].name == "" {
+			return nil, &ngsiCmdError{funcName, 3, fmt.Sprintf("column name error: %d", i+1), nil}
+		}
+		if columns[i].name == "id" {
+			hasID = true
+		}
+	}
+	if !hasID {
+		return nil, &ngsiCmdError{funcName, 4, "id column not found", nil}
+	}
+
+	return &csvEntityReader{reader: reader, columns: columns, ngsiLd: ngsiLd, line: 1}, nil
+}
+
+func (r *csvEntityReader) Read() (map[string]interface{}, error) {
+	const funcName = "csvEntityReader.Read"
+
+	record, err := r.reader.Read()
+	if err == io.EOF {
+		return nil, err
+	}
+	if err != nil {
+		return nil, &ngsiCmdError{funcName, 1, err.Error(), err}
+	}
+	r.line++
+	line := r.line
+	if len(record) > len(r.columns) {
+		return nil, &ngsiCmdError{funcName, 2, fmt.Sprintf("too many fields (%d)", line), nil}
+	}
+
+	entity := make(map[string]interface{})
+	for i, s := range record {
+		col := r.columns[i]
+		switch col.name {
+		case "id":
+			if s == "" {
+				return nil, &ngsiCmdError{funcName, 3, fmt.Sprintf("id is empty (%d)", line), nil}
+			}
+			entity["id"] = s
+		case "type":
+			if s != "" {
+				entity["type"] = s
+			}
+		default:
+			if s == "" {
+				continue
+			}
+			attr, err := csvAttr(col.attrType, s, r.ngsiLd)
+			if err != nil {
+				return nil, &ngsiCmdError{funcName, 4, fmt.Sprintf("%s: %s (%d)", col.name, err.Error(), line), err}
+			}
+			entity[col.name] = attr
+		}
+	}
+
+	return entity, nil
+}
+
+func csvAttr(attrType, s string, ngsiLd bool) (map[string]interface{}, error) {
+	const funcName = "csvAttr"
+
+	var value interface{}
+	var err error
+
+	switch strings.ToLower(attrType) {
+	case "", "text", "string", "datetime":
+		value = s
+	case "number", "float":
+		value, err = strconv.ParseFloat(s, 64)
+	case "integer":
+		value, err = strconv.ParseInt(s, 10, 64)
+	case "boolean":
+		value, err = strconv.ParseBool(s)
+	case "relationship":
+		value = s
+	case "structuredvalue", "geo:json", "geoproperty", "json":
+		err = json.Unmarshal([]byte(s), &value)
+	default:
+		value = s
+	}
+	if err != nil {
+		return nil, &ngsiCmdError{funcName, 1, fmt.Sprintf("%s is not %s", s, attrType), err}
+	}
+
+	if !ngsiLd {
+		if attrType == "" {
+			attrType = "Text"
+		}
+		return map[string]interface{}{"type": attrType, "value": value}, nil
+	}
+
+	switch strings.ToLower(attrType) {
+	case "relationship":
+		return map[string]interface{}{"type": "Relationship", "object": value}, nil
+	case "geoproperty", "geo:json":
+		return map[string]interface{}{"type": "GeoProperty", "value": value}, nil
+	case "datetime":
+		return map[string]interface{}{"type": "Property", "value": map[string]interface{}{"@type": "DateTime", "@value": value}}, nil
+	}
+	return map[string]interface{}{"type": "Property", "value": value}, nil
+}
+
+func newCSVEntityWriter(w io.Writer, ngsiLd bool) *csvEntityWriter {
+	return &csvEntityWriter{writer: csv.NewWriter(w), ngsiLd: ngsiLd}
+}
+
+// Scan collects the names and the types of the attributes of entities without keeping the entities,
+// so that the columns of all the pages are known before the first row is written.
+func (w *csvEntityWriter) Scan(entities entitiesRespose) {
+	if w.types == nil {
+		w.types = make(map[string]string)
+		w.typed = make(map[string]bool)
+	}
+	for _, e := range entities {
+		for k, v := range e {
+			if k == "id" || k == "type" || k == "@context" {
+				continue
+			}
+			if _, ok := w.types[k]; !ok {
+				w.types[k] = ""
+			}
+			if attr, ok := v.(map[string]interface{}); ok && !w.typed[k] {
+				w.types[k] = csvAttrType(attr, w.ngsiLd)
+				w.typed[k] = true
+			}
+		}
+	}
+}
+
+func (w *csvEntityWriter) scannedColumns() []csvColumn {
+	names := make([]string, 0, len(w.types))
+	for name := range w.types {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	columns := []csvColumn{{name: "id"}, {name: "type"}}
+	for _, name := range names {
+		columns = append(columns, csvColumn{name: name, attrType: w.types[name]})
+	}
+	return columns
+}
+
+func (w *csvEntityWriter) Write(entities entitiesRespose, attrs string) error {
+	const funcName = "csvEntityWriter.Write"
+
+	if w.columns == nil {
+		if attrs == "" && w.types != nil {
+			w.columns = w.scannedColumns()
+		} else {
+			w.columns = csvColumns(entities, attrs, w.ngsiLd)
+		}
+		header := make([]string, len(w.columns))
+		for i, col := range w.columns {
+			header[i] = col.name
+			if col.attrType != "" {
+				header[i] += ":" + col.attrType
+			}
+		}
+		if err := w.writer.Write(header); err != nil {
+			return &ngsiCmdError{funcName, 1, err.Error(), err}
+		}
+	}
+
+	for _, e := range entities {
+		record := make([]string, len(w.columns))
+		for i, col := range w.columns {
+			s, err := csvCell(e[col.name], w.ngsiLd)
+			if err != nil {
+				return &ngsiCmdError{funcName, 2, err.Error(), err}
+			}
+			record[i] = s
+		}
+		if err := w.writer.Write(record); err != nil {
+			return &ngsiCmdError{funcName, 3, err.Error(), err}
+		}
+	}
+
+	w.writer.Flush()
+	if err := w.writer.Error(); err != nil {
+		return &ngsiCmdError{funcName, 4, err.Error(), err}
+	}
+
+	return nil
+}
+
+func csvColumns(entities entitiesRespose, attrs string, ngsiLd bool) []csvColumn {
+	columns := []csvColumn{{name: "id"}, {name: "type"}}
+
+	var names []string
+	if attrs != "" {
+		names = strings.Split(attrs, ",")
+	} else {
+		m := make(map[string]bool)
+		for _, e := range entities {
+			for k := range e {
+				if k != "id" && k != "type" && k != "@context" && !m[k] {
+					m[k] = true
+					names = append(names, k)
+				}
+			}
+		}
+		sort.Strings(names)
+	}
+
+	for _, name := range names {
+		col := csvColumn{name: name}
+		for _, e := range entities {
+			if attr, ok := e[name].(map[string]interface{}); ok {
+				col.attrType = csvAttrType(attr, ngsiLd)
+				break
+			}
+		}
+		columns = append(columns, col)
+	}
+
+	return columns
+}
+
+func csvAttrType(attr map[string]interface{}, ngsiLd bool) string {
+	attrType, _ := attr["type"].(string)
+	if !ngsiLd {
+		return attrType
+	}
+	switch attrType {
+	case "Relationship", "GeoProperty":
+		return attrType
+	}
+	switch attr["value"].(type) {
+	case float64:
+		return "Number"
+	case bool:
+		return "Boolean"
+	case map[string]interface{}, []interface{}:
+		return "StructuredValue"
+	}
+	return ""
+}
+
+func csvCell(v interface{}, ngsiLd bool) (string, error) {
+	const funcName = "csvCell"
+
+	if v == nil {
+		return "", nil
+	}
+	if attr, ok := v.(map[string]interface{}); ok {
+		if ngsiLd && attr["type"] == "Relationship" {
+			v = attr["object"]
+		} else {
+			v = attr["value"]
+		}
+	}
+
+	switch v := v.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	}
+
+	b, err := ngsilib.JSONMarshal(v)
+	if err != nil {
+		return "", &ngsiCmdError{funcName, 1, err.Error(), err}
+	}
+	return strings.TrimRight(string(b), "\n"), nil
+}
@@ -0,0 +1,617 @@
+/*
+MIT License
+
+Copyright (c) 2020 Kazuhito Suda
+
+This file is part of NGSI Go
+
+https://github.com/lets-fiware/ngsi-go
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+package ngsicmd
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/cli/v2"
+)
+
+const testCSVData = "id,type,name,price:Number,stock:Integer,available:Boolean,refShop:Relationship,location:geo:json\n" +
+	"urn:ngsi-ld:Product:001,Product,Apple,1.5,10,true,urn:ngsi-ld:Shop:001,\"{\"\"type\"\":\"\"Point\"\",\"\"coordinates\"\":[139,35]}\"\n" +
+	"urn:ngsi-ld:Product:002,Product,Orange,,,,,\n"
+
+func TestBatchCSVV2(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusNoContent
+	reqRes.Path = "/v2/op/update"
+	reqRes.ReqData = []byte(`{"actionType":"append_strict","entities":[{"available":{"type":"Boolean","value":true},"id":"urn:ngsi-ld:Product:001","location":{"type":"geo:json","value":{"coordinates":[139,35],"type":"Point"}},"name":{"type":"Text","value":"Apple"},"price":{"type":"Number","value":1.5},"refShop":{"type":"Relationship","value":"urn:ngsi-ld:Shop:001"},"stock":{"type":"Integer","value":10},"type":"Product"},{"id":"urn:ngsi-ld:Product:002","name":{"type":"Text","value":"Orange"},"type":"Product"}]}`)
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	setupFlagString(set, "host,data,format")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", "--format=csv", "--data=" + testCSVData})
+	err := batch(c, "create")
+
+	assert.NoError(t, err)
+}
+
+func TestBatchCSVV2Page(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+
+	reqRes1 := MockHTTPReqRes{}
+	reqRes1.Res.StatusCode = http.StatusNoContent
+	reqRes1.Path = "/v2/op/update"
+	reqRes2 := MockHTTPReqRes{}
+	reqRes2.Res.StatusCode = http.StatusNoContent
+	reqRes2.Path = "/v2/op/update"
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes1)
+	mock.ReqRes = append(mock.ReqRes, reqRes2)
+	ngsi.HTTP = mock
+	setupFlagString(set, "host,data,format")
+
+	data := "id,type,temperature:Number\n"
+	for i := 0; i < 101; i++ {
+		data += "urn:ngsi-ld:Sensor:" + strings.Repeat("0", 3) + ",Sensor,20\n"
+	}
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", "--format=csv", "--data=" + data})
+	err := batch(c, "upsert")
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, 2, mock.index)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestBatchCSVLd(t *testing.T) {
+	ngsi, set, app, buf := setupTest()
+
+	setupAddBroker(t, ngsi, "orion-ld", "https://orion-ld", "ld")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusCreated
+	reqRes.Path = "/ngsi-ld/v1/entityOperations/create"
+	reqRes.ReqData = []byte(`[{"available":{"type":"Property","value":true},"id":"urn:ngsi-ld:Product:001","location":{"type":"GeoProperty","value":{"coordinates":[139,35],"type":"Point"}},"name":{"type":"Property","value":"Apple"},"price":{"type":"Property","value":1.5},"refShop":{"object":"urn:ngsi-ld:Shop:001","type":"Relationship"},"stock":{"type":"Property","value":10},"type":"Product"},{"id":"urn:ngsi-ld:Product:002","name":{"type":"Property","value":"Orange"},"type":"Product"}]`)
+	reqRes.ResBody = []byte(`["urn:ngsi-ld:Product:001","urn:ngsi-ld:Product:002"]`)
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	setupFlagString(set, "host,data,format")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion-ld", "--format=csv", "--data=" + testCSVData})
+	err := batch(c, "create")
+
+	if assert.NoError(t, err) {
+		actual := buf.String()
+		expected := "[\"urn:ngsi-ld:Product:001\",\"urn:ngsi-ld:Product:002\"]\n"
+		assert.Equal(t, expected, actual)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestBatchCSVLdUpdate(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion-ld", "https://orion-ld", "ld")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusNoContent
+	reqRes.Path = "/ngsi-ld/v1/entityOperations/update"
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	setupFlagString(set, "host,data,format")
+	setupFlagBool(set, "noOverwrite")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion-ld", "--format=csv", "--noOverwrite", "--data=id,temperature:Number,observedAt:DateTime\nurn:ngsi-ld:Sensor:001,20,2020-11-01T00:00:00Z\n"})
+	err := batch(c, "update")
+
+	assert.NoError(t, err)
+}
+
+func TestBatchErrorFormat(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+	setupFlagString(set, "host,data,format")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", "--format=xml"})
+	err := batch(c, "create")
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 3, ngsiErr.ErrNo)
+		assert.Equal(t, "format error: xml", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestBatchCSVErrorMode(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+	setupFlagString(set, "host,data,format")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", "--format=csv"})
+	err := batch(c, "delete")
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 1, ngsiErr.ErrNo)
+		assert.Equal(t, "csv format is not supported: delete", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestBatchCSVErrorReader(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+	setupFlagString(set, "host,data,format")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", "--format=csv"})
+	err := batch(c, "create")
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 2, ngsiErr.ErrNo)
+		assert.Equal(t, "data is empty", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestBatchCSVErrorHeader(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+	setupFlagString(set, "host,data,format")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", "--format=csv", "--data=type,name\n"})
+	err := batch(c, "create")
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 3, ngsiErr.ErrNo)
+		assert.Equal(t, "id column not found", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestBatchCSVErrorRead(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+	setupFlagString(set, "host,data,format")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", "--format=csv", "--data=id,temperature:Number\nurn:ngsi-ld:Sensor:001,abc\n"})
+	err := batch(c, "create")
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 4, ngsiErr.ErrNo)
+		assert.Equal(t, "temperature: abc is not Number (2)", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestBatchCSVErrorPostPage(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Err = errors.New("http error")
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	setupFlagString(set, "host,data,format")
+
+	data := "id,type\n" + strings.Repeat("urn:ngsi-ld:Sensor:001,Sensor\n", 100)
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", "--format=csv", "--data=" + data})
+	err := batch(c, "create")
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 5, ngsiErr.ErrNo)
+		assert.Equal(t, "http error", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestBatchCSVErrorPost(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusBadRequest
+	reqRes.Res.Status = "400 Bad Request"
+	reqRes.ResBody = []byte("error")
+	reqRes.Path = "/v2/op/update"
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	setupFlagString(set, "host,data,format")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", "--format=csv", "--data=" + testCSVData})
+	err := batch(c, "create")
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 6, ngsiErr.ErrNo)
+		assert.Equal(t, "400 Bad Request error", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestBatchCSVPostErrorLdMarshal(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion-ld", "https://orion-ld", "ld")
+	ngsi.JSONConverter = &MockJSONLib{EncodeErr: errors.New("json error"), DecodeErr: errors.New("json error")}
+	setupFlagString(set, "host")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion-ld"})
+	client, _ := newClient(ngsi, c, false)
+	err := batchCSVPost(c, ngsi, client, "upsert", "append", []interface{}{map[string]interface{}{"id": "E1"}})
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 3, ngsiErr.ErrNo)
+		assert.Equal(t, "json error", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestBatchCSVPostErrorLdHTTP(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion-ld", "https://orion-ld", "ld")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Err = errors.New("http error")
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	setupFlagString(set, "host")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion-ld"})
+	client, _ := newClient(ngsi, c, false)
+	err := batchCSVPost(c, ngsi, client, "upsert", "append", []interface{}{map[string]interface{}{"id": "E1"}})
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 4, ngsiErr.ErrNo)
+		assert.Equal(t, "http error", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestBatchCSVPostErrorLdHTTPStatus(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion-ld", "https://orion-ld", "ld")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusMultiStatus
+	reqRes.Res.Status = "207 Multi-Status"
+	reqRes.ResBody = []byte("error")
+	reqRes.Path = "/ngsi-ld/v1/entityOperations/upsert"
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	setupFlagString(set, "host")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion-ld"})
+	client, _ := newClient(ngsi, c, false)
+	err := batchCSVPost(c, ngsi, client, "upsert", "append", []interface{}{map[string]interface{}{"id": "E1"}})
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 5, ngsiErr.ErrNo)
+		assert.Equal(t, "207 Multi-Status error", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestBatchCSVPostErrorV2HTTP(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Err = errors.New("http error")
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	setupFlagString(set, "host")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion"})
+	client, _ := newClient(ngsi, c, false)
+	err := batchCSVPost(c, ngsi, client, "upsert", "append", []interface{}{map[string]interface{}{"id": "E1"}})
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 1, ngsiErr.ErrNo)
+		assert.Equal(t, "http error", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestNewCSVEntityReaderErrorEmpty(t *testing.T) {
+	_, err := newCSVEntityReader(strings.NewReader(""), false)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 1, ngsiErr.ErrNo)
+		assert.Equal(t, "csv header not found", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestNewCSVEntityReaderErrorCSV(t *testing.T) {
+	_, err := newCSVEntityReader(strings.NewReader("id,\"type\n"), false)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 2, ngsiErr.ErrNo)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestNewCSVEntityReaderErrorColumnName(t *testing.T) {
+	_, err := newCSVEntityReader(strings.NewReader("id,:Number\n"), false)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 3, ngsiErr.ErrNo)
+		assert.Equal(t, "column name error: 2", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestCSVEntityReaderReadErrorCSV(t *testing.T) {
+	reader, err := newCSVEntityReader(strings.NewReader("id,type\n\"E1,T1\n"), false)
+	assert.NoError(t, err)
+
+	_, err = reader.Read()
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 1, ngsiErr.ErrNo)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestCSVEntityReaderReadErrorFields(t *testing.T) {
+	reader, err := newCSVEntityReader(strings.NewReader("id,type\nE1,T1,X\n"), false)
+	assert.NoError(t, err)
+
+	_, err = reader.Read()
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 2, ngsiErr.ErrNo)
+		assert.Equal(t, "too many fields (2)", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestCSVEntityReaderReadErrorID(t *testing.T) {
+	reader, err := newCSVEntityReader(strings.NewReader("id,type\n,T1\n"), false)
+	assert.NoError(t, err)
+
+	_, err = reader.Read()
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 3, ngsiErr.ErrNo)
+		assert.Equal(t, "id is empty (2)", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestCSVAttr(t *testing.T) {
+	cases := []struct {
+		attrType string
+		value    string
+		ngsiLd   bool
+		expected map[string]interface{}
+	}{
+		{attrType: "", value: "abc", ngsiLd: false, expected: map[string]interface{}{"type": "Text", "value": "abc"}},
+		{attrType: "Float", value: "1.5", ngsiLd: false, expected: map[string]interface{}{"type": "Float", "value": 1.5}},
+		{attrType: "DateTime", value: "2020-11-01T00:00:00Z", ngsiLd: false, expected: map[string]interface{}{"type": "DateTime", "value": "2020-11-01T00:00:00Z"}},
+		{attrType: "StructuredValue", value: `{"a":1}`, ngsiLd: false, expected: map[string]interface{}{"type": "StructuredValue", "value": map[string]interface{}{"a": float64(1)}}},
+		{attrType: "Color", value: "red", ngsiLd: false, expected: map[string]interface{}{"type": "Color", "value": "red"}},
+		{attrType: "GeoProperty", value: `{"type":"Point","coordinates":[1,2]}`, ngsiLd: true, expected: map[string]interface{}{"type": "GeoProperty", "value": map[string]interface{}{"type": "Point", "coordinates": []interface{}{float64(1), float64(2)}}}},
+		{attrType: "DateTime", value: "2020-11-01T00:00:00Z", ngsiLd: true, expected: map[string]interface{}{"type": "Property", "value": map[string]interface{}{"@type": "DateTime", "@value": "2020-11-01T00:00:00Z"}}},
+	}
+
+	for _, c := range cases {
+		actual, err := csvAttr(c.attrType, c.value, c.ngsiLd)
+		if assert.NoError(t, err) {
+			assert.Equal(t, c.expected, actual)
+		}
+	}
+}
+
+func TestCSVAttrError(t *testing.T) {
+	_, err := csvAttr("Boolean", "yes", false)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 1, ngsiErr.ErrNo)
+		assert.Equal(t, "yes is not Boolean", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestCSVEntityWriterV2(t *testing.T) {
+	buf := &bytes.Buffer{}
+	w := newCSVEntityWriter(buf, false)
+
+	entities := entitiesRespose{
+		{"id": "E1", "type": "T", "name": map[string]interface{}{"type": "Text", "value": "a,b"}, "price": map[string]interface{}{"type": "Number", "value": 1.5}},
+		{"id": "E2", "type": "T", "location": map[string]interface{}{"type": "geo:json", "value": map[string]interface{}{"type": "Point", "coordinates": []interface{}{float64(1), float64(2)}}}},
+	}
+	err := w.Write(entities, "")
+	assert.NoError(t, err)
+	err = w.Write(entitiesRespose{{"id": "E3", "type": "T", "flag": map[string]interface{}{"type": "Boolean", "value": true}}}, "")
+
+	if assert.NoError(t, err) {
+		expected := "id,type,location:geo:json,name:Text,price:Number\n" +
+			"E1,T,,\"a,b\",1.5\n" +
+			"E2,T,\"{\"\"coordinates\"\":[1,2],\"\"type\"\":\"\"Point\"\"}\",,\n" +
+			"E3,T,,,\n"
+		assert.Equal(t, expected, buf.String())
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestCSVEntityWriterScan(t *testing.T) {
+	buf := &bytes.Buffer{}
+	w := newCSVEntityWriter(buf, false)
+
+	w.Scan(entitiesRespose{{"id": "E1", "type": "T", "price": map[string]interface{}{"type": "Number", "value": 1.5}}})
+	w.Scan(entitiesRespose{{"id": "E2", "type": "T", "name": "a", "price": map[string]interface{}{"type": "Text", "value": "b"}}})
+	err := w.Write(entitiesRespose{{"id": "E1", "type": "T", "price": map[string]interface{}{"type": "Number", "value": 1.5}}}, "")
+
+	if assert.NoError(t, err) {
+		expected := "id,type,name,price:Number\n" +
+			"E1,T,,1.5\n"
+		assert.Equal(t, expected, buf.String())
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestCSVEntityWriterLd(t *testing.T) {
+	buf := &bytes.Buffer{}
+	w := newCSVEntityWriter(buf, true)
+
+	entities := entitiesRespose{
+		{"id": "E1", "type": "T", "@context": "http://context",
+			"name":    map[string]interface{}{"type": "Property", "value": "abc"},
+			"price":   map[string]interface{}{"type": "Property", "value": 1.5},
+			"flag":    map[string]interface{}{"type": "Property", "value": false},
+			"obj":     map[string]interface{}{"type": "Property", "value": map[string]interface{}{"a": "b"}},
+			"refShop": map[string]interface{}{"type": "Relationship", "object": "urn:ngsi-ld:Shop:001"},
+		},
+	}
+	err := w.Write(entities, "")
+
+	if assert.NoError(t, err) {
+		expected := "id,type,flag:Boolean,name,obj:StructuredValue,price:Number,refShop:Relationship\n" +
+			"E1,T,false,abc,\"{\"\"a\"\":\"\"b\"\"}\",1.5,urn:ngsi-ld:Shop:001\n"
+		assert.Equal(t, expected, buf.String())
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestCSVEntityWriterAttrs(t *testing.T) {
+	buf := &bytes.Buffer{}
+	w := newCSVEntityWriter(buf, false)
+
+	entities := entitiesRespose{
+		{"id": "E1", "type": "T", "name": "abc", "price": 1.5},
+	}
+	err := w.Write(entities, "price,name")
+
+	if assert.NoError(t, err) {
+		expected := "id,type,price,name\nE1,T,1.5,abc\n"
+		assert.Equal(t, expected, buf.String())
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestCSVEntityWriterErrorCell(t *testing.T) {
+	ngsi, _, _, _ := setupTest()
+	ngsi.JSONConverter = &MockJSONLib{EncodeErr: errors.New("json error"), DecodeErr: errors.New("json error")}
+
+	buf := &bytes.Buffer{}
+	w := newCSVEntityWriter(buf, false)
+
+	entities := entitiesRespose{
+		{"id": "E1", "type": "T", "obj": map[string]interface{}{"type": "StructuredValue", "value": map[string]interface{}{"a": "b"}}},
+	}
+	err := w.Write(entities, "")
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 2, ngsiErr.ErrNo)
+		assert.Equal(t, "json error", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
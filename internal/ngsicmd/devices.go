@@ -0,0 +1,220 @@
+/*
+MIT License
+
+Copyright (c) 2020 Kazuhito Suda
+
+This file is part of NGSI Go
+
+https://github.com/lets-fiware/ngsi-go
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+package ngsicmd
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/lets-fiware/ngsi-go/internal/ngsilib"
+	"github.com/urfave/cli/v2"
+)
+
+func devicesList(c *cli.Context) error {
+	const funcName = "devicesList"
+
+	ngsi, err := initCmd(c, funcName, true)
+	if err != nil {
+		return &ngsiCmdError{funcName, 1, err.Error(), err}
+	}
+
+	client, err := newClient(ngsi, c, false)
+	if err != nil {
+		return &ngsiCmdError{funcName, 2, err.Error(), err}
+	}
+
+	if !client.IsIoTAgent() {
+		return &ngsiCmdError{funcName, 3, "only available on IoT Agent", nil}
+	}
+
+	devices, err := iotaList(client, "devices", pageSize(c, ngsi, client))
+	if err != nil {
+		return &ngsiCmdError{funcName, 4, err.Error(), err}
+	}
+
+	if outputEnabled(c) {
+		if err := outputPrint(c, ngsi, devices); err != nil {
+			return &ngsiCmdError{funcName, 5, err.Error(), err}
+		}
+	} else if c.Bool("json") {
+		b, err := ngsilib.JSONMarshal(devices)
+		if err != nil {
+			return &ngsiCmdError{funcName, 6, err.Error(), err}
+		}
+		printJSON(ngsi, b)
+	} else if c.Bool("verbose") {
+		for _, e := range devices {
+			fmt.Fprintf(ngsi.StdWriter, "%s %s %s\n", iotaString(e, "device_id"), iotaString(e, "entity_name"), iotaString(e, "entity_type"))
+		}
+	} else {
+		for _, e := range devices {
+			fmt.Fprintln(ngsi.StdWriter, iotaString(e, "device_id"))
+		}
+	}
+
+	return nil
+}
+
+func devicesGet(c *cli.Context) error {
+	const funcName = "devicesGet"
+
+	ngsi, err := initCmd(c, funcName, true)
+	if err != nil {
+		return &ngsiCmdError{funcName, 1, err.Error(), err}
+	}
+
+	client, err := newClient(ngsi, c, false)
+	if err != nil {
+		return &ngsiCmdError{funcName, 2, err.Error(), err}
+	}
+
+	if !client.IsIoTAgent() {
+		return &ngsiCmdError{funcName, 3, "only available on IoT Agent", nil}
+	}
+
+	id := c.String("id")
+	client.SetPath("/iot/devices/" + id)
+
+	res, body, err := client.HTTPGet()
+	if err != nil {
+		return &ngsiCmdError{funcName, 4, err.Error(), err}
+	}
+	if res.StatusCode != http.StatusOK {
+		return &ngsiCmdError{funcName, 5, fmt.Sprintf("%s %s %s", id, res.Status, string(body)), nil}
+	}
+
+	if outputEnabled(c) {
+		if err := outputPrint(c, ngsi, body); err != nil {
+			return &ngsiCmdError{funcName, 6, err.Error(), err}
+		}
+		return nil
+	}
+
+	printJSON(ngsi, body)
+
+	return nil
+}
+
+func devicesCreate(c *cli.Context) error {
+	const funcName = "devicesCreate"
+
+	ngsi, err := initCmd(c, funcName, true)
+	if err != nil {
+		return &ngsiCmdError{funcName, 1, err.Error(), err}
+	}
+
+	client, err := newClient(ngsi, c, false)
+	if err != nil {
+		return &ngsiCmdError{funcName, 2, err.Error(), err}
+	}
+
+	if !client.IsIoTAgent() {
+		return &ngsiCmdError{funcName, 3, "only available on IoT Agent", nil}
+	}
+
+	devices, err := iotaItems(c, ngsi, "devices")
+	if err != nil {
+		return &ngsiCmdError{funcName, 4, err.Error(), err}
+	}
+
+	if err := iotaCreate(c, ngsi, client, "devices", devices); err != nil {
+		return &ngsiCmdError{funcName, 5, err.Error(), err}
+	}
+
+	return nil
+}
+
+func devicesUpdate(c *cli.Context) error {
+	const funcName = "devicesUpdate"
+
+	ngsi, err := initCmd(c, funcName, true)
+	if err != nil {
+		return &ngsiCmdError{funcName, 1, err.Error(), err}
+	}
+
+	client, err := newClient(ngsi, c, false)
+	if err != nil {
+		return &ngsiCmdError{funcName, 2, err.Error(), err}
+	}
+
+	if !client.IsIoTAgent() {
+		return &ngsiCmdError{funcName, 3, "only available on IoT Agent", nil}
+	}
+
+	b, err := readAll(c, ngsi)
+	if err != nil {
+		return &ngsiCmdError{funcName, 4, err.Error(), err}
+	}
+
+	id := c.String("id")
+	client.SetPath("/iot/devices/" + id)
+	client.SetHeader("Content-Type", "application/json")
+
+	res, body, err := client.HTTPPut(b)
+	if err != nil {
+		return &ngsiCmdError{funcName, 5, err.Error(), err}
+	}
+	if res.StatusCode != http.StatusNoContent {
+		return &ngsiCmdError{funcName, 6, fmt.Sprintf("%s %s %s", id, res.Status, string(body)), nil}
+	}
+
+	return nil
+}
+
+func devicesDelete(c *cli.Context) error {
+	const funcName = "devicesDelete"
+
+	ngsi, err := initCmd(c, funcName, true)
+	if err != nil {
+		return &ngsiCmdError{funcName, 1, err.Error(), err}
+	}
+
+	client, err := newClient(ngsi, c, false)
+	if err != nil {
+		return &ngsiCmdError{funcName, 2, err.Error(), err}
+	}
+
+	if !client.IsIoTAgent() {
+		return &ngsiCmdError{funcName, 3, "only available on IoT Agent", nil}
+	}
+
+	id := c.String("id")
+	client.SetPath("/iot/devices/" + id)
+
+	res, body, err := client.HTTPDelete()
+	if err != nil {
+		return &ngsiCmdError{funcName, 4, err.Error(), err}
+	}
+	if res.StatusCode != http.StatusNoContent {
+		return &ngsiCmdError{funcName, 5, fmt.Sprintf("%s %s %s", id, res.Status, string(body)), nil}
+	}
+
+	return nil
+}
@@ -0,0 +1,739 @@
+/*
+MIT License
+
+Copyright (c) 2020 Kazuhito Suda
+
+This file is part of NGSI Go
+
+https://github.com/lets-fiware/ngsi-go
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+package ngsicmd
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/cli/v2"
+)
+
+func TestDevicesList(t *testing.T) {
+	ngsi, set, app, buf := setupTest()
+
+	setupAddServer(t, ngsi, "iota", "http://iota:4041", "iota")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusOK
+	reqRes.Path = "/iot/devices"
+	reqRes.ResBody = []byte(`{"count":1,"devices":[{"device_id":"sensor001","entity_name":"urn:ngsi-ld:Device:001","entity_type":"Device"}]}`)
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	setupFlagString(set, "host")
+	setupFlagBool(set, "json,verbose")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{`--host=iota`})
+	err := devicesList(c)
+
+	if assert.NoError(t, err) {
+		actual := buf.String()
+		expected := "sensor001\n"
+		assert.Equal(t, expected, actual)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestDevicesListVerbose(t *testing.T) {
+	ngsi, set, app, buf := setupTest()
+
+	setupAddServer(t, ngsi, "iota", "http://iota:4041", "iota")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusOK
+	reqRes.Path = "/iot/devices"
+	reqRes.ResBody = []byte(`{"count":1,"devices":[{"device_id":"sensor001","entity_name":"urn:ngsi-ld:Device:001","entity_type":"Device"}]}`)
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	setupFlagString(set, "host")
+	setupFlagBool(set, "json,verbose")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{`--host=iota`, `--verbose`})
+	err := devicesList(c)
+
+	if assert.NoError(t, err) {
+		actual := buf.String()
+		expected := "sensor001 urn:ngsi-ld:Device:001 Device\n"
+		assert.Equal(t, expected, actual)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestDevicesListJSON(t *testing.T) {
+	ngsi, set, app, buf := setupTest()
+
+	setupAddServer(t, ngsi, "iota", "http://iota:4041", "iota")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusOK
+	reqRes.Path = "/iot/devices"
+	reqRes.ResBody = []byte(`{"count":1,"devices":[{"device_id":"sensor001","entity_name":"urn:ngsi-ld:Device:001","entity_type":"Device"}]}`)
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	setupFlagString(set, "host")
+	setupFlagBool(set, "json,verbose")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{`--host=iota`, `--json`})
+	err := devicesList(c)
+
+	if assert.NoError(t, err) {
+		actual := buf.String()
+		expected := "[{\"device_id\":\"sensor001\",\"entity_name\":\"urn:ngsi-ld:Device:001\",\"entity_type\":\"Device\"}]\n"
+		assert.Equal(t, expected, actual)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestDevicesListErrorInitCmd(t *testing.T) {
+	_, set, app, _ := setupTest()
+
+	c := cli.NewContext(app, set, nil)
+	err := devicesList(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 1, ngsiErr.ErrNo)
+		assert.Equal(t, "Required host not found", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestDevicesListErrorNewClient(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddServer(t, ngsi, "iota", "http://iota:4041", "iota")
+
+	setupFlagString(set, "host,link")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{`--host=iota`, `--link=abc`})
+	err := devicesList(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 2, ngsiErr.ErrNo)
+		assert.Equal(t, "abc not found", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestDevicesListErrorNotIoTAgent(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddServer(t, ngsi, "comet", "https://comet", "comet")
+
+	setupFlagString(set, "host")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{`--host=comet`})
+	err := devicesList(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 3, ngsiErr.ErrNo)
+		assert.Equal(t, "only available on IoT Agent", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestDevicesListErrorList(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddServer(t, ngsi, "iota", "http://iota:4041", "iota")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Err = errors.New("http error")
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	setupFlagString(set, "host")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{`--host=iota`})
+	err := devicesList(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 4, ngsiErr.ErrNo)
+		assert.Equal(t, "http error", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestDevicesGet(t *testing.T) {
+	ngsi, set, app, buf := setupTest()
+
+	setupAddServer(t, ngsi, "iota", "http://iota:4041", "iota")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusOK
+	reqRes.Path = "/iot/devices/sensor001"
+	reqRes.ResBody = []byte(`{"device_id":"sensor001"}`)
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	setupFlagString(set, "host,id")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{`--host=iota`, `--id=sensor001`})
+	err := devicesGet(c)
+
+	if assert.NoError(t, err) {
+		actual := buf.String()
+		expected := "{\"device_id\":\"sensor001\"}\n"
+		assert.Equal(t, expected, actual)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestDevicesGetErrorInitCmd(t *testing.T) {
+	_, set, app, _ := setupTest()
+
+	c := cli.NewContext(app, set, nil)
+	err := devicesGet(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 1, ngsiErr.ErrNo)
+		assert.Equal(t, "Required host not found", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestDevicesGetErrorNewClient(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddServer(t, ngsi, "iota", "http://iota:4041", "iota")
+
+	setupFlagString(set, "host,link")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{`--host=iota`, `--link=abc`})
+	err := devicesGet(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 2, ngsiErr.ErrNo)
+		assert.Equal(t, "abc not found", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestDevicesGetErrorNotIoTAgent(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddServer(t, ngsi, "comet", "https://comet", "comet")
+
+	setupFlagString(set, "host")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{`--host=comet`})
+	err := devicesGet(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 3, ngsiErr.ErrNo)
+		assert.Equal(t, "only available on IoT Agent", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestDevicesGetErrorHTTP(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddServer(t, ngsi, "iota", "http://iota:4041", "iota")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Err = errors.New("http error")
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	setupFlagString(set, "host,id")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{`--host=iota`, `--id=sensor001`})
+	err := devicesGet(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 4, ngsiErr.ErrNo)
+		assert.Equal(t, "http error", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestDevicesGetErrorHTTPStatus(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddServer(t, ngsi, "iota", "http://iota:4041", "iota")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusNotFound
+	reqRes.Res.Status = "404 Not Found"
+	reqRes.Path = "/iot/devices/sensor001"
+	reqRes.ResBody = []byte(`error`)
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	setupFlagString(set, "host,id")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{`--host=iota`, `--id=sensor001`})
+	err := devicesGet(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 5, ngsiErr.ErrNo)
+		assert.Equal(t, "sensor001 404 Not Found error", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestDevicesCreate(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddServer(t, ngsi, "iota", "http://iota:4041", "iota")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusCreated
+	reqRes.Path = "/iot/devices"
+	reqRes.ReqData = []byte(`{"devices":[{"device_id":"sensor001"}]}`)
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	setupFlagString(set, "host,data")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{`--host=iota`, `--data=[{"device_id":"sensor001"}]`})
+	err := devicesCreate(c)
+
+	assert.NoError(t, err)
+}
+
+func TestDevicesCreateErrorInitCmd(t *testing.T) {
+	_, set, app, _ := setupTest()
+
+	c := cli.NewContext(app, set, nil)
+	err := devicesCreate(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 1, ngsiErr.ErrNo)
+		assert.Equal(t, "Required host not found", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestDevicesCreateErrorNewClient(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddServer(t, ngsi, "iota", "http://iota:4041", "iota")
+
+	setupFlagString(set, "host,link")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{`--host=iota`, `--link=abc`})
+	err := devicesCreate(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 2, ngsiErr.ErrNo)
+		assert.Equal(t, "abc not found", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestDevicesCreateErrorNotIoTAgent(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddServer(t, ngsi, "comet", "https://comet", "comet")
+
+	setupFlagString(set, "host")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{`--host=comet`})
+	err := devicesCreate(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 3, ngsiErr.ErrNo)
+		assert.Equal(t, "only available on IoT Agent", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestDevicesCreateErrorItems(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddServer(t, ngsi, "iota", "http://iota:4041", "iota")
+
+	setupFlagString(set, "host")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{`--host=iota`})
+	err := devicesCreate(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 4, ngsiErr.ErrNo)
+		assert.Equal(t, "data is empty", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestDevicesCreateErrorCreate(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddServer(t, ngsi, "iota", "http://iota:4041", "iota")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusConflict
+	reqRes.Res.Status = "409 Conflict"
+	reqRes.Path = "/iot/devices"
+	reqRes.ResBody = []byte(`error`)
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	setupFlagString(set, "host,data")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{`--host=iota`, `--data=[{"device_id":"sensor001"}]`})
+	err := devicesCreate(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 5, ngsiErr.ErrNo)
+		assert.Equal(t, "409 Conflict error", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestDevicesUpdate(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddServer(t, ngsi, "iota", "http://iota:4041", "iota")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusNoContent
+	reqRes.Path = "/iot/devices/sensor001"
+	reqRes.ReqData = []byte(`{"entity_type":"Device"}`)
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	setupFlagString(set, "host,id,data")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{`--host=iota`, `--id=sensor001`, `--data={"entity_type":"Device"}`})
+	err := devicesUpdate(c)
+
+	assert.NoError(t, err)
+}
+
+func TestDevicesUpdateErrorInitCmd(t *testing.T) {
+	_, set, app, _ := setupTest()
+
+	c := cli.NewContext(app, set, nil)
+	err := devicesUpdate(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 1, ngsiErr.ErrNo)
+		assert.Equal(t, "Required host not found", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestDevicesUpdateErrorNewClient(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddServer(t, ngsi, "iota", "http://iota:4041", "iota")
+
+	setupFlagString(set, "host,link")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{`--host=iota`, `--link=abc`})
+	err := devicesUpdate(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 2, ngsiErr.ErrNo)
+		assert.Equal(t, "abc not found", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestDevicesUpdateErrorNotIoTAgent(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddServer(t, ngsi, "comet", "https://comet", "comet")
+
+	setupFlagString(set, "host")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{`--host=comet`})
+	err := devicesUpdate(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 3, ngsiErr.ErrNo)
+		assert.Equal(t, "only available on IoT Agent", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestDevicesUpdateErrorReadAll(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddServer(t, ngsi, "iota", "http://iota:4041", "iota")
+
+	setupFlagString(set, "host,id")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{`--host=iota`, `--id=sensor001`})
+	err := devicesUpdate(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 4, ngsiErr.ErrNo)
+		assert.Equal(t, "data is empty", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestDevicesUpdateErrorHTTP(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddServer(t, ngsi, "iota", "http://iota:4041", "iota")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Err = errors.New("http error")
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	setupFlagString(set, "host,id,data")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{`--host=iota`, `--id=sensor001`, `--data={}`})
+	err := devicesUpdate(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 5, ngsiErr.ErrNo)
+		assert.Equal(t, "http error", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestDevicesUpdateErrorHTTPStatus(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddServer(t, ngsi, "iota", "http://iota:4041", "iota")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusNotFound
+	reqRes.Res.Status = "404 Not Found"
+	reqRes.Path = "/iot/devices/sensor001"
+	reqRes.ResBody = []byte(`error`)
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	setupFlagString(set, "host,id,data")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{`--host=iota`, `--id=sensor001`, `--data={}`})
+	err := devicesUpdate(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 6, ngsiErr.ErrNo)
+		assert.Equal(t, "sensor001 404 Not Found error", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestDevicesDelete(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddServer(t, ngsi, "iota", "http://iota:4041", "iota")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusNoContent
+	reqRes.Path = "/iot/devices/sensor001"
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	setupFlagString(set, "host,id")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{`--host=iota`, `--id=sensor001`})
+	err := devicesDelete(c)
+
+	assert.NoError(t, err)
+}
+
+func TestDevicesDeleteErrorInitCmd(t *testing.T) {
+	_, set, app, _ := setupTest()
+
+	c := cli.NewContext(app, set, nil)
+	err := devicesDelete(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 1, ngsiErr.ErrNo)
+		assert.Equal(t, "Required host not found", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestDevicesDeleteErrorNewClient(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddServer(t, ngsi, "iota", "http://iota:4041", "iota")
+
+	setupFlagString(set, "host,link")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{`--host=iota`, `--link=abc`})
+	err := devicesDelete(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 2, ngsiErr.ErrNo)
+		assert.Equal(t, "abc not found", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestDevicesDeleteErrorNotIoTAgent(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddServer(t, ngsi, "comet", "https://comet", "comet")
+
+	setupFlagString(set, "host")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{`--host=comet`})
+	err := devicesDelete(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 3, ngsiErr.ErrNo)
+		assert.Equal(t, "only available on IoT Agent", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestDevicesDeleteErrorHTTP(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddServer(t, ngsi, "iota", "http://iota:4041", "iota")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Err = errors.New("http error")
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	setupFlagString(set, "host,id")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{`--host=iota`, `--id=sensor001`})
+	err := devicesDelete(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 4, ngsiErr.ErrNo)
+		assert.Equal(t, "http error", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestDevicesDeleteErrorHTTPStatus(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddServer(t, ngsi, "iota", "http://iota:4041", "iota")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusNotFound
+	reqRes.Res.Status = "404 Not Found"
+	reqRes.Path = "/iot/devices/sensor001"
+	reqRes.ResBody = []byte(`error`)
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	setupFlagString(set, "host,id")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{`--host=iota`, `--id=sensor001`})
+	err := devicesDelete(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 5, ngsiErr.ErrNo)
+		assert.Equal(t, "sensor001 404 Not Found error", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
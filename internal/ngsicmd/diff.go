@@ -0,0 +1,336 @@
+/*
+MIT License
+
+Copyright (c) 2020 Kazuhito Suda
+
+This file is part of NGSI Go
+
+https://github.com/lets-fiware/ngsi-go
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+package ngsicmd
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/lets-fiware/ngsi-go/internal/ngsilib"
+	"github.com/urfave/cli/v2"
+)
+
+// diffDefaultIgnore is the attributes and metadata which are ignored unless --ignore is specified.
+var diffDefaultIgnore = []string{"dateCreated", "dateModified", "createdAt", "modifiedAt"}
+
+type diffEntityKey struct {
+	ID   string `json:"id"`
+	Type string `json:"type"`
+}
+
+type diffAttr struct {
+	Name        string      `json:"name"`
+	Diff        string      `json:"diff"`
+	Source      interface{} `json:"source,omitempty"`
+	Destination interface{} `json:"destination,omitempty"`
+}
+
+type diffEntity struct {
+	ID    string     `json:"id"`
+	Type  string     `json:"type"`
+	Attrs []diffAttr `json:"attrs"`
+}
+
+type diffEntitiesResult struct {
+	OnlySource      []diffEntityKey `json:"onlySource"`
+	OnlyDestination []diffEntityKey `json:"onlyDestination"`
+	Different       []diffEntity    `json:"different"`
+}
+
+func diffEntities(c *cli.Context) error {
+	const funcName = "diffEntities"
+
+	ngsi, err := initCmd(c, funcName, true)
+	if err != nil {
+		return &ngsiCmdError{funcName, 1, err.Error(), err}
+	}
+
+	source, err := newClient(ngsi, c, false)
+	if err != nil {
+		return &ngsiCmdError{funcName, 2, err.Error(), err}
+	}
+
+	flags, err := parseFlags2(ngsi, c)
+	if err != nil {
+		return &ngsiCmdError{funcName, 3, err.Error(), err}
+	}
+
+	destination, err := ngsi.NewClient(ngsi.Destination, flags, false)
+	if err != nil {
+		return &ngsiCmdError{funcName, 4, err.Error() + " (destination)", err}
+	}
+
+	ignore := diffDefaultIgnore
+	if c.IsSet("ignore") {
+		ignore = []string{}
+		for _, name := range strings.Split(c.String("ignore"), ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				ignore = append(ignore, name)
+			}
+		}
+	}
+
+	entityType := c.String("type")
+
+	srcEntities, err := diffReadEntities(source, entityType, pageSize(c, ngsi, source))
+	if err != nil {
+		return &ngsiCmdError{funcName, 5, err.Error(), err}
+	}
+
+	destEntities, err := diffReadEntities(destination, entityType, pageSize(c, ngsi, destination))
+	if err != nil {
+		return &ngsiCmdError{funcName, 6, err.Error() + " (destination)", err}
+	}
+
+	// compare entities in the representation of the destination as cp writes them
+	srcEntities = copyConvertEntities(source, destination, srcEntities)
+
+	result := diffCompare(srcEntities, destEntities, ignore)
+
+	if c.Bool("json") {
+		b, err := ngsilib.JSONMarshal(result)
+		if err != nil {
+			return &ngsiCmdError{funcName, 7, err.Error(), err}
+		}
+		printJSON(ngsi, b)
+		return nil
+	}
+
+	if err := diffPrint(ngsi.StdWriter, result); err != nil {
+		return &ngsiCmdError{funcName, 8, err.Error(), err}
+	}
+
+	return nil
+}
+
+func diffReadEntities(client *ngsilib.Client, entityType string, limit int) (entitiesRespose, error) {
+	const funcName = "diffReadEntities"
+
+	all := entitiesRespose{}
+
+	for offset, count := 0, 1; offset < count; offset += limit {
+		entities, n, err := copyReadEntities(client, entityType, limit, offset)
+		if err != nil {
+			return nil, &ngsiCmdError{funcName, 1, err.Error(), err}
+		}
+		all = append(all, entities...)
+		count = n
+	}
+
+	return all, nil
+}
+
+func diffKey(entity map[string]interface{}) diffEntityKey {
+	id, _ := entity["id"].(string)
+	t, _ := entity["type"].(string)
+	return diffEntityKey{ID: id, Type: t}
+}
+
+func diffCompare(source, destination entitiesRespose, ignore []string) *diffEntitiesResult {
+	result := &diffEntitiesResult{
+		OnlySource:      []diffEntityKey{},
+		OnlyDestination: []diffEntityKey{},
+		Different:       []diffEntity{},
+	}
+
+	dest := make(map[diffEntityKey]map[string]interface{})
+	for _, e := range destination {
+		dest[diffKey(e)] = e
+	}
+
+	src := make(map[diffEntityKey]bool)
+	for _, e := range source {
+		key := diffKey(e)
+		src[key] = true
+
+		d, ok := dest[key]
+		if !ok {
+			result.OnlySource = append(result.OnlySource, key)
+			continue
+		}
+		if attrs := diffAttrs(e, d, ignore); len(attrs) > 0 {
+			result.Different = append(result.Different, diffEntity{ID: key.ID, Type: key.Type, Attrs: attrs})
+		}
+	}
+
+	for _, e := range destination {
+		if key := diffKey(e); !src[key] {
+			result.OnlyDestination = append(result.OnlyDestination, key)
+		}
+	}
+
+	sortKeys := func(keys []diffEntityKey) {
+		sort.Slice(keys, func(i, j int) bool {
+			if keys[i].ID != keys[j].ID {
+				return keys[i].ID < keys[j].ID
+			}
+			return keys[i].Type < keys[j].Type
+		})
+	}
+	sortKeys(result.OnlySource)
+	sortKeys(result.OnlyDestination)
+	sort.Slice(result.Different, func(i, j int) bool {
+		if result.Different[i].ID != result.Different[j].ID {
+			return result.Different[i].ID < result.Different[j].ID
+		}
+		return result.Different[i].Type < result.Different[j].Type
+	})
+
+	return result
+}
+
+func diffAttrs(source, destination map[string]interface{}, ignore []string) []diffAttr {
+	names := []string{}
+	seen := make(map[string]bool)
+	for _, e := range []map[string]interface{}{source, destination} {
+		for name := range e {
+			switch name {
+			case "id", "type", "@context":
+				continue
+			}
+			if !seen[name] && !diffIgnored(name, ignore) {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+
+	attrs := []diffAttr{}
+
+	for _, name := range names {
+		s, sok := source[name]
+		d, dok := destination[name]
+		if !dok {
+			attrs = append(attrs, diffAttr{Name: name, Diff: "onlySource", Source: s})
+			continue
+		}
+		if !sok {
+			attrs = append(attrs, diffAttr{Name: name, Diff: "onlyDestination", Destination: d})
+			continue
+		}
+
+		st, sv, sm := diffAttrParts(s, ignore)
+		dt, dv, dm := diffAttrParts(d, ignore)
+		if !reflect.DeepEqual(st, dt) {
+			attrs = append(attrs, diffAttr{Name: name, Diff: "type", Source: st, Destination: dt})
+		}
+		if !reflect.DeepEqual(sv, dv) {
+			attrs = append(attrs, diffAttr{Name: name, Diff: "value", Source: sv, Destination: dv})
+		}
+		if !reflect.DeepEqual(sm, dm) {
+			attrs = append(attrs, diffAttr{Name: name, Diff: "metadata", Source: sm, Destination: dm})
+		}
+	}
+
+	return attrs
+}
+
+// diffAttrParts returns the type, the value and the metadata of an attribute. The metadata
+// is the metadata of NGSIv2 or the properties other than type and value of NGSI-LD.
+func diffAttrParts(attr interface{}, ignore []string) (interface{}, interface{}, map[string]interface{}) {
+	a, ok := attr.(map[string]interface{})
+	if !ok {
+		return nil, attr, nil
+	}
+
+	metadata := make(map[string]interface{})
+	for key, value := range a {
+		switch key {
+		case "type", "value", "object":
+		case "metadata":
+			if m, ok := value.(map[string]interface{}); ok {
+				for name, v := range m {
+					if !diffIgnored(name, ignore) {
+						metadata[name] = v
+					}
+				}
+			}
+		default:
+			if !diffIgnored(key, ignore) {
+				metadata[key] = value
+			}
+		}
+	}
+
+	value := a["value"]
+	if object, ok := a["object"]; ok {
+		value = object
+	}
+
+	return a["type"], value, metadata
+}
+
+func diffIgnored(name string, ignore []string) bool {
+	for _, pattern := range ignore {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func diffPrint(w io.Writer, result *diffEntitiesResult) error {
+	const funcName = "diffPrint"
+
+	for _, key := range result.OnlySource {
+		fmt.Fprintf(w, "- %s (%s)\n", key.ID, key.Type)
+	}
+	for _, key := range result.OnlyDestination {
+		fmt.Fprintf(w, "+ %s (%s)\n", key.ID, key.Type)
+	}
+	for _, e := range result.Different {
+		fmt.Fprintf(w, "~ %s (%s)\n", e.ID, e.Type)
+		for _, attr := range e.Attrs {
+			switch attr.Diff {
+			case "onlySource":
+				fmt.Fprintf(w, "    - %s\n", attr.Name)
+			case "onlyDestination":
+				fmt.Fprintf(w, "    + %s\n", attr.Name)
+			default:
+				s, err := ngsilib.JSONMarshal(attr.Source)
+				if err != nil {
+					return &ngsiCmdError{funcName, 1, err.Error(), err}
+				}
+				d, err := ngsilib.JSONMarshal(attr.Destination)
+				if err != nil {
+					return &ngsiCmdError{funcName, 2, err.Error(), err}
+				}
+				fmt.Fprintf(w, "    ~ %s %s: %s -> %s\n", attr.Name, attr.Diff, string(s), string(d))
+			}
+		}
+	}
+
+	return nil
+}
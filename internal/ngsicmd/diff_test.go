@@ -0,0 +1,378 @@
+/*
+MIT License
+
+Copyright (c) 2020 Kazuhito Suda
+
+This file is part of NGSI Go
+
+https://github.com/lets-fiware/ngsi-go
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+package ngsicmd
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/lets-fiware/ngsi-go/internal/ngsilib"
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/cli/v2"
+)
+
+func setupDiffMock(src, dest string) *MockHTTP {
+	reqRes1 := MockHTTPReqRes{}
+	reqRes1.Res.StatusCode = http.StatusOK
+	reqRes1.ResBody = []byte(src)
+	reqRes1.ResHeader = http.Header{"Fiware-Total-Count": []string{"2"}}
+	reqRes1.Path = "/v2/entities"
+	reqRes2 := MockHTTPReqRes{}
+	reqRes2.Res.StatusCode = http.StatusOK
+	reqRes2.ResBody = []byte(dest)
+	reqRes2.ResHeader = http.Header{"Fiware-Total-Count": []string{"2"}}
+	reqRes2.Path = "/v2/entities"
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes1, reqRes2)
+	return mock
+}
+
+var testDiffSource = `[{"id":"E1","type":"T","temperature":{"type":"Number","value":21,"metadata":{}},"name":{"type":"Text","value":"a","metadata":{}},"dateModified":{"type":"DateTime","value":"2021-01-01T00:00:00.000Z","metadata":{}}},{"id":"E2","type":"T"}]`
+var testDiffDestination = `[{"id":"E1","type":"T","temperature":{"type":"Number","value":22,"metadata":{"unit":{"type":"Text","value":"C"}}},"name":{"type":"String","value":"a","metadata":{}},"dateModified":{"type":"DateTime","value":"2021-02-01T00:00:00.000Z","metadata":{}}},{"id":"E3","type":"T"}]`
+
+func TestDiffEntities(t *testing.T) {
+	ngsi, set, app, buf := setupTest()
+
+	setupAddBroker(t, ngsi, "orion-src", "https://orion-src", "v2")
+	setupAddBroker(t, ngsi, "orion-dest", "https://orion-dest", "v2")
+
+	setupFlagString(set, "host,destination,type")
+	ngsi.HTTP = setupDiffMock(testDiffSource, testDiffDestination)
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion-src", "--destination=orion-dest", "--type=T"})
+	err := diffEntities(c)
+
+	if assert.NoError(t, err) {
+		expected := "- E2 (T)\n+ E3 (T)\n~ E1 (T)\n" +
+			"    ~ name type: \"Text\" -> \"String\"\n" +
+			"    ~ temperature value: 21 -> 22\n" +
+			"    ~ temperature metadata: {} -> {\"unit\":{\"type\":\"Text\",\"value\":\"C\"}}\n"
+		assert.Equal(t, expected, buf.String())
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestDiffEntitiesJSON(t *testing.T) {
+	ngsi, set, app, buf := setupTest()
+
+	setupAddBroker(t, ngsi, "orion-src", "https://orion-src", "v2")
+	setupAddBroker(t, ngsi, "orion-dest", "https://orion-dest", "v2")
+
+	setupFlagString(set, "host,destination,type,ignore")
+	setupFlagBool(set, "json")
+	ngsi.HTTP = setupDiffMock(testDiffSource, testDiffDestination)
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion-src", "--destination=orion-dest", "--type=T", "--json", "--ignore=temp*, name"})
+	err := diffEntities(c)
+
+	if assert.NoError(t, err) {
+		expected := `{"onlySource":[{"id":"E2","type":"T"}],"onlyDestination":[{"id":"E3","type":"T"}],"different":[{"id":"E1","type":"T","attrs":[{"name":"dateModified","diff":"value","source":"2021-01-01T00:00:00.000Z","destination":"2021-02-01T00:00:00.000Z"}]}]}` + "\n"
+		assert.Equal(t, expected, buf.String())
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestDiffEntitiesTenant(t *testing.T) {
+	ngsi, set, app, buf := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+
+	setupFlagString(set, "host,destination,type,service,service2")
+	ngsi.HTTP = setupDiffMock(`[{"id":"E1","type":"T"}]`, `[{"id":"E1","type":"T"}]`)
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", "--destination=orion", "--service=a", "--service2=b", "--type=T"})
+	err := diffEntities(c)
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, "", buf.String())
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestDiffEntitiesV2Ld(t *testing.T) {
+	ngsi, set, app, buf := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+	setupAddBroker(t, ngsi, "orion-ld", "https://orion-ld", "ld")
+
+	setupFlagString(set, "host,destination,type")
+	reqRes1 := MockHTTPReqRes{}
+	reqRes1.Res.StatusCode = http.StatusOK
+	reqRes1.ResBody = []byte(`[{"id":"E1","type":"T","temperature":{"type":"Number","value":21,"metadata":{}}}]`)
+	reqRes1.ResHeader = http.Header{"Fiware-Total-Count": []string{"1"}}
+	reqRes1.Path = "/v2/entities"
+	reqRes2 := MockHTTPReqRes{}
+	reqRes2.Res.StatusCode = http.StatusOK
+	reqRes2.ResBody = []byte(`[{"id":"urn:ngsi-ld:T:E1","type":"T","temperature":{"type":"Property","value":21},"@context":"https://context"}]`)
+	reqRes2.ResHeader = http.Header{"Ngsild-Results-Count": []string{"1"}}
+	reqRes2.Path = "/ngsi-ld/v1/entities"
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes1, reqRes2)
+	ngsi.HTTP = mock
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", "--destination=orion-ld", "--type=T"})
+	err := diffEntities(c)
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, "", buf.String())
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestDiffEntitiesErrorInitCmd(t *testing.T) {
+	_, set, app, _ := setupTest()
+
+	c := cli.NewContext(app, set, nil)
+	err := diffEntities(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 1, ngsiErr.ErrNo)
+		assert.Equal(t, "Required host not found", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestDiffEntitiesErrorNewClient(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+
+	setupFlagString(set, "host,link")
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--link=abc", "--host=orion"})
+	err := diffEntities(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 2, ngsiErr.ErrNo)
+		assert.Equal(t, "abc not found", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestDiffEntitiesErrorParse2(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+
+	setupFlagString(set, "host,link2,destination")
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", "--link2=abc", "--destination=orion"})
+	err := diffEntities(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 3, ngsiErr.ErrNo)
+		assert.Equal(t, "abc not found", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestDiffEntitiesErrorNewClientDestination(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+
+	setupFlagString(set, "host,destination")
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", "--destination=orion-ld"})
+	err := diffEntities(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 4, ngsiErr.ErrNo)
+		assert.Equal(t, "error host: orion-ld (destination)", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestDiffEntitiesErrorReadSource(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+
+	setupFlagString(set, "host,destination,type")
+	reqRes := MockHTTPReqRes{}
+	reqRes.Err = errors.New("http error")
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", "--destination=orion", "--type=T"})
+	err := diffEntities(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 5, ngsiErr.ErrNo)
+		assert.Equal(t, "http error", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestDiffEntitiesErrorReadDestination(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+
+	setupFlagString(set, "host,destination,type")
+	reqRes1 := MockHTTPReqRes{}
+	reqRes1.Res.StatusCode = http.StatusOK
+	reqRes1.ResBody = []byte(`[]`)
+	reqRes1.ResHeader = http.Header{"Fiware-Total-Count": []string{"0"}}
+	reqRes2 := MockHTTPReqRes{}
+	reqRes2.Res.StatusCode = http.StatusBadRequest
+	reqRes2.Res.Status = "400 Bad Request"
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes1, reqRes2)
+	ngsi.HTTP = mock
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", "--destination=orion", "--type=T"})
+	err := diffEntities(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 6, ngsiErr.ErrNo)
+		assert.Equal(t, "400 Bad Request  (destination)", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestDiffEntitiesErrorJSONMarshal(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+
+	setupFlagString(set, "host,destination,type")
+	setupFlagBool(set, "json")
+	ngsi.HTTP = setupDiffMock(testDiffSource, testDiffDestination)
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", "--destination=orion", "--type=T", "--json"})
+	ngsi.JSONConverter = &MockJSONLib{EncodeErr: errors.New("json error"), Jsonlib: ngsi.JSONConverter}
+	err := diffEntities(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 7, ngsiErr.ErrNo)
+		assert.Equal(t, "json error", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestDiffEntitiesErrorPrint(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+
+	setupFlagString(set, "host,destination,type")
+	ngsi.HTTP = setupDiffMock(testDiffSource, testDiffDestination)
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", "--destination=orion", "--type=T"})
+	ngsi.JSONConverter = &MockJSONLib{EncodeErr: errors.New("json error"), Jsonlib: ngsi.JSONConverter}
+	err := diffEntities(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 8, ngsiErr.ErrNo)
+		assert.Equal(t, "json error", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestDiffReadEntitiesPage(t *testing.T) {
+	ngsi, _, _, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+
+	reqRes1 := MockHTTPReqRes{}
+	reqRes1.Res.StatusCode = http.StatusOK
+	reqRes1.ResBody = []byte(`[{"id":"E1","type":"T"}]`)
+	reqRes1.ResHeader = http.Header{"Fiware-Total-Count": []string{"2"}}
+	reqRes2 := MockHTTPReqRes{}
+	reqRes2.Res.StatusCode = http.StatusOK
+	reqRes2.ResBody = []byte(`[{"id":"E2","type":"T"}]`)
+	reqRes2.ResHeader = http.Header{"Fiware-Total-Count": []string{"2"}}
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes1, reqRes2)
+	ngsi.HTTP = mock
+	client, _ := ngsi.NewClient("orion", &ngsilib.CmdFlags{}, false)
+
+	entities, err := diffReadEntities(client, "T", 1)
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, 2, len(entities))
+		assert.Equal(t, "E2", entities[1]["id"])
+	}
+}
+
+func TestDiffAttrsLd(t *testing.T) {
+	source := map[string]interface{}{
+		"id":         "urn:ngsi-ld:T:E1",
+		"type":       "T",
+		"refStore":   map[string]interface{}{"type": "Relationship", "object": "urn:ngsi-ld:Store:001"},
+		"speed":      map[string]interface{}{"type": "Property", "value": 10.0, "observedAt": "2021-01-01T00:00:00Z", "modifiedAt": "x"},
+		"onlySource": map[string]interface{}{"type": "Property", "value": 1.0},
+	}
+	destination := map[string]interface{}{
+		"id":       "urn:ngsi-ld:T:E1",
+		"type":     "T",
+		"refStore": map[string]interface{}{"type": "Relationship", "object": "urn:ngsi-ld:Store:002"},
+		"speed":    map[string]interface{}{"type": "Property", "value": 10.0, "observedAt": "2021-01-02T00:00:00Z"},
+		"onlyDest": "keyValues",
+	}
+
+	actual := diffAttrs(source, destination, diffDefaultIgnore)
+
+	expected := []diffAttr{
+		{Name: "onlyDest", Diff: "onlyDestination", Destination: "keyValues"},
+		{Name: "onlySource", Diff: "onlySource", Source: map[string]interface{}{"type": "Property", "value": 1.0}},
+		{Name: "refStore", Diff: "value", Source: "urn:ngsi-ld:Store:001", Destination: "urn:ngsi-ld:Store:002"},
+		{Name: "speed", Diff: "metadata", Source: map[string]interface{}{"observedAt": "2021-01-01T00:00:00Z"}, Destination: map[string]interface{}{"observedAt": "2021-01-02T00:00:00Z"}},
+	}
+	assert.Equal(t, expected, actual)
+}
+
+func TestDiffIgnored(t *testing.T) {
+	assert.True(t, diffIgnored("dateModified", []string{"date*"}))
+	assert.False(t, diffIgnored("temperature", []string{"date*"}))
+}
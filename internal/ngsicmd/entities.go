@@ -32,6 +32,7 @@ package ngsicmd
 import (
 	"fmt"
 	"net/http"
+	"strings"
 
 	"github.com/lets-fiware/ngsi-go/internal/ngsilib"
 	"github.com/urfave/cli/v2"
@@ -55,8 +56,6 @@ func entitiesList(c *cli.Context) error {
 		attrs = c.String("attrs")
 	}
 
-	page := 0
-	count := 0
 	limit := 100
 
 	verbose := c.IsSet("verbose")
@@ -66,13 +65,42 @@ func entitiesList(c *cli.Context) error {
 	}
 	lines := c.Bool("lines")
 
+	watch := c.Bool("watch")
+	if watch && (c.IsSet("count") || values) {
+		return &ngsiCmdError{funcName, 16, "--watch cannot be used with --count or --values", nil}
+	}
+
+	// --format csv is an alias of --output csv
+	csvFormat := outputEntitiesCSV(c)
+	if c.IsSet("format") {
+		switch strings.ToLower(c.String("format")) {
+		default:
+			return &ngsiCmdError{funcName, 13, "format error: " + c.String("format"), nil}
+		case "json":
+		case "csv":
+			if outputEnabled(c) && !csvFormat {
+				return &ngsiCmdError{funcName, 22, "--format csv cannot be used with --output or --select", nil}
+			}
+			csvFormat = true
+		}
+	}
+	if csvFormat && c.Bool("keyValues") {
+		return &ngsiCmdError{funcName, 20, "--keyValues cannot be used with csv output", nil}
+	}
+
 	buf := jsonBuffer{}
-	if verbose {
-		buf.bufferOpen(ngsi.StdWriter)
+	if verbose && !csvFormat {
+		buf.bufferOpen(jsonWriter(ngsi))
 		attrs = ""
 	}
 
-	for {
+	var csvWriter *csvEntityWriter
+	if csvFormat {
+		attrs = c.String("attrs")
+		csvWriter = newCSVEntityWriter(ngsi.StdWriter, client.IsNgsiLd())
+	}
+
+	fetch := func(client *ngsilib.Client, page int) ([]byte, int, error) {
 		client.SetPath("/entities")
 
 		args := []string{"id", "type", "idPattern", "typePattern", "query", "mq", "georel",
@@ -108,37 +136,49 @@ func entitiesList(c *cli.Context) error {
 
 		res, body, err := client.HTTPGet()
 		if err != nil {
-			return &ngsiCmdError{funcName, 3, err.Error(), err}
+			return nil, 0, &ngsiCmdError{funcName, 3, err.Error(), err}
 		}
 		if res.StatusCode != http.StatusOK {
-			return &ngsiCmdError{funcName, 4, fmt.Sprintf("%s %s", res.Status, string(body)), nil}
+			return nil, 0, &ngsiCmdError{funcName, 4, fmt.Sprintf("%s %s", res.Status, string(body)), nil}
 		}
 
 		if c.IsSet("count") {
 			count, err := client.ResultsCount(res)
 			if err != nil {
-				return &ngsiCmdError{funcName, 5, "ResultsCount error", nil}
+				return nil, 0, &ngsiCmdError{funcName, 5, "ResultsCount error", nil}
 			}
-			fmt.Fprintln(ngsi.StdWriter, count)
-			break
+			return nil, count, nil
 		}
 
-		count, err = client.ResultsCount(res)
+		count, err := client.ResultsCount(res)
 		if err != nil {
-			return &ngsiCmdError{funcName, 6, "ResultsCount error", err}
+			return nil, 0, &ngsiCmdError{funcName, 6, "ResultsCount error", err}
 		}
 		if count == 0 {
-			break
+			return nil, 0, nil
 		}
 
 		if client.IsSafeString() {
 			body, err = ngsilib.JSONSafeStringDecode(body)
 			if err != nil {
-				return &ngsiCmdError{funcName, 7, err.Error(), err}
+				return nil, 0, &ngsiCmdError{funcName, 7, err.Error(), err}
 			}
 		}
+		return body, count, nil
+	}
 
-		if lines {
+	output := func(body []byte) error {
+		var err error
+		if csvFormat {
+			var entities entitiesRespose
+			err = ngsilib.JSONUnmarshal(body, &entities)
+			if err != nil {
+				return &ngsiCmdError{funcName, 14, err.Error(), err}
+			}
+			if err = csvWriter.Write(entities, attrs); err != nil {
+				return &ngsiCmdError{funcName, 15, err.Error(), err}
+			}
+		} else if lines {
 			if values {
 				var values [][]interface{}
 				err = ngsilib.JSONUnmarshal(body, &values)
@@ -178,16 +218,110 @@ func entitiesList(c *cli.Context) error {
 				fmt.Fprintln(ngsi.StdWriter, e["id"])
 			}
 		}
+		return nil
+	}
 
-		if (page+1)*limit < count {
-			page = page + 1
-		} else {
-			break
+	fetchAll := func() (entitiesRespose, error) {
+		entities := entitiesRespose{}
+		for page, count := 0, 1; page*limit < count; page++ {
+			var body []byte
+			var err error
+			body, count, err = fetch(client, page)
+			if err != nil {
+				return nil, err
+			}
+			if count == 0 {
+				break
+			}
+			var e entitiesRespose
+			if err := ngsilib.JSONUnmarshal(body, &e); err != nil {
+				return nil, &ngsiCmdError{funcName, 17, err.Error(), err}
+			}
+			entities = append(entities, e...)
 		}
+		return entities, nil
 	}
-	if verbose {
+
+	watchEntities := func() error {
+		// entities are watched with the attributes specified by --attrs, not only ids
+		attrs = c.String("attrs")
+		if err := watchRun(c, ngsi, nil, fetchAll); err != nil {
+			return &ngsiCmdError{funcName, 18, err.Error(), err}
+		}
+		return nil
+	}
+
+	if outputEnabled(c) && !csvFormat && !c.IsSet("count") {
+		attrs = c.String("attrs")
+		entities, err := fetchAll()
+		if err != nil {
+			return err
+		}
+		if err := outputPrint(c, ngsi, entities); err != nil {
+			return &ngsiCmdError{funcName, 19, err.Error(), err}
+		}
+		if watch {
+			return watchEntities()
+		}
+		return nil
+	}
+
+	// without --attrs, the columns are the union of the attributes of all the entities. The pages are
+	// read once to collect only the names of the attributes, and then the rows are written page by page.
+	if csvFormat && attrs == "" && !c.IsSet("count") {
+		for page, count := 0, 1; page*limit < count; page++ {
+			var body []byte
+			body, count, err = fetch(client, page)
+			if err != nil {
+				return err
+			}
+			if count == 0 {
+				break
+			}
+			var entities entitiesRespose
+			if err := ngsilib.JSONUnmarshal(body, &entities); err != nil {
+				return &ngsiCmdError{funcName, 21, err.Error(), err}
+			}
+			csvWriter.Scan(entities)
+		}
+	}
+
+	body, count, err := fetch(client, 0)
+	if err != nil {
+		return err
+	}
+	if c.IsSet("count") {
+		fmt.Fprintln(ngsi.StdWriter, count)
+	} else if count > 0 {
+		if err := output(body); err != nil {
+			return err
+		}
+
+		// the remaining pages are fetched in parallel and printed in order
+		job := func(v interface{}) (interface{}, error) {
+			body, _, err := fetch(client.Clone(), v.(int))
+			return body, err
+		}
+		done := func(v interface{}) error {
+			if v.([]byte) == nil {
+				return nil
+			}
+			return output(v.([]byte))
+		}
+		pages := (count + limit - 1) / limit
+		if err := parallelRun(parallelWorkers(c), parallelPages(1, pages), job, done); err != nil {
+			return err
+		}
+	}
+
+	if verbose && !csvFormat {
 		buf.bufferClose()
 	}
+
+	if watch {
+		return watchEntities()
+	}
+
 	return nil
 }
 
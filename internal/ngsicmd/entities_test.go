@@ -30,8 +30,12 @@ SOFTWARE.
 package ngsicmd
 
 import (
+	"bytes"
 	"errors"
+	"fmt"
 	"net/http"
+	"net/url"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -615,6 +619,272 @@ func TestEntitiesListErrorResultsCount3(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestEntitiesListCSV(t *testing.T) {
+	ngsi, set, app, buf := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusOK
+	reqRes.Path = "/v2/entities"
+	reqRes.ResHeader = http.Header{"Fiware-Total-Count": []string{"2"}}
+	reqRes.ResBody = []byte(`[{"id":"airqualityobserved_0","type":"AirQualityObserved","temperature":{"type":"Number","value":6.727447926,"metadata":{}}},{"id":"airqualityobserved_1","type":"AirQualityObserved","temperature":{"type":"Number","value":19.012560208,"metadata":{}}}]`)
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes, reqRes)
+	ngsi.HTTP = mock
+	setupFlagString(set, "host,type,attrs,format")
+	setupFlagBool(set, "verbose")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", "--verbose", "--format=csv"})
+	err := entitiesList(c)
+
+	if assert.NoError(t, err) {
+		actual := buf.String()
+		expected := "id,type,temperature:Number\nairqualityobserved_0,AirQualityObserved,6.727447926\nairqualityobserved_1,AirQualityObserved,19.012560208\n"
+		assert.Equal(t, expected, actual)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestEntitiesListCSVLD(t *testing.T) {
+	ngsi, set, app, buf := setupTest()
+
+	setupAddBroker(t, ngsi, "orion-ld", "https://orion-ld", "ld")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusOK
+	reqRes.Path = "/ngsi-ld/v1/entities"
+	reqRes.ResHeader = http.Header{"Ngsild-Results-Count": []string{"1"}}
+	reqRes.ResBody = []byte(`[{"id":"urn:ngsi-ld:Shelf:unit001","type":"Shelf","name":{"type":"Property","value":"Corner Unit"},"maxCapacity":{"type":"Property","value":50},"locatedIn":{"type":"Relationship","object":"urn:ngsi-ld:Building:store001"}}]`)
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes, reqRes)
+	ngsi.HTTP = mock
+	setupFlagString(set, "host,type,format")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion-ld", "--type=Shelf", "--format=csv"})
+	err := entitiesList(c)
+
+	if assert.NoError(t, err) {
+		actual := buf.String()
+		expected := "id,type,locatedIn:Relationship,maxCapacity:Number,name\nurn:ngsi-ld:Shelf:unit001,Shelf,urn:ngsi-ld:Building:store001,50,Corner Unit\n"
+		assert.Equal(t, expected, actual)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestEntitiesListCSVPages(t *testing.T) {
+	ngsi, set, app, buf := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+
+	reqRes1 := MockHTTPReqRes{}
+	reqRes1.Res.StatusCode = http.StatusOK
+	reqRes1.Path = "/v2/entities"
+	reqRes1.ResHeader = http.Header{"Fiware-Total-Count": []string{"101"}}
+	reqRes1.ResBody = []byte(`[{"id":"device001","type":"Device","temperature":{"type":"Number","value":20}}]`)
+	reqRes2 := MockHTTPReqRes{}
+	reqRes2.Res.StatusCode = http.StatusOK
+	reqRes2.Path = "/v2/entities"
+	reqRes2.ResHeader = http.Header{"Fiware-Total-Count": []string{"101"}}
+	reqRes2.ResBody = []byte(`[{"id":"device002","type":"Device","humidity":{"type":"Number","value":40}}]`)
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes1, reqRes2, reqRes1, reqRes2)
+	ngsi.HTTP = mock
+	setupFlagString(set, "host,format")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", "--format=csv"})
+	err := entitiesList(c)
+
+	if assert.NoError(t, err) {
+		actual := buf.String()
+		expected := "id,type,humidity:Number,temperature:Number\ndevice001,Device,,20\ndevice002,Device,40,\n"
+		assert.Equal(t, expected, actual)
+	} else {
+		t.FailNow()
+	}
+}
+
+// outputRecordHTTP records the output written before each request.
+type outputRecordHTTP struct {
+	*MockHTTP
+	buf     *bytes.Buffer
+	outputs []string
+}
+
+func (h *outputRecordHTTP) Request(method string, url *url.URL, headers map[string]string, body interface{}, idempotent bool) (*http.Response, []byte, error) {
+	h.outputs = append(h.outputs, h.buf.String())
+	return h.MockHTTP.Request(method, url, headers, body, idempotent)
+}
+
+func TestEntitiesListCSVStream(t *testing.T) {
+	ngsi, set, app, buf := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+
+	var pages []MockHTTPReqRes
+	for i := 1; i <= 3; i++ {
+		reqRes := MockHTTPReqRes{}
+		reqRes.Res.StatusCode = http.StatusOK
+		reqRes.Path = "/v2/entities"
+		reqRes.ResHeader = http.Header{"Fiware-Total-Count": []string{"201"}}
+		reqRes.ResBody = []byte(fmt.Sprintf(`[{"id":"device00%d","type":"Device","temperature":{"type":"Number","value":%d}}]`, i, i))
+		pages = append(pages, reqRes)
+	}
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, pages...)
+	mock.ReqRes = append(mock.ReqRes, pages...)
+	h := &outputRecordHTTP{MockHTTP: mock, buf: buf}
+	ngsi.HTTP = h
+	setupFlagString(set, "host,format")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", "--format=csv"})
+	err := entitiesList(c)
+
+	if assert.NoError(t, err) {
+		expected := "id,type,temperature:Number\ndevice001,Device,1\ndevice002,Device,2\ndevice003,Device,3\n"
+		assert.Equal(t, expected, buf.String())
+		// the pages are read once for the columns, and the rows are written before the last page is fetched
+		assert.Equal(t, 6, len(h.outputs))
+		assert.Equal(t, "", h.outputs[3])
+		assert.Contains(t, h.outputs[5], "device001,Device,1\n")
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestEntitiesListErrorFormat(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+	setupFlagString(set, "host,format")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", "--format=xml"})
+	err := entitiesList(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 13, ngsiErr.ErrNo)
+		assert.Equal(t, "format error: xml", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestEntitiesListErrorCSVUnmarshal(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusOK
+	reqRes.Path = "/v2/entities"
+	reqRes.ResHeader = http.Header{"Fiware-Total-Count": []string{"1"}}
+	reqRes.ResBody = []byte(`[{"id":"airqualityobserved_0","type":"AirQualityObserved"}]`)
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	ngsi.JSONConverter = &MockJSONLib{EncodeErr: errors.New("json error"), DecodeErr: errors.New("json error")}
+	setupFlagString(set, "host,format,attrs")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", "--format=csv", "--attrs=id"})
+	err := entitiesList(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 14, ngsiErr.ErrNo)
+		assert.Equal(t, "json error", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestEntitiesListErrorCSVWrite(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusOK
+	reqRes.Path = "/v2/entities"
+	reqRes.ResHeader = http.Header{"Fiware-Total-Count": []string{"1"}}
+	reqRes.ResBody = []byte(`[{"id":"airqualityobserved_0","type":"AirQualityObserved","address":{"type":"StructuredValue","value":{"city":"Tokyo"}}}]`)
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	j := ngsi.JSONConverter
+	ngsi.JSONConverter = &MockJSONLib{EncodeErr: errors.New("json error"), Jsonlib: j}
+	setupFlagString(set, "host,format,attrs")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", "--format=csv", "--attrs=address"})
+	err := entitiesList(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 15, ngsiErr.ErrNo)
+		assert.Equal(t, "json error", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestEntitiesListErrorCSVKeyValues(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+	setupFlagString(set, "host,format")
+	setupFlagBool(set, "keyValues")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", "--format=csv", "--keyValues"})
+	err := entitiesList(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 20, ngsiErr.ErrNo)
+		assert.Equal(t, "--keyValues cannot be used with csv output", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestEntitiesListErrorCSVScan(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusOK
+	reqRes.Path = "/v2/entities"
+	reqRes.ResHeader = http.Header{"Fiware-Total-Count": []string{"1"}}
+	reqRes.ResBody = []byte(`[{"id":"airqualityobserved_0","type":"AirQualityObserved","address":{"type":"StructuredValue","value":{"city":"Tokyo"}}}]`)
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	ngsi.JSONConverter = &MockJSONLib{DecodeErr: errors.New("json error")}
+	setupFlagString(set, "host,format")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", "--format=csv"})
+	err := entitiesList(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 21, ngsiErr.ErrNo)
+		assert.Equal(t, "json error", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
 func TestEntitiesCountV2(t *testing.T) {
 	ngsi, set, app, buf := setupTest()
 
@@ -836,3 +1106,260 @@ func TestEntitiesCountErrorResultsCount(t *testing.T) {
 		t.FailNow()
 	}
 }
+
+func TestEntitiesListParallel(t *testing.T) {
+	ngsi, set, app, buf := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+
+	setupFlagString(set, "host,parallel")
+	mock := NewMockHTTP()
+	for i := 0; i < 3; i++ {
+		reqRes := MockHTTPReqRes{}
+		reqRes.Res.StatusCode = http.StatusOK
+		reqRes.ResBody = []byte(fmt.Sprintf(`[{"id":"device%03d"}]`, i))
+		reqRes.ResHeader = http.Header{"Fiware-Total-Count": []string{"250"}}
+		reqRes.Path = "/v2/entities"
+		mock.ReqRes = append(mock.ReqRes, reqRes)
+	}
+	ngsi.HTTP = mock
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", "--parallel=2"})
+	err := entitiesList(c)
+
+	if assert.NoError(t, err) {
+		actual := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		assert.Equal(t, 3, len(actual))
+		assert.Equal(t, "device000", actual[0])
+		assert.Equal(t, 3, mock.index)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestEntitiesListWatch(t *testing.T) {
+	ngsi, set, app, buf := setupTest()
+	defer setupWatch(1)()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+
+	setupFlagString(set, "host,type")
+	setupFlagBool(set, "watch")
+	list := func(body, count string) MockHTTPReqRes {
+		reqRes := MockHTTPReqRes{}
+		reqRes.Res.StatusCode = http.StatusOK
+		reqRes.ResBody = []byte(body)
+		reqRes.ResHeader = http.Header{"Fiware-Total-Count": []string{count}}
+		return reqRes
+	}
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes,
+		list(`[{"id":"E1","type":"T"},{"id":"E2","type":"T"}]`, "2"),
+		list(`[{"id":"E1","type":"T","a":{"type":"Number","value":1,"metadata":{}}},{"id":"E2","type":"T"}]`, "2"),
+		list(`[{"id":"E1","type":"T","a":{"type":"Number","value":2,"metadata":{}}}]`, "1"))
+	ngsi.HTTP = mock
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", "--type=T", "--watch"})
+
+	err := entitiesList(c)
+
+	if assert.NoError(t, err) {
+		expected := "E1\nE2\n" +
+			"2021-01-02T03:04:05Z - E2 (T)\n" +
+			"2021-01-02T03:04:05Z ~ E1 (T)\n" +
+			"    ~ a value: 1 -> 2\n"
+		assert.Equal(t, expected, buf.String())
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestEntitiesListWatchErrorCount(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+
+	setupFlagString(set, "host")
+	setupFlagBool(set, "watch,count")
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", "--watch", "--count"})
+
+	err := entitiesList(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 16, ngsiErr.ErrNo)
+		assert.Equal(t, "--watch cannot be used with --count or --values", ngsiErr.Message)
+	}
+}
+
+func TestEntitiesListWatchErrorJSON(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+
+	setupFlagString(set, "host")
+	setupFlagBool(set, "watch")
+	reqRes1 := MockHTTPReqRes{}
+	reqRes1.Res.StatusCode = http.StatusOK
+	reqRes1.ResBody = []byte(`[]`)
+	reqRes1.ResHeader = http.Header{"Fiware-Total-Count": []string{"0"}}
+	reqRes2 := MockHTTPReqRes{}
+	reqRes2.Res.StatusCode = http.StatusOK
+	reqRes2.ResBody = []byte(`{}`)
+	reqRes2.ResHeader = http.Header{"Fiware-Total-Count": []string{"1"}}
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes1, reqRes2)
+	ngsi.HTTP = mock
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", "--watch"})
+
+	err := entitiesList(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 18, ngsiErr.ErrNo)
+		ngsiErr = ngsiErr.Err.(*ngsiCmdError)
+		assert.Equal(t, 2, ngsiErr.ErrNo)
+		ngsiErr = ngsiErr.Err.(*ngsiCmdError)
+		assert.Equal(t, 17, ngsiErr.ErrNo)
+	}
+}
+
+func TestEntitiesListOutput(t *testing.T) {
+	ngsi, set, app, buf := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusOK
+	reqRes.Path = "/v2/entities"
+	reqRes.ResHeader = http.Header{"Fiware-Total-Count": []string{"2"}}
+	reqRes.ResBody = []byte(`[{"id":"Room1","type":"Room","temperature":{"type":"Number","value":21.5,"metadata":{}}},{"id":"Room2","type":"Room","temperature":{"type":"Number","value":22,"metadata":{}}}]`)
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	setupFlagString(set, "host,type,output,select")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", "--output=table", "--select=id,temperature.value"})
+	err := entitiesList(c)
+
+	if assert.NoError(t, err) {
+		actual := buf.String()
+		expected := "ID     TEMPERATURE.VALUE\nRoom1  21.5\nRoom2  22\n"
+		assert.Equal(t, expected, actual)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestEntitiesListOutputCSV(t *testing.T) {
+	ngsi, set, app, buf := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusOK
+	reqRes.Path = "/v2/entities"
+	reqRes.ResHeader = http.Header{"Fiware-Total-Count": []string{"2"}}
+	reqRes.ResBody = []byte(`[{"id":"Room1","type":"Room","temperature":{"type":"Number","value":21.5,"metadata":{}}},{"id":"Room2","type":"Room","temperature":{"type":"Number","value":22,"metadata":{}}}]`)
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes, reqRes)
+	ngsi.HTTP = mock
+	setupFlagString(set, "host,type,output,select")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", "--output=csv"})
+	err := entitiesList(c)
+
+	if assert.NoError(t, err) {
+		actual := buf.String()
+		expected := "id,type,temperature:Number\nRoom1,Room,21.5\nRoom2,Room,22\n"
+		assert.Equal(t, expected, actual)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestEntitiesListErrorFormatCSVOutput(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+	setupFlagString(set, "host,format,output")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", "--format=csv", "--output=json"})
+	err := entitiesList(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 22, ngsiErr.ErrNo)
+		assert.Equal(t, "--format csv cannot be used with --output or --select", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestEntitiesListErrorOutput(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusOK
+	reqRes.Path = "/v2/entities"
+	reqRes.ResHeader = http.Header{"Fiware-Total-Count": []string{"2"}}
+	reqRes.ResBody = []byte(`[{"id":"Room1","type":"Room","temperature":{"type":"Number","value":21.5,"metadata":{}}},{"id":"Room2","type":"Room","temperature":{"type":"Number","value":22,"metadata":{}}}]`)
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	setupFlagString(set, "host,type,output,select")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", "--output=xml"})
+	err := entitiesList(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 19, ngsiErr.ErrNo)
+		assert.Equal(t, "output format error: xml (table, json, ndjson, yaml, csv)", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestEntitiesListVerbosePretty(t *testing.T) {
+	ngsi, set, app, buf := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+
+	reqRes1 := MockHTTPReqRes{}
+	reqRes1.Res.StatusCode = http.StatusOK
+	reqRes1.Path = "/v2/entities"
+	reqRes1.ResHeader = http.Header{"Fiware-Total-Count": []string{"101"}}
+	reqRes1.ResBody = []byte(`[{"id":"E1","type":"T"}]`)
+	reqRes2 := MockHTTPReqRes{}
+	reqRes2.Res.StatusCode = http.StatusOK
+	reqRes2.Path = "/v2/entities"
+	reqRes2.ResHeader = http.Header{"Fiware-Total-Count": []string{"101"}}
+	reqRes2.ResBody = []byte(`[{"id":"E2","type":"T","a":{}}]`)
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes1)
+	mock.ReqRes = append(mock.ReqRes, reqRes2)
+	ngsi.HTTP = mock
+	setupFlagString(set, "host,type")
+	setupFlagBool(set, "verbose,pretty")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", "--verbose", "--pretty"})
+	err := entitiesList(c)
+
+	if assert.NoError(t, err) {
+		actual := buf.String()
+		expected := "[\n  {\n    \"id\": \"E1\",\n    \"type\": \"T\"\n  },\n  {\n    \"id\": \"E2\",\n    \"type\": \"T\",\n    \"a\": {}\n  }\n]"
+		assert.Equal(t, expected, actual)
+	} else {
+		t.FailNow()
+	}
+}
@@ -94,29 +94,95 @@ func entityRead(c *cli.Context) error {
 		return &ngsiCmdError{funcName, 2, err.Error(), err}
 	}
 
+	watch := c.Bool("watch")
+	if watch && c.Bool("values") {
+		return &ngsiCmdError{funcName, 6, "--watch cannot be used with --values", nil}
+	}
+
+	csvFormat := outputEntitiesCSV(c)
+	if csvFormat && (c.Bool("keyValues") || c.Bool("values")) {
+		return &ngsiCmdError{funcName, 10, "--keyValues or --values cannot be used with csv output", nil}
+	}
+
 	id := c.String("id")
-	client.SetPath("/entities/" + id)
 
-	args := []string{"type", "attrs"}
-	var opts = []string{"keyValues", "values", "unique", "sysAttrs"}
-	v := parseOptions(c, args, opts)
-	client.SetQuery(v)
+	// fetch returns nil without an error when watching and the entity is not found, so that
+	// the deletion of the entity is printed as a change.
+	fetch := func(watching bool) ([]byte, error) {
+		client.SetPath("/entities/" + id)
+
+		args := []string{"type", "attrs"}
+		var opts = []string{"keyValues", "values", "unique", "sysAttrs"}
+		v := parseOptions(c, args, opts)
+		client.SetQuery(v)
+
+		res, body, err := client.HTTPGet()
+		if err != nil {
+			return nil, &ngsiCmdError{funcName, 3, err.Error(), err}
+		}
+		if watching && res.StatusCode == http.StatusNotFound {
+			return nil, nil
+		}
+		if res.StatusCode != http.StatusOK {
+			return nil, &ngsiCmdError{funcName, 4, fmt.Sprintf("error: %s %s", res.Status, string(body)), nil}
+		}
 
-	res, body, err := client.HTTPGet()
+		if client.IsSafeString() {
+			body, err = ngsilib.JSONSafeStringDecode(body)
+			if err != nil {
+				return nil, &ngsiCmdError{funcName, 5, err.Error(), err}
+			}
+		}
+		return body, nil
+	}
+
+	body, err := fetch(false)
 	if err != nil {
-		return &ngsiCmdError{funcName, 3, err.Error(), err}
+		return err
 	}
-	if res.StatusCode != http.StatusOK {
-		return &ngsiCmdError{funcName, 4, fmt.Sprintf("error: %s %s", res.Status, string(body)), nil}
+	if csvFormat {
+		e := make(map[string]interface{})
+		if err := ngsilib.JSONUnmarshal(body, &e); err != nil {
+			return &ngsiCmdError{funcName, 11, err.Error(), err}
+		}
+		w := newCSVEntityWriter(ngsi.StdWriter, client.IsNgsiLd())
+		if err := w.Write(entitiesRespose{e}, c.String("attrs")); err != nil {
+			return &ngsiCmdError{funcName, 12, err.Error(), err}
+		}
+	} else if outputEnabled(c) {
+		if err := outputPrint(c, ngsi, body); err != nil {
+			return &ngsiCmdError{funcName, 9, err.Error(), err}
+		}
+	} else {
+		printJSON(ngsi, body)
 	}
 
-	if client.IsSafeString() {
-		body, err = ngsilib.JSONSafeStringDecode(body)
+	if watch {
+		entity := func(body []byte) (entitiesRespose, error) {
+			e := make(map[string]interface{})
+			if err := ngsilib.JSONUnmarshal(body, &e); err != nil {
+				return nil, &ngsiCmdError{funcName, 7, err.Error(), err}
+			}
+			return entitiesRespose{e}, nil
+		}
+		prev, err := entity(body)
+		if err != nil {
+			return err
+		}
+		err = watchRun(c, ngsi, prev, func() (entitiesRespose, error) {
+			body, err := fetch(true)
+			if err != nil {
+				return nil, err
+			}
+			if body == nil {
+				return entitiesRespose{}, nil
+			}
+			return entity(body)
+		})
 		if err != nil {
-			return &ngsiCmdError{funcName, 5, err.Error(), err}
+			return &ngsiCmdError{funcName, 8, err.Error(), err}
 		}
 	}
-	fmt.Fprintln(ngsi.StdWriter, string(body))
 
 	return nil
 }
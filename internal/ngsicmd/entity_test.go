@@ -565,3 +565,318 @@ func TestEntityDeleteErrorHTTPStatus(t *testing.T) {
 		t.FailNow()
 	}
 }
+
+func TestEntityReadWatch(t *testing.T) {
+	ngsi, set, app, buf := setupTest()
+	defer setupWatch(1)()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+
+	setupFlagString(set, "host,id")
+	setupFlagBool(set, "watch")
+	reqRes1 := MockHTTPReqRes{}
+	reqRes1.Res.StatusCode = http.StatusOK
+	reqRes1.ResBody = []byte(`{"id":"E1","type":"T","temperature":{"type":"Number","value":21,"metadata":{}}}`)
+	reqRes2 := MockHTTPReqRes{}
+	reqRes2.Res.StatusCode = http.StatusOK
+	reqRes2.ResBody = []byte(`{"id":"E1","type":"T","temperature":{"type":"Number","value":22,"metadata":{}}}`)
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes1, reqRes2)
+	ngsi.HTTP = mock
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", "--id=E1", "--watch"})
+
+	err := entityRead(c)
+
+	if assert.NoError(t, err) {
+		expected := `{"id":"E1","type":"T","temperature":{"type":"Number","value":21,"metadata":{}}}` + "\n" +
+			"2021-01-02T03:04:05Z ~ E1 (T)\n" +
+			"    ~ temperature value: 21 -> 22\n"
+		assert.Equal(t, expected, buf.String())
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestEntityReadWatchNotFound(t *testing.T) {
+	ngsi, set, app, buf := setupTest()
+	defer setupWatch(1)()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+
+	setupFlagString(set, "host,id")
+	setupFlagBool(set, "watch")
+	reqRes1 := MockHTTPReqRes{}
+	reqRes1.Res.StatusCode = http.StatusOK
+	reqRes1.ResBody = []byte(`{"id":"E1","type":"T","temperature":{"type":"Number","value":21,"metadata":{}}}`)
+	reqRes2 := MockHTTPReqRes{}
+	reqRes2.Res.StatusCode = http.StatusNotFound
+	reqRes2.ResBody = []byte(`{"error":"NotFound","description":"The requested entity has not been found. Check type and id"}`)
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes1, reqRes2)
+	ngsi.HTTP = mock
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", "--id=E1", "--watch"})
+
+	err := entityRead(c)
+
+	if assert.NoError(t, err) {
+		expected := `{"id":"E1","type":"T","temperature":{"type":"Number","value":21,"metadata":{}}}` + "\n" +
+			"2021-01-02T03:04:05Z - E1 (T)\n"
+		assert.Equal(t, expected, buf.String())
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestEntityReadWatchErrorValues(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+
+	setupFlagString(set, "host,id")
+	setupFlagBool(set, "watch,values")
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", "--id=E1", "--watch", "--values"})
+
+	err := entityRead(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 6, ngsiErr.ErrNo)
+		assert.Equal(t, "--watch cannot be used with --values", ngsiErr.Message)
+	}
+}
+
+func TestEntityReadWatchErrorJSON(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+
+	setupFlagString(set, "host,id")
+	setupFlagBool(set, "watch")
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusOK
+	reqRes.ResBody = []byte(`[1]`)
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", "--id=E1", "--watch"})
+
+	err := entityRead(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 7, ngsiErr.ErrNo)
+	}
+}
+
+func TestEntityReadWatchErrorWatch(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+
+	setupFlagString(set, "host,id,interval")
+	setupFlagBool(set, "watch")
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusOK
+	reqRes.ResBody = []byte(`{"id":"E1","type":"T"}`)
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", "--id=E1", "--watch", "--interval=-1s"})
+
+	err := entityRead(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 8, ngsiErr.ErrNo)
+		assert.Equal(t, "interval error: -1s", ngsiErr.Message)
+	}
+}
+
+func TestEntityReadOutput(t *testing.T) {
+	ngsi, set, app, buf := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusOK
+	reqRes.Path = "/v2/entities/urn:ngsi-ld:Product:010"
+	reqRes.ResBody = []byte(`{"id":"urn:ngsi-ld:Product:010","type":"Product","name":{"type":"Text","value":"Lemonade"},"price":{"type":"Integer","value":99}}`)
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	setupFlagString(set, "host,id,output,select")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", "--id=urn:ngsi-ld:Product:010", "--output=yaml", "--select=name.value,price.value"})
+	err := entityRead(c)
+
+	if assert.NoError(t, err) {
+		actual := buf.String()
+		expected := "name.value: Lemonade\nprice.value: 99\n"
+		assert.Equal(t, expected, actual)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestEntityReadOutputCSV(t *testing.T) {
+	ngsi, set, app, buf := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusOK
+	reqRes.Path = "/v2/entities/urn:ngsi-ld:Product:010"
+	reqRes.ResBody = []byte(`{"id":"urn:ngsi-ld:Product:010","type":"Product","name":{"type":"Text","value":"Lemonade"},"price":{"type":"Integer","value":99}}`)
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	setupFlagString(set, "host,id,output,select")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", "--id=urn:ngsi-ld:Product:010", "--output=csv"})
+	err := entityRead(c)
+
+	if assert.NoError(t, err) {
+		actual := buf.String()
+		expected := "id,type,name:Text,price:Integer\nurn:ngsi-ld:Product:010,Product,Lemonade,99\n"
+		assert.Equal(t, expected, actual)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestEntityReadErrorOutputCSVKeyValues(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+	setupFlagString(set, "host,id,output")
+	setupFlagBool(set, "keyValues")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", "--id=urn:ngsi-ld:Product:010", "--output=csv", "--keyValues"})
+	err := entityRead(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 10, ngsiErr.ErrNo)
+		assert.Equal(t, "--keyValues or --values cannot be used with csv output", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestEntityReadErrorOutputCSVUnmarshal(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusOK
+	reqRes.Path = "/v2/entities/urn:ngsi-ld:Product:010"
+	reqRes.ResBody = []byte(`[1]`)
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	setupFlagString(set, "host,id,output")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", "--id=urn:ngsi-ld:Product:010", "--output=csv"})
+	err := entityRead(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 11, ngsiErr.ErrNo)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestEntityReadErrorOutputCSVWrite(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusOK
+	reqRes.Path = "/v2/entities/urn:ngsi-ld:Product:010"
+	reqRes.ResBody = []byte(`{"id":"urn:ngsi-ld:Product:010","type":"Product","address":{"type":"StructuredValue","value":{"city":"Tokyo"}}}`)
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	j := ngsi.JSONConverter
+	ngsi.JSONConverter = &MockJSONLib{EncodeErr: errors.New("json error"), Jsonlib: j}
+	setupFlagString(set, "host,id,output")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", "--id=urn:ngsi-ld:Product:010", "--output=csv"})
+	err := entityRead(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 12, ngsiErr.ErrNo)
+		assert.Equal(t, "json error", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestEntityReadErrorOutput(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusOK
+	reqRes.Path = "/v2/entities/urn:ngsi-ld:Product:010"
+	reqRes.ResBody = []byte(`{"id":"urn:ngsi-ld:Product:010","type":"Product","name":{"type":"Text","value":"Lemonade"},"price":{"type":"Integer","value":99}}`)
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	setupFlagString(set, "host,id,output,select")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", "--id=urn:ngsi-ld:Product:010", "--output=xml"})
+	err := entityRead(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 9, ngsiErr.ErrNo)
+		assert.Equal(t, "output format error: xml (table, json, ndjson, yaml, csv)", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestEntityReadPretty(t *testing.T) {
+	ngsi, set, app, buf := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusOK
+	reqRes.ResBody = []byte(`{"id":"urn:ngsi-ld:Product:010","type":"Product","price":{"type":"Integer","value":99}}`)
+	reqRes.Path = "/v2/entities/urn:ngsi-ld:Product:010"
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	setupFlagString(set, "host,id")
+	setupFlagBool(set, "pretty")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", "--id=urn:ngsi-ld:Product:010", "--pretty"})
+	err := entityRead(c)
+
+	if assert.NoError(t, err) {
+		actual := buf.String()
+		expected := "{\n  \"id\": \"urn:ngsi-ld:Product:010\",\n  \"type\": \"Product\",\n  \"price\": {\n    \"type\": \"Integer\",\n    \"value\": 99\n  }\n}\n"
+		assert.Equal(t, expected, actual)
+	} else {
+		t.FailNow()
+	}
+}
@@ -0,0 +1,227 @@
+/*
+MIT License
+
+Copyright (c) 2020 Kazuhito Suda
+
+This file is part of NGSI Go
+
+https://github.com/lets-fiware/ngsi-go
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+package ngsicmd
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/lets-fiware/ngsi-go/internal/ngsilib"
+	"github.com/urfave/cli/v2"
+)
+
+const (
+	snapshotManifest      = "manifest.json"
+	snapshotEntities      = "entities.ndjson"
+	snapshotSubscriptions = "subscriptions.ndjson"
+	snapshotRegistrations = "registrations.ndjson"
+)
+
+// snapshotInfo is written to manifest.json of a snapshot directory.
+type snapshotInfo struct {
+	Host          string   `json:"host"`
+	NgsiType      string   `json:"ngsiType"`
+	Tenant        string   `json:"tenant,omitempty"`
+	Scope         string   `json:"scope,omitempty"`
+	Type          string   `json:"type,omitempty"`
+	Contexts      []string `json:"contexts,omitempty"`
+	Entities      int      `json:"entities"`
+	Subscriptions int      `json:"subscriptions"`
+	Registrations int      `json:"registrations"`
+}
+
+func exportSnapshot(c *cli.Context) error {
+	const funcName = "exportSnapshot"
+
+	ngsi, err := initCmd(c, funcName, true)
+	if err != nil {
+		return &ngsiCmdError{funcName, 1, err.Error(), err}
+	}
+
+	client, err := newClient(ngsi, c, false)
+	if err != nil {
+		return &ngsiCmdError{funcName, 2, err.Error(), err}
+	}
+
+	dir := c.String("dir")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return &ngsiCmdError{funcName, 3, err.Error(), err}
+	}
+
+	info := &snapshotInfo{
+		Host:     c.String("host"),
+		NgsiType: "v2",
+		Tenant:   client.Tenant,
+		Scope:    client.Scope,
+		Type:     c.String("type"),
+	}
+	if client.IsNgsiLd() {
+		info.NgsiType = "ld"
+	}
+
+	entityType := c.String("type")
+	limit := pageSize(c, ngsi, client)
+	contexts := make(map[string]bool)
+
+	info.Entities, err = exportWriteFile(filepath.Join(dir, snapshotEntities), func(w *bufio.Writer) (int, error) {
+		n := 0
+		for offset, count := 0, 1; offset < count; offset += limit {
+			var entities entitiesRespose
+			entities, count, err = copyReadEntities(client, entityType, limit, offset)
+			if err != nil {
+				return n, err
+			}
+			for _, e := range entities {
+				exportContexts(e["@context"], contexts)
+			}
+			if err := exportWriteLines(w, entities); err != nil {
+				return n, err
+			}
+			n += len(entities)
+		}
+		return n, nil
+	})
+	if err != nil {
+		return &ngsiCmdError{funcName, 4, err.Error(), err}
+	}
+
+	if client.Link != nil {
+		contexts[*client.Link] = true
+	}
+	for k := range contexts {
+		info.Contexts = append(info.Contexts, k)
+	}
+	sort.Strings(info.Contexts)
+
+	info.Subscriptions, err = exportWriteFile(filepath.Join(dir, snapshotSubscriptions), func(w *bufio.Writer) (int, error) {
+		items, err := applyList(client, "/subscriptions")
+		if err != nil {
+			return 0, err
+		}
+		return len(items), exportWriteLines(w, items)
+	})
+	if err != nil {
+		return &ngsiCmdError{funcName, 5, err.Error(), err}
+	}
+
+	info.Registrations, err = exportWriteFile(filepath.Join(dir, snapshotRegistrations), func(w *bufio.Writer) (int, error) {
+		items, err := applyList(client, snapshotRegistrationPath(client))
+		if err != nil {
+			return 0, err
+		}
+		return len(items), exportWriteLines(w, items)
+	})
+	if err != nil {
+		return &ngsiCmdError{funcName, 6, err.Error(), err}
+	}
+
+	b, err := ngsilib.JSONMarshal(info)
+	if err != nil {
+		return &ngsiCmdError{funcName, 7, err.Error(), err}
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, snapshotManifest), append(b, '\n'), 0600); err != nil {
+		return &ngsiCmdError{funcName, 8, err.Error(), err}
+	}
+
+	fmt.Fprintf(ngsi.StdWriter, "entities: %d, subscriptions: %d, registrations: %d\n", info.Entities, info.Subscriptions, info.Registrations)
+
+	return nil
+}
+
+func snapshotRegistrationPath(client *ngsilib.Client) string {
+	if client.IsNgsiLd() {
+		return "/csourceRegistrations"
+	}
+	return "/registrations"
+}
+
+// exportWriteFile creates a file and calls write with a buffered writer of the file.
+func exportWriteFile(path string, write func(w *bufio.Writer) (int, error)) (int, error) {
+	const funcName = "exportWriteFile"
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return 0, &ngsiCmdError{funcName, 1, err.Error(), err}
+	}
+
+	w := bufio.NewWriter(f)
+
+	n, err := write(w)
+	if err != nil {
+		_ = f.Close()
+		return 0, &ngsiCmdError{funcName, 2, err.Error(), err}
+	}
+	if err := w.Flush(); err != nil {
+		_ = f.Close()
+		return 0, &ngsiCmdError{funcName, 3, err.Error(), err}
+	}
+	if err := f.Close(); err != nil {
+		return 0, &ngsiCmdError{funcName, 4, err.Error(), err}
+	}
+
+	return n, nil
+}
+
+func exportWriteLines(w *bufio.Writer, items []map[string]interface{}) error {
+	const funcName = "exportWriteLines"
+
+	for _, item := range items {
+		b, err := ngsilib.JSONMarshal(item)
+		if err != nil {
+			return &ngsiCmdError{funcName, 1, err.Error(), err}
+		}
+		if _, err := w.Write(b); err != nil {
+			return &ngsiCmdError{funcName, 2, err.Error(), err}
+		}
+		if err := w.WriteByte('\n'); err != nil {
+			return &ngsiCmdError{funcName, 3, err.Error(), err}
+		}
+	}
+
+	return nil
+}
+
+// exportContexts collects URLs of @context referenced by an entity.
+func exportContexts(v interface{}, contexts map[string]bool) {
+	switch context := v.(type) {
+	case string:
+		contexts[context] = true
+	case []interface{}:
+		for _, e := range context {
+			if s, ok := e.(string); ok {
+				contexts[s] = true
+			}
+		}
+	}
+}
@@ -0,0 +1,375 @@
+/*
+MIT License
+
+Copyright (c) 2020 Kazuhito Suda
+
+This file is part of NGSI Go
+
+https://github.com/lets-fiware/ngsi-go
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+package ngsicmd
+
+import (
+	"bufio"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/cli/v2"
+)
+
+func setupExportMock(entities, subscriptions, registrations string) *MockHTTP {
+	mock := NewMockHTTP()
+	for _, r := range []struct {
+		body string
+		path string
+	}{{entities, "/v2/entities"}, {subscriptions, "/v2/subscriptions"}, {registrations, "/v2/registrations"}} {
+		reqRes := MockHTTPReqRes{}
+		reqRes.Res.StatusCode = http.StatusOK
+		reqRes.ResBody = []byte(r.body)
+		count := "1"
+		if r.body == "[]" {
+			count = "0"
+		}
+		reqRes.ResHeader = http.Header{"Fiware-Total-Count": []string{count}}
+		reqRes.Path = r.path
+		mock.ReqRes = append(mock.ReqRes, reqRes)
+	}
+	return mock
+}
+
+func TestExportSnapshot(t *testing.T) {
+	ngsi, set, app, buf := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+
+	dir := filepath.Join(t.TempDir(), "backup")
+	setupFlagString(set, "host,service,dir")
+	ngsi.HTTP = setupExportMock(
+		`[{"id":"E1","type":"T","temperature":{"type":"Number","value":21,"metadata":{}}}]`,
+		`[{"id":"5f0a","description":"sub1","notification":{"timesSent":1}}]`,
+		`[{"id":"5f1a","description":"reg1"}]`)
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", "--service=smartcity", "--dir=" + dir})
+
+	err := exportSnapshot(c)
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, "entities: 1, subscriptions: 1, registrations: 1\n", buf.String())
+		b, _ := ioutil.ReadFile(filepath.Join(dir, snapshotManifest))
+		assert.Equal(t, `{"host":"orion","ngsiType":"v2","tenant":"smartcity","entities":1,"subscriptions":1,"registrations":1}`+"\n", string(b))
+		b, _ = ioutil.ReadFile(filepath.Join(dir, snapshotEntities))
+		assert.Equal(t, `{"id":"E1","temperature":{"metadata":{},"type":"Number","value":21},"type":"T"}`+"\n", string(b))
+		b, _ = ioutil.ReadFile(filepath.Join(dir, snapshotSubscriptions))
+		assert.Equal(t, `{"description":"sub1","id":"5f0a","notification":{"timesSent":1}}`+"\n", string(b))
+		b, _ = ioutil.ReadFile(filepath.Join(dir, snapshotRegistrations))
+		assert.Equal(t, `{"description":"reg1","id":"5f1a"}`+"\n", string(b))
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestExportSnapshotLd(t *testing.T) {
+	ngsi, set, app, buf := setupTest()
+
+	setupAddBroker(t, ngsi, "orion-ld", "https://orion-ld", "ld")
+
+	dir := t.TempDir()
+	setupFlagString(set, "host,dir,type")
+	mock := NewMockHTTP()
+	for _, r := range []struct {
+		body  string
+		path  string
+		count string
+	}{
+		{`[{"id":"urn:ngsi-ld:T:1","type":"T","@context":["https://context/ngsi-context.jsonld","https://uri.etsi.org/ngsi-ld/v1/ngsi-ld-core-context.jsonld"]}]`, "/ngsi-ld/v1/entities", "1"},
+		{`[]`, "/ngsi-ld/v1/subscriptions", "0"},
+		{`[]`, "/ngsi-ld/v1/csourceRegistrations", "0"},
+	} {
+		reqRes := MockHTTPReqRes{}
+		reqRes.Res.StatusCode = http.StatusOK
+		reqRes.ResBody = []byte(r.body)
+		reqRes.ResHeader = http.Header{"Ngsild-Results-Count": []string{r.count}}
+		reqRes.Path = r.path
+		mock.ReqRes = append(mock.ReqRes, reqRes)
+	}
+	ngsi.HTTP = mock
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion-ld", "--type=T", "--dir=" + dir})
+
+	err := exportSnapshot(c)
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, "entities: 1, subscriptions: 0, registrations: 0\n", buf.String())
+		b, _ := ioutil.ReadFile(filepath.Join(dir, snapshotManifest))
+		expected := `{"host":"orion-ld","ngsiType":"ld","type":"T","contexts":["https://context/ngsi-context.jsonld","https://uri.etsi.org/ngsi-ld/v1/ngsi-ld-core-context.jsonld"],"entities":1,"subscriptions":0,"registrations":0}` + "\n"
+		assert.Equal(t, expected, string(b))
+		b, _ = ioutil.ReadFile(filepath.Join(dir, snapshotSubscriptions))
+		assert.Equal(t, "", string(b))
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestExportSnapshotErrorInitCmd(t *testing.T) {
+	_, set, app, _ := setupTest()
+
+	c := cli.NewContext(app, set, nil)
+
+	err := exportSnapshot(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 1, ngsiErr.ErrNo)
+		assert.Equal(t, "Required host not found", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestExportSnapshotErrorNewClient(t *testing.T) {
+	_, set, app, _ := setupTest()
+
+	setupFlagString(set, "host,link")
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", "--link=abc"})
+
+	err := exportSnapshot(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 2, ngsiErr.ErrNo)
+		assert.Equal(t, "abc not found", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestExportSnapshotErrorMkdir(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+
+	file := filepath.Join(t.TempDir(), "file")
+	_ = ioutil.WriteFile(file, []byte{}, 0600)
+	setupFlagString(set, "host,dir")
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", "--dir=" + filepath.Join(file, "backup")})
+
+	err := exportSnapshot(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 3, ngsiErr.ErrNo)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestExportSnapshotErrorEntities(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+
+	setupFlagString(set, "host,dir")
+	reqRes := MockHTTPReqRes{}
+	reqRes.Err = errors.New("http error")
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", "--dir=" + t.TempDir()})
+
+	err := exportSnapshot(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 4, ngsiErr.ErrNo)
+		assert.Equal(t, "http error", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestExportSnapshotErrorEntitiesJSONMarshal(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+
+	setupFlagString(set, "host,dir")
+	ngsi.HTTP = setupExportMock(`[{"id":"E1","type":"T"}]`, `[]`, `[]`)
+	ngsi.JSONConverter = &MockJSONLib{EncodeErr: errors.New("json error"), Jsonlib: ngsi.JSONConverter}
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", "--dir=" + t.TempDir()})
+
+	err := exportSnapshot(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 4, ngsiErr.ErrNo)
+		assert.Equal(t, "json error", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestExportSnapshotErrorSubscriptions(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+
+	setupFlagString(set, "host,dir")
+	mock := setupExportMock(`[]`, `[]`, `[]`)
+	mock.ReqRes[1].Err = errors.New("http error")
+	ngsi.HTTP = mock
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", "--dir=" + t.TempDir()})
+
+	err := exportSnapshot(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 5, ngsiErr.ErrNo)
+		assert.Equal(t, "http error", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestExportSnapshotErrorRegistrations(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+
+	setupFlagString(set, "host,dir")
+	mock := setupExportMock(`[]`, `[]`, `[]`)
+	mock.ReqRes[2].Err = errors.New("http error")
+	ngsi.HTTP = mock
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", "--dir=" + t.TempDir()})
+
+	err := exportSnapshot(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 6, ngsiErr.ErrNo)
+		assert.Equal(t, "http error", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestExportSnapshotErrorManifestJSONMarshal(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+
+	setupFlagString(set, "host,dir")
+	ngsi.HTTP = setupExportMock(`[]`, `[]`, `[]`)
+	ngsi.JSONConverter = &MockJSONLib{EncodeErr: errors.New("json error"), Jsonlib: ngsi.JSONConverter}
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", "--dir=" + t.TempDir()})
+
+	err := exportSnapshot(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 7, ngsiErr.ErrNo)
+		assert.Equal(t, "json error", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestExportSnapshotErrorManifestWrite(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+
+	dir := t.TempDir()
+	_ = os.Mkdir(filepath.Join(dir, snapshotManifest), 0700)
+	setupFlagString(set, "host,dir")
+	ngsi.HTTP = setupExportMock(`[]`, `[]`, `[]`)
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", "--dir=" + dir})
+
+	err := exportSnapshot(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 8, ngsiErr.ErrNo)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestExportWriteFileErrorOpen(t *testing.T) {
+	_, err := exportWriteFile(t.TempDir(), nil)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 1, ngsiErr.ErrNo)
+	}
+}
+
+func TestExportWriteFileErrorFlush(t *testing.T) {
+	path := filepath.Join(t.TempDir(), snapshotEntities)
+
+	_, err := exportWriteFile(path, func(w *bufio.Writer) (int, error) {
+		w.Reset(&MockWriter{Err: errors.New("write error")})
+		_, _ = w.WriteString("{}")
+		return 1, nil
+	})
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 3, ngsiErr.ErrNo)
+		assert.Equal(t, "write error", ngsiErr.Message)
+	}
+}
+
+func TestExportWriteLinesErrorWrite(t *testing.T) {
+	w := bufio.NewWriterSize(&MockWriter{Err: errors.New("write error")}, 16)
+
+	err := exportWriteLines(w, []map[string]interface{}{{"id": "urn:ngsi-ld:Device:001", "type": "Device"}})
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 2, ngsiErr.ErrNo)
+		assert.Equal(t, "write error", ngsiErr.Message)
+	}
+}
+
+func TestExportContexts(t *testing.T) {
+	contexts := make(map[string]bool)
+
+	exportContexts("https://context/1", contexts)
+	exportContexts([]interface{}{"https://context/2", map[string]interface{}{"name": "https://schema.org/name"}}, contexts)
+	exportContexts(nil, contexts)
+
+	assert.Equal(t, map[string]bool{"https://context/1": true, "https://context/2": true}, contexts)
+}
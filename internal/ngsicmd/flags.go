@@ -70,10 +70,31 @@ var (
 		Value:  180,
 	}
 	timeOutFlag = &cli.IntFlag{
-		Name:   "timeout",
-		Usage:  "I/O time out (second)",
-		Hidden: true,
-		Value:  60,
+		Name:  "timeout",
+		Usage: "I/O time out `SECONDS`",
+		Value: 60,
+	}
+	maxIdleConnsFlag = &cli.IntFlag{
+		Name:  "maxIdleConns",
+		Usage: "maximum number of idle connections",
+		Value: 100,
+	}
+	idleTimeoutFlag = &cli.IntFlag{
+		Name:  "idleTimeout",
+		Usage: "idle connection time out `SECONDS`",
+		Value: 90,
+	}
+	noKeepAliveFlag = &cli.BoolFlag{
+		Name:  "noKeepAlive",
+		Usage: "disable HTTP keep-alive",
+	}
+	retryFlag = &cli.IntFlag{
+		Name:  "retry",
+		Usage: "maximum number of retries for transient failures",
+	}
+	retryWaitFlag = &cli.IntFlag{
+		Name:  "retryWait",
+		Usage: "initial wait between retries `SECONDS`",
 	}
 	maxCountFlag = &cli.IntFlag{
 		Name:   "maxCount",
@@ -166,6 +187,82 @@ var (
 		Usage: "run command",
 		Value: false,
 	}
+	checkpointFlag = &cli.StringFlag{
+		Name:  "checkpoint",
+		Usage: "specify checkpoint `FILE` to record progress",
+	}
+	resumeFlag = &cli.BoolFlag{
+		Name:  "resume",
+		Usage: "resume copy from checkpoint file",
+		Value: false,
+	}
+	parallelFlag = &cli.IntFlag{
+		Name:  "parallel",
+		Usage: "number of parallel workers",
+		Value: 1,
+	}
+	ignoreFlag = &cli.StringFlag{
+		Name:  "ignore",
+		Usage: "attributes and metadata to ignore (comma-separated, wildcards allowed)",
+	}
+	pageSizeFlag = &cli.IntFlag{
+		Name:  "pageSize",
+		Usage: "number of entities read per request",
+	}
+	batchSizeFlag = &cli.IntFlag{
+		Name:  "batchSize",
+		Usage: "number of entities per batch request",
+	}
+	dryRunFlag = &cli.BoolFlag{
+		Name:  "dryRun",
+		Usage: "print converted entities without writing them",
+		Value: false,
+	}
+	watchFlag = &cli.BoolFlag{
+		Name:  "watch",
+		Usage: "poll and print changes until interrupted",
+		Value: false,
+	}
+	intervalFlag = &cli.StringFlag{
+		Name:  "interval",
+		Usage: "polling interval for --watch",
+		Value: "5s",
+	}
+	outputFlag = &cli.StringFlag{
+		Name:    "output",
+		Aliases: []string{"o"},
+		Usage:   "output format (table, json, ndjson, yaml, csv)",
+	}
+	selectFlag = &cli.StringFlag{
+		Name:  "select",
+		Usage: "select values with JSONPath-style paths (comma-separated)",
+	}
+	snapshotDirFlag = &cli.StringFlag{
+		Name:     "dir",
+		Usage:    "specify snapshot `DIR`",
+		Required: true,
+	}
+	applyDryRunFlag = &cli.BoolFlag{
+		Name:  "dryRun",
+		Usage: "print the plan without applying it",
+		Value: false,
+	}
+	applyFileFlag = &cli.StringFlag{
+		Name:     "file",
+		Aliases:  []string{"f"},
+		Usage:    "desired subscriptions and registrations (JSON file or stdin)",
+		Required: true,
+	}
+	applyKeyFlag = &cli.StringFlag{
+		Name:  "key",
+		Usage: "field to match desired and existing items",
+		Value: "description",
+	}
+	applyPruneFlag = &cli.BoolFlag{
+		Name:  "prune",
+		Usage: "delete existing items not in the file",
+		Value: false,
+	}
 )
 
 // flags for NGSI API
@@ -265,6 +362,67 @@ var (
 		Value:    "",
 		Required: true,
 	}
+	attrNameFlag = &cli.StringFlag{
+		Name:  "attrName",
+		Usage: "attrName",
+		Value: "",
+	}
+)
+
+// flags for temporal query
+var (
+	timerelFlag = &cli.StringFlag{
+		Name:  "timerel",
+		Usage: "temporal relationship (before, after, between)",
+	}
+	timeAtFlag = &cli.StringFlag{
+		Name:  "timeAt",
+		Usage: "timeAt",
+	}
+	endTimeAtFlag = &cli.StringFlag{
+		Name:  "endTimeAt",
+		Usage: "endTimeAt",
+	}
+	timepropertyFlag = &cli.StringFlag{
+		Name:  "timeproperty",
+		Usage: "timeproperty (observedAt, createdAt, modifiedAt)",
+	}
+	lastNFlag = &cli.IntFlag{
+		Name:  "lastN",
+		Usage: "number of instances to retrieve",
+	}
+	temporalValuesFlag = &cli.BoolFlag{
+		Name:  "temporalValues",
+		Usage: "temporalValues",
+	}
+)
+
+// flags for time series
+var (
+	fromDateFlag = &cli.StringFlag{
+		Name:  "fromDate",
+		Usage: "starting date from which data should be retrieved",
+	}
+	toDateFlag = &cli.StringFlag{
+		Name:  "toDate",
+		Usage: "final date until which data should be retrieved",
+	}
+	aggrMethodFlag = &cli.StringFlag{
+		Name:  "aggrMethod",
+		Usage: "aggregation method",
+	}
+	aggrPeriodFlag = &cli.StringFlag{
+		Name:  "aggrPeriod",
+		Usage: "aggregation period",
+	}
+	hLimitFlag = &cli.Int64Flag{
+		Name:  "hLimit",
+		Usage: "maximum number of data to retrieve",
+	}
+	hOffsetFlag = &cli.Int64Flag{
+		Name:  "hOffset",
+		Usage: "offset to be applied to data",
+	}
 )
 
 // flags for options
@@ -323,6 +481,10 @@ var (
 		Aliases: []string{"1"},
 		Usage:   "lines",
 	}
+	formatFlag = &cli.StringFlag{
+		Name:  "format",
+		Usage: "format (json, csv)",
+	}
 )
 
 var (
@@ -344,6 +506,16 @@ var (
 		Usage:    "id",
 		Required: true,
 	}
+	resourceRFlag = &cli.StringFlag{
+		Name:     "resource",
+		Usage:    "specify resource of service group",
+		Required: true,
+	}
+	apikeyRFlag = &cli.StringFlag{
+		Name:     "apikey",
+		Usage:    "specify apikey of service group",
+		Required: true,
+	}
 	uriFlag = &cli.StringFlag{
 		Name:  "uri",
 		Usage: "url or uri",
@@ -372,6 +544,14 @@ var (
 		Aliases: []string{"v"},
 		Usage:   "verbose",
 	}
+	tokenVerboseFlag = &cli.BoolFlag{
+		Name:  "verbose",
+		Usage: "print detailed information",
+	}
+	introspectFlag = &cli.BoolFlag{
+		Name:  "introspect",
+		Usage: "print information of token from identity manager",
+	}
 	jsonFlag = &cli.BoolFlag{
 		Name:    "json",
 		Aliases: []string{"j"},
@@ -423,6 +603,50 @@ var (
 		Name:  "ngsiType",
 		Usage: "specify NGSI type: v2 or ld",
 	}
+	serverTypeFlag = &cli.StringFlag{
+		Name:  "serverType",
+		Usage: "specify server type: broker, quantumleap, comet or iota",
+	}
+	proxyFlag = &cli.StringFlag{
+		Name:  "proxy",
+		Usage: "specify HTTP(S) proxy url",
+	}
+	caCertFlag = &cli.StringFlag{
+		Name:  "caCert",
+		Usage: "specify CA certificate file",
+	}
+	clientCertFlag = &cli.StringFlag{
+		Name:  "clientCert",
+		Usage: "specify client certificate file",
+	}
+	clientKeyFlag = &cli.StringFlag{
+		Name:  "clientKey",
+		Usage: "specify client key file",
+	}
+	insecureSkipVerifyFlag = &cli.StringFlag{
+		Name:  "insecureSkipVerify",
+		Usage: "skip verification of server certificate: off or on",
+	}
+	brokerRetryFlag = &cli.StringFlag{
+		Name:  "retry",
+		Usage: "specify maximum number of retries for transient failures",
+	}
+	brokerRetryWaitFlag = &cli.StringFlag{
+		Name:  "retryWait",
+		Usage: "specify initial wait between retries in seconds",
+	}
+	brokerPageSizeFlag = &cli.StringFlag{
+		Name:  "pageSize",
+		Usage: "specify number of entities read per request",
+	}
+	brokerBatchSizeFlag = &cli.StringFlag{
+		Name:  "batchSize",
+		Usage: "specify number of entities per batch request",
+	}
+	brokerHeaderFlag = &cli.StringSliceFlag{
+		Name:  "header",
+		Usage: "specify a header sent to broker (key=value), can be specified more than once",
+	}
 	idmTypeFlag = &cli.StringFlag{
 		Name:    "idmType",
 		Aliases: []string{"t"},
@@ -458,6 +682,14 @@ var (
 		Aliases: []string{"S"},
 		Usage:   "specify client secret",
 	}
+	idmScopeFlag = &cli.StringFlag{
+		Name:  "idmScope",
+		Usage: "specify scope for identity manager",
+	}
+	audienceFlag = &cli.StringFlag{
+		Name:  "audience",
+		Usage: "specify audience for identity manager",
+	}
 	itemsFlag = &cli.StringFlag{
 		Name:    "items",
 		Aliases: []string{"i"},
@@ -489,3 +721,29 @@ var (
 		Required: true,
 	}
 )
+
+// flag for receiver
+var (
+	portFlag = &cli.Int64Flag{
+		Name:  "port",
+		Usage: "port for receiver",
+		Value: 1028,
+	}
+	prettyFlag = &cli.BoolFlag{
+		Name:    "pretty",
+		Aliases: []string{"P"},
+		Usage:   "pretty format",
+	}
+	headerFlag = &cli.BoolFlag{
+		Name:  "header",
+		Usage: "print received headers",
+	}
+	subscriptionIDFlag = &cli.StringFlag{
+		Name:  "subscriptionId",
+		Usage: "subscription id",
+	}
+	subscribeFlag = &cli.BoolFlag{
+		Name:  "subscribe",
+		Usage: "create a temporary subscription",
+	}
+)
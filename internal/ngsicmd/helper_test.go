@@ -35,10 +35,12 @@ import (
 	"flag"
 	"io"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"os"
 	"reflect"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -55,7 +57,10 @@ func setupTest() (*ngsilib.NGSI, *flag.FlagSet, *cli.App, *bytes.Buffer) {
 	ngsi.ConfigFile.SetFileName(&filename)
 	ngsi.CacheFile = &MockIoLib{}
 	ngsi.CacheFile.SetFileName(&filename)
+	ngsi.CacheTmpFile = &MockIoLib{}
 	ngsi.HTTP = NewMockHTTP()
+	ngsi.HTTPServer = &MockHTTPServer{}
+	ngsi.TermLib = &MockTermLib{}
 	buffer := &bytes.Buffer{}
 	ngsi.StdWriter = buffer
 	ngsi.LogWriter = &bytes.Buffer{}
@@ -109,6 +114,14 @@ func setupAddBroker2(t *testing.T, ngsi *ngsilib.NGSI, host, brokerHost, ngsiTyp
 	ngsi.Host = host
 }
 
+func setupAddServer(t *testing.T, ngsi *ngsilib.NGSI, host, brokerHost, serverType string) {
+	broker := ngsilib.Broker{BrokerHost: brokerHost, ServerType: serverType}
+
+	list := ngsi.BrokerList()
+	(*list)[host] = &broker
+	ngsi.Host = host
+}
+
 func setupDeleteBroker(t *testing.T, host string) {
 	_, set, app, _ := setupTest()
 
@@ -126,6 +139,7 @@ func NewMockHTTP() *MockHTTP {
 
 // MockHTTPReqRes is ...
 type MockHTTP struct {
+	mutex  sync.Mutex
 	index  int
 	ReqRes []MockHTTPReqRes
 }
@@ -142,7 +156,7 @@ type MockHTTPReqRes struct {
 
 // MockHTTPRequest is ...
 type MockHTTPRequest interface {
-	Request(method string, url *url.URL, headers map[string]string, body interface{}) (*http.Response, []byte, error)
+	Request(method string, url *url.URL, headers map[string]string, body interface{}, idempotent bool) (*http.Response, []byte, error)
 }
 
 func AddReqRes(ngsi *ngsilib.NGSI, r MockHTTPReqRes) {
@@ -151,9 +165,12 @@ func AddReqRes(ngsi *ngsilib.NGSI, r MockHTTPReqRes) {
 }
 
 // Request is ...
-func (h *MockHTTP) Request(method string, url *url.URL, headers map[string]string, body interface{}) (*http.Response, []byte, error) {
+func (h *MockHTTP) Request(method string, url *url.URL, headers map[string]string, body interface{}, idempotent bool) (*http.Response, []byte, error) {
 	const funcName = "Request"
 
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
 	if len(h.ReqRes) == 0 {
 		panic(errors.New("ReqRes length is 0"))
 	}
@@ -189,6 +206,39 @@ func (h *MockHTTP) Request(method string, url *url.URL, headers map[string]strin
 	return &r.Res, r.ResBody, r.Err
 }
 
+//
+// MockHTTPServer
+//
+
+type MockHTTPServer struct {
+	Requests    []*http.Request
+	StatusCodes []int
+	ListenErr   error
+	ServeErr    error
+	ShutdownErr error
+	Calls       []string
+}
+
+func (s *MockHTTPServer) Listen(addr string) error {
+	s.Calls = append(s.Calls, "listen")
+	return s.ListenErr
+}
+
+func (s *MockHTTPServer) Serve(handler http.Handler) error {
+	s.Calls = append(s.Calls, "serve")
+	for _, req := range s.Requests {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		s.StatusCodes = append(s.StatusCodes, rec.Code)
+	}
+	return s.ServeErr
+}
+
+func (s *MockHTTPServer) Shutdown() error {
+	s.Calls = append(s.Calls, "shutdown")
+	return s.ShutdownErr
+}
+
 //
 // MockIoLib
 //
@@ -265,6 +315,18 @@ func (io *MockIoLib) FilePathJoin(elem ...string) string {
 	return strings.Join(elem, "/")
 }
 
+func (io *MockIoLib) Lock() error {
+	return nil
+}
+
+func (io *MockIoLib) Unlock() error {
+	return nil
+}
+
+func (io *MockIoLib) Rename(oldpath, newpath string) error {
+	return nil
+}
+
 //
 // MockFileLib
 //
@@ -358,9 +420,46 @@ func (s *MockSyslogLib) New() (io.Writer, error) {
 	return nil, s.Err
 }
 
+//
+// MockWriter
+//
+type MockWriter struct {
+	Err error
+}
+
+func (w *MockWriter) Write(p []byte) (int, error) {
+	return 0, w.Err
+}
+
+//
+// MockTermLib
+//
+type MockTermLib struct {
+	Terminal bool
+	Password []string
+	Err      error
+}
+
+func (t *MockTermLib) IsTerminal() bool {
+	return t.Terminal
+}
+
+func (t *MockTermLib) ReadPassword(prompt string) (string, error) {
+	if t.Err != nil {
+		return "", t.Err
+	}
+	if len(t.Password) == 0 {
+		return "", nil
+	}
+	s := t.Password[0]
+	t.Password = t.Password[1:]
+	return s, nil
+}
+
 type MockTimeLib struct {
 	dateTime string
 	unixTime int64
+	sleep    []time.Duration
 }
 
 func (t *MockTimeLib) Now() time.Time {
@@ -372,3 +471,7 @@ func (t *MockTimeLib) Now() time.Time {
 func (t *MockTimeLib) NowUnix() int64 {
 	return t.unixTime + time.Now().Unix()
 }
+
+func (t *MockTimeLib) Sleep(d time.Duration) {
+	t.sleep = append(t.sleep, d)
+}
@@ -0,0 +1,434 @@
+/*
+MIT License
+
+Copyright (c) 2020 Kazuhito Suda
+
+This file is part of NGSI Go
+
+https://github.com/lets-fiware/ngsi-go
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+package ngsicmd
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/lets-fiware/ngsi-go/internal/ngsilib"
+	"github.com/urfave/cli/v2"
+)
+
+var (
+	quantumLeapAggrMethods = []string{"count", "sum", "avg", "min", "max"}
+	quantumLeapAggrPeriods = []string{"year", "month", "day", "hour", "minute", "second"}
+	cometAggrMethods       = []string{"max", "min", "sum", "sum2", "occur"}
+	cometAggrPeriods       = []string{"month", "day", "hour", "minute", "second"}
+)
+
+func hgetEntities(c *cli.Context) error {
+	const funcName = "hgetEntities"
+
+	ngsi, err := initCmd(c, funcName, true)
+	if err != nil {
+		return &ngsiCmdError{funcName, 1, err.Error(), err}
+	}
+
+	client, err := newClient(ngsi, c, false)
+	if err != nil {
+		return &ngsiCmdError{funcName, 2, err.Error(), err}
+	}
+
+	if !client.IsQuantumLeap() {
+		return &ngsiCmdError{funcName, 3, "only available on QuantumLeap", nil}
+	}
+
+	client.SetPath("/entities")
+
+	v, err := hgetQuery(c, client)
+	if err != nil {
+		return &ngsiCmdError{funcName, 4, err.Error(), err}
+	}
+	if c.IsSet("type") {
+		v.Set("type", c.String("type"))
+	}
+	client.SetQuery(v)
+
+	res, body, err := client.HTTPGet()
+	if err != nil {
+		return &ngsiCmdError{funcName, 5, err.Error(), err}
+	}
+	if res.StatusCode != http.StatusOK {
+		return &ngsiCmdError{funcName, 6, fmt.Sprintf("%s %s", res.Status, string(body)), nil}
+	}
+
+	if outputEnabled(c) {
+		if err := outputPrint(c, ngsi, body); err != nil {
+			return &ngsiCmdError{funcName, 7, err.Error(), err}
+		}
+		return nil
+	}
+
+	printJSON(ngsi, body)
+
+	return nil
+}
+
+func hgetAttr(c *cli.Context) error {
+	const funcName = "hgetAttr"
+
+	ngsi, err := initCmd(c, funcName, true)
+	if err != nil {
+		return &ngsiCmdError{funcName, 1, err.Error(), err}
+	}
+
+	client, err := newClient(ngsi, c, false)
+	if err != nil {
+		return &ngsiCmdError{funcName, 2, err.Error(), err}
+	}
+
+	id := c.String("id")
+	attrName := c.String("attrName")
+
+	if client.IsQuantumLeap() {
+		client.SetPath("/entities/" + id + "/attrs/" + attrName)
+	} else if client.IsComet() {
+		if !c.IsSet("type") {
+			return &ngsiCmdError{funcName, 3, "type is required", nil}
+		}
+		client.SetPath("/contextEntities/type/" + c.String("type") + "/id/" + id + "/attributes/" + attrName)
+	} else {
+		return &ngsiCmdError{funcName, 4, "only available on QuantumLeap or STH-Comet", nil}
+	}
+
+	v, err := hgetQuery(c, client)
+	if err != nil {
+		return &ngsiCmdError{funcName, 5, err.Error(), err}
+	}
+	if client.IsQuantumLeap() && c.IsSet("type") {
+		v.Set("type", c.String("type"))
+	}
+	client.SetQuery(v)
+
+	res, body, err := client.HTTPGet()
+	if err != nil {
+		return &ngsiCmdError{funcName, 6, err.Error(), err}
+	}
+	if res.StatusCode != http.StatusOK {
+		return &ngsiCmdError{funcName, 7, fmt.Sprintf("%s %s", res.Status, string(body)), nil}
+	}
+
+	printJSON(ngsi, body)
+
+	return nil
+}
+
+func hgetAttrs(c *cli.Context) error {
+	const funcName = "hgetAttrs"
+
+	ngsi, err := initCmd(c, funcName, true)
+	if err != nil {
+		return &ngsiCmdError{funcName, 1, err.Error(), err}
+	}
+
+	client, err := newClient(ngsi, c, false)
+	if err != nil {
+		return &ngsiCmdError{funcName, 2, err.Error(), err}
+	}
+
+	if !client.IsQuantumLeap() {
+		return &ngsiCmdError{funcName, 3, "only available on QuantumLeap", nil}
+	}
+
+	client.SetPath("/entities/" + c.String("id"))
+
+	v, err := hgetQuery(c, client)
+	if err != nil {
+		return &ngsiCmdError{funcName, 4, err.Error(), err}
+	}
+	if c.IsSet("type") {
+		v.Set("type", c.String("type"))
+	}
+	if c.IsSet("attrs") {
+		v.Set("attrs", c.String("attrs"))
+	}
+	client.SetQuery(v)
+
+	res, body, err := client.HTTPGet()
+	if err != nil {
+		return &ngsiCmdError{funcName, 5, err.Error(), err}
+	}
+	if res.StatusCode != http.StatusOK {
+		return &ngsiCmdError{funcName, 6, fmt.Sprintf("%s %s", res.Status, string(body)), nil}
+	}
+
+	printJSON(ngsi, body)
+
+	return nil
+}
+
+func hgetTypes(c *cli.Context) error {
+	const funcName = "hgetTypes"
+
+	ngsi, err := initCmd(c, funcName, true)
+	if err != nil {
+		return &ngsiCmdError{funcName, 1, err.Error(), err}
+	}
+
+	client, err := newClient(ngsi, c, false)
+	if err != nil {
+		return &ngsiCmdError{funcName, 2, err.Error(), err}
+	}
+
+	if !client.IsQuantumLeap() {
+		return &ngsiCmdError{funcName, 3, "only available on QuantumLeap", nil}
+	}
+
+	path := "/types/" + c.String("type")
+	if c.IsSet("attrName") {
+		path += "/attrs/" + c.String("attrName")
+	}
+	client.SetPath(path)
+
+	v, err := hgetQuery(c, client)
+	if err != nil {
+		return &ngsiCmdError{funcName, 4, err.Error(), err}
+	}
+	if c.IsSet("attrs") {
+		v.Set("attrs", c.String("attrs"))
+	}
+	client.SetQuery(v)
+
+	res, body, err := client.HTTPGet()
+	if err != nil {
+		return &ngsiCmdError{funcName, 5, err.Error(), err}
+	}
+	if res.StatusCode != http.StatusOK {
+		return &ngsiCmdError{funcName, 6, fmt.Sprintf("%s %s", res.Status, string(body)), nil}
+	}
+
+	printJSON(ngsi, body)
+
+	return nil
+}
+
+func hdeleteEntity(c *cli.Context) error {
+	const funcName = "hdeleteEntity"
+
+	ngsi, err := initCmd(c, funcName, true)
+	if err != nil {
+		return &ngsiCmdError{funcName, 1, err.Error(), err}
+	}
+
+	client, err := newClient(ngsi, c, false)
+	if err != nil {
+		return &ngsiCmdError{funcName, 2, err.Error(), err}
+	}
+
+	id := c.String("id")
+	v := url.Values{}
+
+	if client.IsQuantumLeap() {
+		client.SetPath("/entities/" + id)
+		if c.IsSet("type") {
+			v.Set("type", c.String("type"))
+		}
+		if c.IsSet("fromDate") {
+			v.Set("fromDate", c.String("fromDate"))
+		}
+		if c.IsSet("toDate") {
+			v.Set("toDate", c.String("toDate"))
+		}
+	} else if client.IsComet() {
+		if !c.IsSet("type") {
+			return &ngsiCmdError{funcName, 3, "type is required", nil}
+		}
+		client.SetPath("/contextEntities/type/" + c.String("type") + "/id/" + id)
+	} else {
+		return &ngsiCmdError{funcName, 4, "only available on QuantumLeap or STH-Comet", nil}
+	}
+	client.SetQuery(&v)
+
+	if !c.Bool("run") {
+		return &ngsiCmdError{funcName, 5, "run hdelete with --run option", nil}
+	}
+
+	res, body, err := client.HTTPDelete()
+	if err != nil {
+		return &ngsiCmdError{funcName, 6, err.Error(), err}
+	}
+	if res.StatusCode != http.StatusNoContent {
+		return &ngsiCmdError{funcName, 7, fmt.Sprintf("%s %s", res.Status, string(body)), nil}
+	}
+
+	return nil
+}
+
+func hdeleteEntities(c *cli.Context) error {
+	const funcName = "hdeleteEntities"
+
+	ngsi, err := initCmd(c, funcName, true)
+	if err != nil {
+		return &ngsiCmdError{funcName, 1, err.Error(), err}
+	}
+
+	client, err := newClient(ngsi, c, false)
+	if err != nil {
+		return &ngsiCmdError{funcName, 2, err.Error(), err}
+	}
+
+	v := url.Values{}
+
+	if client.IsQuantumLeap() {
+		if !c.IsSet("type") {
+			return &ngsiCmdError{funcName, 3, "type is required", nil}
+		}
+		client.SetPath("/types/" + c.String("type"))
+		if c.IsSet("fromDate") {
+			v.Set("fromDate", c.String("fromDate"))
+		}
+		if c.IsSet("toDate") {
+			v.Set("toDate", c.String("toDate"))
+		}
+	} else if client.IsComet() {
+		client.SetPath("/contextEntities")
+	} else {
+		return &ngsiCmdError{funcName, 4, "only available on QuantumLeap or STH-Comet", nil}
+	}
+	client.SetQuery(&v)
+
+	if !c.Bool("run") {
+		return &ngsiCmdError{funcName, 5, "run hdelete with --run option", nil}
+	}
+
+	res, body, err := client.HTTPDelete()
+	if err != nil {
+		return &ngsiCmdError{funcName, 6, err.Error(), err}
+	}
+	if res.StatusCode != http.StatusNoContent {
+		return &ngsiCmdError{funcName, 7, fmt.Sprintf("%s %s", res.Status, string(body)), nil}
+	}
+
+	return nil
+}
+
+func hdeleteAttr(c *cli.Context) error {
+	const funcName = "hdeleteAttr"
+
+	ngsi, err := initCmd(c, funcName, true)
+	if err != nil {
+		return &ngsiCmdError{funcName, 1, err.Error(), err}
+	}
+
+	client, err := newClient(ngsi, c, false)
+	if err != nil {
+		return &ngsiCmdError{funcName, 2, err.Error(), err}
+	}
+
+	if !client.IsComet() {
+		return &ngsiCmdError{funcName, 3, "only available on STH-Comet", nil}
+	}
+
+	if !c.IsSet("type") {
+		return &ngsiCmdError{funcName, 4, "type is required", nil}
+	}
+	client.SetPath("/contextEntities/type/" + c.String("type") + "/id/" + c.String("id") + "/attributes/" + c.String("attrName"))
+
+	if !c.Bool("run") {
+		return &ngsiCmdError{funcName, 5, "run hdelete with --run option", nil}
+	}
+
+	res, body, err := client.HTTPDelete()
+	if err != nil {
+		return &ngsiCmdError{funcName, 6, err.Error(), err}
+	}
+	if res.StatusCode != http.StatusNoContent {
+		return &ngsiCmdError{funcName, 7, fmt.Sprintf("%s %s", res.Status, string(body)), nil}
+	}
+
+	return nil
+}
+
+func hgetQuery(c *cli.Context, client *ngsilib.Client) (*url.Values, error) {
+	const funcName = "hgetQuery"
+
+	v := url.Values{}
+
+	aggrMethods := quantumLeapAggrMethods
+	aggrPeriods := quantumLeapAggrPeriods
+	fromDate, toDate := "fromDate", "toDate"
+	limit, offset := "limit", "offset"
+	if client.IsComet() {
+		aggrMethods = cometAggrMethods
+		aggrPeriods = cometAggrPeriods
+		fromDate, toDate = "dateFrom", "dateTo"
+		limit, offset = "hLimit", "hOffset"
+	}
+
+	if c.IsSet("aggrMethod") {
+		aggrMethod := strings.ToLower(c.String("aggrMethod"))
+		if !ngsilib.Contains(aggrMethods, aggrMethod) {
+			return nil, &ngsiCmdError{funcName, 1, fmt.Sprintf("error: %s (%s)", aggrMethod, strings.Join(aggrMethods, ", ")), nil}
+		}
+		v.Set("aggrMethod", aggrMethod)
+	}
+	if c.IsSet("aggrPeriod") {
+		aggrPeriod := strings.ToLower(c.String("aggrPeriod"))
+		if !ngsilib.Contains(aggrPeriods, aggrPeriod) {
+			return nil, &ngsiCmdError{funcName, 2, fmt.Sprintf("error: %s (%s)", aggrPeriod, strings.Join(aggrPeriods, ", ")), nil}
+		}
+		v.Set("aggrPeriod", aggrPeriod)
+	}
+	if client.IsComet() && c.IsSet("aggrMethod") != c.IsSet("aggrPeriod") {
+		return nil, &ngsiCmdError{funcName, 3, "aggrMethod and aggrPeriod are required", nil}
+	}
+
+	if c.IsSet("fromDate") {
+		v.Set(fromDate, c.String("fromDate"))
+	}
+	if c.IsSet("toDate") {
+		v.Set(toDate, c.String("toDate"))
+	}
+	if c.IsSet("lastN") {
+		lastN := c.Int("lastN")
+		if lastN < 1 {
+			return nil, &ngsiCmdError{funcName, 4, fmt.Sprintf("lastN error: %d", lastN), nil}
+		}
+		v.Set("lastN", fmt.Sprintf("%d", lastN))
+	}
+
+	hLimit := c.Int64("hLimit")
+	if client.IsComet() && hLimit == 0 && !c.IsSet("lastN") && !c.IsSet("aggrMethod") {
+		hLimit = 100
+	}
+	if hLimit > 0 {
+		v.Set(limit, fmt.Sprintf("%d", hLimit))
+	}
+	if c.IsSet("hOffset") {
+		v.Set(offset, fmt.Sprintf("%d", c.Int64("hOffset")))
+	} else if client.IsComet() && hLimit > 0 {
+		v.Set(offset, "0")
+	}
+
+	return &v, nil
+}
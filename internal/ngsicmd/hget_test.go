@@ -0,0 +1,1415 @@
+/*
+MIT License
+
+Copyright (c) 2020 Kazuhito Suda
+
+This file is part of NGSI Go
+
+https://github.com/lets-fiware/ngsi-go
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+package ngsicmd
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/cli/v2"
+)
+
+func TestHgetEntities(t *testing.T) {
+	ngsi, set, app, buf := setupTest()
+
+	setupAddServer(t, ngsi, "ql", "https://quantumleap", "quantumleap")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusOK
+	reqRes.Path = "/v2/entities"
+	reqRes.ResBody = []byte(`[{"id":"urn:ngsi-ld:Sensor:001","type":"Sensor","index":["2020-11-01T00:00:00.000+00:00"]}]`)
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	setupFlagString(set, "host,type,fromDate,toDate")
+	set.Int64("hLimit", 0, "doc")
+	set.Int64("hOffset", 0, "doc")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=ql", "--type=Sensor", "--fromDate=2020-11-01T00:00:00Z", "--toDate=2020-11-02T00:00:00Z", "--hLimit=10", "--hOffset=5"})
+	err := hgetEntities(c)
+
+	if assert.NoError(t, err) {
+		actual := buf.String()
+		expected := "[{\"id\":\"urn:ngsi-ld:Sensor:001\",\"type\":\"Sensor\",\"index\":[\"2020-11-01T00:00:00.000+00:00\"]}]\n"
+		assert.Equal(t, expected, actual)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestHgetEntitiesOutput(t *testing.T) {
+	ngsi, set, app, buf := setupTest()
+
+	setupAddServer(t, ngsi, "ql", "https://quantumleap", "quantumleap")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusOK
+	reqRes.Path = "/v2/entities"
+	reqRes.ResBody = []byte(`[{"id":"urn:ngsi-ld:Sensor:001","type":"Sensor","index":["2020-11-01T00:00:00.000+00:00"]}]`)
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	setupFlagString(set, "host,output,select")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=ql", "--output=csv", "--select=id"})
+	err := hgetEntities(c)
+
+	if assert.NoError(t, err) {
+		actual := buf.String()
+		expected := "id\nurn:ngsi-ld:Sensor:001\n"
+		assert.Equal(t, expected, actual)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestHgetEntitiesErrorInitCmd(t *testing.T) {
+	_, set, app, _ := setupTest()
+
+	c := cli.NewContext(app, set, nil)
+	err := hgetEntities(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 1, ngsiErr.ErrNo)
+		assert.Equal(t, "Required host not found", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestHgetEntitiesErrorNewClient(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddServer(t, ngsi, "ql", "https://quantumleap", "quantumleap")
+
+	setupFlagString(set, "host,link")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=ql", "--link=abc"})
+	err := hgetEntities(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 2, ngsiErr.ErrNo)
+		assert.Equal(t, "abc not found", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestHgetEntitiesErrorNotQuantumLeap(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddServer(t, ngsi, "comet", "https://comet", "comet")
+
+	setupFlagString(set, "host")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=comet"})
+	err := hgetEntities(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 3, ngsiErr.ErrNo)
+		assert.Equal(t, "only available on QuantumLeap", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestHgetEntitiesErrorQuery(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddServer(t, ngsi, "ql", "https://quantumleap", "quantumleap")
+
+	setupFlagString(set, "host")
+	set.Int("lastN", 0, "doc")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=ql", "--lastN=0"})
+	err := hgetEntities(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 4, ngsiErr.ErrNo)
+		assert.Equal(t, "lastN error: 0", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestHgetEntitiesErrorHTTP(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddServer(t, ngsi, "ql", "https://quantumleap", "quantumleap")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Err = errors.New("http error")
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	setupFlagString(set, "host")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=ql"})
+	err := hgetEntities(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 5, ngsiErr.ErrNo)
+		assert.Equal(t, "http error", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestHgetEntitiesErrorHTTPStatus(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddServer(t, ngsi, "ql", "https://quantumleap", "quantumleap")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusBadRequest
+	reqRes.Res.Status = "400 Bad Request"
+	reqRes.Path = "/v2/entities"
+	reqRes.ResBody = []byte(`error`)
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	setupFlagString(set, "host")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=ql"})
+	err := hgetEntities(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 6, ngsiErr.ErrNo)
+		assert.Equal(t, "400 Bad Request error", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestHgetEntitiesErrorOutput(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddServer(t, ngsi, "ql", "https://quantumleap", "quantumleap")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusOK
+	reqRes.Path = "/v2/entities"
+	reqRes.ResBody = []byte(`[]`)
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	setupFlagString(set, "host,output")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=ql", "--output=xml"})
+	err := hgetEntities(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 7, ngsiErr.ErrNo)
+		assert.Equal(t, "output format error: xml (table, json, ndjson, yaml, csv)", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestHgetAttrQuantumLeap(t *testing.T) {
+	ngsi, set, app, buf := setupTest()
+
+	setupAddServer(t, ngsi, "ql", "https://quantumleap", "quantumleap")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusOK
+	reqRes.Path = "/v2/entities/urn:ngsi-ld:Sensor:001/attrs/temperature"
+	reqRes.ResBody = []byte(`{"attrName":"temperature","entityId":"urn:ngsi-ld:Sensor:001","index":["2020-11-01T00:00:00.000+00:00"],"values":[20]}`)
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	setupFlagString(set, "host,id,type,attrName,aggrMethod,aggrPeriod")
+	set.Int("lastN", 0, "doc")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=ql", "--id=urn:ngsi-ld:Sensor:001", "--type=Sensor", "--attrName=temperature", "--aggrMethod=avg", "--aggrPeriod=hour", "--lastN=10"})
+	err := hgetAttr(c)
+
+	if assert.NoError(t, err) {
+		actual := buf.String()
+		expected := "{\"attrName\":\"temperature\",\"entityId\":\"urn:ngsi-ld:Sensor:001\",\"index\":[\"2020-11-01T00:00:00.000+00:00\"],\"values\":[20]}\n"
+		assert.Equal(t, expected, actual)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestHgetAttrComet(t *testing.T) {
+	ngsi, set, app, buf := setupTest()
+
+	setupAddServer(t, ngsi, "comet", "https://comet", "comet")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusOK
+	reqRes.Path = "/STH/v1/contextEntities/type/Sensor/id/sensor001/attributes/temperature"
+	reqRes.ResBody = []byte(`{"contextResponses":[{"contextElement":{"attributes":[{"name":"temperature","values":[{"recvTime":"2020-11-01T00:00:00.000Z","attrType":"Number","attrValue":"20"}]}],"id":"sensor001","isPattern":false,"type":"Sensor"},"statusCode":{"code":"200","reasonPhrase":"OK"}}]}`)
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	setupFlagString(set, "host,id,type,attrName,fromDate,toDate")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=comet", "--id=sensor001", "--type=Sensor", "--attrName=temperature", "--fromDate=2020-11-01T00:00:00Z", "--toDate=2020-11-02T00:00:00Z"})
+	err := hgetAttr(c)
+
+	if assert.NoError(t, err) {
+		actual := buf.String()
+		expected := "{\"contextResponses\":[{\"contextElement\":{\"attributes\":[{\"name\":\"temperature\",\"values\":[{\"recvTime\":\"2020-11-01T00:00:00.000Z\",\"attrType\":\"Number\",\"attrValue\":\"20\"}]}],\"id\":\"sensor001\",\"isPattern\":false,\"type\":\"Sensor\"},\"statusCode\":{\"code\":\"200\",\"reasonPhrase\":\"OK\"}}]}\n"
+		assert.Equal(t, expected, actual)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestHgetAttrCometAggr(t *testing.T) {
+	ngsi, set, app, buf := setupTest()
+
+	setupAddServer(t, ngsi, "comet", "https://comet", "comet")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusOK
+	reqRes.Path = "/STH/v1/contextEntities/type/Sensor/id/sensor001/attributes/temperature"
+	reqRes.ResBody = []byte(`{"contextResponses":[{"contextElement":{"attributes":[{"name":"temperature","values":[{"recvTime":"2020-11-01T00:00:00.000Z","attrType":"Number","attrValue":"20"}]}],"id":"sensor001","isPattern":false,"type":"Sensor"},"statusCode":{"code":"200","reasonPhrase":"OK"}}]}`)
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	setupFlagString(set, "host,id,type,attrName,aggrMethod,aggrPeriod")
+	set.Int64("hOffset", 0, "doc")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=comet", "--id=sensor001", "--type=Sensor", "--attrName=temperature", "--aggrMethod=max", "--aggrPeriod=day", "--hOffset=0"})
+	err := hgetAttr(c)
+
+	if assert.NoError(t, err) {
+		actual := buf.String()
+		expected := "{\"contextResponses\":[{\"contextElement\":{\"attributes\":[{\"name\":\"temperature\",\"values\":[{\"recvTime\":\"2020-11-01T00:00:00.000Z\",\"attrType\":\"Number\",\"attrValue\":\"20\"}]}],\"id\":\"sensor001\",\"isPattern\":false,\"type\":\"Sensor\"},\"statusCode\":{\"code\":\"200\",\"reasonPhrase\":\"OK\"}}]}\n"
+		assert.Equal(t, expected, actual)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestHgetAttrErrorInitCmd(t *testing.T) {
+	_, set, app, _ := setupTest()
+
+	c := cli.NewContext(app, set, nil)
+	err := hgetAttr(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 1, ngsiErr.ErrNo)
+		assert.Equal(t, "Required host not found", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestHgetAttrErrorNewClient(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddServer(t, ngsi, "ql", "https://quantumleap", "quantumleap")
+
+	setupFlagString(set, "host,link")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=ql", "--link=abc"})
+	err := hgetAttr(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 2, ngsiErr.ErrNo)
+		assert.Equal(t, "abc not found", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestHgetAttrErrorCometType(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddServer(t, ngsi, "comet", "https://comet", "comet")
+
+	setupFlagString(set, "host,id,attrName")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=comet", "--id=sensor001", "--attrName=temperature"})
+	err := hgetAttr(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 3, ngsiErr.ErrNo)
+		assert.Equal(t, "type is required", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestHgetAttrErrorBroker(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+
+	setupFlagString(set, "host,id,attrName")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", "--id=sensor001", "--attrName=temperature"})
+	err := hgetAttr(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 4, ngsiErr.ErrNo)
+		assert.Equal(t, "only available on QuantumLeap or STH-Comet", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestHgetAttrErrorQuery(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddServer(t, ngsi, "ql", "https://quantumleap", "quantumleap")
+
+	setupFlagString(set, "host,id,attrName,aggrMethod")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=ql", "--id=sensor001", "--attrName=temperature", "--aggrMethod=sum2"})
+	err := hgetAttr(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 5, ngsiErr.ErrNo)
+		assert.Equal(t, "error: sum2 (count, sum, avg, min, max)", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestHgetAttrErrorHTTP(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddServer(t, ngsi, "ql", "https://quantumleap", "quantumleap")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Err = errors.New("http error")
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	setupFlagString(set, "host,id,attrName")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=ql", "--id=sensor001", "--attrName=temperature"})
+	err := hgetAttr(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 6, ngsiErr.ErrNo)
+		assert.Equal(t, "http error", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestHgetAttrErrorHTTPStatus(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddServer(t, ngsi, "ql", "https://quantumleap", "quantumleap")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusNotFound
+	reqRes.Res.Status = "404 Not Found"
+	reqRes.Path = "/v2/entities/sensor001/attrs/temperature"
+	reqRes.ResBody = []byte(`error`)
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	setupFlagString(set, "host,id,attrName")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=ql", "--id=sensor001", "--attrName=temperature"})
+	err := hgetAttr(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 7, ngsiErr.ErrNo)
+		assert.Equal(t, "404 Not Found error", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestHgetAttrs(t *testing.T) {
+	ngsi, set, app, buf := setupTest()
+
+	setupAddServer(t, ngsi, "ql", "https://quantumleap", "quantumleap")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusOK
+	reqRes.Path = "/v2/entities/urn:ngsi-ld:Sensor:001"
+	reqRes.ResBody = []byte(`{"entityId":"urn:ngsi-ld:Sensor:001","index":["2020-11-01T00:00:00.000+00:00"],"attributes":[{"attrName":"temperature","values":[20]}]}`)
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	setupFlagString(set, "host,id,type,attrs")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=ql", "--id=urn:ngsi-ld:Sensor:001", "--type=Sensor", "--attrs=temperature"})
+	err := hgetAttrs(c)
+
+	if assert.NoError(t, err) {
+		actual := buf.String()
+		expected := "{\"entityId\":\"urn:ngsi-ld:Sensor:001\",\"index\":[\"2020-11-01T00:00:00.000+00:00\"],\"attributes\":[{\"attrName\":\"temperature\",\"values\":[20]}]}\n"
+		assert.Equal(t, expected, actual)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestHgetAttrsErrorInitCmd(t *testing.T) {
+	_, set, app, _ := setupTest()
+
+	c := cli.NewContext(app, set, nil)
+	err := hgetAttrs(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 1, ngsiErr.ErrNo)
+		assert.Equal(t, "Required host not found", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestHgetAttrsErrorNewClient(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddServer(t, ngsi, "ql", "https://quantumleap", "quantumleap")
+
+	setupFlagString(set, "host,link")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=ql", "--link=abc"})
+	err := hgetAttrs(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 2, ngsiErr.ErrNo)
+		assert.Equal(t, "abc not found", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestHgetAttrsErrorNotQuantumLeap(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddServer(t, ngsi, "comet", "https://comet", "comet")
+
+	setupFlagString(set, "host,id")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=comet", "--id=sensor001"})
+	err := hgetAttrs(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 3, ngsiErr.ErrNo)
+		assert.Equal(t, "only available on QuantumLeap", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestHgetAttrsErrorQuery(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddServer(t, ngsi, "ql", "https://quantumleap", "quantumleap")
+
+	setupFlagString(set, "host,id,aggrPeriod")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=ql", "--id=sensor001", "--aggrPeriod=week"})
+	err := hgetAttrs(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 4, ngsiErr.ErrNo)
+		assert.Equal(t, "error: week (year, month, day, hour, minute, second)", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestHgetAttrsErrorHTTP(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddServer(t, ngsi, "ql", "https://quantumleap", "quantumleap")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Err = errors.New("http error")
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	setupFlagString(set, "host,id")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=ql", "--id=sensor001"})
+	err := hgetAttrs(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 5, ngsiErr.ErrNo)
+		assert.Equal(t, "http error", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestHgetAttrsErrorHTTPStatus(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddServer(t, ngsi, "ql", "https://quantumleap", "quantumleap")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusNotFound
+	reqRes.Res.Status = "404 Not Found"
+	reqRes.Path = "/v2/entities/sensor001"
+	reqRes.ResBody = []byte(`error`)
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	setupFlagString(set, "host,id")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=ql", "--id=sensor001"})
+	err := hgetAttrs(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 6, ngsiErr.ErrNo)
+		assert.Equal(t, "404 Not Found error", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestHgetTypes(t *testing.T) {
+	ngsi, set, app, buf := setupTest()
+
+	setupAddServer(t, ngsi, "ql", "https://quantumleap", "quantumleap")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusOK
+	reqRes.Path = "/v2/types/Sensor/attrs/temperature"
+	reqRes.ResBody = []byte(`{"entityType":"Sensor","entities":[{"entityId":"urn:ngsi-ld:Sensor:001","index":["2020-11-01T00:00:00.000+00:00"],"values":[20]}]}`)
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	setupFlagString(set, "host,type,attrName")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=ql", "--type=Sensor", "--attrName=temperature"})
+	err := hgetTypes(c)
+
+	if assert.NoError(t, err) {
+		actual := buf.String()
+		expected := "{\"entityType\":\"Sensor\",\"entities\":[{\"entityId\":\"urn:ngsi-ld:Sensor:001\",\"index\":[\"2020-11-01T00:00:00.000+00:00\"],\"values\":[20]}]}\n"
+		assert.Equal(t, expected, actual)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestHgetTypesAttrs(t *testing.T) {
+	ngsi, set, app, buf := setupTest()
+
+	setupAddServer(t, ngsi, "ql", "https://quantumleap", "quantumleap")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusOK
+	reqRes.Path = "/v2/types/Sensor"
+	reqRes.ResBody = []byte(`{"entityType":"Sensor","entities":[{"entityId":"urn:ngsi-ld:Sensor:001","index":["2020-11-01T00:00:00.000+00:00"],"values":[20]}]}`)
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	setupFlagString(set, "host,type,attrs")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=ql", "--type=Sensor", "--attrs=temperature"})
+	err := hgetTypes(c)
+
+	if assert.NoError(t, err) {
+		actual := buf.String()
+		expected := "{\"entityType\":\"Sensor\",\"entities\":[{\"entityId\":\"urn:ngsi-ld:Sensor:001\",\"index\":[\"2020-11-01T00:00:00.000+00:00\"],\"values\":[20]}]}\n"
+		assert.Equal(t, expected, actual)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestHgetTypesErrorInitCmd(t *testing.T) {
+	_, set, app, _ := setupTest()
+
+	c := cli.NewContext(app, set, nil)
+	err := hgetTypes(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 1, ngsiErr.ErrNo)
+		assert.Equal(t, "Required host not found", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestHgetTypesErrorNewClient(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddServer(t, ngsi, "ql", "https://quantumleap", "quantumleap")
+
+	setupFlagString(set, "host,link")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=ql", "--link=abc"})
+	err := hgetTypes(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 2, ngsiErr.ErrNo)
+		assert.Equal(t, "abc not found", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestHgetTypesErrorNotQuantumLeap(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddServer(t, ngsi, "comet", "https://comet", "comet")
+
+	setupFlagString(set, "host,type")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=comet", "--type=Sensor"})
+	err := hgetTypes(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 3, ngsiErr.ErrNo)
+		assert.Equal(t, "only available on QuantumLeap", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestHgetTypesErrorQuery(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddServer(t, ngsi, "ql", "https://quantumleap", "quantumleap")
+
+	setupFlagString(set, "host,type")
+	set.Int("lastN", 0, "doc")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=ql", "--type=Sensor", "--lastN=-1"})
+	err := hgetTypes(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 4, ngsiErr.ErrNo)
+		assert.Equal(t, "lastN error: -1", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestHgetTypesErrorHTTP(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddServer(t, ngsi, "ql", "https://quantumleap", "quantumleap")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Err = errors.New("http error")
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	setupFlagString(set, "host,type")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=ql", "--type=Sensor"})
+	err := hgetTypes(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 5, ngsiErr.ErrNo)
+		assert.Equal(t, "http error", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestHgetTypesErrorHTTPStatus(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddServer(t, ngsi, "ql", "https://quantumleap", "quantumleap")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusNotFound
+	reqRes.Res.Status = "404 Not Found"
+	reqRes.Path = "/v2/types/Sensor"
+	reqRes.ResBody = []byte(`error`)
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	setupFlagString(set, "host,type")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=ql", "--type=Sensor"})
+	err := hgetTypes(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 6, ngsiErr.ErrNo)
+		assert.Equal(t, "404 Not Found error", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestHdeleteEntityQuantumLeap(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddServer(t, ngsi, "ql", "https://quantumleap", "quantumleap")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusNoContent
+	reqRes.Path = "/v2/entities/sensor001"
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	setupFlagString(set, "host,id,type,fromDate,toDate")
+	setupFlagBool(set, "run")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=ql", "--id=sensor001", "--type=Sensor", "--fromDate=2020-11-01T00:00:00Z", "--toDate=2020-11-02T00:00:00Z", "--run"})
+	err := hdeleteEntity(c)
+
+	assert.NoError(t, err)
+}
+
+func TestHdeleteEntityComet(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddServer(t, ngsi, "comet", "https://comet", "comet")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusNoContent
+	reqRes.Path = "/STH/v1/contextEntities/type/Sensor/id/sensor001"
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	setupFlagString(set, "host,id,type")
+	setupFlagBool(set, "run")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=comet", "--id=sensor001", "--type=Sensor", "--run"})
+	err := hdeleteEntity(c)
+
+	assert.NoError(t, err)
+}
+
+func TestHdeleteEntityErrorInitCmd(t *testing.T) {
+	_, set, app, _ := setupTest()
+
+	c := cli.NewContext(app, set, nil)
+	err := hdeleteEntity(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 1, ngsiErr.ErrNo)
+		assert.Equal(t, "Required host not found", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestHdeleteEntityErrorNewClient(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddServer(t, ngsi, "ql", "https://quantumleap", "quantumleap")
+
+	setupFlagString(set, "host,link")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=ql", "--link=abc"})
+	err := hdeleteEntity(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 2, ngsiErr.ErrNo)
+		assert.Equal(t, "abc not found", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestHdeleteEntityErrorCometType(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddServer(t, ngsi, "comet", "https://comet", "comet")
+
+	setupFlagString(set, "host,id")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=comet", "--id=sensor001"})
+	err := hdeleteEntity(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 3, ngsiErr.ErrNo)
+		assert.Equal(t, "type is required", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestHdeleteEntityErrorBroker(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+
+	setupFlagString(set, "host,id")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", "--id=sensor001"})
+	err := hdeleteEntity(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 4, ngsiErr.ErrNo)
+		assert.Equal(t, "only available on QuantumLeap or STH-Comet", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestHdeleteEntityErrorRun(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddServer(t, ngsi, "ql", "https://quantumleap", "quantumleap")
+
+	setupFlagString(set, "host,id")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=ql", "--id=sensor001"})
+	err := hdeleteEntity(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 5, ngsiErr.ErrNo)
+		assert.Equal(t, "run hdelete with --run option", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestHdeleteEntityErrorHTTP(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddServer(t, ngsi, "ql", "https://quantumleap", "quantumleap")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Err = errors.New("http error")
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	setupFlagString(set, "host,id")
+	setupFlagBool(set, "run")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=ql", "--id=sensor001", "--run"})
+	err := hdeleteEntity(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 6, ngsiErr.ErrNo)
+		assert.Equal(t, "http error", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestHdeleteEntityErrorHTTPStatus(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddServer(t, ngsi, "ql", "https://quantumleap", "quantumleap")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusNotFound
+	reqRes.Res.Status = "404 Not Found"
+	reqRes.Path = "/v2/entities/sensor001"
+	reqRes.ResBody = []byte(`error`)
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	setupFlagString(set, "host,id")
+	setupFlagBool(set, "run")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=ql", "--id=sensor001", "--run"})
+	err := hdeleteEntity(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 7, ngsiErr.ErrNo)
+		assert.Equal(t, "404 Not Found error", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestHdeleteEntitiesQuantumLeap(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddServer(t, ngsi, "ql", "https://quantumleap", "quantumleap")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusNoContent
+	reqRes.Path = "/v2/types/Sensor"
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	setupFlagString(set, "host,type,fromDate,toDate")
+	setupFlagBool(set, "run")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=ql", "--type=Sensor", "--fromDate=2020-11-01T00:00:00Z", "--toDate=2020-11-02T00:00:00Z", "--run"})
+	err := hdeleteEntities(c)
+
+	assert.NoError(t, err)
+}
+
+func TestHdeleteEntitiesComet(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddServer(t, ngsi, "comet", "https://comet", "comet")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusNoContent
+	reqRes.Path = "/STH/v1/contextEntities"
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	setupFlagString(set, "host")
+	setupFlagBool(set, "run")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=comet", "--run"})
+	err := hdeleteEntities(c)
+
+	assert.NoError(t, err)
+}
+
+func TestHdeleteEntitiesErrorInitCmd(t *testing.T) {
+	_, set, app, _ := setupTest()
+
+	c := cli.NewContext(app, set, nil)
+	err := hdeleteEntities(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 1, ngsiErr.ErrNo)
+		assert.Equal(t, "Required host not found", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestHdeleteEntitiesErrorNewClient(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddServer(t, ngsi, "ql", "https://quantumleap", "quantumleap")
+
+	setupFlagString(set, "host,link")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=ql", "--link=abc"})
+	err := hdeleteEntities(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 2, ngsiErr.ErrNo)
+		assert.Equal(t, "abc not found", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestHdeleteEntitiesErrorType(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddServer(t, ngsi, "ql", "https://quantumleap", "quantumleap")
+
+	setupFlagString(set, "host")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=ql"})
+	err := hdeleteEntities(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 3, ngsiErr.ErrNo)
+		assert.Equal(t, "type is required", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestHdeleteEntitiesErrorBroker(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+
+	setupFlagString(set, "host")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion"})
+	err := hdeleteEntities(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 4, ngsiErr.ErrNo)
+		assert.Equal(t, "only available on QuantumLeap or STH-Comet", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestHdeleteEntitiesErrorRun(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddServer(t, ngsi, "comet", "https://comet", "comet")
+
+	setupFlagString(set, "host")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=comet"})
+	err := hdeleteEntities(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 5, ngsiErr.ErrNo)
+		assert.Equal(t, "run hdelete with --run option", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestHdeleteEntitiesErrorHTTP(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddServer(t, ngsi, "comet", "https://comet", "comet")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Err = errors.New("http error")
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	setupFlagString(set, "host")
+	setupFlagBool(set, "run")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=comet", "--run"})
+	err := hdeleteEntities(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 6, ngsiErr.ErrNo)
+		assert.Equal(t, "http error", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestHdeleteEntitiesErrorHTTPStatus(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddServer(t, ngsi, "comet", "https://comet", "comet")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusBadRequest
+	reqRes.Res.Status = "400 Bad Request"
+	reqRes.Path = "/STH/v1/contextEntities"
+	reqRes.ResBody = []byte(`error`)
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	setupFlagString(set, "host")
+	setupFlagBool(set, "run")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=comet", "--run"})
+	err := hdeleteEntities(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 7, ngsiErr.ErrNo)
+		assert.Equal(t, "400 Bad Request error", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestHdeleteAttr(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddServer(t, ngsi, "comet", "https://comet", "comet")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusNoContent
+	reqRes.Path = "/STH/v1/contextEntities/type/Sensor/id/sensor001/attributes/temperature"
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	setupFlagString(set, "host,id,type,attrName")
+	setupFlagBool(set, "run")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=comet", "--id=sensor001", "--type=Sensor", "--attrName=temperature", "--run"})
+	err := hdeleteAttr(c)
+
+	assert.NoError(t, err)
+}
+
+func TestHdeleteAttrErrorInitCmd(t *testing.T) {
+	_, set, app, _ := setupTest()
+
+	c := cli.NewContext(app, set, nil)
+	err := hdeleteAttr(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 1, ngsiErr.ErrNo)
+		assert.Equal(t, "Required host not found", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestHdeleteAttrErrorNewClient(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddServer(t, ngsi, "comet", "https://comet", "comet")
+
+	setupFlagString(set, "host,link")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=comet", "--link=abc"})
+	err := hdeleteAttr(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 2, ngsiErr.ErrNo)
+		assert.Equal(t, "abc not found", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestHdeleteAttrErrorNotComet(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddServer(t, ngsi, "ql", "https://quantumleap", "quantumleap")
+
+	setupFlagString(set, "host,id,attrName")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=ql", "--id=sensor001", "--attrName=temperature"})
+	err := hdeleteAttr(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 3, ngsiErr.ErrNo)
+		assert.Equal(t, "only available on STH-Comet", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestHdeleteAttrErrorType(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddServer(t, ngsi, "comet", "https://comet", "comet")
+
+	setupFlagString(set, "host,id,attrName")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=comet", "--id=sensor001", "--attrName=temperature"})
+	err := hdeleteAttr(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 4, ngsiErr.ErrNo)
+		assert.Equal(t, "type is required", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestHdeleteAttrErrorRun(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddServer(t, ngsi, "comet", "https://comet", "comet")
+
+	setupFlagString(set, "host,id,type,attrName")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=comet", "--id=sensor001", "--type=Sensor", "--attrName=temperature"})
+	err := hdeleteAttr(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 5, ngsiErr.ErrNo)
+		assert.Equal(t, "run hdelete with --run option", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestHdeleteAttrErrorHTTP(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddServer(t, ngsi, "comet", "https://comet", "comet")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Err = errors.New("http error")
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	setupFlagString(set, "host,id,type,attrName")
+	setupFlagBool(set, "run")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=comet", "--id=sensor001", "--type=Sensor", "--attrName=temperature", "--run"})
+	err := hdeleteAttr(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 6, ngsiErr.ErrNo)
+		assert.Equal(t, "http error", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestHdeleteAttrErrorHTTPStatus(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddServer(t, ngsi, "comet", "https://comet", "comet")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusNotFound
+	reqRes.Res.Status = "404 Not Found"
+	reqRes.Path = "/STH/v1/contextEntities/type/Sensor/id/sensor001/attributes/temperature"
+	reqRes.ResBody = []byte(`error`)
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	setupFlagString(set, "host,id,type,attrName")
+	setupFlagBool(set, "run")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=comet", "--id=sensor001", "--type=Sensor", "--attrName=temperature", "--run"})
+	err := hdeleteAttr(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 7, ngsiErr.ErrNo)
+		assert.Equal(t, "404 Not Found error", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestHgetAttrErrorCometAggrMethod(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddServer(t, ngsi, "comet", "https://comet", "comet")
+
+	setupFlagString(set, "host,id,type,attrName,aggrMethod")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=comet", "--id=sensor001", "--type=Sensor", "--attrName=temperature", "--aggrMethod=avg"})
+	err := hgetAttr(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 5, ngsiErr.ErrNo)
+		assert.Equal(t, "error: avg (max, min, sum, sum2, occur)", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestHgetAttrErrorCometAggrPeriod(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddServer(t, ngsi, "comet", "https://comet", "comet")
+
+	setupFlagString(set, "host,id,type,attrName,aggrMethod")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=comet", "--id=sensor001", "--type=Sensor", "--attrName=temperature", "--aggrMethod=max"})
+	err := hgetAttr(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 5, ngsiErr.ErrNo)
+		assert.Equal(t, "aggrMethod and aggrPeriod are required", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
@@ -0,0 +1,298 @@
+/*
+MIT License
+
+Copyright (c) 2020 Kazuhito Suda
+
+This file is part of NGSI Go
+
+https://github.com/lets-fiware/ngsi-go
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+package ngsicmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/lets-fiware/ngsi-go/internal/ngsilib"
+	"github.com/urfave/cli/v2"
+)
+
+// snapshotReadOnly lists fields which a broker returns but does not accept on creation.
+var snapshotReadOnly = []string{"timesSent", "lastNotification", "lastFailure", "lastSuccess", "lastFailureReason", "lastSuccessCode", "failsCounter", "forwardingInformation"}
+
+func importSnapshot(c *cli.Context) error {
+	const funcName = "importSnapshot"
+
+	ngsi, err := initCmd(c, funcName, true)
+	if err != nil {
+		return &ngsiCmdError{funcName, 1, err.Error(), err}
+	}
+
+	client, err := newClient(ngsi, c, false)
+	if err != nil {
+		return &ngsiCmdError{funcName, 2, err.Error(), err}
+	}
+
+	dir := c.String("dir")
+
+	b, err := ioutil.ReadFile(filepath.Join(dir, snapshotManifest))
+	if err != nil {
+		return &ngsiCmdError{funcName, 3, err.Error(), err}
+	}
+
+	info := &snapshotInfo{}
+	if err := ngsilib.JSONUnmarshal(b, info); err != nil {
+		return &ngsiCmdError{funcName, 4, err.Error(), err}
+	}
+
+	if (info.NgsiType == "ld") != client.IsNgsiLd() {
+		return &ngsiCmdError{funcName, 5, fmt.Sprintf("NGSI type mismatch: snapshot is %s", info.NgsiType), nil}
+	}
+
+	size := batchSize(c, ngsi, client)
+	context := importContext(client, info.Contexts)
+
+	// subscriptions are created last so that importing entities does not trigger notifications
+	entities, failedIDs, err := importEntities(ngsi, client, filepath.Join(dir, snapshotEntities), size, context)
+	if err != nil {
+		return &ngsiCmdError{funcName, 6, err.Error(), err}
+	}
+
+	registrations := &applyResource{name: "registration", path: snapshotRegistrationPath(client)}
+	regs, err := importItems(ngsi, client, registrations, filepath.Join(dir, snapshotRegistrations), context)
+	if err != nil {
+		return &ngsiCmdError{funcName, 7, err.Error(), err}
+	}
+
+	subscriptions := &applyResource{name: "subscription", path: "/subscriptions"}
+	subs, err := importItems(ngsi, client, subscriptions, filepath.Join(dir, snapshotSubscriptions), context)
+	if err != nil {
+		return &ngsiCmdError{funcName, 8, err.Error(), err}
+	}
+
+	fmt.Fprintf(ngsi.StdWriter, "entities: %d, subscriptions: %d, registrations: %d\n", entities, subs, regs)
+
+	if len(failedIDs) > 0 {
+		return &ngsiCmdError{funcName, 9, fmt.Sprintf("%d entities failed: %v", len(failedIDs), failedIDs), nil}
+	}
+
+	return nil
+}
+
+// importContext returns @context of NGSI-LD items which have none. The contexts recorded in
+// the manifest are used unless --link is specified, in which case the Link header is sent instead.
+func importContext(client *ngsilib.Client, contexts []string) interface{} {
+	if !client.IsNgsiLd() || client.Link != nil {
+		return nil
+	}
+	switch len(contexts) {
+	case 0:
+		return ldCoreContext
+	case 1:
+		return contexts[0]
+	}
+	context := make([]interface{}, len(contexts))
+	for i, v := range contexts {
+		context[i] = v
+	}
+	return context
+}
+
+// importSetContext sets context to an NGSI-LD item which has no @context. When --link is
+// specified, @context is removed because it must not be sent with the Link header.
+func importSetContext(client *ngsilib.Client, item map[string]interface{}, context interface{}) {
+	if !client.IsNgsiLd() {
+		return
+	}
+	if client.Link != nil {
+		delete(item, "@context")
+	} else if _, ok := item["@context"]; !ok {
+		item["@context"] = context
+	}
+}
+
+// importOpen opens a file of a snapshot and returns a decoder of the file.
+func importOpen(path string) (*os.File, *json.Decoder, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	return f, json.NewDecoder(f), nil
+}
+
+// importEntities writes entities in an NDJSON file with batch requests and returns the number of entities written.
+// NGSIv2 entities are written with /op/update. NGSI-LD entities, which have no /op/update, are upserted and
+// the ids of entities rejected by the broker are returned.
+func importEntities(ngsi *ngsilib.NGSI, client *ngsilib.Client, path string, size int, context interface{}) (int, []string, error) {
+	const funcName = "importEntities"
+
+	f, dec, err := importOpen(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil, nil
+		}
+		return 0, nil, &ngsiCmdError{funcName, 1, err.Error(), err}
+	}
+	defer func() { _ = f.Close() }()
+
+	failedIDs := []string{}
+	offset := 0
+
+	for {
+		var entities entitiesRespose
+		for len(entities) < size {
+			var e map[string]interface{}
+			err := dec.Decode(&e)
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return 0, nil, &ngsiCmdError{funcName, 2, err.Error(), err}
+			}
+			entities = append(entities, e)
+		}
+		if len(entities) == 0 {
+			break
+		}
+
+		if client.IsNgsiLd() {
+			for _, e := range entities {
+				importSetContext(client, e, context)
+			}
+			ids, err := copyWriteEntities(ngsi, client, entities, offset, size)
+			if err != nil {
+				return 0, nil, &ngsiCmdError{funcName, 3, err.Error(), err}
+			}
+			failedIDs = append(failedIDs, ids...)
+		} else {
+			items := make([]interface{}, len(entities))
+			for i, e := range entities {
+				items[i] = e
+			}
+			if err := opUpdateBatch(ngsi, client, items, "append", false, false, size); err != nil {
+				return 0, nil, &ngsiCmdError{funcName, 4, err.Error(), err}
+			}
+		}
+		offset += len(entities)
+	}
+
+	return offset - len(failedIDs), failedIDs, nil
+}
+
+// importItems creates subscriptions or registrations in an NDJSON file and returns the number of items created.
+// Items which already exist are skipped so that an import can be run again after a failure.
+func importItems(ngsi *ngsilib.NGSI, client *ngsilib.Client, resource *applyResource, path string, context interface{}) (int, error) {
+	const funcName = "importItems"
+
+	f, dec, err := importOpen(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, &ngsiCmdError{funcName, 1, err.Error(), err}
+	}
+	defer func() { _ = f.Close() }()
+
+	existing, err := applyList(client, resource.path)
+	if err != nil {
+		return 0, &ngsiCmdError{funcName, 2, err.Error(), err}
+	}
+
+	n := 0
+	for {
+		var item map[string]interface{}
+		err := dec.Decode(&item)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return n, &ngsiCmdError{funcName, 3, err.Error(), err}
+		}
+
+		importCleanItem(client, item)
+
+		if importExists(client, item, existing) {
+			ngsi.Logging(ngsilib.LogInfo, fmt.Sprintf("%s %v already exists\n", resource.name, item["id"]))
+			continue
+		}
+
+		importSetContext(client, item, context)
+
+		id, err := applyPost(client, resource, item)
+		if err != nil {
+			return n, &ngsiCmdError{funcName, 4, err.Error(), err}
+		}
+		ngsi.Logging(ngsilib.LogInfo, fmt.Sprintf("%s %s is created\n", resource.name, id))
+		n++
+	}
+
+	return n, nil
+}
+
+// importExists reports whether an item exists in a broker. An NGSIv2 broker assigns a new id to
+// an item, so the fields of the item except the id are compared instead.
+func importExists(client *ngsilib.Client, item map[string]interface{}, existing []map[string]interface{}) bool {
+	if client.IsNgsiLd() {
+		for _, e := range existing {
+			if id, ok := item["id"].(string); ok && e["id"] == id {
+				return true
+			}
+		}
+		return false
+	}
+
+	fields := make(map[string]interface{}, len(item))
+	for k, v := range item {
+		if k != "id" {
+			fields[k] = v
+		}
+	}
+	for _, e := range existing {
+		if applyEqual(fields, e) {
+			return true
+		}
+	}
+	return false
+}
+
+// importCleanItem removes fields which are set by a broker.
+func importCleanItem(client *ngsilib.Client, item map[string]interface{}) {
+	for _, k := range snapshotReadOnly {
+		delete(item, k)
+	}
+	if notification, ok := item["notification"].(map[string]interface{}); ok {
+		for _, k := range snapshotReadOnly {
+			delete(notification, k)
+		}
+		delete(notification, "status")
+	}
+	if client.IsNgsiLd() {
+		delete(item, "status")
+	} else if status, ok := item["status"].(string); ok && (status == "expired" || status == "failed") {
+		delete(item, "status")
+	}
+}
@@ -0,0 +1,560 @@
+/*
+MIT License
+
+Copyright (c) 2020 Kazuhito Suda
+
+This file is part of NGSI Go
+
+https://github.com/lets-fiware/ngsi-go
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+package ngsicmd
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lets-fiware/ngsi-go/internal/ngsilib"
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/cli/v2"
+)
+
+func setupSnapshot(t *testing.T, ngsiType, entities, subscriptions, registrations string) string {
+	dir := t.TempDir()
+	files := map[string]string{
+		snapshotManifest:      `{"host":"orion","ngsiType":"` + ngsiType + `"}`,
+		snapshotEntities:      entities,
+		snapshotSubscriptions: subscriptions,
+		snapshotRegistrations: registrations,
+	}
+	for name, data := range files {
+		if data != "" {
+			if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(data), 0600); err != nil {
+				t.FailNow()
+			}
+		}
+	}
+	return dir
+}
+
+func setupImportList(path, body, count string) MockHTTPReqRes {
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusOK
+	reqRes.ResBody = []byte(body)
+	if strings.HasPrefix(path, "/ngsi-ld/") {
+		reqRes.ResHeader = http.Header{"Ngsild-Results-Count": []string{count}}
+	} else {
+		reqRes.ResHeader = http.Header{"Fiware-Total-Count": []string{count}}
+	}
+	reqRes.Path = path
+	return reqRes
+}
+
+func TestImportSnapshot(t *testing.T) {
+	ngsi, set, app, buf := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+
+	dir := setupSnapshot(t, "v2",
+		`{"id":"E1","type":"T"}`+"\n"+`{"id":"E2","type":"T"}`+"\n",
+		`{"id":"5f0a","description":"sub1","status":"expired","notification":{"timesSent":1,"lastNotification":"2021-01-01T00:00:00.000Z","http":{"url":"http://receiver"}}}`+"\n",
+		`{"id":"5f1a","description":"reg1","forwardingInformation":{"timesSent":1}}`+"\n")
+	setupFlagString(set, "host,dir")
+
+	reqRes1 := MockHTTPReqRes{}
+	reqRes1.Res.StatusCode = http.StatusNoContent
+	reqRes1.ReqData = []byte(`{"actionType":"append","entities":[{"id":"E1","type":"T"},{"id":"E2","type":"T"}]}`)
+	reqRes1.Path = "/v2/op/update"
+	reqRes2 := MockHTTPReqRes{}
+	reqRes2.Res.StatusCode = http.StatusCreated
+	reqRes2.ReqData = []byte(`{"description":"reg1"}`)
+	reqRes2.Path = "/v2/registrations"
+	reqRes3 := MockHTTPReqRes{}
+	reqRes3.Res.StatusCode = http.StatusCreated
+	reqRes3.ReqData = []byte(`{"description":"sub1","notification":{"http":{"url":"http://receiver"}}}`)
+	reqRes3.Path = "/v2/subscriptions"
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes1, setupImportList("/v2/registrations", "[]", "0"), reqRes2, setupImportList("/v2/subscriptions", "[]", "0"), reqRes3)
+	ngsi.HTTP = mock
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", "--dir=" + dir})
+
+	err := importSnapshot(c)
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, "entities: 2, subscriptions: 1, registrations: 1\n", buf.String())
+		assert.Equal(t, 5, mock.index)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestImportSnapshotBatchSize(t *testing.T) {
+	ngsi, set, app, buf := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+
+	dir := setupSnapshot(t, "v2", `{"id":"E1","type":"T"}`+"\n"+`{"id":"E2","type":"T"}`+"\n"+`{"id":"E3","type":"T"}`+"\n", "", "")
+	setupFlagString(set, "host,dir,batchSize")
+
+	reqRes1 := MockHTTPReqRes{}
+	reqRes1.Res.StatusCode = http.StatusNoContent
+	reqRes1.ReqData = []byte(`{"actionType":"append","entities":[{"id":"E1","type":"T"},{"id":"E2","type":"T"}]}`)
+	reqRes2 := MockHTTPReqRes{}
+	reqRes2.Res.StatusCode = http.StatusNoContent
+	reqRes2.ReqData = []byte(`{"actionType":"append","entities":[{"id":"E3","type":"T"}]}`)
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes1, reqRes2)
+	ngsi.HTTP = mock
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", "--dir=" + dir, "--batchSize=2"})
+
+	err := importSnapshot(c)
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, "entities: 3, subscriptions: 0, registrations: 0\n", buf.String())
+		assert.Equal(t, 2, mock.index)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestImportSnapshotLd(t *testing.T) {
+	ngsi, set, app, buf := setupTest()
+
+	setupAddBroker(t, ngsi, "orion-ld", "https://orion-ld", "ld")
+
+	dir := setupSnapshot(t, "ld",
+		`{"id":"urn:ngsi-ld:T:1","type":"T","@context":"https://context"}`+"\n",
+		`{"id":"urn:ngsi-ld:Subscription:1","type":"Subscription","status":"active","notification":{"status":"ok","endpoint":{"uri":"http://receiver"}}}`+"\n",
+		"")
+	setupFlagString(set, "host,dir")
+
+	reqRes1 := MockHTTPReqRes{}
+	reqRes1.Res.StatusCode = http.StatusNoContent
+	reqRes1.ReqData = []byte(`[{"@context":"https://context","id":"urn:ngsi-ld:T:1","type":"T"}]`)
+	reqRes1.Path = "/ngsi-ld/v1/entityOperations/upsert"
+	reqRes2 := MockHTTPReqRes{}
+	reqRes2.Res.StatusCode = http.StatusCreated
+	reqRes2.ReqData = []byte(`{"@context":"https://uri.etsi.org/ngsi-ld/v1/ngsi-ld-core-context.jsonld","id":"urn:ngsi-ld:Subscription:1","notification":{"endpoint":{"uri":"http://receiver"}},"type":"Subscription"}`)
+	reqRes2.Path = "/ngsi-ld/v1/subscriptions"
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes1, setupImportList("/ngsi-ld/v1/subscriptions", "[]", "0"), reqRes2)
+	ngsi.HTTP = mock
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion-ld", "--dir=" + dir})
+
+	err := importSnapshot(c)
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, "entities: 1, subscriptions: 1, registrations: 0\n", buf.String())
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestImportSnapshotErrorInitCmd(t *testing.T) {
+	_, set, app, _ := setupTest()
+
+	c := cli.NewContext(app, set, nil)
+
+	err := importSnapshot(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 1, ngsiErr.ErrNo)
+		assert.Equal(t, "Required host not found", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestImportSnapshotErrorNewClient(t *testing.T) {
+	_, set, app, _ := setupTest()
+
+	setupFlagString(set, "host,link")
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", "--link=abc"})
+
+	err := importSnapshot(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 2, ngsiErr.ErrNo)
+		assert.Equal(t, "abc not found", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestImportSnapshotErrorManifest(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+
+	setupFlagString(set, "host,dir")
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", "--dir=" + t.TempDir()})
+
+	err := importSnapshot(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 3, ngsiErr.ErrNo)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestImportSnapshotErrorManifestJSON(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+
+	dir := t.TempDir()
+	_ = ioutil.WriteFile(filepath.Join(dir, snapshotManifest), []byte("{"), 0600)
+	setupFlagString(set, "host,dir")
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", "--dir=" + dir})
+
+	err := importSnapshot(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 4, ngsiErr.ErrNo)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestImportSnapshotErrorNgsiType(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+
+	dir := setupSnapshot(t, "ld", "", "", "")
+	setupFlagString(set, "host,dir")
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", "--dir=" + dir})
+
+	err := importSnapshot(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 5, ngsiErr.ErrNo)
+		assert.Equal(t, "NGSI type mismatch: snapshot is ld", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestImportSnapshotErrorEntities(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+
+	dir := setupSnapshot(t, "v2", `{"id":}`, "", "")
+	setupFlagString(set, "host,dir")
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", "--dir=" + dir})
+
+	err := importSnapshot(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 6, ngsiErr.ErrNo)
+		assert.Equal(t, "invalid character '}' looking for beginning of value", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestImportSnapshotErrorEntitiesHTTP(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+
+	dir := setupSnapshot(t, "v2", `{"id":"E1","type":"T"}`, "", "")
+	setupFlagString(set, "host,dir")
+	reqRes := MockHTTPReqRes{}
+	reqRes.Err = errors.New("http error")
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", "--dir=" + dir})
+
+	err := importSnapshot(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 6, ngsiErr.ErrNo)
+		assert.Equal(t, "http error", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestImportSnapshotErrorRegistrations(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+
+	dir := setupSnapshot(t, "v2", "", "", `{"description":"reg1"}`)
+	setupFlagString(set, "host,dir")
+	reqRes := MockHTTPReqRes{}
+	reqRes.Err = errors.New("http error")
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", "--dir=" + dir})
+
+	err := importSnapshot(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 7, ngsiErr.ErrNo)
+		assert.Equal(t, "http error", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestImportSnapshotErrorSubscriptions(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+
+	dir := setupSnapshot(t, "v2", "", `{"description":"sub1"}`+"\n"+`{"description":`, "")
+	setupFlagString(set, "host,dir")
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusCreated
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, setupImportList("/v2/subscriptions", "[]", "0"), reqRes)
+	ngsi.HTTP = mock
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", "--dir=" + dir})
+
+	err := importSnapshot(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 8, ngsiErr.ErrNo)
+		assert.Equal(t, "unexpected EOF", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestImportSnapshotErrorFailed(t *testing.T) {
+	ngsi, set, app, buf := setupTest()
+
+	setupAddBroker(t, ngsi, "orion-ld", "https://orion-ld", "ld")
+
+	dir := setupSnapshot(t, "ld", `{"id":"E1","type":"T"}`, "", "")
+	setupFlagString(set, "host,dir")
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusBadRequest
+	reqRes.Res.Status = "400 Bad Request"
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion-ld", "--dir=" + dir})
+
+	err := importSnapshot(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 9, ngsiErr.ErrNo)
+		assert.Equal(t, "1 entities failed: [E1]", ngsiErr.Message)
+		assert.Equal(t, "entities: 0, subscriptions: 0, registrations: 0\n", buf.String())
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestImportSnapshotErrorEntitiesStatus(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+
+	dir := setupSnapshot(t, "v2", `{"id":"E1","type":"T"}`, "", "")
+	setupFlagString(set, "host,dir")
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusBadRequest
+	reqRes.Res.Status = "400 Bad Request"
+	reqRes.ResBody = []byte("error")
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", "--dir=" + dir})
+
+	err := importSnapshot(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 6, ngsiErr.ErrNo)
+		assert.Equal(t, "400 Bad Request error", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestImportSnapshotRerun(t *testing.T) {
+	ngsi, set, app, buf := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+
+	dir := setupSnapshot(t, "v2", "",
+		`{"id":"5f0a","description":"sub1","notification":{"timesSent":1,"http":{"url":"http://receiver"}}}`+"\n"+
+			`{"id":"5f0b","description":"sub2","notification":{"http":{"url":"http://receiver"}}}`+"\n",
+		"")
+	setupFlagString(set, "host,dir")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusCreated
+	reqRes.ReqData = []byte(`{"description":"sub2","notification":{"http":{"url":"http://receiver"}}}`)
+	reqRes.Path = "/v2/subscriptions"
+	mock := NewMockHTTP()
+	existing := `[{"id":"6a0a","description":"sub1","status":"active","notification":{"timesSent":3,"http":{"url":"http://receiver"}}}]`
+	mock.ReqRes = append(mock.ReqRes, setupImportList("/v2/subscriptions", existing, "1"), reqRes)
+	ngsi.HTTP = mock
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", "--dir=" + dir})
+
+	err := importSnapshot(c)
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, "entities: 0, subscriptions: 1, registrations: 0\n", buf.String())
+		assert.Equal(t, 2, mock.index)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestImportSnapshotLdLink(t *testing.T) {
+	ngsi, set, app, buf := setupTest()
+
+	setupAddBroker(t, ngsi, "orion-ld", "https://orion-ld", "ld")
+
+	dir := setupSnapshot(t, "ld",
+		`{"id":"urn:ngsi-ld:T:1","type":"T","@context":"https://context"}`+"\n",
+		`{"id":"urn:ngsi-ld:Subscription:1","type":"Subscription"}`+"\n"+`{"id":"urn:ngsi-ld:Subscription:2","type":"Subscription"}`+"\n",
+		"")
+	setupFlagString(set, "host,dir,link")
+
+	reqRes1 := MockHTTPReqRes{}
+	reqRes1.Res.StatusCode = http.StatusNoContent
+	reqRes1.ReqData = []byte(`[{"id":"urn:ngsi-ld:T:1","type":"T"}]`)
+	reqRes1.Path = "/ngsi-ld/v1/entityOperations/upsert"
+	reqRes2 := MockHTTPReqRes{}
+	reqRes2.Res.StatusCode = http.StatusCreated
+	reqRes2.ReqData = []byte(`{"id":"urn:ngsi-ld:Subscription:2","type":"Subscription"}`)
+	reqRes2.Path = "/ngsi-ld/v1/subscriptions"
+	mock := NewMockHTTP()
+	existing := `[{"id":"urn:ngsi-ld:Subscription:1","type":"Subscription"}]`
+	mock.ReqRes = append(mock.ReqRes, reqRes1, setupImportList("/ngsi-ld/v1/subscriptions", existing, "1"), reqRes2)
+	ngsi.HTTP = mock
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion-ld", "--dir=" + dir, "--link=https://context"})
+
+	err := importSnapshot(c)
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, "entities: 1, subscriptions: 1, registrations: 0\n", buf.String())
+		assert.Equal(t, 3, mock.index)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestImportItemsErrorList(t *testing.T) {
+	ngsi, _, _, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+
+	dir := setupSnapshot(t, "v2", "", `{"description":"sub1"}`, "")
+	reqRes := MockHTTPReqRes{}
+	reqRes.Err = errors.New("http error")
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	client, _ := ngsi.NewClient("orion", &ngsilib.CmdFlags{}, false)
+
+	resource := &applyResource{name: "subscription", path: "/subscriptions"}
+	_, err := importItems(ngsi, client, resource, filepath.Join(dir, snapshotSubscriptions), nil)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 2, ngsiErr.ErrNo)
+	}
+}
+
+func TestImportContext(t *testing.T) {
+	ngsi, _, _, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+	setupAddBroker(t, ngsi, "orion-ld", "https://orion-ld", "ld")
+	v2, _ := ngsi.NewClient("orion", &ngsilib.CmdFlags{}, false)
+	ld, _ := ngsi.NewClient("orion-ld", &ngsilib.CmdFlags{}, false)
+
+	assert.Equal(t, nil, importContext(v2, []string{"https://context"}))
+	assert.Equal(t, ldCoreContext, importContext(ld, nil))
+	assert.Equal(t, "https://context", importContext(ld, []string{"https://context"}))
+	assert.Equal(t, []interface{}{"https://a", "https://b"}, importContext(ld, []string{"https://a", "https://b"}))
+}
+
+func TestImportItemsErrorOpen(t *testing.T) {
+	ngsi, _, _, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+	client, _ := ngsi.NewClient("orion", &ngsilib.CmdFlags{}, false)
+
+	resource := &applyResource{name: "subscription", path: "/subscriptions"}
+	_, err := importItems(ngsi, client, resource, string([]byte{0}), nil)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 1, ngsiErr.ErrNo)
+	}
+}
+
+func TestImportEntitiesErrorOpen(t *testing.T) {
+	ngsi, _, _, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+	client, _ := ngsi.NewClient("orion", &ngsilib.CmdFlags{}, false)
+
+	_, _, err := importEntities(ngsi, client, string([]byte{0}), 100, nil)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 1, ngsiErr.ErrNo)
+	}
+}
@@ -95,6 +95,12 @@ func initCmd(c *cli.Context, cmdName string, requiredHost bool) (*ngsilib.NGSI,
 		ngsi.LogWriter = io.MultiWriter(ngsi.LogWriter, &syslogWriter)
 	}
 
+	// receiver has its own --pretty, so only the global flag is taken as the setting
+	if g := globalContext(c); g.IsSet("pretty") {
+		d.Pretty = g.Bool("pretty")
+		ngsi.Updated = true
+	}
+
 	if c.IsSet("margin") {
 		margin := c.Int64("margin")
 		if margin > 600 || margin < 10 {
@@ -111,9 +117,43 @@ func initCmd(c *cli.Context, cmdName string, requiredHost bool) (*ngsilib.NGSI,
 		ngsi.Timeout = time.Duration(timeout) * time.Second
 	}
 
+	if c.IsSet("maxIdleConns") {
+		maxIdleConns := c.Int("maxIdleConns")
+		if maxIdleConns > 1000 || maxIdleConns < 0 {
+			maxIdleConns = 100
+		}
+		ngsi.MaxIdleConns = maxIdleConns
+	}
+
+	if c.IsSet("idleTimeout") {
+		idleTimeout := c.Int("idleTimeout")
+		if idleTimeout > 600 || idleTimeout < 0 {
+			idleTimeout = 90
+		}
+		ngsi.IdleTimeout = time.Duration(idleTimeout) * time.Second
+	}
+
+	if c.IsSet("noKeepAlive") {
+		ngsi.KeepAlive = !c.Bool("noKeepAlive")
+	}
+
+	if c.IsSet("retry") {
+		retry := c.Int("retry")
+		if retry >= 0 && retry <= 10 {
+			ngsi.Retry = retry
+		}
+	}
+
+	if c.IsSet("retryWait") {
+		retryWait := c.Int("retryWait")
+		if retryWait >= 1 && retryWait <= 60 {
+			ngsi.RetryWait = time.Duration(retryWait) * time.Second
+		}
+	}
+
 	if c.IsSet("maxCount") {
 		maxsize := c.Int("maxCount")
-		if maxsize > 3000 || maxsize < 1 {
+		if maxsize > ngsilib.MaxSize || maxsize < 1 {
 			maxsize = 100
 		}
 		ngsi.Maxsize = maxsize
@@ -158,3 +198,14 @@ func initCmd(c *cli.Context, cmdName string, requiredHost bool) (*ngsilib.NGSI,
 
 	return ngsi, nil
 }
+
+// globalContext returns the context of the global options.
+func globalContext(c *cli.Context) *cli.Context {
+	lineage := c.Lineage()
+	for i := len(lineage) - 1; i > 0; i-- {
+		if lineage[i].App != nil {
+			return lineage[i]
+		}
+	}
+	return c
+}
@@ -31,7 +31,9 @@ package ngsicmd
 
 import (
 	"errors"
+	"flag"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/urfave/cli/v2"
@@ -121,6 +123,42 @@ func TestInitCmdStderr(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestInitCmdPretty(t *testing.T) {
+	_, set, app, _ := setupTest()
+
+	setupFlagBool(set, "pretty")
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--pretty"})
+
+	ngsi, err := initCmd(c, "Testing", false)
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, true, ngsi.GetPreviousArgs().Pretty)
+		assert.Equal(t, true, ngsi.Updated)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestInitCmdPrettySubcommand(t *testing.T) {
+	_, set, app, _ := setupTest()
+
+	setupFlagBool(set, "pretty")
+	g := cli.NewContext(app, set, &cli.Context{})
+	sub := flag.NewFlagSet("receiver", flag.ContinueOnError)
+	setupFlagBool(sub, "pretty")
+	c := cli.NewContext(app, sub, g)
+	_ = sub.Parse([]string{"--pretty"})
+
+	ngsi, err := initCmd(c, "Testing", false)
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, false, ngsi.GetPreviousArgs().Pretty)
+	} else {
+		t.FailNow()
+	}
+}
+
 func TestInitCmdErrorStderr(t *testing.T) {
 	ngsi, set, app, _ := setupTest()
 
@@ -252,6 +290,86 @@ func TestInitCmdErrorInitTokenMgr(t *testing.T) {
 		assert.Equal(t, "error ", ngsiErr.Message)
 	}
 }
+func TestInitCmdTransport(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+
+	setupFlagString(set, "timeout,maxIdleConns,idleTimeout")
+	setupFlagBool(set, "noKeepAlive")
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--timeout=30", "--maxIdleConns=10", "--idleTimeout=60", "--noKeepAlive"})
+
+	ngsi, err := initCmd(c, "Testing", false)
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, 30*time.Second, ngsi.Timeout)
+		assert.Equal(t, 10, ngsi.MaxIdleConns)
+		assert.Equal(t, 60*time.Second, ngsi.IdleTimeout)
+		assert.Equal(t, false, ngsi.KeepAlive)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestInitCmdTransport2(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+
+	setupFlagString(set, "maxIdleConns,idleTimeout")
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--maxIdleConns=5000", "--idleTimeout=-1"})
+
+	ngsi, err := initCmd(c, "Testing", false)
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, 100, ngsi.MaxIdleConns)
+		assert.Equal(t, 90*time.Second, ngsi.IdleTimeout)
+		assert.Equal(t, true, ngsi.KeepAlive)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestInitCmdRetry(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+
+	setupFlagString(set, "retry,retryWait")
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--retry=3", "--retryWait=2"})
+
+	ngsi, err := initCmd(c, "Testing", false)
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, 3, ngsi.Retry)
+		assert.Equal(t, 2*time.Second, ngsi.RetryWait)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestInitCmdRetry2(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+
+	setupFlagString(set, "retry,retryWait")
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--retry=11", "--retryWait=0"})
+
+	ngsi, err := initCmd(c, "Testing", false)
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, -1, ngsi.Retry)
+		assert.Equal(t, time.Duration(0), ngsi.RetryWait)
+	} else {
+		t.FailNow()
+	}
+}
+
 func TestInitCmdArgs(t *testing.T) {
 	ngsi, set, app, _ := setupTest()
 
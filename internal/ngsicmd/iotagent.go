@@ -0,0 +1,220 @@
+/*
+MIT License
+
+Copyright (c) 2020 Kazuhito Suda
+
+This file is part of NGSI Go
+
+https://github.com/lets-fiware/ngsi-go
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+package ngsicmd
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/lets-fiware/ngsi-go/internal/ngsilib"
+	"github.com/urfave/cli/v2"
+)
+
+// iotaList gets all service groups or devices from an IoT Agent. kind is "services" or "devices".
+func iotaList(client *ngsilib.Client, kind string, limit int) ([]map[string]interface{}, error) {
+	const funcName = "iotaList"
+
+	list := []map[string]interface{}{}
+
+	for page := 0; ; page++ {
+		client.SetPath("/iot/" + kind)
+
+		v := url.Values{}
+		v.Set("limit", fmt.Sprintf("%d", limit))
+		v.Set("offset", fmt.Sprintf("%d", page*limit))
+		client.SetQuery(&v)
+
+		res, body, err := client.HTTPGet()
+		if err != nil {
+			return nil, &ngsiCmdError{funcName, 1, err.Error(), err}
+		}
+		if res.StatusCode != http.StatusOK {
+			return nil, &ngsiCmdError{funcName, 2, fmt.Sprintf("%s %s", res.Status, string(body)), nil}
+		}
+
+		var r map[string]json.RawMessage
+		if err := ngsilib.JSONUnmarshal(body, &r); err != nil {
+			return nil, &ngsiCmdError{funcName, 3, err.Error(), err}
+		}
+		count := 0
+		if b, ok := r["count"]; ok {
+			if err := ngsilib.JSONUnmarshal(b, &count); err != nil {
+				return nil, &ngsiCmdError{funcName, 4, err.Error(), err}
+			}
+		}
+		var items []map[string]interface{}
+		if b, ok := r[kind]; ok {
+			if err := ngsilib.JSONUnmarshal(b, &items); err != nil {
+				return nil, &ngsiCmdError{funcName, 5, err.Error(), err}
+			}
+		}
+		list = append(list, items...)
+
+		if len(items) == 0 || len(list) >= count {
+			break
+		}
+	}
+
+	return list, nil
+}
+
+// iotaItems reads service groups or devices from --data. The data is a JSON array, a JSON object with
+// the kind key such as {"devices": [...]}, a JSON object of an item or CSV when --format is csv.
+func iotaItems(c *cli.Context, ngsi *ngsilib.NGSI, kind string) ([]interface{}, error) {
+	const funcName = "iotaItems"
+
+	b, err := readAll(c, ngsi)
+	if err != nil {
+		return nil, &ngsiCmdError{funcName, 1, err.Error(), err}
+	}
+
+	switch strings.ToLower(c.String("format")) {
+	case "", "json":
+	case "csv":
+		items, err := iotaCSVItems(bytes.NewReader(b))
+		if err != nil {
+			return nil, &ngsiCmdError{funcName, 2, err.Error(), err}
+		}
+		return items, nil
+	default:
+		return nil, &ngsiCmdError{funcName, 3, "format error: " + c.String("format"), nil}
+	}
+
+	var v interface{}
+	if err := ngsilib.JSONUnmarshal(b, &v); err != nil {
+		return nil, &ngsiCmdError{funcName, 4, err.Error(), err}
+	}
+
+	switch v := v.(type) {
+	case []interface{}:
+		return v, nil
+	case map[string]interface{}:
+		if items, ok := v[kind]; ok {
+			if items, ok := items.([]interface{}); ok {
+				return items, nil
+			}
+			return nil, &ngsiCmdError{funcName, 5, kind + " is not array", nil}
+		}
+		return []interface{}{v}, nil
+	}
+	return nil, &ngsiCmdError{funcName, 6, "data error", nil}
+}
+
+// iotaCSVItems reads items from CSV. The header has field names such as device_id and entity_name.
+// A cell of a JSON array, a JSON object or a boolean is decoded, so that attributes can be given.
+func iotaCSVItems(r io.Reader) ([]interface{}, error) {
+	const funcName = "iotaCSVItems"
+
+	reader := csv.NewReader(r)
+
+	header, err := reader.Read()
+	if err != nil {
+		if err == io.EOF {
+			return nil, &ngsiCmdError{funcName, 1, "csv header not found", nil}
+		}
+		return nil, &ngsiCmdError{funcName, 2, err.Error(), err}
+	}
+	for i := range header {
+		header[i] = strings.TrimSpace(header[i])
+	}
+
+	items := []interface{}{}
+
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, &ngsiCmdError{funcName, 3, err.Error(), err}
+		}
+
+		item := make(map[string]interface{})
+		for i, cell := range record {
+			cell = strings.TrimSpace(cell)
+			if cell == "" || header[i] == "" {
+				continue
+			}
+			switch {
+			case strings.HasPrefix(cell, "[") || strings.HasPrefix(cell, "{") || cell == "true" || cell == "false":
+				var v interface{}
+				if err := ngsilib.JSONUnmarshal([]byte(cell), &v); err != nil {
+					return nil, &ngsiCmdError{funcName, 4, fmt.Sprintf("line %d: %s: %s", line, header[i], err.Error()), err}
+				}
+				item[header[i]] = v
+			default:
+				item[header[i]] = cell
+			}
+		}
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+// iotaCreate posts service groups or devices to an IoT Agent in batches.
+func iotaCreate(c *cli.Context, ngsi *ngsilib.NGSI, client *ngsilib.Client, kind string, items []interface{}) error {
+	const funcName = "iotaCreate"
+
+	size := batchSize(c, ngsi, client)
+
+	return batchRun(len(items), size, func(start, end int) (bool, error) {
+		client.SetPath("/iot/" + kind)
+		client.SetHeader("Content-Type", "application/json")
+
+		b, err := ngsilib.JSONMarshal(map[string]interface{}{kind: items[start:end]})
+		if err != nil {
+			return false, &ngsiCmdError{funcName, 1, err.Error(), err}
+		}
+
+		res, body, err := client.HTTPPost(b)
+		if err != nil {
+			return false, &ngsiCmdError{funcName, 2, err.Error(), err}
+		}
+		if res.StatusCode == http.StatusRequestEntityTooLarge {
+			return true, nil
+		}
+		if res.StatusCode != http.StatusCreated {
+			return false, &ngsiCmdError{funcName, 3, fmt.Sprintf("%s %s", res.Status, string(body)), nil}
+		}
+		return false, nil
+	})
+}
+
+func iotaString(item map[string]interface{}, key string) string {
+	s, _ := item[key].(string)
+	return s
+}
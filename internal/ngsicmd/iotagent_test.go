@@ -0,0 +1,320 @@
+/*
+MIT License
+
+Copyright (c) 2020 Kazuhito Suda
+
+This file is part of NGSI Go
+
+https://github.com/lets-fiware/ngsi-go
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+package ngsicmd
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/cli/v2"
+)
+
+func TestIotaList(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddServer(t, ngsi, "iota", "http://iota:4041", "iota")
+
+	reqRes1 := MockHTTPReqRes{}
+	reqRes1.Res.StatusCode = http.StatusOK
+	reqRes1.ResBody = []byte(`{"count":2,"devices":[{"device_id":"sensor001"}]}`)
+	reqRes1.Path = "/iot/devices"
+	reqRes2 := MockHTTPReqRes{}
+	reqRes2.Res.StatusCode = http.StatusOK
+	reqRes2.ResBody = []byte(`{"count":2,"devices":[{"device_id":"sensor002"}]}`)
+	reqRes2.Path = "/iot/devices"
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes1, reqRes2)
+	ngsi.HTTP = mock
+	setupFlagString(set, "host")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=iota"})
+	client, _ := newClient(ngsi, c, false)
+
+	actual, err := iotaList(client, "devices", 1)
+
+	if assert.NoError(t, err) {
+		expected := []map[string]interface{}{{"device_id": "sensor001"}, {"device_id": "sensor002"}}
+		assert.Equal(t, expected, actual)
+		assert.Equal(t, "limit=1&offset=1", client.URL.RawQuery)
+	}
+}
+
+func TestIotaListEmpty(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddServer(t, ngsi, "iota", "http://iota:4041", "iota")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusOK
+	reqRes.ResBody = []byte(`{}`)
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	setupFlagString(set, "host")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=iota"})
+	client, _ := newClient(ngsi, c, false)
+
+	actual, err := iotaList(client, "services", 100)
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, []map[string]interface{}{}, actual)
+	}
+}
+
+func TestIotaListError(t *testing.T) {
+	cases := []struct {
+		reqRes  MockHTTPReqRes
+		errNo   int
+		message string
+	}{
+		{reqRes: MockHTTPReqRes{Err: errors.New("http error")}, errNo: 1, message: "http error"},
+		{reqRes: MockHTTPReqRes{Res: http.Response{StatusCode: http.StatusBadRequest, Status: "400 Bad Request"}, ResBody: []byte("error")}, errNo: 2, message: "400 Bad Request error"},
+		{reqRes: MockHTTPReqRes{Res: http.Response{StatusCode: http.StatusOK}, ResBody: []byte(`[]`)}, errNo: 3},
+		{reqRes: MockHTTPReqRes{Res: http.Response{StatusCode: http.StatusOK}, ResBody: []byte(`{"count":"1"}`)}, errNo: 4},
+		{reqRes: MockHTTPReqRes{Res: http.Response{StatusCode: http.StatusOK}, ResBody: []byte(`{"count":1,"devices":{}}`)}, errNo: 5},
+	}
+
+	for _, c := range cases {
+		ngsi, set, app, _ := setupTest()
+
+		setupAddServer(t, ngsi, "iota", "http://iota:4041", "iota")
+
+		mock := NewMockHTTP()
+		mock.ReqRes = append(mock.ReqRes, c.reqRes)
+		ngsi.HTTP = mock
+		setupFlagString(set, "host")
+
+		ctx := cli.NewContext(app, set, nil)
+		_ = set.Parse([]string{"--host=iota"})
+		client, _ := newClient(ngsi, ctx, false)
+
+		_, err := iotaList(client, "devices", 100)
+
+		if assert.Error(t, err) {
+			ngsiErr := err.(*ngsiCmdError)
+			assert.Equal(t, c.errNo, ngsiErr.ErrNo)
+			if c.message != "" {
+				assert.Equal(t, c.message, ngsiErr.Message)
+			}
+		}
+	}
+}
+
+func TestIotaItems(t *testing.T) {
+	cases := []struct {
+		args     []string
+		expected []interface{}
+	}{
+		{args: []string{`--data=[{"device_id":"sensor001"},{"device_id":"sensor002"}]`},
+			expected: []interface{}{map[string]interface{}{"device_id": "sensor001"}, map[string]interface{}{"device_id": "sensor002"}}},
+		{args: []string{`--data={"devices":[{"device_id":"sensor001"}]}`},
+			expected: []interface{}{map[string]interface{}{"device_id": "sensor001"}}},
+		{args: []string{`--data={"device_id":"sensor001"}`},
+			expected: []interface{}{map[string]interface{}{"device_id": "sensor001"}}},
+		{args: []string{"--data=device_id,entity_name\nsensor001,urn:ngsi-ld:Device:001\n", "--format=csv"},
+			expected: []interface{}{map[string]interface{}{"device_id": "sensor001", "entity_name": "urn:ngsi-ld:Device:001"}}},
+	}
+
+	for _, c := range cases {
+		ngsi, set, app, _ := setupTest()
+
+		setupFlagString(set, "data,format")
+
+		ctx := cli.NewContext(app, set, nil)
+		_ = set.Parse(c.args)
+
+		actual, err := iotaItems(ctx, ngsi, "devices")
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, c.expected, actual)
+		}
+	}
+}
+
+func TestIotaItemsError(t *testing.T) {
+	cases := []struct {
+		args    []string
+		errNo   int
+		message string
+	}{
+		{args: []string{}, errNo: 1, message: "data is empty"},
+		{args: []string{"--data=", "--format=csv"}, errNo: 1, message: "data is empty"},
+		{args: []string{"--data=\n", "--format=csv"}, errNo: 2, message: "csv header not found"},
+		{args: []string{"--data=[]", "--format=xml"}, errNo: 3, message: "format error: xml"},
+		{args: []string{"--data={"}, errNo: 4},
+		{args: []string{`--data={"devices":{}}`}, errNo: 5, message: "devices is not array"},
+		{args: []string{"--data=1"}, errNo: 6, message: "data error"},
+	}
+
+	for _, c := range cases {
+		ngsi, set, app, _ := setupTest()
+
+		setupFlagString(set, "data,format")
+
+		ctx := cli.NewContext(app, set, nil)
+		_ = set.Parse(c.args)
+
+		_, err := iotaItems(ctx, ngsi, "devices")
+
+		if assert.Error(t, err) {
+			ngsiErr := err.(*ngsiCmdError)
+			assert.Equal(t, c.errNo, ngsiErr.ErrNo)
+			if c.message != "" {
+				assert.Equal(t, c.message, ngsiErr.Message)
+			}
+		}
+	}
+}
+
+func TestIotaCSVItems(t *testing.T) {
+	_, _, _, _ = setupTest()
+
+	data := "device_id,entity_name,entity_type,timestamp,attributes\n" +
+		`sensor001,urn:ngsi-ld:Device:001,Device,true,"[{""object_id"":""t"",""name"":""temperature"",""type"":""Number""}]"` + "\n" +
+		"sensor002,,Device,,\n"
+
+	actual, err := iotaCSVItems(strings.NewReader(data))
+
+	if assert.NoError(t, err) {
+		expected := []interface{}{
+			map[string]interface{}{"device_id": "sensor001", "entity_name": "urn:ngsi-ld:Device:001", "entity_type": "Device", "timestamp": true,
+				"attributes": []interface{}{map[string]interface{}{"object_id": "t", "name": "temperature", "type": "Number"}}},
+			map[string]interface{}{"device_id": "sensor002", "entity_type": "Device"},
+		}
+		assert.Equal(t, expected, actual)
+	}
+}
+
+func TestIotaCSVItemsError(t *testing.T) {
+	cases := []struct {
+		data    string
+		errNo   int
+		message string
+	}{
+		{data: "", errNo: 1, message: "csv header not found"},
+		{data: "device_id,\"entity_name\n", errNo: 2},
+		{data: "device_id,entity_name\nsensor001,\"urn\n", errNo: 3},
+		{data: "device_id,attributes\nsensor001,[\n", errNo: 4},
+	}
+
+	for _, c := range cases {
+		_, _, _, _ = setupTest()
+
+		_, err := iotaCSVItems(strings.NewReader(c.data))
+
+		if assert.Error(t, err) {
+			ngsiErr := err.(*ngsiCmdError)
+			assert.Equal(t, c.errNo, ngsiErr.ErrNo)
+			if c.message != "" {
+				assert.Equal(t, c.message, ngsiErr.Message)
+			}
+		}
+	}
+}
+
+func TestIotaCreate(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddServer(t, ngsi, "iota", "http://iota:4041", "iota")
+
+	reqRes1 := MockHTTPReqRes{}
+	reqRes1.Res.StatusCode = http.StatusRequestEntityTooLarge
+	reqRes1.ReqData = []byte(`{"devices":[{"device_id":"sensor001"},{"device_id":"sensor002"}]}`)
+	reqRes2 := MockHTTPReqRes{}
+	reqRes2.Res.StatusCode = http.StatusCreated
+	reqRes2.ReqData = []byte(`{"devices":[{"device_id":"sensor001"}]}`)
+	reqRes2.Path = "/iot/devices"
+	reqRes3 := MockHTTPReqRes{}
+	reqRes3.Res.StatusCode = http.StatusCreated
+	reqRes3.ReqData = []byte(`{"devices":[{"device_id":"sensor002"}]}`)
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes1, reqRes2, reqRes3)
+	ngsi.HTTP = mock
+	setupFlagString(set, "host")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=iota"})
+	client, _ := newClient(ngsi, c, false)
+
+	items := []interface{}{map[string]interface{}{"device_id": "sensor001"}, map[string]interface{}{"device_id": "sensor002"}}
+	err := iotaCreate(c, ngsi, client, "devices", items)
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, 3, mock.index)
+	}
+}
+
+func TestIotaCreateError(t *testing.T) {
+	cases := []struct {
+		reqRes  MockHTTPReqRes
+		errNo   int
+		message string
+	}{
+		{reqRes: MockHTTPReqRes{Err: errors.New("http error")}, errNo: 2, message: "http error"},
+		{reqRes: MockHTTPReqRes{Res: http.Response{StatusCode: http.StatusConflict, Status: "409 Conflict"}, ResBody: []byte("error")}, errNo: 3, message: "409 Conflict error"},
+	}
+
+	for _, c := range cases {
+		ngsi, set, app, _ := setupTest()
+
+		setupAddServer(t, ngsi, "iota", "http://iota:4041", "iota")
+
+		mock := NewMockHTTP()
+		mock.ReqRes = append(mock.ReqRes, c.reqRes)
+		ngsi.HTTP = mock
+		setupFlagString(set, "host")
+
+		ctx := cli.NewContext(app, set, nil)
+		_ = set.Parse([]string{"--host=iota"})
+		client, _ := newClient(ngsi, ctx, false)
+
+		err := iotaCreate(ctx, ngsi, client, "devices", []interface{}{map[string]interface{}{"device_id": "sensor001"}})
+
+		if assert.Error(t, err) {
+			ngsiErr := err.(*ngsiCmdError)
+			assert.Equal(t, c.errNo, ngsiErr.ErrNo)
+			assert.Equal(t, c.message, ngsiErr.Message)
+		}
+	}
+}
+
+func TestIotaString(t *testing.T) {
+	item := map[string]interface{}{"device_id": "sensor001", "timestamp": true}
+
+	assert.Equal(t, "sensor001", iotaString(item, "device_id"))
+	assert.Equal(t, "", iotaString(item, "timestamp"))
+	assert.Equal(t, "", iotaString(item, "entity_name"))
+}
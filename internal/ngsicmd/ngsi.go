@@ -72,24 +72,40 @@ func Run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
 			cacheFlag,
 			marginFlag,
 			timeOutFlag,
+			maxIdleConnsFlag,
+			idleTimeoutFlag,
+			noKeepAliveFlag,
+			retryFlag,
+			retryWaitFlag,
 			maxCountFlag,
 			batchFlag,
+			prettyFlag,
 		},
 		Commands: []*cli.Command{
 			&appendCmd,
+			&applyCmd,
 			&brokersCmd,
 			&contextCmd,
 			&copyCmd,
+			&credentialsCmd,
 			&countCmd,
 			&createCmd,
 			&debugCmd,
 			&deleteCmd,
+			&devicesCmd,
+			&diffCmd,
 			&documentsCmd,
+			&exportCmd,
 			&getCmd,
+			&hdeleteCmd,
+			&hgetCmd,
+			&importCmd,
 			&listCmd,
 			&lsCmd,
+			&receiverCmd,
 			&removeCmd,
 			&replaceCmd,
+			&servicesCmd,
 			&settingsCmd,
 			&templateCmd,
 			&tokenCmd,
@@ -129,6 +145,26 @@ func message(err error) (s string) {
 	return
 }
 
+var applyCmd = cli.Command{
+	Name:     "apply",
+	Usage:    "apply subscriptions and registrations in a file",
+	Category: "CONVENIENCE",
+	Flags: []cli.Flag{
+		hostFlag,
+		tokenFlag,
+		tenantFlag,
+		scopeFlag,
+		linkFlag,
+		applyFileFlag,
+		applyKeyFlag,
+		applyPruneFlag,
+		applyDryRunFlag,
+	},
+	Action: func(c *cli.Context) error {
+		return apply(c)
+	},
+}
+
 var copyCmd = cli.Command{
 	Name:     "cp",
 	Usage:    "copy entities",
@@ -140,10 +176,18 @@ var copyCmd = cli.Command{
 		tenantFlag,
 		scopeFlag,
 		typeRFlag,
+		linkFlag,
 		token2Flag,
 		tenant2Flag,
 		scope2Flag,
+		link2Flag,
 		runFlag,
+		checkpointFlag,
+		resumeFlag,
+		dryRunFlag,
+		parallelFlag,
+		pageSizeFlag,
+		batchSizeFlag,
 	},
 	Action: func(c *cli.Context) error {
 		return copy(c)
@@ -196,6 +240,39 @@ var countCmd = cli.Command{
 	},
 }
 
+var diffCmd = cli.Command{
+	Name:     "diff",
+	Usage:    "print differences between two brokers or two tenants",
+	Category: "CONVENIENCE",
+	Flags: []cli.Flag{
+		hostFlag,
+		destinationFlag,
+		tokenFlag,
+		tenantFlag,
+		scopeFlag,
+		token2Flag,
+		tenant2Flag,
+		scope2Flag,
+	},
+	Subcommands: []*cli.Command{
+		{
+			Name:  "entities",
+			Usage: "print differences of entities",
+			Flags: []cli.Flag{
+				typeRFlag,
+				linkFlag,
+				link2Flag,
+				ignoreFlag,
+				jsonFlag,
+				pageSizeFlag,
+			},
+			Action: func(c *cli.Context) error {
+				return diffEntities(c)
+			},
+		},
+	},
+}
+
 var documentsCmd = cli.Command{
 	Name:     "man",
 	Usage:    "print urls of document",
@@ -205,6 +282,43 @@ var documentsCmd = cli.Command{
 	},
 }
 
+var exportCmd = cli.Command{
+	Name:     "export",
+	Usage:    "export entities, subscriptions and registrations",
+	Category: "CONVENIENCE",
+	Flags: []cli.Flag{
+		hostFlag,
+		tokenFlag,
+		tenantFlag,
+		scopeFlag,
+		typeFlag,
+		linkFlag,
+		snapshotDirFlag,
+		pageSizeFlag,
+	},
+	Action: func(c *cli.Context) error {
+		return exportSnapshot(c)
+	},
+}
+
+var importCmd = cli.Command{
+	Name:     "import",
+	Usage:    "import entities, subscriptions and registrations",
+	Category: "CONVENIENCE",
+	Flags: []cli.Flag{
+		hostFlag,
+		tokenFlag,
+		tenantFlag,
+		scopeFlag,
+		linkFlag,
+		snapshotDirFlag,
+		batchSizeFlag,
+	},
+	Action: func(c *cli.Context) error {
+		return importSnapshot(c)
+	},
+}
+
 var lsCmd = cli.Command{
 	Name:     "ls",
 	Usage:    "list entities",
@@ -234,12 +348,332 @@ var lsCmd = cli.Command{
 		verboseFlag,
 		linesFlag,
 		safeStringFlag,
+		parallelFlag,
 	},
 	Action: func(c *cli.Context) error {
 		return entitiesList(c)
 	},
 }
 
+var servicesCmd = cli.Command{
+	Name:     "services",
+	Usage:    "manage service groups of IoT Agent",
+	Category: "IOT AGENT",
+	Flags: []cli.Flag{
+		hostFlag,
+		tokenFlag,
+		tenantFlag,
+		scopeFlag,
+	},
+	Subcommands: []*cli.Command{
+		{
+			Name:  "list",
+			Usage: "list service groups",
+			Flags: []cli.Flag{
+				jsonFlag,
+				verboseFlag,
+				pageSizeFlag,
+				outputFlag,
+				selectFlag,
+			},
+			Action: func(c *cli.Context) error {
+				return servicesList(c)
+			},
+		},
+		{
+			Name:  "get",
+			Usage: "get service group",
+			Flags: []cli.Flag{
+				resourceRFlag,
+				apikeyRFlag,
+				pageSizeFlag,
+				outputFlag,
+				selectFlag,
+			},
+			Action: func(c *cli.Context) error {
+				return servicesGet(c)
+			},
+		},
+		{
+			Name:  "create",
+			Usage: "create service group(s)",
+			Flags: []cli.Flag{
+				dataFlag,
+				formatFlag,
+				batchSizeFlag,
+			},
+			Action: func(c *cli.Context) error {
+				return servicesCreate(c)
+			},
+		},
+		{
+			Name:  "update",
+			Usage: "update service group",
+			Flags: []cli.Flag{
+				resourceRFlag,
+				apikeyRFlag,
+				dataFlag,
+			},
+			Action: func(c *cli.Context) error {
+				return servicesUpdate(c)
+			},
+		},
+		{
+			Name:  "delete",
+			Usage: "delete service group",
+			Flags: []cli.Flag{
+				resourceRFlag,
+				apikeyRFlag,
+			},
+			Action: func(c *cli.Context) error {
+				return servicesDelete(c)
+			},
+		},
+	},
+}
+
+var devicesCmd = cli.Command{
+	Name:     "devices",
+	Usage:    "manage devices of IoT Agent",
+	Category: "IOT AGENT",
+	Flags: []cli.Flag{
+		hostFlag,
+		tokenFlag,
+		tenantFlag,
+		scopeFlag,
+	},
+	Subcommands: []*cli.Command{
+		{
+			Name:  "list",
+			Usage: "list devices",
+			Flags: []cli.Flag{
+				jsonFlag,
+				verboseFlag,
+				pageSizeFlag,
+				outputFlag,
+				selectFlag,
+			},
+			Action: func(c *cli.Context) error {
+				return devicesList(c)
+			},
+		},
+		{
+			Name:  "get",
+			Usage: "get device",
+			Flags: []cli.Flag{
+				idRFlag,
+				outputFlag,
+				selectFlag,
+			},
+			Action: func(c *cli.Context) error {
+				return devicesGet(c)
+			},
+		},
+		{
+			Name:  "create",
+			Usage: "create device(s)",
+			Flags: []cli.Flag{
+				dataFlag,
+				formatFlag,
+				batchSizeFlag,
+			},
+			Action: func(c *cli.Context) error {
+				return devicesCreate(c)
+			},
+		},
+		{
+			Name:  "update",
+			Usage: "update device",
+			Flags: []cli.Flag{
+				idRFlag,
+				dataFlag,
+			},
+			Action: func(c *cli.Context) error {
+				return devicesUpdate(c)
+			},
+		},
+		{
+			Name:  "delete",
+			Usage: "delete device",
+			Flags: []cli.Flag{
+				idRFlag,
+			},
+			Action: func(c *cli.Context) error {
+				return devicesDelete(c)
+			},
+		},
+	},
+}
+
+var hgetCmd = cli.Command{
+	Name:     "hget",
+	Usage:    "get historical data",
+	Category: "TIME SERIES",
+	Flags: []cli.Flag{
+		hostFlag,
+		tokenFlag,
+		tenantFlag,
+		scopeFlag,
+	},
+	Subcommands: []*cli.Command{
+		{
+			Name:  "entities",
+			Usage: "get list of entities",
+			Flags: []cli.Flag{
+				typeFlag,
+				fromDateFlag,
+				toDateFlag,
+				hLimitFlag,
+				hOffsetFlag,
+				outputFlag,
+				selectFlag,
+			},
+			Action: func(c *cli.Context) error {
+				return hgetEntities(c)
+			},
+		},
+		{
+			Name:  "attr",
+			Usage: "get history of an attribute",
+			Flags: []cli.Flag{
+				idRFlag,
+				typeFlag,
+				attrNameRFlag,
+				fromDateFlag,
+				toDateFlag,
+				lastNFlag,
+				aggrMethodFlag,
+				aggrPeriodFlag,
+				hLimitFlag,
+				hOffsetFlag,
+			},
+			Action: func(c *cli.Context) error {
+				return hgetAttr(c)
+			},
+		},
+		{
+			Name:  "attrs",
+			Usage: "get history of attributes",
+			Flags: []cli.Flag{
+				idRFlag,
+				typeFlag,
+				attrsFlag,
+				fromDateFlag,
+				toDateFlag,
+				lastNFlag,
+				aggrMethodFlag,
+				aggrPeriodFlag,
+				hLimitFlag,
+				hOffsetFlag,
+			},
+			Action: func(c *cli.Context) error {
+				return hgetAttrs(c)
+			},
+		},
+		{
+			Name:  "types",
+			Usage: "get history of entities of a type",
+			Flags: []cli.Flag{
+				typeRFlag,
+				attrNameFlag,
+				attrsFlag,
+				fromDateFlag,
+				toDateFlag,
+				lastNFlag,
+				aggrMethodFlag,
+				aggrPeriodFlag,
+				hLimitFlag,
+				hOffsetFlag,
+			},
+			Action: func(c *cli.Context) error {
+				return hgetTypes(c)
+			},
+		},
+	},
+}
+
+var hdeleteCmd = cli.Command{
+	Name:     "hdelete",
+	Usage:    "delete historical data",
+	Category: "TIME SERIES",
+	Flags: []cli.Flag{
+		hostFlag,
+		tokenFlag,
+		tenantFlag,
+		scopeFlag,
+	},
+	Subcommands: []*cli.Command{
+		{
+			Name:  "entities",
+			Usage: "delete history of entities",
+			Flags: []cli.Flag{
+				typeFlag,
+				fromDateFlag,
+				toDateFlag,
+				runFlag,
+			},
+			Action: func(c *cli.Context) error {
+				return hdeleteEntities(c)
+			},
+		},
+		{
+			Name:  "entity",
+			Usage: "delete history of an entity",
+			Flags: []cli.Flag{
+				idRFlag,
+				typeFlag,
+				fromDateFlag,
+				toDateFlag,
+				runFlag,
+			},
+			Action: func(c *cli.Context) error {
+				return hdeleteEntity(c)
+			},
+		},
+		{
+			Name:  "attr",
+			Usage: "delete history of an attribute",
+			Flags: []cli.Flag{
+				idRFlag,
+				typeFlag,
+				attrNameRFlag,
+				runFlag,
+			},
+			Action: func(c *cli.Context) error {
+				return hdeleteAttr(c)
+			},
+		},
+	},
+}
+
+var receiverCmd = cli.Command{
+	Name:     "receiver",
+	Usage:    "receive notifications",
+	Category: "CONVENIENCE",
+	Flags: []cli.Flag{
+		hostFlag,
+		tokenFlag,
+		tenantFlag,
+		scopeFlag,
+		linkFlag,
+		portFlag,
+		notifyURLFlag,
+		prettyFlag,
+		headerFlag,
+		subscriptionIDFlag,
+		subscribeFlag,
+		typeFlag,
+		idPatternFlag,
+		queryFlag,
+		wAttrsFlag,
+		nAttrsFlag,
+		safeStringFlag,
+	},
+	Action: func(c *cli.Context) error {
+		return receiver(c)
+	},
+}
+
 var removeCmd = cli.Command{
 	Name:     "rm",
 	Usage:    "remove entities",
@@ -249,8 +683,20 @@ var removeCmd = cli.Command{
 		tokenFlag,
 		tenantFlag,
 		scopeFlag,
-		typeRFlag,
+		typeFlag,
+		idFlag,
+		idPatternFlag,
+		typePatternFlag,
+		queryFlag,
+		mqFlag,
+		georelFlag,
+		geometryFlag,
+		coordsFlag,
+		linkFlag,
 		runFlag,
+		parallelFlag,
+		pageSizeFlag,
+		batchSizeFlag,
 	},
 	Action: func(c *cli.Context) error {
 		return remove(c)
@@ -371,6 +817,17 @@ var brokersCmd = cli.Command{
 				hostFlag,
 				brokerHostFlag,
 				ngsiTypeFlag,
+				serverTypeFlag,
+				proxyFlag,
+				caCertFlag,
+				clientCertFlag,
+				clientKeyFlag,
+				insecureSkipVerifyFlag,
+				brokerRetryFlag,
+				brokerRetryWaitFlag,
+				brokerPageSizeFlag,
+				brokerBatchSizeFlag,
+				brokerHeaderFlag,
 				idmTypeFlag,
 				idmHostFlag,
 				apiPathFlag,
@@ -378,6 +835,8 @@ var brokersCmd = cli.Command{
 				passwordFlag,
 				clientIDFlag,
 				clientSecretFlag,
+				idmScopeFlag,
+				audienceFlag,
 				tokenFlag,
 				tenantFlag,
 				scopeFlag,
@@ -405,6 +864,17 @@ var brokersCmd = cli.Command{
 				hostFlag,
 				brokerHostFlag,
 				ngsiTypeFlag,
+				serverTypeFlag,
+				proxyFlag,
+				caCertFlag,
+				clientCertFlag,
+				clientKeyFlag,
+				insecureSkipVerifyFlag,
+				brokerRetryFlag,
+				brokerRetryWaitFlag,
+				brokerPageSizeFlag,
+				brokerBatchSizeFlag,
+				brokerHeaderFlag,
 				idmTypeFlag,
 				idmHostFlag,
 				apiPathFlag,
@@ -412,6 +882,8 @@ var brokersCmd = cli.Command{
 				passwordFlag,
 				clientIDFlag,
 				clientSecretFlag,
+				idmScopeFlag,
+				audienceFlag,
 				tokenFlag,
 				tenantFlag,
 				scopeFlag,
@@ -475,6 +947,43 @@ var contextCmd = cli.Command{
 	},
 }
 
+var credentialsCmd = cli.Command{
+	Name:     "credentials",
+	Usage:    "manage credentials",
+	Category: "MANAGEMENT",
+	Subcommands: []*cli.Command{
+		{
+			Name:  "list",
+			Usage: "list credentials",
+			Flags: []cli.Flag{},
+			Action: func(c *cli.Context) error {
+				return credentialsList(c)
+			},
+		},
+		{
+			Name:  "set",
+			Usage: "set credential",
+			Flags: []cli.Flag{
+				hostFlag,
+				passwordFlag,
+			},
+			Action: func(c *cli.Context) error {
+				return credentialsSet(c)
+			},
+		},
+		{
+			Name:  "delete",
+			Usage: "delete credential",
+			Flags: []cli.Flag{
+				hostFlag,
+			},
+			Action: func(c *cli.Context) error {
+				return credentialsDelete(c)
+			},
+		},
+	},
+}
+
 var settingsCmd = cli.Command{
 	Name:     "settings",
 	Category: "MANAGEMENT",
@@ -516,13 +1025,42 @@ var tokenCmd = cli.Command{
 	Usage: "manage token",
 	Flags: []cli.Flag{
 		hostFlag,
-		verboseFlag,
+		tokenVerboseFlag,
+		introspectFlag,
 		expiresFlag,
 	},
 	Category: "MANAGEMENT",
 	Action: func(c *cli.Context) error {
 		return tokenCommand(c)
 	},
+	Subcommands: []*cli.Command{
+		{
+			Name:  "list",
+			Usage: "list tokens",
+			Flags: []cli.Flag{},
+			Action: func(c *cli.Context) error {
+				return tokenList(c)
+			},
+		},
+		{
+			Name:  "revoke",
+			Usage: "revoke token",
+			Flags: []cli.Flag{
+				hostFlag,
+			},
+			Action: func(c *cli.Context) error {
+				return tokenRevoke(c)
+			},
+		},
+		{
+			Name:  "clear",
+			Usage: "clear tokens",
+			Flags: []cli.Flag{},
+			Action: func(c *cli.Context) error {
+				return tokenClear(c)
+			},
+		},
+	},
 }
 
 var appendCmd = cli.Command{
@@ -551,6 +1089,19 @@ var appendCmd = cli.Command{
 				return attrsAppend(c)
 			},
 		},
+		{
+			Name:  "temporal",
+			Usage: "append attrs to temporal entity",
+			Flags: []cli.Flag{
+				idRFlag,
+				dataFlag,
+				linkFlag,
+				safeStringFlag,
+			},
+			Action: func(c *cli.Context) error {
+				return temporalAppend(c)
+			},
+		},
 	},
 }
 
@@ -571,8 +1122,11 @@ var createCmd = cli.Command{
 			Flags: []cli.Flag{
 				keyValuesFlag,
 				dataFlag,
+				formatFlag,
 				linkFlag,
 				safeStringFlag,
+				parallelFlag,
+				batchSizeFlag,
 			},
 			Action: func(c *cli.Context) error {
 				return batch(c, "create")
@@ -644,6 +1198,18 @@ var createCmd = cli.Command{
 				return registrationsCreate(c)
 			},
 		},
+		{
+			Name:  "temporal",
+			Usage: "create temporal entity",
+			Flags: []cli.Flag{
+				dataFlag,
+				linkFlag,
+				safeStringFlag,
+			},
+			Action: func(c *cli.Context) error {
+				return temporalCreate(c)
+			},
+		},
 	},
 }
 
@@ -665,6 +1231,8 @@ var deleteCmd = cli.Command{
 				keyValuesFlag,
 				dataFlag,
 				linkFlag,
+				parallelFlag,
+				batchSizeFlag,
 			},
 			Action: func(c *cli.Context) error {
 				return batch(c, "delete")
@@ -715,6 +1283,18 @@ var deleteCmd = cli.Command{
 				return registrationsDelete(c)
 			},
 		},
+		{
+			Name:  "temporal",
+			Usage: "delete temporal entity or attr",
+			Flags: []cli.Flag{
+				idRFlag,
+				attrNameFlag,
+				linkFlag,
+			},
+			Action: func(c *cli.Context) error {
+				return temporalDelete(c)
+			},
+		},
 	},
 }
 
@@ -742,6 +1322,8 @@ var getCmd = cli.Command{
 				linesFlag,
 				dataFlag,
 				safeStringFlag,
+				outputFlag,
+				selectFlag,
 			},
 			Action: func(c *cli.Context) error {
 				return opQuery(c)
@@ -760,6 +1342,10 @@ var getCmd = cli.Command{
 				sysAttrsFlag,
 				linkFlag,
 				safeStringFlag,
+				watchFlag,
+				intervalFlag,
+				outputFlag,
+				selectFlag,
 			},
 			Action: func(c *cli.Context) error {
 				return entityRead(c)
@@ -790,6 +1376,8 @@ var getCmd = cli.Command{
 				valuesFlag,
 				uniqueFlag,
 				safeStringFlag,
+				watchFlag,
+				intervalFlag,
 			},
 			Action: func(c *cli.Context) error {
 				return attrsRead(c)
@@ -802,6 +1390,8 @@ var getCmd = cli.Command{
 				idRFlag,
 				localTimeFlag,
 				safeStringFlag,
+				outputFlag,
+				selectFlag,
 			},
 			Action: func(c *cli.Context) error {
 				return subscriptionGet(c)
@@ -813,6 +1403,8 @@ var getCmd = cli.Command{
 			Flags: []cli.Flag{
 				idRFlag,
 				safeStringFlag,
+				outputFlag,
+				selectFlag,
 			},
 			Action: func(c *cli.Context) error {
 				return registrationsGet(c)
@@ -823,11 +1415,34 @@ var getCmd = cli.Command{
 			Usage: "get type",
 			Flags: []cli.Flag{
 				typeRFlag,
+				outputFlag,
+				selectFlag,
 			},
 			Action: func(c *cli.Context) error {
 				return typeGet(c)
 			},
 		},
+		{
+			Name:  "temporal",
+			Usage: "get temporal entity",
+			Flags: []cli.Flag{
+				idRFlag,
+				typeFlag,
+				attrsFlag,
+				timerelFlag,
+				timeAtFlag,
+				endTimeAtFlag,
+				timepropertyFlag,
+				lastNFlag,
+				temporalValuesFlag,
+				sysAttrsFlag,
+				linkFlag,
+				safeStringFlag,
+			},
+			Action: func(c *cli.Context) error {
+				return temporalRead(c)
+			},
+		},
 	},
 }
 
@@ -848,6 +1463,8 @@ var listCmd = cli.Command{
 			Flags: []cli.Flag{
 				verboseFlag,
 				jsonFlag,
+				outputFlag,
+				selectFlag,
 			},
 			Action: func(c *cli.Context) error {
 				return typesList(c)
@@ -876,7 +1493,13 @@ var listCmd = cli.Command{
 				linkFlag,
 				verboseFlag,
 				linesFlag,
+				formatFlag,
 				safeStringFlag,
+				parallelFlag,
+				watchFlag,
+				intervalFlag,
+				outputFlag,
+				selectFlag,
 			},
 			Action: func(c *cli.Context) error {
 				return entitiesList(c)
@@ -893,6 +1516,8 @@ var listCmd = cli.Command{
 				queryFlag,
 				itemsFlag,
 				safeStringFlag,
+				outputFlag,
+				selectFlag,
 			},
 			Action: func(c *cli.Context) error {
 				return subscriptionsList(c)
@@ -905,11 +1530,40 @@ var listCmd = cli.Command{
 				verboseFlag,
 				jsonFlag,
 				safeStringFlag,
+				outputFlag,
+				selectFlag,
 			},
 			Action: func(c *cli.Context) error {
 				return registrationsList(c)
 			},
 		},
+		{
+			Name:  "temporal",
+			Usage: "list temporal entities",
+			Flags: []cli.Flag{
+				idFlag,
+				typeFlag,
+				idPatternFlag,
+				queryFlag,
+				georelFlag,
+				geometryFlag,
+				coordsFlag,
+				attrsFlag,
+				timerelFlag,
+				timeAtFlag,
+				endTimeAtFlag,
+				timepropertyFlag,
+				lastNFlag,
+				temporalValuesFlag,
+				sysAttrsFlag,
+				linkFlag,
+				linesFlag,
+				safeStringFlag,
+			},
+			Action: func(c *cli.Context) error {
+				return temporalList(c)
+			},
+		},
 	},
 }
 
@@ -931,6 +1585,8 @@ var replaceCmd = cli.Command{
 			Flags: []cli.Flag{
 				keyValuesFlag,
 				dataFlag,
+				parallelFlag,
+				batchSizeFlag,
 			},
 			Action: func(c *cli.Context) error {
 				return batch(c, "replace")
@@ -1035,9 +1691,12 @@ var updateCmd = cli.Command{
 			Flags: []cli.Flag{
 				keyValuesFlag,
 				dataFlag,
+				formatFlag,
 				noOverwriteFlag,
 				replaceFlag,
 				linkFlag,
+				parallelFlag,
+				batchSizeFlag,
 			},
 			Action: func(c *cli.Context) error {
 				return batch(c, "update")
@@ -1062,9 +1721,12 @@ var upsertCmd = cli.Command{
 			Usage:    "upsert entities",
 			Flags: []cli.Flag{
 				dataFlag,
+				formatFlag,
 				replaceFlag,
 				updateFlag,
 				linkFlag,
+				parallelFlag,
+				batchSizeFlag,
 			},
 			Action: func(c *cli.Context) error {
 				return batch(c, "upsert")
@@ -60,10 +60,12 @@ func opQuery(c *cli.Context) error {
 
 	verbose := c.IsSet("verbose")
 	lines := c.Bool("lines")
+	output := outputEnabled(c)
+	items := []interface{}{}
 
 	buf := jsonBuffer{}
 	if verbose {
-		buf.bufferOpen(ngsi.StdWriter)
+		buf.bufferOpen(jsonWriter(ngsi))
 	}
 
 	for {
@@ -95,7 +97,7 @@ func opQuery(c *cli.Context) error {
 			return &ngsiCmdError{funcName, 4, err.Error(), err}
 		}
 
-		res, body, err := client.HTTPPost(b)
+		res, body, err := client.HTTPPostIdempotent(b)
 		if err != nil {
 			return &ngsiCmdError{funcName, 6, err.Error(), err}
 		}
@@ -127,7 +129,14 @@ func opQuery(c *cli.Context) error {
 			}
 		}
 
-		if lines {
+		if output {
+			var values []interface{}
+			err = ngsilib.JSONUnmarshal(body, &values)
+			if err != nil {
+				return &ngsiCmdError{funcName, 16, err.Error(), err}
+			}
+			items = append(items, values...)
+		} else if lines {
 			if c.IsSet("values") {
 				var values [][]interface{}
 				err = ngsilib.JSONUnmarshal(body, &values)
@@ -175,7 +184,11 @@ func opQuery(c *cli.Context) error {
 		}
 	}
 
-	if verbose {
+	if output {
+		if err := outputPrint(c, ngsi, items); err != nil {
+			return &ngsiCmdError{funcName, 17, err.Error(), err}
+		}
+	} else if verbose {
 		buf.bufferClose()
 	}
 	return nil
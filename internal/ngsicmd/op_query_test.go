@@ -611,3 +611,87 @@ func TestOpQueryErrorUnmarshal(t *testing.T) {
 		t.FailNow()
 	}
 }
+
+func TestOpQueryOutput(t *testing.T) {
+	ngsi, set, app, buf := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusOK
+	reqRes.Path = "/v2/op/query"
+	reqRes.ResHeader = http.Header{"Fiware-Total-Count": []string{"2"}}
+	reqRes.ResBody = []byte(`[{"id":"Room1","type":"Room","temperature":{"type":"Number","value":21.5,"metadata":{}}},{"id":"Room2","type":"Room","temperature":{"type":"Number","value":22,"metadata":{}}}]`)
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	setupFlagString(set, "host,data,output,select")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", "--data={\"entities\":[{\"idPattern\":\".*\",\"type\":\"Room\"}]}", "--output=csv", "--select=id,temperature.value"})
+	err := opQuery(c)
+
+	if assert.NoError(t, err) {
+		actual := buf.String()
+		expected := "id,temperature.value\nRoom1,21.5\nRoom2,22\n"
+		assert.Equal(t, expected, actual)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestOpQueryErrorOutputUnmarshal(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusOK
+	reqRes.Path = "/v2/op/query"
+	reqRes.ResHeader = http.Header{"Fiware-Total-Count": []string{"2"}}
+	reqRes.ResBody = []byte(`{"id":"Room1"}`)
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	setupFlagString(set, "host,data,output,select")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", "--data={\"entities\":[{\"idPattern\":\".*\",\"type\":\"Room\"}]}", "--output=json"})
+	err := opQuery(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 16, ngsiErr.ErrNo)
+		assert.Equal(t, "json: cannot unmarshal object into Go value of type []interface {}", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestOpQueryErrorOutput(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusOK
+	reqRes.Path = "/v2/op/query"
+	reqRes.ResHeader = http.Header{"Fiware-Total-Count": []string{"2"}}
+	reqRes.ResBody = []byte(`[{"id":"Room1","type":"Room","temperature":{"type":"Number","value":21.5,"metadata":{}}},{"id":"Room2","type":"Room","temperature":{"type":"Number","value":22,"metadata":{}}}]`)
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	setupFlagString(set, "host,data,output,select")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", "--data={\"entities\":[{\"idPattern\":\".*\",\"type\":\"Room\"}]}", "--output=xml"})
+	err := opQuery(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 17, ngsiErr.ErrNo)
+		assert.Equal(t, "output format error: xml (table, json, ndjson, yaml, csv)", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
@@ -73,47 +73,68 @@ func opUpdate(c *cli.Context, ngsi *ngsilib.NGSI, client *ngsilib.Client, action
 			lines = true
 		}
 	}
-	var entities []interface{}
+	size := batchSize(c, ngsi, client)
 
-	for dec.More() {
-		entity := make(map[string]interface{})
-		err := dec.Decode(&entity)
-		if err != nil {
-			if err, ok := err.(*json.SyntaxError); ok {
-				return &ngsiCmdError{funcName, 4, fmt.Sprintf("%s (%d)", err.Error(), err.Offset), err}
-			}
-			return &ngsiCmdError{funcName, 5, err.Error(), err}
-		}
-		entities = append(entities, entity)
-
-		if len(entities) >= 100 {
-			res, body, err := client.OpUpdate(entities, actionType, keyValues, safeStirng)
+	next := func() (interface{}, bool, error) {
+		var entities []interface{}
+		for dec.More() {
+			entity := make(map[string]interface{})
+			err := dec.Decode(&entity)
 			if err != nil {
-				return &ngsiCmdError{funcName, 6, err.Error(), err}
+				if err, ok := err.(*json.SyntaxError); ok {
+					return nil, false, &ngsiCmdError{funcName, 4, fmt.Sprintf("%s (%d)", err.Error(), err.Offset), err}
+				}
+				return nil, false, &ngsiCmdError{funcName, 5, err.Error(), err}
 			}
-			if res.StatusCode != http.StatusNoContent {
-				return &ngsiCmdError{funcName, 7, fmt.Sprintf("%s %s", res.Status, string(body)), nil}
+			entities = append(entities, entity)
+
+			if len(entities) >= size {
+				break
 			}
-			entities = nil
 		}
+		return entities, len(entities) > 0, nil
 	}
 
-	if len(entities) > 0 {
-		res, body, err := client.OpUpdate(entities, actionType, keyValues, safeStirng)
-		if err != nil {
-			return &ngsiCmdError{funcName, 8, err.Error(), err}
-		}
-		if res.StatusCode != http.StatusNoContent {
-			return &ngsiCmdError{funcName, 9, fmt.Sprintf("%s %s", res.Status, string(body)), nil}
+	job := func(v interface{}) (interface{}, error) {
+		if err := opUpdateBatch(ngsi, client.Clone(), v.([]interface{}), actionType, keyValues, safeStirng, size); err != nil {
+			return nil, &ngsiCmdError{funcName, 6, err.Error(), err}
 		}
+		return nil, nil
+	}
+
+	done := func(interface{}) error { return nil }
+
+	if err := parallelRun(parallelWorkers(c), next, job, done); err != nil {
+		return err
 	}
 
 	if !lines {
 		t, err = dec.Token()
 		if err != nil {
-			return &ngsiCmdError{funcName, 10, err.Error(), err}
+			return &ngsiCmdError{funcName, 7, err.Error(), err}
 		}
 	}
 
 	return nil
 }
+
+// opUpdateBatch sends entities with /op/update in batches of size entities. A batch is split
+// when the payload is too large.
+func opUpdateBatch(ngsi *ngsilib.NGSI, client *ngsilib.Client, entities []interface{}, actionType string, keyValues, safeString bool, size int) error {
+	const funcName = "opUpdateBatch"
+
+	return batchRun(len(entities), size, func(start, end int) (bool, error) {
+		res, body, err := client.OpUpdate(entities[start:end], actionType, keyValues, safeString)
+		if err != nil {
+			return false, &ngsiCmdError{funcName, 1, err.Error(), err}
+		}
+		if res.StatusCode == http.StatusRequestEntityTooLarge {
+			ngsi.Logging(ngsilib.LogInfo, fmt.Sprintf("%s, split %d entities\n", res.Status, end-start))
+			return true, nil
+		}
+		if res.StatusCode != http.StatusNoContent {
+			return false, &ngsiCmdError{funcName, 2, fmt.Sprintf("%s %s", res.Status, string(body)), nil}
+		}
+		return false, nil
+	})
+}
@@ -88,6 +88,95 @@ func TestOpUpdateArrayDataOver100(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestOpUpdateArrayDataParallel(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+
+	testData := "["
+	for i := 0; i < 250; i++ {
+		testData = testData + fmt.Sprintf("{\"id\":\"urn:ngsi-ld:Product:%d\",\"type\":\"Product\"},", i)
+	}
+	testData = testData[:len(testData)-1] + "]"
+	setupFlagString(set, "host,data,link,parallel")
+	mock := NewMockHTTP()
+	for i := 0; i < 3; i++ {
+		reqRes := MockHTTPReqRes{}
+		reqRes.Res.StatusCode = http.StatusNoContent
+		reqRes.Path = "/v2/op/update"
+		mock.ReqRes = append(mock.ReqRes, reqRes)
+	}
+	ngsi.HTTP = mock
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", "--parallel=3", "--data=" + testData})
+	client, _ := newClient(ngsi, c, false)
+	err := opUpdate(c, ngsi, client, "append_strict")
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, 3, mock.index)
+	}
+}
+
+func TestOpUpdateArrayDataBatchSize(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+
+	setupFlagString(set, "host,data,link,batchSize")
+	reqRes1 := MockHTTPReqRes{}
+	reqRes1.Res.StatusCode = http.StatusNoContent
+	reqRes1.ReqData = []byte(`{"actionType":"append_strict","entities":[{"id":"E1","type":"T"},{"id":"E2","type":"T"}]}`)
+	reqRes1.Path = "/v2/op/update"
+	reqRes2 := MockHTTPReqRes{}
+	reqRes2.Res.StatusCode = http.StatusNoContent
+	reqRes2.ReqData = []byte(`{"actionType":"append_strict","entities":[{"id":"E3","type":"T"}]}`)
+	reqRes2.Path = "/v2/op/update"
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes1)
+	mock.ReqRes = append(mock.ReqRes, reqRes2)
+	ngsi.HTTP = mock
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", "--batchSize=2", `--data=[{"id":"E1","type":"T"},{"id":"E2","type":"T"},{"id":"E3","type":"T"}]`})
+	client, _ := newClient(ngsi, c, false)
+	err := opUpdate(c, ngsi, client, "append_strict")
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, 2, mock.index)
+	}
+}
+
+func TestOpUpdateArrayDataTooLarge(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+
+	setupFlagString(set, "host,data,link")
+	reqRes1 := MockHTTPReqRes{}
+	reqRes1.Res.StatusCode = http.StatusRequestEntityTooLarge
+	reqRes1.Path = "/v2/op/update"
+	reqRes2 := MockHTTPReqRes{}
+	reqRes2.Res.StatusCode = http.StatusNoContent
+	reqRes2.ReqData = []byte(`{"actionType":"append_strict","entities":[{"id":"E1","type":"T"}]}`)
+	reqRes2.Path = "/v2/op/update"
+	reqRes3 := MockHTTPReqRes{}
+	reqRes3.Res.StatusCode = http.StatusNoContent
+	reqRes3.ReqData = []byte(`{"actionType":"append_strict","entities":[{"id":"E2","type":"T"},{"id":"E3","type":"T"}]}`)
+	reqRes3.Path = "/v2/op/update"
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes1)
+	mock.ReqRes = append(mock.ReqRes, reqRes2)
+	mock.ReqRes = append(mock.ReqRes, reqRes3)
+	ngsi.HTTP = mock
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", `--data=[{"id":"E1","type":"T"},{"id":"E2","type":"T"},{"id":"E3","type":"T"}]`})
+	client, _ := newClient(ngsi, c, false)
+	err := opUpdate(c, ngsi, client, "append_strict")
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, 3, mock.index)
+	}
+}
+
 func TestOpUpdateLineData(t *testing.T) {
 	ngsi, set, app, _ := setupTest()
 
@@ -292,7 +381,7 @@ func TestOpUpdateArrayErrorHTTP2StatusCode(t *testing.T) {
 
 	if assert.Error(t, err) {
 		ngsiErr := err.(*ngsiCmdError)
-		assert.Equal(t, 7, ngsiErr.ErrNo)
+		assert.Equal(t, 6, ngsiErr.ErrNo)
 		assert.Equal(t, " ", ngsiErr.Message)
 	} else {
 		t.FailNow()
@@ -318,7 +407,7 @@ func TestOpUpdateErrorHTTP(t *testing.T) {
 
 	if assert.Error(t, err) {
 		ngsiErr := err.(*ngsiCmdError)
-		assert.Equal(t, 8, ngsiErr.ErrNo)
+		assert.Equal(t, 6, ngsiErr.ErrNo)
 		assert.Equal(t, "url error", ngsiErr.Message)
 	} else {
 		t.FailNow()
@@ -344,7 +433,7 @@ func TestOpUpdateErrorHTTPStatus(t *testing.T) {
 
 	if assert.Error(t, err) {
 		ngsiErr := err.(*ngsiCmdError)
-		assert.Equal(t, 9, ngsiErr.ErrNo)
+		assert.Equal(t, 6, ngsiErr.ErrNo)
 	} else {
 		t.FailNow()
 	}
@@ -369,7 +458,7 @@ func TestOpUpdateArrayDataError(t *testing.T) {
 
 	if assert.Error(t, err) {
 		ngsiErr := err.(*ngsiCmdError)
-		assert.Equal(t, 10, ngsiErr.ErrNo)
+		assert.Equal(t, 7, ngsiErr.ErrNo)
 		assert.Equal(t, "EOF", ngsiErr.Message)
 	} else {
 		t.FailNow()
@@ -0,0 +1,440 @@
+/*
+MIT License
+
+Copyright (c) 2020 Kazuhito Suda
+
+This file is part of NGSI Go
+
+https://github.com/lets-fiware/ngsi-go
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+package ngsicmd
+
+import (
+	"encoding/csv"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/lets-fiware/ngsi-go/internal/ngsilib"
+	"github.com/urfave/cli/v2"
+	"gopkg.in/yaml.v2"
+)
+
+var outputFormats = []string{"table", "json", "ndjson", "yaml", "csv"}
+
+const (
+	outputKey = iota
+	outputIndex
+	outputWildcard
+)
+
+type outputSegment struct {
+	kind  int
+	key   string
+	index int
+}
+
+// outputPath is a path of --select. A path which starts with $ is evaluated against the whole
+// result and the others are evaluated against each item of a list.
+type outputPath struct {
+	name     string
+	root     bool
+	segments []outputSegment
+}
+
+// outputEnabled returns true when --output or --select is specified.
+func outputEnabled(c *cli.Context) bool {
+	return c.IsSet("output") || c.IsSet("select")
+}
+
+// outputEntitiesCSV returns true when entities are printed with the csv writer of entities, which
+// writes attributes as typed columns. It is used for --output csv without --select.
+func outputEntitiesCSV(c *cli.Context) bool {
+	return c.IsSet("output") && strings.ToLower(c.String("output")) == "csv" && !c.IsSet("select")
+}
+
+// outputPrint prints v in the format of --output after selecting values with --select.
+// v is JSON bytes or a value which can be marshaled to JSON.
+func outputPrint(c *cli.Context, ngsi *ngsilib.NGSI, v interface{}) error {
+	const funcName = "outputPrint"
+
+	w := ngsi.StdWriter
+
+	format := "json"
+	if c.IsSet("output") {
+		format = strings.ToLower(c.String("output"))
+		if !ngsilib.Contains(outputFormats, format) {
+			return &ngsiCmdError{funcName, 1, fmt.Sprintf("output format error: %s (%s)", c.String("output"), strings.Join(outputFormats, ", ")), nil}
+		}
+	}
+
+	doc, err := outputNormalize(v)
+	if err != nil {
+		return &ngsiCmdError{funcName, 2, err.Error(), err}
+	}
+
+	header := "value"
+	var columns []string
+	if c.IsSet("select") {
+		paths, err := outputParseSelect(c.String("select"))
+		if err != nil {
+			return &ngsiCmdError{funcName, 3, err.Error(), err}
+		}
+		doc = outputSelect(doc, paths)
+		if len(paths) == 1 {
+			header = paths[0].name
+		} else {
+			for _, path := range paths {
+				columns = append(columns, path.name)
+			}
+		}
+	}
+
+	switch format {
+	case "json":
+		b, err := ngsilib.JSONMarshal(doc)
+		if err != nil {
+			return &ngsiCmdError{funcName, 4, err.Error(), err}
+		}
+		printJSON(ngsi, b)
+	case "ndjson":
+		items, ok := doc.([]interface{})
+		if !ok {
+			items = []interface{}{doc}
+		}
+		for _, item := range items {
+			b, err := ngsilib.JSONMarshal(item)
+			if err != nil {
+				return &ngsiCmdError{funcName, 5, err.Error(), err}
+			}
+			fmt.Fprintln(w, string(b))
+		}
+	case "yaml":
+		b, err := yaml.Marshal(doc)
+		if err != nil {
+			return &ngsiCmdError{funcName, 6, err.Error(), err}
+		}
+		fmt.Fprint(w, string(b))
+	case "csv":
+		columns, rows := outputRows(doc, header, columns)
+		cw := csv.NewWriter(w)
+		_ = cw.Write(columns)
+		_ = cw.WriteAll(rows)
+		if err := cw.Error(); err != nil {
+			return &ngsiCmdError{funcName, 7, err.Error(), err}
+		}
+	case "table":
+		columns, rows := outputRows(doc, header, columns)
+		tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+		for i := range columns {
+			columns[i] = strings.ToUpper(columns[i])
+		}
+		fmt.Fprintln(tw, strings.Join(columns, "\t"))
+		for _, row := range rows {
+			fmt.Fprintln(tw, strings.Join(row, "\t"))
+		}
+		if err := tw.Flush(); err != nil {
+			return &ngsiCmdError{funcName, 8, err.Error(), err}
+		}
+	}
+
+	return nil
+}
+
+// outputNormalize converts v into a value of generic JSON types such as map[string]interface{}.
+func outputNormalize(v interface{}) (interface{}, error) {
+	const funcName = "outputNormalize"
+
+	b, ok := v.([]byte)
+	if !ok {
+		var err error
+		b, err = ngsilib.JSONMarshal(v)
+		if err != nil {
+			return nil, &ngsiCmdError{funcName, 1, err.Error(), err}
+		}
+	}
+
+	var doc interface{}
+	if err := ngsilib.JSONUnmarshal(b, &doc); err != nil {
+		return nil, &ngsiCmdError{funcName, 2, err.Error(), err}
+	}
+
+	return doc, nil
+}
+
+// outputParseSelect parses comma-separated paths such as id,temperature.value or $[0].id.
+func outputParseSelect(expr string) ([]outputPath, error) {
+	const funcName = "outputParseSelect"
+
+	var paths []outputPath
+
+	depth, start := 0, 0
+	for i := 0; i <= len(expr); i++ {
+		if i < len(expr) {
+			switch expr[i] {
+			case '[':
+				depth++
+				continue
+			case ']':
+				depth--
+				continue
+			case ',':
+				if depth > 0 {
+					continue
+				}
+			default:
+				continue
+			}
+		}
+		path, err := outputParsePath(strings.TrimSpace(expr[start:i]))
+		if err != nil {
+			return nil, &ngsiCmdError{funcName, 1, err.Error(), err}
+		}
+		paths = append(paths, path)
+		start = i + 1
+	}
+
+	root := paths[0].root
+	for _, path := range paths {
+		if path.root != root {
+			return nil, &ngsiCmdError{funcName, 2, "select error: paths with and without $ cannot be mixed", nil}
+		}
+	}
+
+	return paths, nil
+}
+
+func outputParsePath(s string) (outputPath, error) {
+	const funcName = "outputParsePath"
+
+	path := outputPath{name: strings.TrimPrefix(strings.TrimPrefix(s, "$"), ".")}
+	if path.name == "" {
+		path.name = "value"
+	}
+
+	p := s
+	if strings.HasPrefix(p, "$") {
+		path.root = true
+		p = p[1:]
+	} else if s == "" {
+		return path, &ngsiCmdError{funcName, 1, "select error: empty path", nil}
+	}
+
+	for p != "" {
+		switch {
+		case p[0] == '[':
+			end := strings.Index(p, "]")
+			if end < 0 {
+				return path, &ngsiCmdError{funcName, 2, "select error: ] not found: " + s, nil}
+			}
+			in := strings.TrimSpace(p[1:end])
+			p = p[end+1:]
+			if in == "*" {
+				path.segments = append(path.segments, outputSegment{kind: outputWildcard})
+			} else if len(in) >= 2 && (in[0] == '\'' || in[0] == '"') && in[len(in)-1] == in[0] {
+				path.segments = append(path.segments, outputSegment{kind: outputKey, key: in[1 : len(in)-1]})
+			} else if n, err := strconv.Atoi(in); err == nil {
+				path.segments = append(path.segments, outputSegment{kind: outputIndex, index: n})
+			} else {
+				return path, &ngsiCmdError{funcName, 3, "select error: " + s, nil}
+			}
+		default:
+			if p[0] == '.' {
+				p = p[1:]
+			}
+			end := strings.IndexAny(p, ".[")
+			if end < 0 {
+				end = len(p)
+			}
+			key := p[:end]
+			p = p[end:]
+			if key == "" {
+				return path, &ngsiCmdError{funcName, 4, "select error: " + s, nil}
+			}
+			if key == "*" {
+				path.segments = append(path.segments, outputSegment{kind: outputWildcard})
+			} else {
+				path.segments = append(path.segments, outputSegment{kind: outputKey, key: key})
+			}
+		}
+	}
+
+	return path, nil
+}
+
+// outputSelect returns the value of a path, or an object of values keyed by the paths when
+// two or more paths are given.
+func outputSelect(doc interface{}, paths []outputPath) interface{} {
+	item := func(v interface{}) interface{} {
+		if len(paths) == 1 {
+			return outputEval(v, paths[0].segments)
+		}
+		m := make(map[string]interface{})
+		for _, path := range paths {
+			m[path.name] = outputEval(v, path.segments)
+		}
+		return m
+	}
+
+	if list, ok := doc.([]interface{}); ok && !paths[0].root {
+		result := make([]interface{}, len(list))
+		for i, v := range list {
+			result[i] = item(v)
+		}
+		return result
+	}
+
+	return item(doc)
+}
+
+func outputEval(v interface{}, segments []outputSegment) interface{} {
+	if len(segments) == 0 {
+		return v
+	}
+
+	seg, rest := segments[0], segments[1:]
+
+	switch seg.kind {
+	case outputKey:
+		if m, ok := v.(map[string]interface{}); ok {
+			return outputEval(m[seg.key], rest)
+		}
+	case outputIndex:
+		if a, ok := v.([]interface{}); ok {
+			i := seg.index
+			if i < 0 {
+				i += len(a)
+			}
+			if i >= 0 && i < len(a) {
+				return outputEval(a[i], rest)
+			}
+		}
+	case outputWildcard:
+		result := []interface{}{}
+		switch e := v.(type) {
+		case []interface{}:
+			for _, x := range e {
+				result = append(result, outputEval(x, rest))
+			}
+		case map[string]interface{}:
+			keys := make([]string, 0, len(e))
+			for k := range e {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				result = append(result, outputEval(e[k], rest))
+			}
+		}
+		return result
+	}
+
+	return nil
+}
+
+// outputRows returns columns and rows for table and CSV. The columns are order if given, or else
+// the keys of objects with id and type first. A list of values which are not objects has a column
+// named header.
+func outputRows(doc interface{}, header string, order []string) ([]string, [][]string) {
+	list, ok := doc.([]interface{})
+	if !ok {
+		list = []interface{}{doc}
+	}
+
+	seen := make(map[string]bool)
+	keys := []string{}
+	objects := true
+	for _, v := range list {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			objects = false
+			break
+		}
+		for k := range m {
+			if !seen[k] {
+				seen[k] = true
+				keys = append(keys, k)
+			}
+		}
+	}
+
+	if !objects || len(list) == 0 {
+		rows := make([][]string, len(list))
+		for i, v := range list {
+			rows[i] = []string{outputCell(v)}
+		}
+		return []string{header}, rows
+	}
+
+	if len(order) > 0 {
+		keys = order
+	} else {
+		sort.Slice(keys, func(i, j int) bool {
+			rank := func(k string) int {
+				switch k {
+				case "id":
+					return 0
+				case "type":
+					return 1
+				}
+				return 2
+			}
+			if rank(keys[i]) != rank(keys[j]) {
+				return rank(keys[i]) < rank(keys[j])
+			}
+			return keys[i] < keys[j]
+		})
+	}
+
+	rows := make([][]string, len(list))
+	for i, v := range list {
+		m := v.(map[string]interface{})
+		row := make([]string, len(keys))
+		for j, k := range keys {
+			row[j] = outputCell(m[k])
+		}
+		rows[i] = row
+	}
+
+	return keys, rows
+}
+
+func outputCell(v interface{}) string {
+	switch e := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return e
+	case float64:
+		return strconv.FormatFloat(e, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(e)
+	}
+	b, err := ngsilib.JSONMarshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
@@ -0,0 +1,306 @@
+/*
+MIT License
+
+Copyright (c) 2020 Kazuhito Suda
+
+This file is part of NGSI Go
+
+https://github.com/lets-fiware/ngsi-go
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+package ngsicmd
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/cli/v2"
+)
+
+var testOutputEntities = `[{"id":"E1","type":"Room","temperature":{"type":"Number","value":21.5,"metadata":{}}},` +
+	`{"type":"Room","id":"E2","temperature":{"type":"Number","value":22,"metadata":{}},"name":{"type":"Text","value":"a,b","metadata":{}}}]`
+
+func TestOutputEnabled(t *testing.T) {
+	_, set, app, _ := setupTest()
+
+	setupFlagString(set, "output,select")
+
+	c := cli.NewContext(app, set, nil)
+	assert.Equal(t, false, outputEnabled(c))
+
+	_ = set.Parse([]string{"--select=id"})
+	assert.Equal(t, true, outputEnabled(c))
+}
+
+func TestOutputPrint(t *testing.T) {
+	cases := []struct {
+		args     []string
+		expected string
+	}{
+		{
+			args:     []string{},
+			expected: `[{"id":"E1","temperature":{"metadata":{},"type":"Number","value":21.5},"type":"Room"},{"id":"E2","name":{"metadata":{},"type":"Text","value":"a,b"},"temperature":{"metadata":{},"type":"Number","value":22},"type":"Room"}]` + "\n",
+		},
+		{
+			args:     []string{"--output=json", "--select=id"},
+			expected: `["E1","E2"]` + "\n",
+		},
+		{
+			args:     []string{"--output=ndjson", "--select=id,temperature.value"},
+			expected: `{"id":"E1","temperature.value":21.5}` + "\n" + `{"id":"E2","temperature.value":22}` + "\n",
+		},
+		{
+			args:     []string{"--output=yaml", "--select=$[1]['name'].value"},
+			expected: "a,b\n",
+		},
+		{
+			args:     []string{"--output=yaml", "--select=id, name.value"},
+			expected: "- id: E1\n  name.value: null\n- id: E2\n  name.value: a,b\n",
+		},
+		{
+			args:     []string{"--output=csv", "--select=id,name.value,temperature.value"},
+			expected: "id,name.value,temperature.value\nE1,,21.5\nE2,\"a,b\",22\n",
+		},
+		{
+			args:     []string{"--output=CSV", "--select=$[*].id"},
+			expected: "[*].id\nE1\nE2\n",
+		},
+		{
+			args:     []string{"--output=table", "--select=id,temperature.value"},
+			expected: "ID  TEMPERATURE.VALUE\nE1  21.5\nE2  22\n",
+		},
+		{
+			args:     []string{"--output=table", "--select=type"},
+			expected: "TYPE\nRoom\nRoom\n",
+		},
+		{
+			args: []string{"--output=table"},
+			expected: "ID  TYPE  NAME                                         TEMPERATURE\n" +
+				"E1  Room                                               {\"metadata\":{},\"type\":\"Number\",\"value\":21.5}\n" +
+				"E2  Room  {\"metadata\":{},\"type\":\"Text\",\"value\":\"a,b\"}  {\"metadata\":{},\"type\":\"Number\",\"value\":22}\n",
+		},
+	}
+
+	for _, c := range cases {
+		ngsi, set, app, buf := setupTest()
+
+		setupFlagString(set, "output,select")
+		ctx := cli.NewContext(app, set, nil)
+		_ = set.Parse(c.args)
+
+		err := outputPrint(ctx, ngsi, []byte(testOutputEntities))
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, c.expected, buf.String())
+		} else {
+			t.FailNow()
+		}
+	}
+}
+
+func TestOutputPrintPretty(t *testing.T) {
+	ngsi, set, app, buf := setupTest()
+
+	setupFlagString(set, "output,select")
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--output=json", "--select=id"})
+	ngsi.GetPreviousArgs().Pretty = true
+
+	err := outputPrint(c, ngsi, []byte(testOutputEntities))
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, "[\n  \"E1\",\n  \"E2\"\n]\n", buf.String())
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestOutputPrintValue(t *testing.T) {
+	ngsi, set, app, buf := setupTest()
+
+	setupFlagString(set, "output,select")
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--output=table"})
+
+	err := outputPrint(c, ngsi, []string{"Room", "Car"})
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, "VALUE\nRoom\nCar\n", buf.String())
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestOutputPrintErrorFormat(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupFlagString(set, "output,select")
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--output=xml"})
+
+	err := outputPrint(c, ngsi, []byte(testOutputEntities))
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 1, ngsiErr.ErrNo)
+		assert.Equal(t, "output format error: xml (table, json, ndjson, yaml, csv)", ngsiErr.Message)
+	}
+}
+
+func TestOutputPrintErrorNormalize(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupFlagString(set, "output,select")
+	c := cli.NewContext(app, set, nil)
+
+	err := outputPrint(c, ngsi, []byte("{"))
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 2, ngsiErr.ErrNo)
+		assert.Equal(t, "unexpected EOF", ngsiErr.Message)
+	}
+}
+
+func TestOutputPrintErrorSelect(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupFlagString(set, "output,select")
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--select=id,$[0]"})
+
+	err := outputPrint(c, ngsi, []byte(testOutputEntities))
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 3, ngsiErr.ErrNo)
+		assert.Equal(t, "select error: paths with and without $ cannot be mixed", ngsiErr.Message)
+	}
+}
+
+func TestOutputPrintErrorJSON(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupFlagString(set, "output,select")
+	c := cli.NewContext(app, set, nil)
+	ngsi.JSONConverter = &MockJSONLib{EncodeErr: errors.New("json error"), Jsonlib: ngsi.JSONConverter}
+
+	err := outputPrint(c, ngsi, []byte(testOutputEntities))
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 4, ngsiErr.ErrNo)
+		assert.Equal(t, "json error", ngsiErr.Message)
+	}
+}
+
+func TestOutputPrintErrorNdjson(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupFlagString(set, "output,select")
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--output=ndjson"})
+	ngsi.JSONConverter = &MockJSONLib{EncodeErr: errors.New("json error"), Jsonlib: ngsi.JSONConverter}
+
+	err := outputPrint(c, ngsi, []byte(testOutputEntities))
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 5, ngsiErr.ErrNo)
+		assert.Equal(t, "json error", ngsiErr.Message)
+	}
+}
+
+func TestOutputNormalizeError(t *testing.T) {
+	ngsi, _, _, _ := setupTest()
+
+	ngsi.JSONConverter = &MockJSONLib{EncodeErr: errors.New("json error"), Jsonlib: ngsi.JSONConverter}
+
+	_, err := outputNormalize([]string{"Room"})
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 1, ngsiErr.ErrNo)
+		assert.Equal(t, "json error", ngsiErr.Message)
+	}
+}
+
+func TestOutputParseSelect(t *testing.T) {
+	paths, err := outputParseSelect("$.a[0]['b.c'],$[*].d.*")
+
+	if assert.NoError(t, err) {
+		expected := []outputPath{
+			{name: "a[0]['b.c']", root: true, segments: []outputSegment{{kind: outputKey, key: "a"}, {kind: outputIndex}, {kind: outputKey, key: "b.c"}}},
+			{name: "[*].d.*", root: true, segments: []outputSegment{{kind: outputWildcard}, {kind: outputKey, key: "d"}, {kind: outputWildcard}}},
+		}
+		assert.Equal(t, expected, paths)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestOutputParseSelectError(t *testing.T) {
+	cases := []struct {
+		expr    string
+		errNo   int
+		message string
+	}{
+		{expr: "id,", errNo: 1, message: "select error: empty path"},
+		{expr: "$[0", errNo: 1, message: "select error: ] not found: $[0"},
+		{expr: "a[x]", errNo: 1, message: "select error: a[x]"},
+		{expr: "a..b", errNo: 1, message: "select error: a..b"},
+		{expr: "$,id", errNo: 2, message: "select error: paths with and without $ cannot be mixed"},
+	}
+
+	for _, c := range cases {
+		_, err := outputParseSelect(c.expr)
+
+		if assert.Error(t, err) {
+			ngsiErr := err.(*ngsiCmdError)
+			assert.Equal(t, c.errNo, ngsiErr.ErrNo)
+			assert.Equal(t, c.message, ngsiErr.Message)
+		}
+	}
+}
+
+func TestOutputEval(t *testing.T) {
+	doc := map[string]interface{}{
+		"a": []interface{}{1.0, 2.0, 3.0},
+		"m": map[string]interface{}{"y": 2.0, "x": 1.0},
+	}
+
+	assert.Equal(t, 3.0, outputEval(doc, []outputSegment{{kind: outputKey, key: "a"}, {kind: outputIndex, index: -1}}))
+	assert.Equal(t, nil, outputEval(doc, []outputSegment{{kind: outputKey, key: "a"}, {kind: outputIndex, index: 3}}))
+	assert.Equal(t, []interface{}{1.0, 2.0}, outputEval(doc, []outputSegment{{kind: outputKey, key: "m"}, {kind: outputWildcard}}))
+	assert.Equal(t, nil, outputEval(doc, []outputSegment{{kind: outputKey, key: "m"}, {kind: outputIndex}}))
+	assert.Equal(t, []interface{}{}, outputEval("a", []outputSegment{{kind: outputWildcard}}))
+}
+
+func TestOutputCell(t *testing.T) {
+	_, _, _, _ = setupTest()
+
+	assert.Equal(t, "", outputCell(nil))
+	assert.Equal(t, "true", outputCell(true))
+	assert.Equal(t, "0.1", outputCell(0.1))
+	assert.Equal(t, "[1,2]", outputCell([]interface{}{1.0, 2.0}))
+}
@@ -0,0 +1,127 @@
+/*
+MIT License
+
+Copyright (c) 2020 Kazuhito Suda
+
+This file is part of NGSI Go
+
+https://github.com/lets-fiware/ngsi-go
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+package ngsicmd
+
+import (
+	"github.com/urfave/cli/v2"
+)
+
+const maxParallel = 100
+
+type parallelResult struct {
+	value interface{}
+	err   error
+}
+
+// parallelWorkers returns the number of workers specified by --parallel.
+func parallelWorkers(c *cli.Context) int {
+	n := 1
+	if c.IsSet("parallel") {
+		n = c.Int("parallel")
+	}
+	if n < 1 {
+		n = 1
+	}
+	if n > maxParallel {
+		n = maxParallel
+	}
+	return n
+}
+
+// parallelRun runs job with at most n workers for each input returned by next, which is called
+// sequentially until it returns false. done is called with the results in the order of the inputs.
+// It stops at the first error and returns it after the running jobs end.
+func parallelRun(n int, next func() (interface{}, bool, error), job func(interface{}) (interface{}, error), done func(interface{}) error) error {
+	if n < 1 {
+		n = 1
+	}
+
+	queue := make(chan chan parallelResult, n-1)
+	quit := make(chan struct{})
+	var nextErr error
+
+	go func() {
+		defer close(queue)
+		for {
+			in, ok, err := next()
+			if err != nil {
+				nextErr = err
+				return
+			}
+			if !ok {
+				return
+			}
+			ch := make(chan parallelResult, 1)
+			select {
+			case queue <- ch:
+			case <-quit:
+				return
+			}
+			go func() {
+				v, err := job(in)
+				ch <- parallelResult{v, err}
+			}()
+		}
+	}()
+
+	var err error
+	for ch := range queue {
+		r := <-ch
+		err = r.err
+		if err == nil {
+			err = done(r.value)
+		}
+		if err != nil {
+			break
+		}
+	}
+
+	if err != nil {
+		close(quit)
+		for ch := range queue {
+			<-ch
+		}
+		return err
+	}
+
+	return nextErr
+}
+
+// parallelPages returns next for parallelRun which gives the numbers from start to end - 1.
+func parallelPages(start, end int) func() (interface{}, bool, error) {
+	page := start
+	return func() (interface{}, bool, error) {
+		if page >= end {
+			return nil, false, nil
+		}
+		page++
+		return page - 1, true, nil
+	}
+}
@@ -0,0 +1,181 @@
+/*
+MIT License
+
+Copyright (c) 2020 Kazuhito Suda
+
+This file is part of NGSI Go
+
+https://github.com/lets-fiware/ngsi-go
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+package ngsicmd
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/cli/v2"
+)
+
+func TestParallelWorkers(t *testing.T) {
+	_, set, app, _ := setupTest()
+
+	setupFlagString(set, "parallel")
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--parallel=8"})
+
+	assert.Equal(t, 8, parallelWorkers(c))
+}
+
+func TestParallelWorkersDefault(t *testing.T) {
+	_, set, app, _ := setupTest()
+
+	c := cli.NewContext(app, set, nil)
+
+	assert.Equal(t, 1, parallelWorkers(c))
+}
+
+func TestParallelWorkersRange(t *testing.T) {
+	_, set, app, _ := setupTest()
+
+	setupFlagString(set, "parallel")
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--parallel=1000"})
+	assert.Equal(t, 100, parallelWorkers(c))
+
+	_ = set.Parse([]string{"--parallel=0"})
+	assert.Equal(t, 1, parallelWorkers(c))
+}
+
+func TestParallelRun(t *testing.T) {
+	var mutex sync.Mutex
+	running := 0
+	max := 0
+
+	job := func(v interface{}) (interface{}, error) {
+		mutex.Lock()
+		running++
+		if running > max {
+			max = running
+		}
+		mutex.Unlock()
+
+		time.Sleep(time.Duration(10-v.(int)) * time.Millisecond)
+
+		mutex.Lock()
+		running--
+		mutex.Unlock()
+		return v.(int) * 10, nil
+	}
+
+	actual := []int{}
+	done := func(v interface{}) error {
+		actual = append(actual, v.(int))
+		return nil
+	}
+
+	err := parallelRun(3, parallelPages(0, 10), job, done)
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, []int{0, 10, 20, 30, 40, 50, 60, 70, 80, 90}, actual)
+		assert.True(t, max <= 3)
+	}
+}
+
+func TestParallelRunSequential(t *testing.T) {
+	actual := []int{}
+	job := func(v interface{}) (interface{}, error) { return v, nil }
+	done := func(v interface{}) error {
+		actual = append(actual, v.(int))
+		return nil
+	}
+
+	err := parallelRun(0, parallelPages(1, 4), job, done)
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, []int{1, 2, 3}, actual)
+	}
+}
+
+func TestParallelRunErrorJob(t *testing.T) {
+	actual := []int{}
+	job := func(v interface{}) (interface{}, error) {
+		if v.(int) == 3 {
+			return nil, errors.New("job error")
+		}
+		return v, nil
+	}
+	done := func(v interface{}) error {
+		actual = append(actual, v.(int))
+		return nil
+	}
+
+	err := parallelRun(4, parallelPages(0, 100), job, done)
+
+	if assert.Error(t, err) {
+		assert.Equal(t, "job error", err.Error())
+		assert.Equal(t, []int{0, 1, 2}, actual)
+	}
+}
+
+func TestParallelRunErrorDone(t *testing.T) {
+	job := func(v interface{}) (interface{}, error) { return v, nil }
+	done := func(v interface{}) error {
+		if v.(int) == 5 {
+			return errors.New("done error")
+		}
+		return nil
+	}
+
+	err := parallelRun(2, parallelPages(0, 100), job, done)
+
+	if assert.Error(t, err) {
+		assert.Equal(t, "done error", err.Error())
+	}
+}
+
+func TestParallelRunErrorNext(t *testing.T) {
+	i := 0
+	next := func() (interface{}, bool, error) {
+		if i == 2 {
+			return nil, false, errors.New("next error")
+		}
+		i++
+		return i, true, nil
+	}
+	actual := []int{}
+	job := func(v interface{}) (interface{}, error) { return v, nil }
+	done := func(v interface{}) error {
+		actual = append(actual, v.(int))
+		return nil
+	}
+
+	err := parallelRun(2, next, job, done)
+
+	if assert.Error(t, err) {
+		assert.Equal(t, "next error", err.Error())
+		assert.Equal(t, []int{1, 2}, actual)
+	}
+}
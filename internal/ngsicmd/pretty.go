@@ -0,0 +1,143 @@
+/*
+MIT License
+
+Copyright (c) 2020 Kazuhito Suda
+
+This file is part of NGSI Go
+
+https://github.com/lets-fiware/ngsi-go
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+package ngsicmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/lets-fiware/ngsi-go/internal/ngsilib"
+)
+
+// prettyWriter indents JSON written to it in the same way as json.Indent with two spaces.
+// It keeps only the state of the current position, so a long JSON stream is indented
+// without being buffered.
+type prettyWriter struct {
+	writer   io.Writer
+	depth    int
+	inString bool
+	escape   bool
+	opened   bool
+}
+
+func newPrettyWriter(w io.Writer) *prettyWriter {
+	return &prettyWriter{writer: w}
+}
+
+func (p *prettyWriter) newline(out []byte) []byte {
+	out = append(out, '\n')
+	return append(out, strings.Repeat("  ", p.depth)...)
+}
+
+func (p *prettyWriter) Write(b []byte) (int, error) {
+	out := make([]byte, 0, len(b)+len(b)/4)
+
+	for _, ch := range b {
+		if p.inString {
+			out = append(out, ch)
+			if p.escape {
+				p.escape = false
+			} else if ch == '\\' {
+				p.escape = true
+			} else if ch == '"' {
+				p.inString = false
+			}
+			continue
+		}
+
+		switch ch {
+		case ' ', '\t', '\r', '\n':
+			continue
+		}
+
+		if p.opened {
+			p.opened = false
+			if ch == '}' || ch == ']' {
+				p.depth--
+				out = append(out, ch)
+				continue
+			}
+			out = p.newline(out)
+		}
+
+		switch ch {
+		case '{', '[':
+			out = append(out, ch)
+			p.depth++
+			p.opened = true
+		case '}', ']':
+			p.depth--
+			out = p.newline(out)
+			out = append(out, ch)
+		case ',':
+			out = append(out, ch)
+			out = p.newline(out)
+		case ':':
+			out = append(out, ':', ' ')
+		case '"':
+			out = append(out, ch)
+			p.inString = true
+		default:
+			out = append(out, ch)
+		}
+	}
+
+	if _, err := p.writer.Write(out); err != nil {
+		return 0, err
+	}
+
+	return len(b), nil
+}
+
+// isPretty returns true when JSON responses are indented by --pretty or the pretty setting.
+func isPretty(ngsi *ngsilib.NGSI) bool {
+	d := ngsi.GetPreviousArgs()
+	return d != nil && d.Pretty
+}
+
+// jsonWriter returns a writer for JSON responses.
+func jsonWriter(ngsi *ngsilib.NGSI) io.Writer {
+	if isPretty(ngsi) {
+		return newPrettyWriter(ngsi.StdWriter)
+	}
+	return ngsi.StdWriter
+}
+
+// printJSON prints a JSON response with a newline. The response is printed as it is when it is not JSON.
+func printJSON(ngsi *ngsilib.NGSI, b []byte) {
+	if isPretty(ngsi) && json.Valid(b) {
+		_, _ = newPrettyWriter(ngsi.StdWriter).Write(b)
+		fmt.Fprintln(ngsi.StdWriter)
+		return
+	}
+	fmt.Fprintln(ngsi.StdWriter, string(b))
+}
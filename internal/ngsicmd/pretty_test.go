@@ -0,0 +1,111 @@
+/*
+MIT License
+
+Copyright (c) 2020 Kazuhito Suda
+
+This file is part of NGSI Go
+
+https://github.com/lets-fiware/ngsi-go
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+package ngsicmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testPrettyJSON = []string{
+	`{"id":"urn:ngsi-ld:Product:010","type":"Product","name":{"type":"Text","value":"Lemonade","metadata":{}},"price":{"type":"Integer","value":99}}`,
+	`[ {"a" : [1, 2, [ ]], "b": { }, "c": "x,y:{z}", "d": "\"q\\\\"}, null, true ]`,
+	`"string"`,
+	`[]`,
+}
+
+func TestPrettyWriter(t *testing.T) {
+	for _, s := range testPrettyJSON {
+		expected := &bytes.Buffer{}
+		_ = json.Indent(expected, []byte(s), "", "  ")
+
+		actual := &bytes.Buffer{}
+		n, err := newPrettyWriter(actual).Write([]byte(s))
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, len(s), n)
+			assert.Equal(t, expected.String(), actual.String())
+		} else {
+			t.FailNow()
+		}
+	}
+}
+
+func TestPrettyWriterStream(t *testing.T) {
+	for _, s := range testPrettyJSON {
+		expected := &bytes.Buffer{}
+		_ = json.Indent(expected, []byte(s), "", "  ")
+
+		actual := &bytes.Buffer{}
+		w := newPrettyWriter(actual)
+		for i := 0; i < len(s); i++ {
+			_, _ = w.Write([]byte{s[i]})
+		}
+
+		assert.Equal(t, expected.String(), actual.String())
+	}
+}
+
+func TestPrettyWriterError(t *testing.T) {
+	w := newPrettyWriter(&MockWriter{Err: errors.New("write error")})
+
+	n, err := w.Write([]byte(`{}`))
+
+	if assert.Error(t, err) {
+		assert.Equal(t, 0, n)
+		assert.Equal(t, "write error", err.Error())
+	}
+}
+
+func TestJSONWriter(t *testing.T) {
+	ngsi, _, _, buf := setupTest()
+
+	assert.Equal(t, ngsi.StdWriter, jsonWriter(ngsi))
+
+	ngsi.GetPreviousArgs().Pretty = true
+	_, _ = jsonWriter(ngsi).Write([]byte(`{"id":"E1"}`))
+
+	assert.Equal(t, "{\n  \"id\": \"E1\"\n}", buf.String())
+}
+
+func TestPrintJSON(t *testing.T) {
+	ngsi, _, _, buf := setupTest()
+
+	printJSON(ngsi, []byte(`{"id":"E1"}`))
+	ngsi.GetPreviousArgs().Pretty = true
+	printJSON(ngsi, []byte(`{"id":"E1"}`))
+	printJSON(ngsi, []byte(`Lemonade is, good`))
+
+	assert.Equal(t, "{\"id\":\"E1\"}\n{\n  \"id\": \"E1\"\n}\nLemonade is, good\n", buf.String())
+}
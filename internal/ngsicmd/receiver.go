@@ -0,0 +1,324 @@
+/*
+MIT License
+
+Copyright (c) 2020 Kazuhito Suda
+
+This file is part of NGSI Go
+
+https://github.com/lets-fiware/ngsi-go
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+package ngsicmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/lets-fiware/ngsi-go/internal/ngsilib"
+	"github.com/urfave/cli/v2"
+)
+
+type notificationReceiver struct {
+	ngsi           *ngsilib.NGSI
+	pretty         bool
+	header         bool
+	safeString     bool
+	subscriptionID string
+	mutex          sync.Mutex
+}
+
+type notificationMessage struct {
+	SubscriptionID string `json:"subscriptionId"`
+}
+
+func receiver(c *cli.Context) error {
+	const funcName = "receiver"
+
+	subscribe := c.Bool("subscribe")
+
+	ngsi, err := initCmd(c, funcName, subscribe)
+	if err != nil {
+		return &ngsiCmdError{funcName, 1, err.Error(), err}
+	}
+
+	port := c.Int64("port")
+	if port < 1 || port > 65535 {
+		return &ngsiCmdError{funcName, 2, fmt.Sprintf("port error: %d", port), nil}
+	}
+
+	safeString, err := ngsi.BoolFlag(c.String("safeString"))
+	if err != nil {
+		return &ngsiCmdError{funcName, 3, err.Error(), err}
+	}
+
+	r := &notificationReceiver{
+		ngsi:           ngsi,
+		pretty:         c.Bool("pretty") || isPretty(ngsi),
+		header:         c.Bool("header"),
+		safeString:     safeString,
+		subscriptionID: c.String("subscriptionId"),
+	}
+
+	var client *ngsilib.Client
+	id := ""
+
+	if subscribe {
+		client, err = newClient(ngsi, c, false)
+		if err != nil {
+			return &ngsiCmdError{funcName, 4, err.Error(), err}
+		}
+	}
+
+	// the port is bound before subscribing, as a broker sends the first notification
+	// as soon as the subscription is created
+	if err := ngsi.HTTPServer.Listen(fmt.Sprintf(":%d", port)); err != nil {
+		return &ngsiCmdError{funcName, 5, err.Error(), err}
+	}
+
+	ngsi.Logging(ngsilib.LogInfo, fmt.Sprintf("receiver is listening on port %d\n", port))
+
+	if subscribe {
+		notifyURL := c.String("url")
+		if notifyURL == "" {
+			notifyURL = fmt.Sprintf("http://localhost:%d/", port)
+		}
+
+		id, err = receiverSubscribe(c, client, notifyURL)
+		if err != nil {
+			_ = ngsi.HTTPServer.Shutdown()
+			return &ngsiCmdError{funcName, 6, err.Error(), err}
+		}
+		if r.subscriptionID == "" {
+			r.subscriptionID = id
+		}
+		ngsi.Logging(ngsilib.LogInfo, fmt.Sprintf("subscription %s is created\n", id))
+	}
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sig)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- ngsi.HTTPServer.Serve(r)
+	}()
+
+	select {
+	case err = <-done:
+	case <-sig:
+		err = ngsi.HTTPServer.Shutdown()
+	}
+
+	var errUnsubscribe error
+	if id != "" {
+		errUnsubscribe = receiverUnsubscribe(client, id)
+		if errUnsubscribe == nil {
+			ngsi.Logging(ngsilib.LogInfo, fmt.Sprintf("subscription %s is deleted\n", id))
+		}
+	}
+
+	if err != nil {
+		return &ngsiCmdError{funcName, 7, err.Error(), err}
+	}
+	if errUnsubscribe != nil {
+		return &ngsiCmdError{funcName, 8, errUnsubscribe.Error(), errUnsubscribe}
+	}
+
+	return nil
+}
+
+func receiverSubscribe(c *cli.Context, client *ngsilib.Client, notifyURL string) (string, error) {
+	const funcName = "receiverSubscribe"
+
+	idPattern := ".*"
+	if c.IsSet("idPattern") {
+		idPattern = c.String("idPattern")
+	}
+
+	var attrs, wAttrs []string
+	if c.IsSet("nAttrs") {
+		attrs = strings.Split(c.String("nAttrs"), ",")
+	}
+	if c.IsSet("wAttrs") {
+		wAttrs = strings.Split(c.String("wAttrs"), ",")
+	}
+
+	var sub interface{}
+
+	if client.IsNgsiLd() {
+		if !c.IsSet("type") {
+			return "", &ngsiCmdError{funcName, 1, "type is required", nil}
+		}
+		s := map[string]interface{}{
+			"type":        "Subscription",
+			"description": "ngsi receiver",
+			"entities":    []map[string]string{{"idPattern": idPattern, "type": c.String("type")}},
+			"notification": map[string]interface{}{
+				"format":   "normalized",
+				"endpoint": map[string]string{"uri": notifyURL, "accept": "application/json"},
+			},
+		}
+		if wAttrs != nil {
+			s["watchedAttributes"] = wAttrs
+		}
+		if attrs != nil {
+			s["notification"].(map[string]interface{})["attributes"] = attrs
+		}
+		if c.IsSet("query") {
+			s["q"] = c.String("query")
+		}
+		sub = s
+	} else {
+		s := &subscriptionV2{
+			Description: "ngsi receiver",
+			Subject: &subscriptionSubjectV2{
+				Entities: []subscriptionEntityV2{{IDPattern: idPattern, Type: c.String("type")}},
+			},
+			Notification: &subscriptionNotificationV2{
+				HTTP:  &subscriptionHTTPV2{URL: notifyURL},
+				Attrs: attrs,
+			},
+		}
+		if wAttrs != nil || c.IsSet("query") {
+			s.Subject.Condition = &subscriptionConditionV2{Attrs: wAttrs}
+			if c.IsSet("query") {
+				s.Subject.Condition.Expression = &subscriptionExpressionV2{Q: c.String("query")}
+			}
+		}
+		sub = s
+	}
+
+	b, err := ngsilib.JSONMarshalEncode(sub, client.IsSafeString())
+	if err != nil {
+		return "", &ngsiCmdError{funcName, 2, err.Error(), err}
+	}
+
+	client.SetPath("/subscriptions")
+	client.SetHeader("Content-Type", "application/json")
+
+	res, body, err := client.HTTPPost(b)
+	if err != nil {
+		return "", &ngsiCmdError{funcName, 3, err.Error(), err}
+	}
+	if res.StatusCode != http.StatusCreated {
+		return "", &ngsiCmdError{funcName, 4, fmt.Sprintf("%s %s", res.Status, string(body)), nil}
+	}
+
+	location := res.Header.Get("Location")
+	location = location[strings.LastIndex(location, "/")+1:]
+
+	return location, nil
+}
+
+func receiverUnsubscribe(client *ngsilib.Client, id string) error {
+	const funcName = "receiverUnsubscribe"
+
+	client.SetPath("/subscriptions/" + id)
+
+	res, body, err := client.HTTPDelete()
+	if err != nil {
+		return &ngsiCmdError{funcName, 1, err.Error(), err}
+	}
+	if res.StatusCode != http.StatusNoContent {
+		return &ngsiCmdError{funcName, 2, fmt.Sprintf("%s %s %s", res.Status, string(body), id), nil}
+	}
+
+	return nil
+}
+
+func (r *notificationReceiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := ioutil.ReadAll(req.Body)
+	if err == nil {
+		err = r.print(req, body)
+	}
+	if err != nil {
+		r.ngsi.Logging(ngsilib.LogErr, message(err)+"\n")
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (r *notificationReceiver) print(req *http.Request, body []byte) error {
+	const funcName = "notificationReceiver.print"
+
+	var n notificationMessage
+	if err := ngsilib.JSONUnmarshal(body, &n); err != nil {
+		return &ngsiCmdError{funcName, 1, err.Error(), err}
+	}
+
+	if r.subscriptionID != "" && r.subscriptionID != n.SubscriptionID {
+		r.ngsi.Logging(ngsilib.LogInfo, fmt.Sprintf("notification from %s is skipped\n", n.SubscriptionID))
+		return nil
+	}
+
+	var err error
+	if r.safeString {
+		body, err = ngsilib.JSONSafeStringDecode(body)
+		if err != nil {
+			return &ngsiCmdError{funcName, 2, err.Error(), err}
+		}
+	}
+
+	if r.pretty {
+		buf := &bytes.Buffer{}
+		if err := json.Indent(buf, body, "", "  "); err != nil {
+			return &ngsiCmdError{funcName, 3, err.Error(), err}
+		}
+		body = buf.Bytes()
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.header {
+		fmt.Fprintf(r.ngsi.StdWriter, "%s %s\n", req.Method, req.URL.String())
+		keys := make([]string, 0, len(req.Header))
+		for k := range req.Header {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			fmt.Fprintf(r.ngsi.StdWriter, "%s: %s\n", k, strings.Join(req.Header[k], ", "))
+		}
+		fmt.Fprintln(r.ngsi.StdWriter)
+	}
+
+	fmt.Fprintln(r.ngsi.StdWriter, string(body))
+
+	return nil
+}
@@ -0,0 +1,608 @@
+/*
+MIT License
+
+Copyright (c) 2020 Kazuhito Suda
+
+This file is part of NGSI Go
+
+https://github.com/lets-fiware/ngsi-go
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+package ngsicmd
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/cli/v2"
+)
+
+const testNotificationV2 = `{"subscriptionId":"5fa7988a627088ba9b91b1c1","data":[{"id":"device001","type":"Device","temperature":{"type":"Number","value":21.5,"metadata":{}}}]}`
+
+func newNotificationRequest(method, body string) *http.Request {
+	req := httptest.NewRequest(method, "/notify", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Fiware-Service", "openiot")
+	return req
+}
+
+func TestReceiver(t *testing.T) {
+	ngsi, set, app, buf := setupTest()
+
+	server := &MockHTTPServer{Requests: []*http.Request{newNotificationRequest(http.MethodPost, testNotificationV2)}}
+	ngsi.HTTPServer = server
+	set.Int64("port", 1028, "doc")
+
+	c := cli.NewContext(app, set, nil)
+	err := receiver(c)
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, []int{http.StatusNoContent}, server.StatusCodes)
+		expected := testNotificationV2 + "\n"
+		assert.Equal(t, expected, buf.String())
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestReceiverPrettyHeader(t *testing.T) {
+	ngsi, set, app, buf := setupTest()
+
+	server := &MockHTTPServer{Requests: []*http.Request{newNotificationRequest(http.MethodPost, `{"subscriptionId":"5fa7988a627088ba9b91b1c1","data":[]}`)}}
+	ngsi.HTTPServer = server
+	set.Int64("port", 1028, "doc")
+	setupFlagBool(set, "pretty,header")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--pretty", "--header"})
+	err := receiver(c)
+
+	if assert.NoError(t, err) {
+		expected := "POST /notify\nContent-Type: application/json\nFiware-Service: openiot\n\n{\n  \"subscriptionId\": \"5fa7988a627088ba9b91b1c1\",\n  \"data\": []\n}\n"
+		assert.Equal(t, expected, buf.String())
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestReceiverSafeString(t *testing.T) {
+	ngsi, set, app, buf := setupTest()
+
+	server := &MockHTTPServer{Requests: []*http.Request{newNotificationRequest(http.MethodPost, `{"subscriptionId":"5fa7988a627088ba9b91b1c1","data":[{"id":"E1","name":{"type":"Text","value":"%3CLemonade%3E"}}]}`)}}
+	ngsi.HTTPServer = server
+	set.Int64("port", 1028, "doc")
+	setupFlagString(set, "safeString")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--safeString=on"})
+	err := receiver(c)
+
+	if assert.NoError(t, err) {
+		expected := `{"data":[{"id":"E1","name":{"type":"Text","value":"<Lemonade>"}}],"subscriptionId":"5fa7988a627088ba9b91b1c1"}` + "\n"
+		assert.Equal(t, expected, buf.String())
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestReceiverSubscriptionID(t *testing.T) {
+	ngsi, set, app, buf := setupTest()
+
+	server := &MockHTTPServer{Requests: []*http.Request{
+		newNotificationRequest(http.MethodPost, `{"subscriptionId":"other","data":[]}`),
+		newNotificationRequest(http.MethodPost, testNotificationV2),
+	}}
+	ngsi.HTTPServer = server
+	set.Int64("port", 1028, "doc")
+	setupFlagString(set, "subscriptionId")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--subscriptionId=5fa7988a627088ba9b91b1c1"})
+	err := receiver(c)
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, []int{http.StatusNoContent, http.StatusNoContent}, server.StatusCodes)
+		expected := testNotificationV2 + "\n"
+		assert.Equal(t, expected, buf.String())
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestReceiverMethodNotAllowed(t *testing.T) {
+	ngsi, set, app, buf := setupTest()
+
+	server := &MockHTTPServer{Requests: []*http.Request{newNotificationRequest(http.MethodGet, "")}}
+	ngsi.HTTPServer = server
+	set.Int64("port", 1028, "doc")
+
+	c := cli.NewContext(app, set, nil)
+	err := receiver(c)
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, []int{http.StatusMethodNotAllowed}, server.StatusCodes)
+		assert.Equal(t, "", buf.String())
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestReceiverBadRequest(t *testing.T) {
+	ngsi, set, app, buf := setupTest()
+
+	server := &MockHTTPServer{Requests: []*http.Request{newNotificationRequest(http.MethodPost, "notification")}}
+	ngsi.HTTPServer = server
+	set.Int64("port", 1028, "doc")
+
+	c := cli.NewContext(app, set, nil)
+	err := receiver(c)
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, []int{http.StatusBadRequest}, server.StatusCodes)
+		assert.Equal(t, "", buf.String())
+	} else {
+		t.FailNow()
+	}
+}
+
+// serverRecordHTTP records the calls of the HTTP server made before each request.
+type serverRecordHTTP struct {
+	*MockHTTP
+	server *MockHTTPServer
+	calls  [][]string
+}
+
+func (h *serverRecordHTTP) Request(method string, url *url.URL, headers map[string]string, body interface{}, idempotent bool) (*http.Response, []byte, error) {
+	h.calls = append(h.calls, append([]string{}, h.server.Calls...))
+	return h.MockHTTP.Request(method, url, headers, body, idempotent)
+}
+
+func TestReceiverSubscribeV2(t *testing.T) {
+	ngsi, set, app, buf := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+
+	reqRes1 := MockHTTPReqRes{}
+	reqRes1.Res.StatusCode = http.StatusCreated
+	reqRes1.Path = "/v2/subscriptions"
+	reqRes1.ReqData = []byte(`{"description":"ngsi receiver","subject":{"entities":[{"idPattern":".*","type":"Device"}],"condition":{"attrs":["temperature"],"expression":{"q":"temperature>20"}}},"notification":{"http":{"url":"http://192.168.0.1:1028/"},"attrs":["temperature"]}}`)
+	reqRes1.ResHeader = http.Header{"Location": []string{"/v2/subscriptions/5fa7988a627088ba9b91b1c1"}}
+	reqRes2 := MockHTTPReqRes{}
+	reqRes2.Res.StatusCode = http.StatusNoContent
+	reqRes2.Path = "/v2/subscriptions/5fa7988a627088ba9b91b1c1"
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes1)
+	mock.ReqRes = append(mock.ReqRes, reqRes2)
+	server := &MockHTTPServer{Requests: []*http.Request{
+		newNotificationRequest(http.MethodPost, `{"subscriptionId":"other","data":[]}`),
+		newNotificationRequest(http.MethodPost, testNotificationV2),
+	}}
+	ngsi.HTTPServer = server
+	h := &serverRecordHTTP{MockHTTP: mock, server: server}
+	ngsi.HTTP = h
+	set.Int64("port", 1028, "doc")
+	setupFlagString(set, "host,url,type,query,wAttrs,nAttrs")
+	setupFlagBool(set, "subscribe")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", "--subscribe", "--url=http://192.168.0.1:1028/", "--type=Device", "--query=temperature>20", "--wAttrs=temperature", "--nAttrs=temperature"})
+	err := receiver(c)
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, 2, mock.index)
+		// the receiver listens before the subscription is created and deleted
+		assert.Equal(t, [][]string{{"listen"}, {"listen", "serve"}}, h.calls)
+		expected := testNotificationV2 + "\n"
+		assert.Equal(t, expected, buf.String())
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestReceiverSubscribeLd(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion-ld", "https://orion-ld", "ld")
+
+	reqRes1 := MockHTTPReqRes{}
+	reqRes1.Res.StatusCode = http.StatusCreated
+	reqRes1.Path = "/ngsi-ld/v1/subscriptions"
+	reqRes1.ReqData = []byte(`{"description":"ngsi receiver","entities":[{"idPattern":".*","type":"Device"}],"notification":{"attributes":["temperature"],"endpoint":{"accept":"application/json","uri":"http://localhost:1028/"},"format":"normalized"},"type":"Subscription","watchedAttributes":["temperature"]}`)
+	reqRes1.ResHeader = http.Header{"Location": []string{"/ngsi-ld/v1/subscriptions/urn:ngsi-ld:Subscription:5fa7988a627088ba9b91b1c1"}}
+	reqRes2 := MockHTTPReqRes{}
+	reqRes2.Res.StatusCode = http.StatusNoContent
+	reqRes2.Path = "/ngsi-ld/v1/subscriptions/urn:ngsi-ld:Subscription:5fa7988a627088ba9b91b1c1"
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes1)
+	mock.ReqRes = append(mock.ReqRes, reqRes2)
+	ngsi.HTTP = mock
+	set.Int64("port", 1028, "doc")
+	setupFlagString(set, "host,type,wAttrs,nAttrs")
+	setupFlagBool(set, "subscribe")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion-ld", "--subscribe", "--type=Device", "--wAttrs=temperature", "--nAttrs=temperature"})
+	err := receiver(c)
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, 2, mock.index)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestReceiverErrorInitCmd(t *testing.T) {
+	_, set, app, _ := setupTest()
+
+	set.Int64("port", 1028, "doc")
+	setupFlagBool(set, "subscribe")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--subscribe"})
+	err := receiver(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 1, ngsiErr.ErrNo)
+		assert.Equal(t, "Required host not found", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestReceiverErrorPort(t *testing.T) {
+	_, set, app, _ := setupTest()
+
+	set.Int64("port", 1028, "doc")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--port=65536"})
+	err := receiver(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 2, ngsiErr.ErrNo)
+		assert.Equal(t, "port error: 65536", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestReceiverErrorSafeString(t *testing.T) {
+	_, set, app, _ := setupTest()
+
+	set.Int64("port", 1028, "doc")
+	setupFlagString(set, "safeString")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--safeString=abc"})
+	err := receiver(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 3, ngsiErr.ErrNo)
+		assert.Equal(t, "unkown parameter: abc", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestReceiverErrorNewClient(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+
+	set.Int64("port", 1028, "doc")
+	setupFlagString(set, "host,link")
+	setupFlagBool(set, "subscribe")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", "--subscribe", "--link=abc"})
+	err := receiver(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 4, ngsiErr.ErrNo)
+		assert.Equal(t, "abc not found", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestReceiverErrorSubscribe(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion-ld", "https://orion-ld", "ld")
+
+	server := &MockHTTPServer{}
+	ngsi.HTTPServer = server
+	set.Int64("port", 1028, "doc")
+	setupFlagString(set, "host")
+	setupFlagBool(set, "subscribe")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion-ld", "--subscribe"})
+	err := receiver(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 6, ngsiErr.ErrNo)
+		assert.Equal(t, "type is required", ngsiErr.Message)
+		assert.Equal(t, []string{"listen", "shutdown"}, server.Calls)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestReceiverErrorListen(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+
+	mock := NewMockHTTP()
+	ngsi.HTTP = mock
+	ngsi.HTTPServer = &MockHTTPServer{ListenErr: errors.New("address already in use")}
+
+	set.Int64("port", 1028, "doc")
+	setupFlagString(set, "host")
+	setupFlagBool(set, "subscribe")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", "--subscribe"})
+	err := receiver(c)
+
+	if assert.Error(t, err) {
+		assert.Equal(t, 0, mock.index)
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 5, ngsiErr.ErrNo)
+		assert.Equal(t, "address already in use", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestReceiverErrorServe(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+
+	reqRes1 := MockHTTPReqRes{}
+	reqRes1.Res.StatusCode = http.StatusCreated
+	reqRes1.Path = "/v2/subscriptions"
+	reqRes1.ResHeader = http.Header{"Location": []string{"/v2/subscriptions/5fa7988a627088ba9b91b1c1"}}
+	reqRes2 := MockHTTPReqRes{}
+	reqRes2.Res.StatusCode = http.StatusNoContent
+	reqRes2.Path = "/v2/subscriptions/5fa7988a627088ba9b91b1c1"
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes1)
+	mock.ReqRes = append(mock.ReqRes, reqRes2)
+	ngsi.HTTP = mock
+	ngsi.HTTPServer = &MockHTTPServer{ServeErr: errors.New("serve error")}
+
+	set.Int64("port", 1028, "doc")
+	setupFlagString(set, "host")
+	setupFlagBool(set, "subscribe")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", "--subscribe"})
+	err := receiver(c)
+
+	if assert.Error(t, err) {
+		assert.Equal(t, 2, mock.index)
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 7, ngsiErr.ErrNo)
+		assert.Equal(t, "serve error", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestReceiverErrorUnsubscribe(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+
+	reqRes1 := MockHTTPReqRes{}
+	reqRes1.Res.StatusCode = http.StatusCreated
+	reqRes1.Path = "/v2/subscriptions"
+	reqRes1.ResHeader = http.Header{"Location": []string{"/v2/subscriptions/5fa7988a627088ba9b91b1c1"}}
+	reqRes2 := MockHTTPReqRes{}
+	reqRes2.Res.StatusCode = http.StatusNotFound
+	reqRes2.Res.Status = "404 Not Found"
+	reqRes2.Path = "/v2/subscriptions/5fa7988a627088ba9b91b1c1"
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes1)
+	mock.ReqRes = append(mock.ReqRes, reqRes2)
+	ngsi.HTTP = mock
+
+	set.Int64("port", 1028, "doc")
+	setupFlagString(set, "host")
+	setupFlagBool(set, "subscribe")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", "--subscribe"})
+	err := receiver(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 8, ngsiErr.ErrNo)
+		assert.Equal(t, "404 Not Found  5fa7988a627088ba9b91b1c1", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestReceiverSubscribeErrorMarshal(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+	ngsi.JSONConverter = &MockJSONLib{EncodeErr: errors.New("json error"), DecodeErr: errors.New("json error")}
+	setupFlagString(set, "host")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion"})
+	client, _ := newClient(ngsi, c, false)
+	_, err := receiverSubscribe(c, client, "http://localhost:1028/")
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 2, ngsiErr.ErrNo)
+		assert.Equal(t, "json error", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestReceiverSubscribeErrorHTTP(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Err = errors.New("http error")
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	setupFlagString(set, "host")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion"})
+	client, _ := newClient(ngsi, c, false)
+	_, err := receiverSubscribe(c, client, "http://localhost:1028/")
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 3, ngsiErr.ErrNo)
+		assert.Equal(t, "http error", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestReceiverSubscribeErrorHTTPStatus(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusBadRequest
+	reqRes.Res.Status = "400 Bad Request"
+	reqRes.ResBody = []byte("error")
+	reqRes.Path = "/v2/subscriptions"
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	setupFlagString(set, "host")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion"})
+	client, _ := newClient(ngsi, c, false)
+	_, err := receiverSubscribe(c, client, "http://localhost:1028/")
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 4, ngsiErr.ErrNo)
+		assert.Equal(t, "400 Bad Request error", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestReceiverUnsubscribeErrorHTTP(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Err = errors.New("http error")
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	setupFlagString(set, "host")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion"})
+	client, _ := newClient(ngsi, c, false)
+	err := receiverUnsubscribe(client, "5fa7988a627088ba9b91b1c1")
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 1, ngsiErr.ErrNo)
+		assert.Equal(t, "http error", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestNotificationReceiverPrintErrorSafeString(t *testing.T) {
+	ngsi, _, _, _ := setupTest()
+
+	j := ngsi.JSONConverter
+	ngsi.JSONConverter = &MockJSONLib{EncodeErr: errors.New("json error"), Jsonlib: j}
+	r := &notificationReceiver{ngsi: ngsi, safeString: true}
+
+	err := r.print(newNotificationRequest(http.MethodPost, ""), []byte(testNotificationV2))
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 2, ngsiErr.ErrNo)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestNotificationReceiverPrintErrorPretty(t *testing.T) {
+	ngsi, _, _, _ := setupTest()
+
+	r := &notificationReceiver{ngsi: ngsi, pretty: true}
+
+	err := r.print(newNotificationRequest(http.MethodPost, ""), []byte(`{"subscriptionId":"5fa7988a627088ba9b91b1c1"} data`))
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 3, ngsiErr.ErrNo)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestNotificationReceiverPrintErrorUnmarshal(t *testing.T) {
+	ngsi, _, _, _ := setupTest()
+
+	r := &notificationReceiver{ngsi: ngsi}
+	ngsi.JSONConverter = &MockJSONLib{EncodeErr: errors.New("json error"), DecodeErr: errors.New("json error")}
+
+	err := r.print(newNotificationRequest(http.MethodPost, ""), []byte(testNotificationV2))
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 1, ngsiErr.ErrNo)
+		assert.Equal(t, "json error", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
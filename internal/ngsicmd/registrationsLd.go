@@ -107,12 +107,19 @@ func registrationsListLd(c *cli.Context, ngsi *ngsilib.NGSI, client *ngsilib.Cli
 		}
 	}
 
-	if c.IsSet("json") {
+	if outputEnabled(c) {
+		if registrations == nil {
+			registrations = []map[string]interface{}{}
+		}
+		if err := outputPrint(c, ngsi, registrations); err != nil {
+			return &ngsiCmdError{funcName, 6, err.Error(), err}
+		}
+	} else if c.IsSet("json") {
 		b, err := ngsilib.JSONMarshal(registrations)
 		if err != nil {
 			return &ngsiCmdError{funcName, 5, err.Error(), err}
 		}
-		fmt.Fprintln(ngsi.StdWriter, string(b))
+		printJSON(ngsi, b)
 	} else if c.IsSet("verbose") {
 		for _, e := range registrations {
 			fmt.Fprintf(ngsi.StdWriter, "%s %s\n", e["id"].(string), e["description"].(string))
@@ -147,7 +154,14 @@ func registrationsGetLd(c *cli.Context, ngsi *ngsilib.NGSI, client *ngsilib.Clie
 		}
 	}
 
-	fmt.Fprintln(ngsi.StdWriter, string(body))
+	if outputEnabled(c) {
+		if err := outputPrint(c, ngsi, body); err != nil {
+			return &ngsiCmdError{funcName, 4, err.Error(), err}
+		}
+		return nil
+	}
+
+	printJSON(ngsi, body)
 	return nil
 }
 
@@ -32,13 +32,13 @@ package ngsicmd
 import (
 	"fmt"
 	"net/http"
-	"net/url"
-	"strconv"
 
 	"github.com/lets-fiware/ngsi-go/internal/ngsilib"
 	"github.com/urfave/cli/v2"
 )
 
+var removeFilters = []string{"type", "id", "idPattern", "typePattern", "query", "mq", "georel", "geometry", "coords"}
+
 func remove(c *cli.Context) error {
 	const funcName = "remove"
 
@@ -47,65 +47,184 @@ func remove(c *cli.Context) error {
 		return &ngsiCmdError{funcName, 1, err.Error(), err}
 	}
 
-	entityType := c.String("type")
-
 	client, err := newClient(ngsi, c, false)
 	if err != nil {
 		return &ngsiCmdError{funcName, 2, err.Error(), err}
 	}
 
-	if client.IsNgsiLd() {
-		return &ngsiCmdError{funcName, 3, "only available on NGSIv2", err}
+	// refuse to remove all entities in a broker
+	filtered := false
+	for _, key := range removeFilters {
+		if c.String(key) != "" {
+			filtered = true
+		}
 	}
-
-	if !c.IsSet("run") {
-		return &ngsiCmdError{funcName, 4, "run remove with --run option", err}
+	if !filtered {
+		return &ngsiCmdError{funcName, 3, "specify at least one filter such as --type", nil}
 	}
 
-	limit := 100
+	limit := pageSize(c, ngsi, client)
+	size := batchSize(c, ngsi, client)
 	total := 0
-	for {
-		// get count
+
+	fetch := func(client *ngsilib.Client, offset int) (entitiesRespose, int, error) {
 		client.SetPath("/entities")
 
-		v := url.Values{}
-		v.Set("type", entityType)
-		v.Set("options", "count")
+		v := parseOptions(c, removeFilters, nil)
+		if client.IsNgsiLd() {
+			v.Set("count", "true")
+		} else {
+			v.Set("options", "count")
+			v.Set("attrs", "id")
+		}
 		v.Set("limit", fmt.Sprintf("%d", limit))
-		v.Set("attrs", "id")
-		client.SetQuery(&v)
+		if offset > 0 {
+			v.Set("offset", fmt.Sprintf("%d", offset))
+		}
+		client.SetQuery(v)
+
+		client.SetHeader("Accept", "application/json")
 
 		res, body, err := client.HTTPGet()
 		if err != nil {
-			return &ngsiCmdError{funcName, 5, err.Error(), err}
+			return nil, 0, &ngsiCmdError{funcName, 4, err.Error(), err}
 		}
 		if res.StatusCode != http.StatusOK {
-			return &ngsiCmdError{funcName, 6, fmt.Sprintf("%s %s", res.Status, string(body)), nil}
+			return nil, 0, &ngsiCmdError{funcName, 5, fmt.Sprintf("%s %s", res.Status, string(body)), nil}
 		}
 
-		count, err := strconv.Atoi(res.Header.Get("fiware-total-count"))
+		count, err := client.ResultsCount(res)
 		if err != nil {
-			return &ngsiCmdError{funcName, 7, err.Error(), err}
+			return nil, 0, &ngsiCmdError{funcName, 6, err.Error(), err}
 		}
 		if count == 0 {
-			break
-		}
-		if count >= limit {
-			total += limit
-		} else {
-			total += count
+			return nil, 0, nil
 		}
 
 		var entities entitiesRespose
 		err = ngsilib.JSONUnmarshalDecode(body, &entities, false)
 		if err != nil {
-			return &ngsiCmdError{funcName, 8, err.Error(), err}
+			return nil, 0, &ngsiCmdError{funcName, 7, err.Error(), err}
 		}
+		return entities, count, nil
+	}
 
-		_, _, err = client.OpUpdate(&entities, "delete", false, false)
+	if !c.IsSet("run") {
+		_, count, err := fetch(client, 0)
 		if err != nil {
-			return &ngsiCmdError{funcName, 9, err.Error(), err}
+			return err
+		}
+		fmt.Fprintf(ngsi.StdWriter, "%d entities will be removed\n", count)
+		return &ngsiCmdError{funcName, 8, "run remove with --run option", nil}
+	}
+
+	// post deletes entities with a batch request. It returns true when the payload is too large.
+	post := func(client *ngsilib.Client, entities entitiesRespose) (bool, error) {
+		var res *http.Response
+		var body []byte
+		var err error
+
+		if client.IsNgsiLd() {
+			client.SetPath("/entityOperations/delete")
+			client.SetHeader("Content-Type", "application/json")
+
+			b, err := ngsilib.JSONMarshal(copyEntityIDs(entities))
+			if err != nil {
+				return false, &ngsiCmdError{funcName, 9, err.Error(), err}
+			}
+			res, body, err = client.HTTPPost(b)
+			if err != nil {
+				return false, &ngsiCmdError{funcName, 10, err.Error(), err}
+			}
+		} else {
+			res, body, err = client.OpUpdate(&entities, "delete", false, false)
+			if err != nil {
+				return false, &ngsiCmdError{funcName, 10, err.Error(), err}
+			}
+		}
+
+		if res.StatusCode == http.StatusRequestEntityTooLarge {
+			ngsi.Logging(ngsilib.LogInfo, fmt.Sprintf("%s, split %d entities\n", res.Status, len(entities)))
+			return true, nil
+		}
+		// 207 Multi-Status means that some entities could not be removed
+		if res.StatusCode < http.StatusOK || res.StatusCode >= http.StatusMultipleChoices || res.StatusCode == http.StatusMultiStatus {
+			return false, &ngsiCmdError{funcName, 11, fmt.Sprintf("%s %s", res.Status, string(body)), nil}
+		}
+		return false, nil
+	}
+
+	parallel := parallelWorkers(c)
+
+	// each round fetches up to --parallel pages and then deletes them, so that
+	// the offsets of the pages are not shifted by deletion while fetching.
+	// it stops when a round makes no progress, e.g. the broker accepts the
+	// delete requests but the entities still match the filters.
+	prevCount := -1
+	for {
+		entities, count, err := fetch(client, 0)
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			break
+		}
+		if prevCount >= 0 && count >= prevCount {
+			return &ngsiCmdError{funcName, 12, fmt.Sprintf("%d entities remain after removing them", count), nil}
+		}
+		prevCount = count
+
+		batches := []entitiesRespose{entities}
+
+		pages := (count + limit - 1) / limit
+		if pages > parallel {
+			pages = parallel
+		}
+		job := func(v interface{}) (interface{}, error) {
+			entities, _, err := fetch(client.Clone(), v.(int)*limit)
+			return entities, err
+		}
+		done := func(v interface{}) error {
+			if entities := v.(entitiesRespose); len(entities) > 0 {
+				batches = append(batches, entities)
+			}
+			return nil
+		}
+		if err := parallelRun(parallel, parallelPages(1, pages), job, done); err != nil {
+			return err
+		}
+
+		i := 0
+		next := func() (interface{}, bool, error) {
+			if i >= len(batches) {
+				return nil, false, nil
+			}
+			i++
+			return batches[i-1], true, nil
+		}
+		job = func(v interface{}) (interface{}, error) {
+			entities := v.(entitiesRespose)
+			client := client.Clone()
+			err := batchRun(len(entities), size, func(start, end int) (bool, error) {
+				return post(client, entities[start:end])
+			})
+			if err != nil {
+				return nil, err
+			}
+			return len(entities), nil
+		}
+		removed := 0
+		done = func(v interface{}) error {
+			removed += v.(int)
+			return nil
+		}
+		if err := parallelRun(parallel, next, job, done); err != nil {
+			return err
+		}
+		if removed == 0 {
+			return &ngsiCmdError{funcName, 13, fmt.Sprintf("no entities removed, %d entities remain", count), nil}
 		}
+		total += removed
 	}
 
 	fmt.Fprintf(ngsi.StdWriter, "%d", total)
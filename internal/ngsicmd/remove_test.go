@@ -43,7 +43,7 @@ func TestRemove(t *testing.T) {
 
 	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
 
-	setupFlagString(set, "host")
+	setupFlagString(set, "host,type")
 	setupFlagBool(set, "run")
 	reqRes1 := MockHTTPReqRes{}
 	reqRes1.Res.StatusCode = http.StatusOK
@@ -65,7 +65,7 @@ func TestRemove(t *testing.T) {
 	mock.ReqRes = append(mock.ReqRes, reqRes3)
 	ngsi.HTTP = mock
 	c := cli.NewContext(app, set, nil)
-	_ = set.Parse([]string{"--host=orion", "--run"})
+	_ = set.Parse([]string{"--host=orion", "--type=Device", "--run"})
 	err := remove(c)
 
 	assert.NoError(t, err)
@@ -76,7 +76,7 @@ func TestRemovePage(t *testing.T) {
 
 	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
 
-	setupFlagString(set, "host")
+	setupFlagString(set, "host,type")
 	setupFlagBool(set, "run")
 	reqRes1 := MockHTTPReqRes{}
 	reqRes1.Res.StatusCode = http.StatusOK
@@ -98,7 +98,7 @@ func TestRemovePage(t *testing.T) {
 	mock.ReqRes = append(mock.ReqRes, reqRes3)
 	ngsi.HTTP = mock
 	c := cli.NewContext(app, set, nil)
-	_ = set.Parse([]string{"--host=orion", "--run"})
+	_ = set.Parse([]string{"--host=orion", "--type=Device", "--run"})
 	err := remove(c)
 
 	assert.NoError(t, err)
@@ -109,7 +109,7 @@ func TestRemoveCountZero(t *testing.T) {
 
 	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
 
-	setupFlagString(set, "host")
+	setupFlagString(set, "host,type")
 	setupFlagBool(set, "run")
 	reqRes := MockHTTPReqRes{}
 	reqRes.Res.StatusCode = http.StatusOK
@@ -119,7 +119,7 @@ func TestRemoveCountZero(t *testing.T) {
 	mock.ReqRes = append(mock.ReqRes, reqRes)
 	ngsi.HTTP = mock
 	c := cli.NewContext(app, set, nil)
-	_ = set.Parse([]string{"--host=orion", "--run"})
+	_ = set.Parse([]string{"--host=orion", "--type=Device", "--run"})
 	err := remove(c)
 
 	assert.NoError(t, err)
@@ -145,7 +145,7 @@ func TestRemoveErrorNewClient(t *testing.T) {
 
 	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
 
-	setupFlagString(set, "host,link")
+	setupFlagString(set, "host,type,link")
 	c := cli.NewContext(app, set, nil)
 	_ = set.Parse([]string{"--link=abc", "--host=orion"})
 	err := remove(c)
@@ -159,39 +159,73 @@ func TestRemoveErrorNewClient(t *testing.T) {
 	}
 }
 
-func TestRemoveErrorIsNgsiLd(t *testing.T) {
+func TestRemoveErrorFilter(t *testing.T) {
 	ngsi, set, app, _ := setupTest()
 
-	setupAddBroker(t, ngsi, "orion-ld", "https://orion-ld", "ld")
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
 
-	setupFlagString(set, "host")
+	setupFlagString(set, "host,type")
+	setupFlagBool(set, "run")
 	c := cli.NewContext(app, set, nil)
-	_ = set.Parse([]string{"--host=orion-ld"})
+	_ = set.Parse([]string{"--host=orion", "--run"})
 	err := remove(c)
 
 	if assert.Error(t, err) {
 		ngsiErr := err.(*ngsiCmdError)
 		assert.Equal(t, 3, ngsiErr.ErrNo)
-		assert.Equal(t, "only available on NGSIv2", ngsiErr.Message)
+		assert.Equal(t, "specify at least one filter such as --type", ngsiErr.Message)
 	} else {
 		t.FailNow()
 	}
 }
 
 func TestRemoveErrorRunFlag(t *testing.T) {
+	ngsi, set, app, buf := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+
+	setupFlagString(set, "host,type")
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusOK
+	reqRes.ResBody = []byte(`[{"id":"device001"}]`)
+	reqRes.ResHeader = http.Header{"Fiware-Total-Count": []string{"3"}}
+	reqRes.Path = "/v2/entities"
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", "--type=Device"})
+	err := remove(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 8, ngsiErr.ErrNo)
+		assert.Equal(t, "run remove with --run option", ngsiErr.Message)
+		assert.Equal(t, "3 entities will be removed\n", buf.String())
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestRemoveErrorRunFlagHTTP(t *testing.T) {
 	ngsi, set, app, _ := setupTest()
 
 	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
 
-	setupFlagString(set, "host")
+	setupFlagString(set, "host,type")
+	reqRes := MockHTTPReqRes{}
+	reqRes.Err = errors.New("http error")
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
 	c := cli.NewContext(app, set, nil)
-	_ = set.Parse([]string{"--host=orion"})
+	_ = set.Parse([]string{"--host=orion", "--type=Device"})
 	err := remove(c)
 
 	if assert.Error(t, err) {
 		ngsiErr := err.(*ngsiCmdError)
 		assert.Equal(t, 4, ngsiErr.ErrNo)
-		assert.Equal(t, "run remove with --run option", ngsiErr.Message)
+		assert.Equal(t, "http error", ngsiErr.Message)
 	} else {
 		t.FailNow()
 	}
@@ -202,7 +236,7 @@ func TestRemoveErrorHTTP(t *testing.T) {
 
 	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
 
-	setupFlagString(set, "host")
+	setupFlagString(set, "host,type")
 	setupFlagBool(set, "run")
 	reqRes := MockHTTPReqRes{}
 	reqRes.Res.StatusCode = http.StatusOK
@@ -211,12 +245,12 @@ func TestRemoveErrorHTTP(t *testing.T) {
 	mock.ReqRes = append(mock.ReqRes, reqRes)
 	ngsi.HTTP = mock
 	c := cli.NewContext(app, set, nil)
-	_ = set.Parse([]string{"--host=orion", "--run"})
+	_ = set.Parse([]string{"--host=orion", "--type=Device", "--run"})
 	err := remove(c)
 
 	if assert.Error(t, err) {
 		ngsiErr := err.(*ngsiCmdError)
-		assert.Equal(t, 5, ngsiErr.ErrNo)
+		assert.Equal(t, 4, ngsiErr.ErrNo)
 		assert.Equal(t, "url error", ngsiErr.Message)
 	} else {
 		t.FailNow()
@@ -228,7 +262,7 @@ func TestRemoveErrorHTTPStatus(t *testing.T) {
 
 	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
 
-	setupFlagString(set, "host")
+	setupFlagString(set, "host,type")
 	setupFlagBool(set, "run")
 	reqRes := MockHTTPReqRes{}
 	reqRes.Res.StatusCode = http.StatusBadRequest
@@ -237,12 +271,12 @@ func TestRemoveErrorHTTPStatus(t *testing.T) {
 	mock.ReqRes = append(mock.ReqRes, reqRes)
 	ngsi.HTTP = mock
 	c := cli.NewContext(app, set, nil)
-	_ = set.Parse([]string{"--host=orion", "--run"})
+	_ = set.Parse([]string{"--host=orion", "--type=Device", "--run"})
 	err := remove(c)
 
 	if assert.Error(t, err) {
 		ngsiErr := err.(*ngsiCmdError)
-		assert.Equal(t, 6, ngsiErr.ErrNo)
+		assert.Equal(t, 5, ngsiErr.ErrNo)
 	} else {
 		t.FailNow()
 	}
@@ -253,7 +287,7 @@ func TestRemoveErrorResultCount(t *testing.T) {
 
 	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
 
-	setupFlagString(set, "host")
+	setupFlagString(set, "host,type")
 	setupFlagBool(set, "run")
 	reqRes := MockHTTPReqRes{}
 	reqRes.Res.StatusCode = http.StatusOK
@@ -262,12 +296,12 @@ func TestRemoveErrorResultCount(t *testing.T) {
 	mock.ReqRes = append(mock.ReqRes, reqRes)
 	ngsi.HTTP = mock
 	c := cli.NewContext(app, set, nil)
-	_ = set.Parse([]string{"--host=orion", "--run"})
+	_ = set.Parse([]string{"--host=orion", "--type=Device", "--run"})
 	err := remove(c)
 
 	if assert.Error(t, err) {
 		ngsiErr := err.(*ngsiCmdError)
-		assert.Equal(t, 7, ngsiErr.ErrNo)
+		assert.Equal(t, 6, ngsiErr.ErrNo)
 		assert.Equal(t, "strconv.Atoi: parsing \"\": invalid syntax", ngsiErr.Message)
 	} else {
 		t.FailNow()
@@ -279,7 +313,7 @@ func TestRemoveErrorUnmarshal(t *testing.T) {
 
 	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
 
-	setupFlagString(set, "host")
+	setupFlagString(set, "host,type")
 	setupFlagBool(set, "run")
 	reqRes1 := MockHTTPReqRes{}
 	reqRes1.Res.StatusCode = http.StatusOK
@@ -302,13 +336,13 @@ func TestRemoveErrorUnmarshal(t *testing.T) {
 	mock.ReqRes = append(mock.ReqRes, reqRes3)
 	ngsi.HTTP = mock
 	c := cli.NewContext(app, set, nil)
-	_ = set.Parse([]string{"--host=orion", "--run"})
+	_ = set.Parse([]string{"--host=orion", "--type=Device", "--run"})
 	ngsi.JSONConverter = &MockJSONLib{EncodeErr: errors.New("json error"), DecodeErr: errors.New("json error")}
 	err := remove(c)
 
 	if assert.Error(t, err) {
 		ngsiErr := err.(*ngsiCmdError)
-		assert.Equal(t, 8, ngsiErr.ErrNo)
+		assert.Equal(t, 7, ngsiErr.ErrNo)
 		assert.Equal(t, "json error", ngsiErr.Message)
 	} else {
 		t.FailNow()
@@ -320,7 +354,7 @@ func TestRemoveErrorOpUpdate(t *testing.T) {
 
 	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
 
-	setupFlagString(set, "host")
+	setupFlagString(set, "host,type")
 	setupFlagBool(set, "run")
 	reqRes1 := MockHTTPReqRes{}
 	reqRes1.Res.StatusCode = http.StatusOK
@@ -343,14 +377,326 @@ func TestRemoveErrorOpUpdate(t *testing.T) {
 	mock.ReqRes = append(mock.ReqRes, reqRes3)
 	ngsi.HTTP = mock
 	c := cli.NewContext(app, set, nil)
-	_ = set.Parse([]string{"--host=orion", "--run"})
+	_ = set.Parse([]string{"--host=orion", "--type=Device", "--run"})
 	err := remove(c)
 
 	if assert.Error(t, err) {
 		ngsiErr := err.(*ngsiCmdError)
-		assert.Equal(t, 9, ngsiErr.ErrNo)
+		assert.Equal(t, 10, ngsiErr.ErrNo)
 		assert.Equal(t, "error", ngsiErr.Message)
 	} else {
 		t.FailNow()
 	}
 }
+
+func TestRemoveParallel(t *testing.T) {
+	ngsi, set, app, buf := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+
+	setupFlagString(set, "host,type,parallel")
+	setupFlagBool(set, "run")
+	mock := NewMockHTTP()
+	for i := 0; i < 3; i++ {
+		reqRes := MockHTTPReqRes{}
+		reqRes.Res.StatusCode = http.StatusOK
+		reqRes.ResBody = []byte(`[{"id":"device001"}]`)
+		reqRes.ResHeader = http.Header{"Fiware-Total-Count": []string{"250"}}
+		reqRes.Path = "/v2/entities"
+		mock.ReqRes = append(mock.ReqRes, reqRes)
+	}
+	for i := 0; i < 3; i++ {
+		reqRes := MockHTTPReqRes{}
+		reqRes.Res.StatusCode = http.StatusNoContent
+		reqRes.ReqData = []byte(`{"actionType":"delete","entities":[{"id":"device001"}]}`)
+		reqRes.Path = "/v2/op/update"
+		mock.ReqRes = append(mock.ReqRes, reqRes)
+	}
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusOK
+	reqRes.ResBody = []byte(`[]`)
+	reqRes.ResHeader = http.Header{"Fiware-Total-Count": []string{"0"}}
+	reqRes.Path = "/v2/entities"
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", "--type=Device", "--run", "--parallel=5"})
+	err := remove(c)
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, "3", buf.String())
+		assert.Equal(t, 7, mock.index)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestRemoveErrorHTTPStatusDelete(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+
+	setupFlagString(set, "host,type")
+	setupFlagBool(set, "run")
+	reqRes1 := MockHTTPReqRes{}
+	reqRes1.Res.StatusCode = http.StatusOK
+	reqRes1.ResBody = []byte(`[{"id":"device001"}]`)
+	reqRes1.ResHeader = http.Header{"Fiware-Total-Count": []string{"1"}}
+	reqRes1.Path = "/v2/entities"
+	reqRes2 := MockHTTPReqRes{}
+	reqRes2.Res.StatusCode = http.StatusBadRequest
+	reqRes2.Res.Status = "400 Bad Request"
+	reqRes2.ResBody = []byte(`{"error":"BadRequest"}`)
+	reqRes2.Path = "/v2/op/update"
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes1)
+	mock.ReqRes = append(mock.ReqRes, reqRes2)
+	ngsi.HTTP = mock
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", "--type=Device", "--run"})
+	err := remove(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 11, ngsiErr.ErrNo)
+		assert.Equal(t, `400 Bad Request {"error":"BadRequest"}`, ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestRemoveTooLarge(t *testing.T) {
+	ngsi, set, app, buf := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+
+	setupFlagString(set, "host,type,pageSize")
+	setupFlagBool(set, "run")
+	reqRes1 := MockHTTPReqRes{}
+	reqRes1.Res.StatusCode = http.StatusOK
+	reqRes1.ResBody = []byte(`[{"id":"device001"},{"id":"device002"}]`)
+	reqRes1.ResHeader = http.Header{"Fiware-Total-Count": []string{"2"}}
+	reqRes1.Path = "/v2/entities"
+	reqRes2 := MockHTTPReqRes{}
+	reqRes2.Res.StatusCode = http.StatusRequestEntityTooLarge
+	reqRes2.Path = "/v2/op/update"
+	reqRes3 := MockHTTPReqRes{}
+	reqRes3.Res.StatusCode = http.StatusNoContent
+	reqRes3.ReqData = []byte(`{"actionType":"delete","entities":[{"id":"device001"}]}`)
+	reqRes3.Path = "/v2/op/update"
+	reqRes4 := MockHTTPReqRes{}
+	reqRes4.Res.StatusCode = http.StatusNoContent
+	reqRes4.ReqData = []byte(`{"actionType":"delete","entities":[{"id":"device002"}]}`)
+	reqRes4.Path = "/v2/op/update"
+	reqRes5 := MockHTTPReqRes{}
+	reqRes5.Res.StatusCode = http.StatusOK
+	reqRes5.ResBody = []byte(`[]`)
+	reqRes5.ResHeader = http.Header{"Fiware-Total-Count": []string{"0"}}
+	reqRes5.Path = "/v2/entities"
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes1, reqRes2, reqRes3, reqRes4, reqRes5)
+	ngsi.HTTP = mock
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", "--type=Device", "--run", "--pageSize=2"})
+	err := remove(c)
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, "2", buf.String())
+		assert.Equal(t, 5, mock.index)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestRemoveIDPattern(t *testing.T) {
+	ngsi, set, app, buf := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+
+	setupFlagString(set, "host,idPattern,query")
+	setupFlagBool(set, "run")
+	reqRes1 := MockHTTPReqRes{}
+	reqRes1.Res.StatusCode = http.StatusOK
+	reqRes1.ResBody = []byte(`[{"id":"test001"}]`)
+	reqRes1.ResHeader = http.Header{"Fiware-Total-Count": []string{"1"}}
+	reqRes1.Path = "/v2/entities"
+	reqRes2 := MockHTTPReqRes{}
+	reqRes2.Res.StatusCode = http.StatusNoContent
+	reqRes2.ReqData = []byte(`{"actionType":"delete","entities":[{"id":"test001"}]}`)
+	reqRes2.Path = "/v2/op/update"
+	reqRes3 := MockHTTPReqRes{}
+	reqRes3.Res.StatusCode = http.StatusOK
+	reqRes3.ResBody = []byte(`[]`)
+	reqRes3.ResHeader = http.Header{"Fiware-Total-Count": []string{"0"}}
+	reqRes3.Path = "/v2/entities"
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes1, reqRes2, reqRes3)
+	ngsi.HTTP = mock
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", "--idPattern=^test", "--query=temperature>40", "--run"})
+	err := remove(c)
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, "1", buf.String())
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestRemoveLd(t *testing.T) {
+	ngsi, set, app, buf := setupTest()
+
+	setupAddBroker(t, ngsi, "orion-ld", "https://orion-ld", "ld")
+
+	setupFlagString(set, "host,type")
+	setupFlagBool(set, "run")
+	reqRes1 := MockHTTPReqRes{}
+	reqRes1.Res.StatusCode = http.StatusOK
+	reqRes1.ResBody = []byte(`[{"id":"urn:ngsi-ld:Device:001","type":"Device"},{"id":"urn:ngsi-ld:Device:002","type":"Device"}]`)
+	reqRes1.ResHeader = http.Header{"Ngsild-Results-Count": []string{"2"}}
+	reqRes1.Path = "/ngsi-ld/v1/entities"
+	reqRes2 := MockHTTPReqRes{}
+	reqRes2.Res.StatusCode = http.StatusNoContent
+	reqRes2.ReqData = []byte(`["urn:ngsi-ld:Device:001","urn:ngsi-ld:Device:002"]`)
+	reqRes2.Path = "/ngsi-ld/v1/entityOperations/delete"
+	reqRes3 := MockHTTPReqRes{}
+	reqRes3.Res.StatusCode = http.StatusOK
+	reqRes3.ResBody = []byte(`[]`)
+	reqRes3.ResHeader = http.Header{"Ngsild-Results-Count": []string{"0"}}
+	reqRes3.Path = "/ngsi-ld/v1/entities"
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes1, reqRes2, reqRes3)
+	ngsi.HTTP = mock
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion-ld", "--type=Device", "--run"})
+	err := remove(c)
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, "2", buf.String())
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestRemoveLdErrorMultiStatus(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion-ld", "https://orion-ld", "ld")
+
+	setupFlagString(set, "host,type")
+	setupFlagBool(set, "run")
+	reqRes1 := MockHTTPReqRes{}
+	reqRes1.Res.StatusCode = http.StatusOK
+	reqRes1.ResBody = []byte(`[{"id":"urn:ngsi-ld:Device:001","type":"Device"}]`)
+	reqRes1.ResHeader = http.Header{"Ngsild-Results-Count": []string{"1"}}
+	reqRes1.Path = "/ngsi-ld/v1/entities"
+	reqRes2 := MockHTTPReqRes{}
+	reqRes2.Res.StatusCode = http.StatusMultiStatus
+	reqRes2.Res.Status = "207 Multi-Status"
+	reqRes2.ResBody = []byte(`{"success":[],"errors":[{"entityId":"urn:ngsi-ld:Device:001"}]}`)
+	reqRes2.Path = "/ngsi-ld/v1/entityOperations/delete"
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes1, reqRes2)
+	ngsi.HTTP = mock
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion-ld", "--type=Device", "--run"})
+	err := remove(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 11, ngsiErr.ErrNo)
+		assert.Equal(t, `207 Multi-Status {"success":[],"errors":[{"entityId":"urn:ngsi-ld:Device:001"}]}`, ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestRemoveLdErrorJSONMarshal(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion-ld", "https://orion-ld", "ld")
+
+	setupFlagString(set, "host,type")
+	setupFlagBool(set, "run")
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusOK
+	reqRes.ResBody = []byte(`[{"id":"urn:ngsi-ld:Device:001","type":"Device"}]`)
+	reqRes.ResHeader = http.Header{"Ngsild-Results-Count": []string{"1"}}
+	reqRes.Path = "/ngsi-ld/v1/entities"
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion-ld", "--type=Device", "--run"})
+	ngsi.JSONConverter = &MockJSONLib{EncodeErr: errors.New("json error"), Jsonlib: ngsi.JSONConverter}
+	err := remove(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 9, ngsiErr.ErrNo)
+		assert.Equal(t, "json error", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestRemoveErrorNotDecreased(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+
+	setupFlagString(set, "host,type")
+	setupFlagBool(set, "run")
+	reqRes1 := MockHTTPReqRes{}
+	reqRes1.Res.StatusCode = http.StatusOK
+	reqRes1.ResBody = []byte(`[{"id":"device001"}]`)
+	reqRes1.ResHeader = http.Header{"Fiware-Total-Count": []string{"1"}}
+	reqRes1.Path = "/v2/entities"
+	reqRes2 := MockHTTPReqRes{}
+	reqRes2.Res.StatusCode = http.StatusNoContent
+	reqRes2.Path = "/v2/op/update"
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes1)
+	mock.ReqRes = append(mock.ReqRes, reqRes2)
+	mock.ReqRes = append(mock.ReqRes, reqRes1)
+	ngsi.HTTP = mock
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", "--type=Device", "--run"})
+	err := remove(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 12, ngsiErr.ErrNo)
+		assert.Equal(t, "1 entities remain after removing them", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestRemoveErrorNoEntitiesRemoved(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+
+	setupFlagString(set, "host,type")
+	setupFlagBool(set, "run")
+	reqRes1 := MockHTTPReqRes{}
+	reqRes1.Res.StatusCode = http.StatusOK
+	reqRes1.ResBody = []byte(`[]`)
+	reqRes1.ResHeader = http.Header{"Fiware-Total-Count": []string{"1"}}
+	reqRes1.Path = "/v2/entities"
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes1)
+	ngsi.HTTP = mock
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", "--type=Device", "--run"})
+	err := remove(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 13, ngsiErr.ErrNo)
+		assert.Equal(t, "no entities removed, 1 entities remain", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
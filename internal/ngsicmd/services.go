@@ -0,0 +1,241 @@
+/*
+MIT License
+
+Copyright (c) 2020 Kazuhito Suda
+
+This file is part of NGSI Go
+
+https://github.com/lets-fiware/ngsi-go
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+package ngsicmd
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/lets-fiware/ngsi-go/internal/ngsilib"
+	"github.com/urfave/cli/v2"
+)
+
+func servicesList(c *cli.Context) error {
+	const funcName = "servicesList"
+
+	ngsi, err := initCmd(c, funcName, true)
+	if err != nil {
+		return &ngsiCmdError{funcName, 1, err.Error(), err}
+	}
+
+	client, err := newClient(ngsi, c, false)
+	if err != nil {
+		return &ngsiCmdError{funcName, 2, err.Error(), err}
+	}
+
+	if !client.IsIoTAgent() {
+		return &ngsiCmdError{funcName, 3, "only available on IoT Agent", nil}
+	}
+
+	services, err := iotaList(client, "services", pageSize(c, ngsi, client))
+	if err != nil {
+		return &ngsiCmdError{funcName, 4, err.Error(), err}
+	}
+
+	if outputEnabled(c) {
+		if err := outputPrint(c, ngsi, services); err != nil {
+			return &ngsiCmdError{funcName, 5, err.Error(), err}
+		}
+	} else if c.Bool("json") {
+		b, err := ngsilib.JSONMarshal(services)
+		if err != nil {
+			return &ngsiCmdError{funcName, 6, err.Error(), err}
+		}
+		printJSON(ngsi, b)
+	} else if c.Bool("verbose") {
+		for _, e := range services {
+			fmt.Fprintf(ngsi.StdWriter, "%s %s %s\n", iotaString(e, "resource"), iotaString(e, "apikey"), iotaString(e, "entity_type"))
+		}
+	} else {
+		for _, e := range services {
+			fmt.Fprintf(ngsi.StdWriter, "%s %s\n", iotaString(e, "resource"), iotaString(e, "apikey"))
+		}
+	}
+
+	return nil
+}
+
+func servicesGet(c *cli.Context) error {
+	const funcName = "servicesGet"
+
+	ngsi, err := initCmd(c, funcName, true)
+	if err != nil {
+		return &ngsiCmdError{funcName, 1, err.Error(), err}
+	}
+
+	client, err := newClient(ngsi, c, false)
+	if err != nil {
+		return &ngsiCmdError{funcName, 2, err.Error(), err}
+	}
+
+	if !client.IsIoTAgent() {
+		return &ngsiCmdError{funcName, 3, "only available on IoT Agent", nil}
+	}
+
+	services, err := iotaList(client, "services", pageSize(c, ngsi, client))
+	if err != nil {
+		return &ngsiCmdError{funcName, 4, err.Error(), err}
+	}
+
+	resource := c.String("resource")
+	apikey := c.String("apikey")
+
+	var service map[string]interface{}
+	for _, e := range services {
+		if iotaString(e, "resource") == resource && iotaString(e, "apikey") == apikey {
+			service = e
+			break
+		}
+	}
+	if service == nil {
+		return &ngsiCmdError{funcName, 5, fmt.Sprintf("service group not found: %s %s", resource, apikey), nil}
+	}
+
+	if outputEnabled(c) {
+		if err := outputPrint(c, ngsi, service); err != nil {
+			return &ngsiCmdError{funcName, 6, err.Error(), err}
+		}
+		return nil
+	}
+
+	b, err := ngsilib.JSONMarshal(service)
+	if err != nil {
+		return &ngsiCmdError{funcName, 7, err.Error(), err}
+	}
+	printJSON(ngsi, b)
+
+	return nil
+}
+
+func servicesCreate(c *cli.Context) error {
+	const funcName = "servicesCreate"
+
+	ngsi, err := initCmd(c, funcName, true)
+	if err != nil {
+		return &ngsiCmdError{funcName, 1, err.Error(), err}
+	}
+
+	client, err := newClient(ngsi, c, false)
+	if err != nil {
+		return &ngsiCmdError{funcName, 2, err.Error(), err}
+	}
+
+	if !client.IsIoTAgent() {
+		return &ngsiCmdError{funcName, 3, "only available on IoT Agent", nil}
+	}
+
+	services, err := iotaItems(c, ngsi, "services")
+	if err != nil {
+		return &ngsiCmdError{funcName, 4, err.Error(), err}
+	}
+
+	if err := iotaCreate(c, ngsi, client, "services", services); err != nil {
+		return &ngsiCmdError{funcName, 5, err.Error(), err}
+	}
+
+	return nil
+}
+
+func servicesUpdate(c *cli.Context) error {
+	const funcName = "servicesUpdate"
+
+	ngsi, err := initCmd(c, funcName, true)
+	if err != nil {
+		return &ngsiCmdError{funcName, 1, err.Error(), err}
+	}
+
+	client, err := newClient(ngsi, c, false)
+	if err != nil {
+		return &ngsiCmdError{funcName, 2, err.Error(), err}
+	}
+
+	if !client.IsIoTAgent() {
+		return &ngsiCmdError{funcName, 3, "only available on IoT Agent", nil}
+	}
+
+	b, err := readAll(c, ngsi)
+	if err != nil {
+		return &ngsiCmdError{funcName, 4, err.Error(), err}
+	}
+
+	client.SetPath("/iot/services")
+	client.SetQuery(servicesQuery(c))
+	client.SetHeader("Content-Type", "application/json")
+
+	res, body, err := client.HTTPPut(b)
+	if err != nil {
+		return &ngsiCmdError{funcName, 5, err.Error(), err}
+	}
+	if res.StatusCode != http.StatusNoContent {
+		return &ngsiCmdError{funcName, 6, fmt.Sprintf("%s %s", res.Status, string(body)), nil}
+	}
+
+	return nil
+}
+
+func servicesDelete(c *cli.Context) error {
+	const funcName = "servicesDelete"
+
+	ngsi, err := initCmd(c, funcName, true)
+	if err != nil {
+		return &ngsiCmdError{funcName, 1, err.Error(), err}
+	}
+
+	client, err := newClient(ngsi, c, false)
+	if err != nil {
+		return &ngsiCmdError{funcName, 2, err.Error(), err}
+	}
+
+	if !client.IsIoTAgent() {
+		return &ngsiCmdError{funcName, 3, "only available on IoT Agent", nil}
+	}
+
+	client.SetPath("/iot/services")
+	client.SetQuery(servicesQuery(c))
+
+	res, body, err := client.HTTPDelete()
+	if err != nil {
+		return &ngsiCmdError{funcName, 4, err.Error(), err}
+	}
+	if res.StatusCode != http.StatusNoContent {
+		return &ngsiCmdError{funcName, 5, fmt.Sprintf("%s %s", res.Status, string(body)), nil}
+	}
+
+	return nil
+}
+
+// servicesQuery returns a query which identifies a service group by the resource and the apikey.
+func servicesQuery(c *cli.Context) *url.Values {
+	v := url.Values{}
+	v.Set("resource", c.String("resource"))
+	v.Set("apikey", c.String("apikey"))
+	return &v
+}
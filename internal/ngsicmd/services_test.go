@@ -0,0 +1,738 @@
+/*
+MIT License
+
+Copyright (c) 2020 Kazuhito Suda
+
+This file is part of NGSI Go
+
+https://github.com/lets-fiware/ngsi-go
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+package ngsicmd
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/cli/v2"
+)
+
+func TestServicesList(t *testing.T) {
+	ngsi, set, app, buf := setupTest()
+
+	setupAddServer(t, ngsi, "iota", "http://iota:4041", "iota")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusOK
+	reqRes.Path = "/iot/services"
+	reqRes.ResBody = []byte(`{"count":2,"services":[{"resource":"/iot/d","apikey":"abc","entity_type":"Thing"},{"resource":"/iot/json","apikey":"def","entity_type":"Device"}]}`)
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	setupFlagString(set, "host")
+	setupFlagBool(set, "json,verbose")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{`--host=iota`})
+	err := servicesList(c)
+
+	if assert.NoError(t, err) {
+		actual := buf.String()
+		expected := "/iot/d abc\n/iot/json def\n"
+		assert.Equal(t, expected, actual)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestServicesListVerbose(t *testing.T) {
+	ngsi, set, app, buf := setupTest()
+
+	setupAddServer(t, ngsi, "iota", "http://iota:4041", "iota")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusOK
+	reqRes.Path = "/iot/services"
+	reqRes.ResBody = []byte(`{"count":2,"services":[{"resource":"/iot/d","apikey":"abc","entity_type":"Thing"},{"resource":"/iot/json","apikey":"def","entity_type":"Device"}]}`)
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	setupFlagString(set, "host")
+	setupFlagBool(set, "json,verbose")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{`--host=iota`, `--verbose`})
+	err := servicesList(c)
+
+	if assert.NoError(t, err) {
+		actual := buf.String()
+		expected := "/iot/d abc Thing\n/iot/json def Device\n"
+		assert.Equal(t, expected, actual)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestServicesListJSON(t *testing.T) {
+	ngsi, set, app, buf := setupTest()
+
+	setupAddServer(t, ngsi, "iota", "http://iota:4041", "iota")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusOK
+	reqRes.Path = "/iot/services"
+	reqRes.ResBody = []byte(`{"count":2,"services":[{"resource":"/iot/d","apikey":"abc","entity_type":"Thing"},{"resource":"/iot/json","apikey":"def","entity_type":"Device"}]}`)
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	setupFlagString(set, "host")
+	setupFlagBool(set, "json,verbose")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{`--host=iota`, `--json`})
+	err := servicesList(c)
+
+	if assert.NoError(t, err) {
+		actual := buf.String()
+		expected := "[{\"apikey\":\"abc\",\"entity_type\":\"Thing\",\"resource\":\"/iot/d\"},{\"apikey\":\"def\",\"entity_type\":\"Device\",\"resource\":\"/iot/json\"}]\n"
+		assert.Equal(t, expected, actual)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestServicesListErrorInitCmd(t *testing.T) {
+	_, set, app, _ := setupTest()
+
+	c := cli.NewContext(app, set, nil)
+	err := servicesList(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 1, ngsiErr.ErrNo)
+		assert.Equal(t, "Required host not found", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestServicesListErrorNewClient(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddServer(t, ngsi, "iota", "http://iota:4041", "iota")
+
+	setupFlagString(set, "host,link")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{`--host=iota`, `--link=abc`})
+	err := servicesList(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 2, ngsiErr.ErrNo)
+		assert.Equal(t, "abc not found", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestServicesListErrorNotIoTAgent(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddServer(t, ngsi, "comet", "https://comet", "comet")
+
+	setupFlagString(set, "host")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{`--host=comet`})
+	err := servicesList(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 3, ngsiErr.ErrNo)
+		assert.Equal(t, "only available on IoT Agent", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestServicesListErrorList(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddServer(t, ngsi, "iota", "http://iota:4041", "iota")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Err = errors.New("http error")
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	setupFlagString(set, "host")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{`--host=iota`})
+	err := servicesList(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 4, ngsiErr.ErrNo)
+		assert.Equal(t, "http error", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestServicesGet(t *testing.T) {
+	ngsi, set, app, buf := setupTest()
+
+	setupAddServer(t, ngsi, "iota", "http://iota:4041", "iota")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusOK
+	reqRes.Path = "/iot/services"
+	reqRes.ResBody = []byte(`{"count":2,"services":[{"resource":"/iot/d","apikey":"abc","entity_type":"Thing"},{"resource":"/iot/json","apikey":"def","entity_type":"Device"}]}`)
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	setupFlagString(set, "host,resource,apikey")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{`--host=iota`, `--resource=/iot/json`, `--apikey=def`})
+	err := servicesGet(c)
+
+	if assert.NoError(t, err) {
+		actual := buf.String()
+		expected := "{\"apikey\":\"def\",\"entity_type\":\"Device\",\"resource\":\"/iot/json\"}\n"
+		assert.Equal(t, expected, actual)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestServicesGetErrorInitCmd(t *testing.T) {
+	_, set, app, _ := setupTest()
+
+	c := cli.NewContext(app, set, nil)
+	err := servicesGet(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 1, ngsiErr.ErrNo)
+		assert.Equal(t, "Required host not found", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestServicesGetErrorNewClient(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddServer(t, ngsi, "iota", "http://iota:4041", "iota")
+
+	setupFlagString(set, "host,link")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{`--host=iota`, `--link=abc`})
+	err := servicesGet(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 2, ngsiErr.ErrNo)
+		assert.Equal(t, "abc not found", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestServicesGetErrorNotIoTAgent(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddServer(t, ngsi, "comet", "https://comet", "comet")
+
+	setupFlagString(set, "host")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{`--host=comet`})
+	err := servicesGet(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 3, ngsiErr.ErrNo)
+		assert.Equal(t, "only available on IoT Agent", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestServicesGetErrorList(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddServer(t, ngsi, "iota", "http://iota:4041", "iota")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Err = errors.New("http error")
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	setupFlagString(set, "host")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{`--host=iota`})
+	err := servicesGet(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 4, ngsiErr.ErrNo)
+		assert.Equal(t, "http error", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestServicesGetErrorNotFound(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddServer(t, ngsi, "iota", "http://iota:4041", "iota")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusOK
+	reqRes.Path = "/iot/services"
+	reqRes.ResBody = []byte(`{"count":2,"services":[{"resource":"/iot/d","apikey":"abc","entity_type":"Thing"},{"resource":"/iot/json","apikey":"def","entity_type":"Device"}]}`)
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	setupFlagString(set, "host,resource,apikey")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{`--host=iota`, `--resource=/iot/d`, `--apikey=xyz`})
+	err := servicesGet(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 5, ngsiErr.ErrNo)
+		assert.Equal(t, "service group not found: /iot/d xyz", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestServicesCreate(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddServer(t, ngsi, "iota", "http://iota:4041", "iota")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusCreated
+	reqRes.Path = "/iot/services"
+	reqRes.ReqData = []byte(`{"services":[{"apikey":"abc","resource":"/iot/d"}]}`)
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	setupFlagString(set, "host,data")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{`--host=iota`, `--data={"services":[{"resource":"/iot/d","apikey":"abc"}]}`})
+	err := servicesCreate(c)
+
+	assert.NoError(t, err)
+}
+
+func TestServicesCreateErrorInitCmd(t *testing.T) {
+	_, set, app, _ := setupTest()
+
+	c := cli.NewContext(app, set, nil)
+	err := servicesCreate(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 1, ngsiErr.ErrNo)
+		assert.Equal(t, "Required host not found", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestServicesCreateErrorNewClient(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddServer(t, ngsi, "iota", "http://iota:4041", "iota")
+
+	setupFlagString(set, "host,link")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{`--host=iota`, `--link=abc`})
+	err := servicesCreate(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 2, ngsiErr.ErrNo)
+		assert.Equal(t, "abc not found", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestServicesCreateErrorNotIoTAgent(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddServer(t, ngsi, "comet", "https://comet", "comet")
+
+	setupFlagString(set, "host")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{`--host=comet`})
+	err := servicesCreate(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 3, ngsiErr.ErrNo)
+		assert.Equal(t, "only available on IoT Agent", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestServicesCreateErrorItems(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddServer(t, ngsi, "iota", "http://iota:4041", "iota")
+
+	setupFlagString(set, "host")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{`--host=iota`})
+	err := servicesCreate(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 4, ngsiErr.ErrNo)
+		assert.Equal(t, "data is empty", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestServicesCreateErrorCreate(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddServer(t, ngsi, "iota", "http://iota:4041", "iota")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusConflict
+	reqRes.Res.Status = "409 Conflict"
+	reqRes.Path = "/iot/services"
+	reqRes.ResBody = []byte(`error`)
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	setupFlagString(set, "host,data")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{`--host=iota`, `--data=[{"resource":"/iot/d","apikey":"abc"}]`})
+	err := servicesCreate(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 5, ngsiErr.ErrNo)
+		assert.Equal(t, "409 Conflict error", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestServicesUpdate(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddServer(t, ngsi, "iota", "http://iota:4041", "iota")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusNoContent
+	reqRes.Path = "/iot/services"
+	reqRes.ReqData = []byte(`{"entity_type":"Device"}`)
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	setupFlagString(set, "host,resource,apikey,data")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{`--host=iota`, `--resource=/iot/d`, `--apikey=abc`, `--data={"entity_type":"Device"}`})
+	err := servicesUpdate(c)
+
+	assert.NoError(t, err)
+}
+
+func TestServicesUpdateErrorInitCmd(t *testing.T) {
+	_, set, app, _ := setupTest()
+
+	c := cli.NewContext(app, set, nil)
+	err := servicesUpdate(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 1, ngsiErr.ErrNo)
+		assert.Equal(t, "Required host not found", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestServicesUpdateErrorNewClient(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddServer(t, ngsi, "iota", "http://iota:4041", "iota")
+
+	setupFlagString(set, "host,link")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{`--host=iota`, `--link=abc`})
+	err := servicesUpdate(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 2, ngsiErr.ErrNo)
+		assert.Equal(t, "abc not found", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestServicesUpdateErrorNotIoTAgent(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddServer(t, ngsi, "comet", "https://comet", "comet")
+
+	setupFlagString(set, "host")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{`--host=comet`})
+	err := servicesUpdate(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 3, ngsiErr.ErrNo)
+		assert.Equal(t, "only available on IoT Agent", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestServicesUpdateErrorReadAll(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddServer(t, ngsi, "iota", "http://iota:4041", "iota")
+
+	setupFlagString(set, "host,resource,apikey")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{`--host=iota`, `--resource=/iot/d`, `--apikey=abc`})
+	err := servicesUpdate(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 4, ngsiErr.ErrNo)
+		assert.Equal(t, "data is empty", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestServicesUpdateErrorHTTP(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddServer(t, ngsi, "iota", "http://iota:4041", "iota")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Err = errors.New("http error")
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	setupFlagString(set, "host,resource,apikey,data")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{`--host=iota`, `--resource=/iot/d`, `--apikey=abc`, `--data={}`})
+	err := servicesUpdate(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 5, ngsiErr.ErrNo)
+		assert.Equal(t, "http error", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestServicesUpdateErrorHTTPStatus(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddServer(t, ngsi, "iota", "http://iota:4041", "iota")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusNotFound
+	reqRes.Res.Status = "404 Not Found"
+	reqRes.Path = "/iot/services"
+	reqRes.ResBody = []byte(`error`)
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	setupFlagString(set, "host,resource,apikey,data")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{`--host=iota`, `--resource=/iot/d`, `--apikey=abc`, `--data={}`})
+	err := servicesUpdate(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 6, ngsiErr.ErrNo)
+		assert.Equal(t, "404 Not Found error", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestServicesDelete(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddServer(t, ngsi, "iota", "http://iota:4041", "iota")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusNoContent
+	reqRes.Path = "/iot/services"
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	setupFlagString(set, "host,resource,apikey")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{`--host=iota`, `--resource=/iot/d`, `--apikey=abc`})
+	err := servicesDelete(c)
+
+	assert.NoError(t, err)
+}
+
+func TestServicesDeleteErrorInitCmd(t *testing.T) {
+	_, set, app, _ := setupTest()
+
+	c := cli.NewContext(app, set, nil)
+	err := servicesDelete(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 1, ngsiErr.ErrNo)
+		assert.Equal(t, "Required host not found", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestServicesDeleteErrorNewClient(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddServer(t, ngsi, "iota", "http://iota:4041", "iota")
+
+	setupFlagString(set, "host,link")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{`--host=iota`, `--link=abc`})
+	err := servicesDelete(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 2, ngsiErr.ErrNo)
+		assert.Equal(t, "abc not found", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestServicesDeleteErrorNotIoTAgent(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddServer(t, ngsi, "comet", "https://comet", "comet")
+
+	setupFlagString(set, "host")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{`--host=comet`})
+	err := servicesDelete(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 3, ngsiErr.ErrNo)
+		assert.Equal(t, "only available on IoT Agent", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestServicesDeleteErrorHTTP(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddServer(t, ngsi, "iota", "http://iota:4041", "iota")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Err = errors.New("http error")
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	setupFlagString(set, "host,resource,apikey")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{`--host=iota`, `--resource=/iot/d`, `--apikey=abc`})
+	err := servicesDelete(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 4, ngsiErr.ErrNo)
+		assert.Equal(t, "http error", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestServicesDeleteErrorHTTPStatus(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddServer(t, ngsi, "iota", "http://iota:4041", "iota")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusNotFound
+	reqRes.Res.Status = "404 Not Found"
+	reqRes.Path = "/iot/services"
+	reqRes.ResBody = []byte(`error`)
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	setupFlagString(set, "host,resource,apikey")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{`--host=iota`, `--resource=/iot/d`, `--apikey=abc`})
+	err := servicesDelete(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 5, ngsiErr.ErrNo)
+		assert.Equal(t, "404 Not Found error", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
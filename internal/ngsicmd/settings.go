@@ -57,6 +57,9 @@ func settingsList(c *cli.Context) error {
 	printItem(ngsi.StdWriter, "Stderr", d.Stderr, all)
 	printItem(ngsi.StdWriter, "LogFile", d.Logfile, all)
 	printItem(ngsi.StdWriter, "LogLevel", d.Loglevel, all)
+	if all || d.Pretty {
+		printItem(ngsi.StdWriter, "Pretty", fmt.Sprint(d.Pretty), all)
+	}
 
 	return nil
 }
@@ -97,6 +100,8 @@ func settingsDelete(c *cli.Context) error {
 		case "logfile", "loglevel":
 			d.Logfile = ""
 			d.Loglevel = ""
+		case "pretty":
+			d.Pretty = false
 		}
 	}
 
@@ -131,6 +136,7 @@ func settingsClear(c *cli.Context) error {
 	d.Stderr = ""
 	d.Logfile = ""
 	d.Loglevel = ""
+	d.Pretty = false
 
 	err = ngsi.SavePreviousArgs()
 	if err != nil {
@@ -67,7 +67,23 @@ func TestSettingsListAll(t *testing.T) {
 	err := settingsList(c)
 
 	if assert.NoError(t, err) {
-		assert.Equal(t, "Host: \nFIWARE-Service: \nFIWARE-ServicePath: \nToken: \nSyslog: \nStderr: \nLogFile: \nLogLevel: \n", buf.String())
+		assert.Equal(t, "Host: \nFIWARE-Service: \nFIWARE-ServicePath: \nToken: \nSyslog: \nStderr: \nLogFile: \nLogLevel: \nPretty: false\n", buf.String())
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestSettingsListPretty(t *testing.T) {
+	_, set, app, buf := setupTest()
+
+	setupFlagString(set, "host")
+	setupFlagBool(set, "pretty")
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--pretty"})
+	err := settingsList(c)
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, "Pretty: true\n", buf.String())
 	} else {
 		t.FailNow()
 	}
@@ -101,6 +117,22 @@ func TestSettingsDelete(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestSettingsDeletePretty(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	ngsi.GetPreviousArgs().Pretty = true
+	setupFlagString(set, "host,items")
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--items=pretty"})
+	err := settingsDelete(c)
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, false, ngsi.GetPreviousArgs().Pretty)
+	} else {
+		t.FailNow()
+	}
+}
+
 func TestSettingsDeleteErrorInitCmd(t *testing.T) {
 	_, set, app, _ := setupTest()
 
@@ -171,13 +203,18 @@ func TestSettingsDeleteErrorSave(t *testing.T) {
 }
 
 func TestSettingsClear(t *testing.T) {
-	_, set, app, _ := setupTest()
+	ngsi, set, app, _ := setupTest()
 
+	ngsi.GetPreviousArgs().Pretty = true
 	setupFlagString(set, "host")
 	c := cli.NewContext(app, set, nil)
 	err := settingsClear(c)
 
-	assert.NoError(t, err)
+	if assert.NoError(t, err) {
+		assert.Equal(t, false, ngsi.GetPreviousArgs().Pretty)
+	} else {
+		t.FailNow()
+	}
 }
 
 func TestSettingsClearErrInitCmd(t *testing.T) {
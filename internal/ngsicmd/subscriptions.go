@@ -108,9 +108,17 @@ func subscriptionsUpdate(c *cli.Context) error {
 		return &ngsiCmdError{funcName, 2, err.Error(), err}
 	}
 	if client.IsNgsiV2() {
-		return subscriptionsUpdateV2(c, ngsi, client)
+		err = subscriptionsUpdateV2(c, ngsi, client)
+	} else {
+		err = subscriptionsUpdateLd(c, ngsi, client)
+	}
+	if err != nil {
+		return err
 	}
-	return subscriptionsUpdateLd(c, ngsi, client)
+
+	fmt.Fprintln(ngsi.StdWriter, c.String("id"))
+
+	return nil
 }
 
 func subscriptionsDelete(c *cli.Context) error {
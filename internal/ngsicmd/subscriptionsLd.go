@@ -102,12 +102,19 @@ func subscriptionsListLd(c *cli.Context, ngsi *ngsilib.NGSI, client *ngsilib.Cli
 		}
 	}
 
-	if c.IsSet("json") {
+	if outputEnabled(c) {
+		if subscriptions == nil {
+			subscriptions = []map[string]interface{}{}
+		}
+		if err := outputPrint(c, ngsi, subscriptions); err != nil {
+			return &ngsiCmdError{funcName, 6, err.Error(), err}
+		}
+	} else if c.IsSet("json") {
 		b, err := ngsilib.JSONMarshal(subscriptions)
 		if err != nil {
 			return &ngsiCmdError{funcName, 5, err.Error(), err}
 		}
-		fmt.Fprintln(ngsi.StdWriter, string(b))
+		printJSON(ngsi, b)
 	} else if c.IsSet("verbose") {
 		for _, e := range subscriptions {
 			fmt.Fprintf(ngsi.StdWriter, "%s %s\n", e["id"].(string), e["description"].(string))
@@ -142,7 +149,14 @@ func subscriptionGetLd(c *cli.Context, ngsi *ngsilib.NGSI, client *ngsilib.Clien
 		}
 	}
 
-	fmt.Fprintln(ngsi.StdWriter, string(body))
+	if outputEnabled(c) {
+		if err := outputPrint(c, ngsi, body); err != nil {
+			return &ngsiCmdError{funcName, 4, err.Error(), err}
+		}
+		return nil
+	}
+
+	printJSON(ngsi, body)
 
 	return nil
 }
@@ -183,7 +197,28 @@ func subscriptionsCreateLd(c *cli.Context, ngsi *ngsilib.NGSI, client *ngsilib.C
 func subscriptionsUpdateLd(c *cli.Context, ngsi *ngsilib.NGSI, client *ngsilib.Client) error {
 	const funcName = "subscriptionsUpdateLD"
 
-	return &ngsiCmdError{funcName, 1, "not yet implemented", nil}
+	id := c.String("id")
+
+	client.SetPath("/subscriptions/" + id)
+
+	client.SetContentType()
+
+	b, err := readAll(c, ngsi)
+	if err != nil {
+		return &ngsiCmdError{funcName, 1, err.Error(), err}
+	}
+
+	res, body, err := client.HTTPPatch(b)
+	if err != nil {
+		return &ngsiCmdError{funcName, 2, err.Error(), err}
+	}
+	if res.StatusCode != http.StatusNoContent {
+		return &ngsiCmdError{funcName, 3, fmt.Sprintf("%s %s %s", res.Status, string(body), id), nil}
+	}
+
+	ngsi.Logging(ngsilib.LogInfo, fmt.Sprintf("%s is updated", id))
+
+	return nil
 }
 
 func subscriptionsDeleteLd(c *cli.Context, ngsi *ngsilib.NGSI, client *ngsilib.Client) error {
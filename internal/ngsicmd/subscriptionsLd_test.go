@@ -570,6 +570,30 @@ func TestSubscriptionsCreateLdErrorStatus(t *testing.T) {
 }
 
 func TestSubscriptionsUpdateLd(t *testing.T) {
+	ngsi, set, app, buf := setupTest()
+
+	setupAddBroker(t, ngsi, "orion-ld", "https://orion-ld", "ld")
+
+	setupFlagString(set, "host,id,data")
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusNoContent
+	reqRes.ReqData = []byte(`{"description":"updated"}`)
+	reqRes.Path = "/ngsi-ld/v1/subscriptions/urn:ngsi-ld:Subscription:001"
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion-ld", "--id=urn:ngsi-ld:Subscription:001", `--data={"description":"updated"}`})
+	client, _ := newClient(ngsi, c, false)
+
+	err := subscriptionsUpdateLd(c, ngsi, client)
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, "", buf.String())
+	}
+}
+
+func TestSubscriptionsUpdateLdErrorData(t *testing.T) {
 	ngsi, set, app, _ := setupTest()
 
 	setupAddBroker(t, ngsi, "orion-ld", "https://orion-ld", "ld")
@@ -582,7 +606,56 @@ func TestSubscriptionsUpdateLd(t *testing.T) {
 	if assert.Error(t, err) {
 		ngsiErr := err.(*ngsiCmdError)
 		assert.Equal(t, 1, ngsiErr.ErrNo)
-		assert.Equal(t, "not yet implemented", ngsiErr.Message)
+		assert.Equal(t, "data is empty", ngsiErr.Message)
+	}
+}
+
+func TestSubscriptionsUpdateLdErrorHTTP(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion-ld", "https://orion-ld", "ld")
+
+	setupFlagString(set, "host,id,data")
+	reqRes := MockHTTPReqRes{}
+	reqRes.Err = errors.New("http error")
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion-ld", "--id=urn:ngsi-ld:Subscription:001", `--data={"description":"updated"}`})
+	client, _ := newClient(ngsi, c, false)
+
+	err := subscriptionsUpdateLd(c, ngsi, client)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 2, ngsiErr.ErrNo)
+		assert.Equal(t, "http error", ngsiErr.Message)
+	}
+}
+
+func TestSubscriptionsUpdateLdErrorHTTPStatus(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion-ld", "https://orion-ld", "ld")
+
+	setupFlagString(set, "host,id,data")
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusNotFound
+	reqRes.Res.Status = "404 Not Found"
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion-ld", "--id=urn:ngsi-ld:Subscription:001", `--data={"description":"updated"}`})
+	client, _ := newClient(ngsi, c, false)
+
+	err := subscriptionsUpdateLd(c, ngsi, client)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 3, ngsiErr.ErrNo)
+		assert.Equal(t, "404 Not Found  urn:ngsi-ld:Subscription:001", ngsiErr.Message)
 	}
 }
 
@@ -950,3 +1023,117 @@ var subscriptionLdData = `[
   ]`
 
 var testDataLdRespose = "[{\"description\":\"ngsi source subscription\",\"expires\":\"2020-09-01T01:24:01.00Z\",\"id\":\"3ea2e78f675f2d199d3025ff\",\"notification\":{\"attrsFormat\":\"keyValues\",\"http\":{\"url\":\"https://ngsiproxy\"},\"lastFailure\":\"2020-09-01T07:42:07.00Z\",\"lastFailureReason\":\"Timeout was reached\",\"lastNotification\":\"2020-09-01T07:43:00.00Z\",\"lastSuccess\":\"2020-09-01T07:43:04.00Z\",\"lastSuccessCode\":204,\"onlyChangedAttrs\":false,\"timesSent\":3406},\"status\":\"expired\",\"subject\":{\"condition\":{\"attrs\":[\"observed\",\"location\"]},\"entities\":[{\"idPattern\":\".*\"}]}},{\"description\":\"ngsi source subscription\",\"expires\":\"2020-09-16T03:57:49.00Z\",\"id\":\"5f64060ef6752d199d302600\",\"notification\":{\"attrsFormat\":\"keyValues\",\"http\":{\"url\":\"https://ngsiproxy\"},\"lastNotification\":\"2020-09-16T03:40:27.00Z\",\"lastSuccess\":\"2020-09-16T03:40:28.00Z\",\"lastSuccessCode\":404,\"onlyChangedAttrs\":false,\"timesSent\":27},\"status\":\"expired\",\"subject\":{\"condition\":{\"attrs\":[\"dateRetrieved\"]},\"entities\":[{\"idPattern\":\".*\",\"type\":\"WeatherObserved\"}]}},{\"description\":\"ngsi source subscription\",\"expires\":\"2020-09-16T04:03:05.00Z\",\"id\":\"1f32db4bf6752d199d302601\",\"notification\":{\"attrsFormat\":\"keyValues\",\"http\":{\"url\":\"https://ngsiproxy\"},\"lastFailure\":\"2020-09-16T03:40:06.00Z\",\"lastFailureReason\":\"Timeout was reached\",\"lastNotification\":\"2020-09-16T04:03:00.00Z\",\"lastSuccess\":\"2020-09-16T04:03:04.00Z\",\"lastSuccessCode\":404,\"onlyChangedAttrs\":false,\"timesSent\":3408},\"status\":\"expired\",\"subject\":{\"condition\":{\"attrs\":[\"observed\",\"location\"]},\"entities\":[{\"idPattern\":\".*\"}]}},{\"description\":\"ngsi source subscription\",\"expires\":\"2020-09-16T04:03:07.00Z\",\"id\":\"3978fabd87752d199d302602\",\"notification\":{\"attrsFormat\":\"keyValues\",\"http\":{\"url\":\"https://ngsiproxy\"},\"lastNotification\":\"2020-09-16T04:00:13.00Z\",\"lastSuccess\":\"2020-09-16T04:00:13.00Z\",\"lastSuccessCode\":204,\"onlyChangedAttrs\":false,\"timesSent\":10},\"status\":\"expired\",\"subject\":{\"condition\":{\"attrs\":[\"dateRetrieved\"]},\"entities\":[{\"idPattern\":\".*\",\"type\":\"WeatherObserved\"}]}},{\"description\":\"ngsi source subscription\",\"expires\":\"2020-09-24T07:49:13.00Z\",\"id\":\"9f6c254ac4a6068bb276774e\",\"notification\":{\"attrsFormat\":\"keyValues\",\"http\":{\"url\":\"https://ngsiproxy\"},\"lastNotification\":\"2020-09-24T07:30:02.00Z\",\"lastSuccess\":\"2020-09-24T07:30:02.00Z\",\"lastSuccessCode\":404,\"onlyChangedAttrs\":false,\"timesSent\":28},\"status\":\"inactive\",\"subject\":{\"condition\":{\"attrs\":[\"dateObserved\"]},\"entities\":[{\"idPattern\":\".*\"}]}},{\"description\":\"FIWARE\",\"expires\":\"2020-09-24T07:49:56.00Z\",\"id\":\"4f6c2576c4a6068bb276774f\",\"notification\":{\"attrsFormat\":\"keyValues\",\"http\":{\"url\":\"https://ngsiproxy\"},\"lastNotification\":\"2020-09-24T07:40:26.00Z\",\"lastSuccess\":\"2020-09-24T07:40:26.00Z\",\"lastSuccessCode\":404,\"onlyChangedAttrs\":false,\"timesSent\":278},\"status\":\"active\",\"subject\":{\"condition\":{\"attrs\":[\"dateRetrieved\"]},\"entities\":[{\"idPattern\":\".*\",\"type\":\"WeatherObserved\"}]}}]\n"
+
+func TestSubscriptionssubscriptionsListLdOutput(t *testing.T) {
+	ngsi, set, app, buf := setupTest()
+
+	setupAddBroker(t, ngsi, "orion-ld", "https://orion-ld", "ld")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusOK
+	reqRes.ResBody = []byte(subscriptionLdData)
+	reqRes.Path = "/ngsi-ld/v1/subscriptions"
+	reqRes.ResHeader = http.Header{"Ngsild-Results-Count": []string{"6"}}
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	setupFlagString(set, "output,select")
+
+	c := cli.NewContext(app, set, nil)
+	client, _ := newClient(ngsi, c, false)
+	_ = set.Parse([]string{"--output=ndjson", "--select=$[0].id"})
+	err := subscriptionsListLd(c, ngsi, client)
+
+	if assert.NoError(t, err) {
+		actual := buf.String()
+		expected := "\"3ea2e78f675f2d199d3025ff\"\n"
+		assert.Equal(t, expected, actual)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestSubscriptionssubscriptionsListLdErrorOutput(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion-ld", "https://orion-ld", "ld")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusOK
+	reqRes.ResBody = []byte(subscriptionLdData)
+	reqRes.Path = "/ngsi-ld/v1/subscriptions"
+	reqRes.ResHeader = http.Header{"Ngsild-Results-Count": []string{"6"}}
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	setupFlagString(set, "output,select")
+
+	c := cli.NewContext(app, set, nil)
+	client, _ := newClient(ngsi, c, false)
+	_ = set.Parse([]string{"--output=xml"})
+	err := subscriptionsListLd(c, ngsi, client)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 6, ngsiErr.ErrNo)
+		assert.Equal(t, "output format error: xml (table, json, ndjson, yaml, csv)", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestSubscriptionssubscriptionsGetLdOutput(t *testing.T) {
+	ngsi, set, app, buf := setupTest()
+
+	setupAddBroker(t, ngsi, "orion-ld", "https://orion-ld", "ld")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusOK
+	reqRes.ResBody = []byte(`{"id":"3ea2e78f675f2d199d3025ff","type":"Subscription"}`)
+	reqRes.Path = "/ngsi-ld/v1/subscriptions/3ea2e78f675f2d199d3025ff"
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	setupFlagString(set, "id,output,select")
+
+	c := cli.NewContext(app, set, nil)
+	client, _ := newClient(ngsi, c, false)
+	_ = set.Parse([]string{"--id=3ea2e78f675f2d199d3025ff", "--output=yaml"})
+	err := subscriptionGetLd(c, ngsi, client)
+
+	if assert.NoError(t, err) {
+		actual := buf.String()
+		expected := "id: 3ea2e78f675f2d199d3025ff\ntype: Subscription\n"
+		assert.Equal(t, expected, actual)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestSubscriptionssubscriptionsGetLdErrorOutput(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion-ld", "https://orion-ld", "ld")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusOK
+	reqRes.ResBody = []byte(`{"id":"3ea2e78f675f2d199d3025ff","type":"Subscription"}`)
+	reqRes.Path = "/ngsi-ld/v1/subscriptions/3ea2e78f675f2d199d3025ff"
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	setupFlagString(set, "id,output,select")
+
+	c := cli.NewContext(app, set, nil)
+	client, _ := newClient(ngsi, c, false)
+	_ = set.Parse([]string{"--id=3ea2e78f675f2d199d3025ff", "--output=xml"})
+	err := subscriptionGetLd(c, ngsi, client)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 4, ngsiErr.ErrNo)
+		assert.Equal(t, "output format error: xml (table, json, ndjson, yaml, csv)", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
@@ -212,12 +212,24 @@ func subscriptionsListV2(c *cli.Context, ngsi *ngsilib.NGSI, client *ngsilib.Cli
 		subscriptions = subs
 	}
 
-	if c.IsSet("json") {
+	if outputEnabled(c) {
+		if subscriptions == nil {
+			subscriptions = []subscriptionResposeV2{}
+		}
+		if c.IsSet("localTime") {
+			for i := range subscriptions {
+				toLocaltime(&subscriptions[i])
+			}
+		}
+		if err := outputPrint(c, ngsi, subscriptions); err != nil {
+			return &ngsiCmdError{funcName, 8, err.Error(), err}
+		}
+	} else if c.IsSet("json") {
 		b, err := ngsilib.JSONMarshal(subscriptions)
 		if err != nil {
 			return &ngsiCmdError{funcName, 6, err.Error(), err}
 		}
-		fmt.Fprintln(ngsi.StdWriter, string(b))
+		printJSON(ngsi, b)
 	} else if c.IsSet("verbose") {
 		items := []string{"id", "status", "expires", "description"}
 		var err error
@@ -265,11 +277,21 @@ func subscriptionGetV2(c *cli.Context, ngsi *ngsilib.NGSI, client *ngsilib.Clien
 	if c.IsSet("localTime") {
 		toLocaltime(&sub)
 	}
+	if outputEnabled(c) {
+		if err := outputPrint(c, ngsi, &sub); err != nil {
+			return &ngsiCmdError{funcName, 5, err.Error(), err}
+		}
+		return nil
+	}
 	b, err := ngsilib.JSONMarshal(&sub)
 	if err != nil {
 		return &ngsiCmdError{funcName, 4, err.Error(), err}
 	}
-	fmt.Fprint(ngsi.StdWriter, string(b))
+	if isPretty(ngsi) {
+		printJSON(ngsi, b)
+	} else {
+		fmt.Fprint(ngsi.StdWriter, string(b))
+	}
 	return nil
 }
 
@@ -351,8 +373,6 @@ func subscriptionsUpdateV2(c *cli.Context, ngsi *ngsilib.NGSI, client *ngsilib.C
 	ngsi.Logging(ngsilib.LogInfo, fmt.Sprintf("%s is updated, FIWARE-Service: %s, FIWARE-ServicePath: %s",
 		id, c.String("service"), c.String("path")))
 
-	fmt.Fprintln(ngsi.StdWriter, id)
-
 	return nil
 }
 
@@ -354,6 +354,29 @@ func TestSubscriptionsUpdateErrorNewClient(t *testing.T) {
 	}
 }
 
+func TestSubscriptionsUpdate(t *testing.T) {
+	ngsi, set, app, buf := setupTest()
+
+	setupAddBroker(t, ngsi, "orion-ld", "https://orion-ld", "ld")
+
+	setupFlagString(set, "host,id,data")
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusNoContent
+	reqRes.ReqData = []byte(`{"description":"updated"}`)
+	reqRes.Path = "/ngsi-ld/v1/subscriptions/urn:ngsi-ld:Subscription:001"
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion-ld", "--id=urn:ngsi-ld:Subscription:001", `--data={"description":"updated"}`})
+	err := subscriptionsUpdate(c)
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, "urn:ngsi-ld:Subscription:001\n", buf.String())
+	}
+}
+
 func TestSubscriptionsUpdateErrorV2(t *testing.T) {
 	ngsi, set, app, _ := setupTest()
 
@@ -400,7 +423,7 @@ func TestSubscriptionsUpdateErrorLd(t *testing.T) {
 	if assert.Error(t, err) {
 		ngsiErr := err.(*ngsiCmdError)
 		assert.Equal(t, 1, ngsiErr.ErrNo)
-		assert.Equal(t, "not yet implemented", ngsiErr.Message)
+		assert.Equal(t, "data is empty", ngsiErr.Message)
 	} else {
 		t.FailNow()
 	}
@@ -0,0 +1,615 @@
+/*
+MIT License
+
+Copyright (c) 2020 Kazuhito Suda
+
+This file is part of NGSI Go
+
+https://github.com/lets-fiware/ngsi-go
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+package ngsicmd
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/lets-fiware/ngsi-go/internal/ngsilib"
+	"github.com/urfave/cli/v2"
+)
+
+var (
+	timerelValues      = []string{"before", "after", "between"}
+	timepropertyValues = []string{"observedAt", "createdAt", "modifiedAt"}
+)
+
+func temporalList(c *cli.Context) error {
+	const funcName = "temporalList"
+
+	ngsi, err := initCmd(c, funcName, true)
+	if err != nil {
+		return &ngsiCmdError{funcName, 1, err.Error(), err}
+	}
+
+	client, err := newClient(ngsi, c, false)
+	if err != nil {
+		return &ngsiCmdError{funcName, 2, err.Error(), err}
+	}
+
+	if !client.IsNgsiLd() {
+		return &ngsiCmdError{funcName, 3, "only available on NGSI-LD", nil}
+	}
+
+	page := 0
+	count := 0
+	limit := 100
+
+	lines := c.Bool("lines")
+
+	buf := jsonBuffer{}
+	if !lines {
+		buf.bufferOpen(jsonWriter(ngsi))
+	}
+
+	for {
+		client.SetPath("/temporal/entities")
+
+		args := []string{"id", "type", "idPattern", "query", "georel", "geometry", "coords", "attrs"}
+		opts := []string{"temporalValues", "sysAttrs"}
+		v := parseOptions(c, args, opts)
+
+		if err := setTemporalQuery(c, v); err != nil {
+			return &ngsiCmdError{funcName, 4, err.Error(), err}
+		}
+		v.Set("count", "true")
+		v.Set("limit", fmt.Sprintf("%d", limit))
+		v.Set("offset", fmt.Sprintf("%d", page*limit))
+		client.SetQuery(v)
+
+		res, body, err := client.HTTPGet()
+		if err != nil {
+			return &ngsiCmdError{funcName, 5, err.Error(), err}
+		}
+		if res.StatusCode != http.StatusOK && res.StatusCode != http.StatusPartialContent {
+			return &ngsiCmdError{funcName, 6, fmt.Sprintf("%s %s", res.Status, string(body)), nil}
+		}
+		logContentRange(ngsi, res)
+
+		count, err = client.ResultsCount(res)
+		if err != nil {
+			return &ngsiCmdError{funcName, 7, "ResultsCount error", err}
+		}
+		if count == 0 {
+			break
+		}
+
+		if client.IsSafeString() {
+			body, err = ngsilib.JSONSafeStringDecode(body)
+			if err != nil {
+				return &ngsiCmdError{funcName, 8, err.Error(), err}
+			}
+		}
+
+		if res.StatusCode == http.StatusPartialContent {
+			// the temporal values of the entities are truncated, so the rest of them are read entity by entity
+			var entities entitiesRespose
+			err = ngsilib.JSONUnmarshal(body, &entities)
+			if err != nil {
+				return &ngsiCmdError{funcName, 11, err.Error(), err}
+			}
+			for _, e := range entities {
+				q := temporalEntityQuery(v)
+				id, _ := e["id"].(string)
+				if err := temporalReadAll(ngsi, client.Clone(), id, q, e, res); err != nil {
+					return &ngsiCmdError{funcName, 12, err.Error(), err}
+				}
+			}
+			body, err = ngsilib.JSONMarshal(entities)
+			if err != nil {
+				return &ngsiCmdError{funcName, 13, err.Error(), err}
+			}
+		}
+
+		if lines {
+			var entities entitiesRespose
+			err = ngsilib.JSONUnmarshal(body, &entities)
+			if err != nil {
+				return &ngsiCmdError{funcName, 9, err.Error(), err}
+			}
+			for _, e := range entities {
+				b, err := ngsilib.JSONMarshal(&e)
+				if err != nil {
+					return &ngsiCmdError{funcName, 10, err.Error(), err}
+				}
+				fmt.Fprintln(ngsi.StdWriter, string(b))
+			}
+		} else {
+			buf.bufferWrite(body)
+		}
+
+		if (page+1)*limit < count {
+			page = page + 1
+		} else {
+			break
+		}
+	}
+	if !lines {
+		buf.bufferClose()
+	}
+	return nil
+}
+
+func temporalRead(c *cli.Context) error {
+	const funcName = "temporalRead"
+
+	ngsi, err := initCmd(c, funcName, true)
+	if err != nil {
+		return &ngsiCmdError{funcName, 1, err.Error(), err}
+	}
+
+	client, err := newClient(ngsi, c, false)
+	if err != nil {
+		return &ngsiCmdError{funcName, 2, err.Error(), err}
+	}
+
+	if !client.IsNgsiLd() {
+		return &ngsiCmdError{funcName, 3, "only available on NGSI-LD", nil}
+	}
+
+	id := c.String("id")
+	client.SetPath("/temporal/entities/" + id)
+
+	args := []string{"type", "attrs"}
+	opts := []string{"temporalValues", "sysAttrs"}
+	v := parseOptions(c, args, opts)
+
+	if err := setTemporalQuery(c, v); err != nil {
+		return &ngsiCmdError{funcName, 4, err.Error(), err}
+	}
+	client.SetQuery(v)
+
+	res, body, err := client.HTTPGet()
+	if err != nil {
+		return &ngsiCmdError{funcName, 5, err.Error(), err}
+	}
+	if res.StatusCode != http.StatusOK && res.StatusCode != http.StatusPartialContent {
+		return &ngsiCmdError{funcName, 6, fmt.Sprintf("%s %s", res.Status, string(body)), nil}
+	}
+	logContentRange(ngsi, res)
+
+	if client.IsSafeString() {
+		body, err = ngsilib.JSONSafeStringDecode(body)
+		if err != nil {
+			return &ngsiCmdError{funcName, 7, err.Error(), err}
+		}
+	}
+
+	if res.StatusCode == http.StatusPartialContent {
+		var entity map[string]interface{}
+		if err := ngsilib.JSONUnmarshal(body, &entity); err != nil {
+			return &ngsiCmdError{funcName, 8, err.Error(), err}
+		}
+		if err := temporalReadAll(ngsi, client, id, v, entity, res); err != nil {
+			return &ngsiCmdError{funcName, 9, err.Error(), err}
+		}
+		body, err = ngsilib.JSONMarshal(entity)
+		if err != nil {
+			return &ngsiCmdError{funcName, 10, err.Error(), err}
+		}
+	}
+	printJSON(ngsi, body)
+
+	return nil
+}
+
+func temporalCreate(c *cli.Context) error {
+	const funcName = "temporalCreate"
+
+	ngsi, err := initCmd(c, funcName, true)
+	if err != nil {
+		return &ngsiCmdError{funcName, 1, err.Error(), err}
+	}
+
+	client, err := newClient(ngsi, c, false)
+	if err != nil {
+		return &ngsiCmdError{funcName, 2, err.Error(), err}
+	}
+
+	if !client.IsNgsiLd() {
+		return &ngsiCmdError{funcName, 3, "only available on NGSI-LD", nil}
+	}
+
+	client.SetPath("/temporal/entities")
+
+	client.SetContentType()
+
+	b, err := readAll(c, ngsi)
+	if err != nil {
+		return &ngsiCmdError{funcName, 4, err.Error(), err}
+	}
+
+	if client.IsSafeString() {
+		b, err = ngsilib.JSONSafeStringEncode(b)
+		if err != nil {
+			return &ngsiCmdError{funcName, 5, err.Error(), err}
+		}
+	}
+
+	res, body, err := client.HTTPPost(b)
+	if err != nil {
+		return &ngsiCmdError{funcName, 6, err.Error(), err}
+	}
+	if res.StatusCode != http.StatusCreated && res.StatusCode != http.StatusNoContent {
+		return &ngsiCmdError{funcName, 7, fmt.Sprintf("%s %s", res.Status, string(body)), nil}
+	}
+
+	return nil
+}
+
+func temporalAppend(c *cli.Context) error {
+	const funcName = "temporalAppend"
+
+	ngsi, err := initCmd(c, funcName, true)
+	if err != nil {
+		return &ngsiCmdError{funcName, 1, err.Error(), err}
+	}
+
+	client, err := newClient(ngsi, c, false)
+	if err != nil {
+		return &ngsiCmdError{funcName, 2, err.Error(), err}
+	}
+
+	if !client.IsNgsiLd() {
+		return &ngsiCmdError{funcName, 3, "only available on NGSI-LD", nil}
+	}
+
+	id := c.String("id")
+	client.SetPath("/temporal/entities/" + id + "/attrs")
+
+	client.SetContentType()
+
+	b, err := readAll(c, ngsi)
+	if err != nil {
+		return &ngsiCmdError{funcName, 4, err.Error(), err}
+	}
+
+	if client.IsSafeString() {
+		b, err = ngsilib.JSONSafeStringEncode(b)
+		if err != nil {
+			return &ngsiCmdError{funcName, 5, err.Error(), err}
+		}
+	}
+
+	res, body, err := client.HTTPPost(b)
+	if err != nil {
+		return &ngsiCmdError{funcName, 6, err.Error(), err}
+	}
+	if res.StatusCode != http.StatusNoContent {
+		return &ngsiCmdError{funcName, 7, fmt.Sprintf("%s %s", res.Status, string(body)), nil}
+	}
+
+	return nil
+}
+
+func temporalDelete(c *cli.Context) error {
+	const funcName = "temporalDelete"
+
+	ngsi, err := initCmd(c, funcName, true)
+	if err != nil {
+		return &ngsiCmdError{funcName, 1, err.Error(), err}
+	}
+
+	client, err := newClient(ngsi, c, false)
+	if err != nil {
+		return &ngsiCmdError{funcName, 2, err.Error(), err}
+	}
+
+	if !client.IsNgsiLd() {
+		return &ngsiCmdError{funcName, 3, "only available on NGSI-LD", nil}
+	}
+
+	path := "/temporal/entities/" + c.String("id")
+	if c.IsSet("attrName") {
+		path += "/attrs/" + c.String("attrName")
+	}
+	client.SetPath(path)
+
+	res, body, err := client.HTTPDelete()
+	if err != nil {
+		return &ngsiCmdError{funcName, 4, err.Error(), err}
+	}
+	if res.StatusCode != http.StatusNoContent {
+		return &ngsiCmdError{funcName, 5, fmt.Sprintf("%s %s", res.Status, string(body)), nil}
+	}
+
+	return nil
+}
+
+func setTemporalQuery(c *cli.Context, v *url.Values) error {
+	const funcName = "setTemporalQuery"
+
+	if c.IsSet("timerel") {
+		timerel := strings.ToLower(c.String("timerel"))
+		if !ngsilib.Contains(timerelValues, timerel) {
+			return &ngsiCmdError{funcName, 1, "error: " + timerel + " (before, after, between)", nil}
+		}
+		if !c.IsSet("timeAt") {
+			return &ngsiCmdError{funcName, 2, "timeAt is required", nil}
+		}
+		if timerel == "between" && !c.IsSet("endTimeAt") {
+			return &ngsiCmdError{funcName, 3, "endTimeAt is required", nil}
+		}
+		v.Set("timerel", timerel)
+		v.Set("timeAt", c.String("timeAt"))
+		if c.IsSet("endTimeAt") {
+			v.Set("endTimeAt", c.String("endTimeAt"))
+		}
+	} else if c.IsSet("timeAt") || c.IsSet("endTimeAt") {
+		return &ngsiCmdError{funcName, 4, "timerel is required", nil}
+	}
+
+	if c.IsSet("timeproperty") {
+		timeproperty := c.String("timeproperty")
+		if !ngsilib.Contains(timepropertyValues, timeproperty) {
+			return &ngsiCmdError{funcName, 5, "error: " + timeproperty + " (observedAt, createdAt, modifiedAt)", nil}
+		}
+		v.Set("timeproperty", timeproperty)
+	}
+
+	if c.IsSet("lastN") {
+		lastN := c.Int("lastN")
+		if lastN < 1 {
+			return &ngsiCmdError{funcName, 6, fmt.Sprintf("lastN error: %d", lastN), nil}
+		}
+		v.Set("lastN", fmt.Sprintf("%d", lastN))
+	}
+
+	return nil
+}
+
+func logContentRange(ngsi *ngsilib.NGSI, res *http.Response) {
+	if res.StatusCode == http.StatusPartialContent {
+		ngsi.Logging(ngsilib.LogInfo, fmt.Sprintf("partial content: %s\n", res.Header.Get("Content-Range")))
+	}
+}
+
+// temporalEntityQuery returns the query of a list request without the parameters which select entities
+// so that it can be used to read the rest of the temporal values of an entity.
+func temporalEntityQuery(v *url.Values) *url.Values {
+	q := url.Values{}
+	for key, values := range *v {
+		switch key {
+		case "id", "type", "idPattern", "q", "georel", "geometry", "coords", "count", "limit", "offset":
+		default:
+			q[key] = append([]string{}, values...)
+		}
+	}
+	return &q
+}
+
+// temporalReadAll reads the temporal values of an entity following the Content-Range of a partial
+// response res until the broker returns all of them, and merges them into entity.
+func temporalReadAll(ngsi *ngsilib.NGSI, client *ngsilib.Client, id string, v *url.Values, entity map[string]interface{}, res *http.Response) error {
+	const funcName = "temporalReadAll"
+
+	received := temporalCount(entity)
+
+	for res.StatusCode == http.StatusPartialContent {
+		first, last, err := temporalContentRange(res.Header.Get("Content-Range"))
+		if err != nil {
+			return &ngsiCmdError{funcName, 1, err.Error(), err}
+		}
+		next := temporalNextQuery(v, first, last, received)
+		if next == nil {
+			break
+		}
+		v = next
+
+		client.SetPath("/temporal/entities/" + id)
+		client.SetQuery(v)
+
+		var body []byte
+		res, body, err = client.HTTPGet()
+		if err != nil {
+			return &ngsiCmdError{funcName, 2, err.Error(), err}
+		}
+		if res.StatusCode != http.StatusOK && res.StatusCode != http.StatusPartialContent {
+			return &ngsiCmdError{funcName, 3, fmt.Sprintf("%s %s", res.Status, string(body)), nil}
+		}
+		logContentRange(ngsi, res)
+
+		if client.IsSafeString() {
+			body, err = ngsilib.JSONSafeStringDecode(body)
+			if err != nil {
+				return &ngsiCmdError{funcName, 4, err.Error(), err}
+			}
+		}
+
+		var e map[string]interface{}
+		if err := ngsilib.JSONUnmarshal(body, &e); err != nil {
+			return &ngsiCmdError{funcName, 5, err.Error(), err}
+		}
+		n := temporalMerge(entity, e)
+		if n == 0 {
+			break
+		}
+		received += n
+	}
+
+	return nil
+}
+
+var temporalRangeRegexp = regexp.MustCompile(`^(.+)-(\d{4}-\d{2}-\d{2}T.+)$`)
+
+// temporalContentRange parses Content-Range such as "date-time 2020-08-01T12:05:00Z-2020-08-01T12:07:00Z/3"
+// and returns the earlier and the later timestamps of the range.
+func temporalContentRange(s string) (string, string, error) {
+	const funcName = "temporalContentRange"
+
+	r := s
+	if pos := strings.Index(r, " "); pos != -1 {
+		r = r[pos+1:]
+	}
+	if pos := strings.LastIndex(r, "/"); pos != -1 {
+		r = r[:pos]
+	}
+	m := temporalRangeRegexp.FindStringSubmatch(r)
+	if m == nil {
+		return "", "", &ngsiCmdError{funcName, 1, "Content-Range error: " + s, nil}
+	}
+	first, last := m[1], m[2]
+	if first > last {
+		first, last = last, first
+	}
+	return first, last, nil
+}
+
+// temporalNextQuery returns the query which reads the temporal values following a partial response whose
+// range is from first to last, or nil when all of them have been read. The values are read backwards
+// from first when lastN is specified, otherwise forwards from last.
+func temporalNextQuery(v *url.Values, first, last string, received int) *url.Values {
+	q := url.Values{}
+	for key, values := range *v {
+		q[key] = append([]string{}, values...)
+	}
+
+	timerel := q.Get("timerel")
+	timeAt := q.Get("timeAt")
+
+	if lastN := q.Get("lastN"); lastN != "" {
+		n, _ := strconv.Atoi(lastN)
+		if n <= received {
+			return nil
+		}
+		q.Set("lastN", strconv.Itoa(n-received))
+		switch timerel {
+		case "between", "after":
+			q.Set("timerel", "between")
+			q.Set("endTimeAt", first)
+		default:
+			q.Set("timerel", "before")
+			q.Set("timeAt", first)
+		}
+	} else {
+		switch timerel {
+		case "between":
+			q.Set("timeAt", last)
+		case "before":
+			q.Set("timerel", "between")
+			q.Set("timeAt", last)
+			q.Set("endTimeAt", timeAt)
+		default:
+			q.Set("timerel", "after")
+			q.Set("timeAt", last)
+		}
+	}
+
+	if q.Encode() == v.Encode() {
+		return nil
+	}
+	return &q
+}
+
+// temporalMerge appends the instances of the attributes of src which are not in dst to dst,
+// and returns the number of the appended instances of the attribute which has the most of them.
+func temporalMerge(dst, src map[string]interface{}) int {
+	max := 0
+	for key, value := range src {
+		if key == "id" || key == "type" || key == "@context" {
+			continue
+		}
+		n := 0
+		old, ok := dst[key]
+		if !ok {
+			dst[key] = value
+			n = len(temporalInstances(value))
+		} else if attr, ok := old.(map[string]interface{}); ok && attr["values"] != nil {
+			attr["values"], n = temporalAppendInstances(temporalInstances(old), temporalInstances(value))
+		} else {
+			dst[key], n = temporalAppendInstances(temporalInstances(old), temporalInstances(value))
+		}
+		if n > max {
+			max = n
+		}
+	}
+	return max
+}
+
+// temporalCount returns the number of the instances of the attribute of entity which has the most of them.
+func temporalCount(entity map[string]interface{}) int {
+	max := 0
+	for key, value := range entity {
+		if key == "id" || key == "type" || key == "@context" {
+			continue
+		}
+		if n := len(temporalInstances(value)); n > max {
+			max = n
+		}
+	}
+	return max
+}
+
+// temporalInstances returns the instances of a temporal attribute in the normalized or the
+// temporalValues representation.
+func temporalInstances(v interface{}) []interface{} {
+	switch v := v.(type) {
+	case []interface{}:
+		return v
+	case map[string]interface{}:
+		if values, ok := v["values"].([]interface{}); ok {
+			return values
+		}
+		return []interface{}{v}
+	}
+	return nil
+}
+
+func temporalAppendInstances(dst, src []interface{}) ([]interface{}, int) {
+	keys := make(map[string]bool)
+	for _, e := range dst {
+		keys[temporalInstanceKey(e)] = true
+	}
+	n := 0
+	for _, e := range src {
+		key := temporalInstanceKey(e)
+		if !keys[key] {
+			keys[key] = true
+			dst = append(dst, e)
+			n++
+		}
+	}
+	return dst, n
+}
+
+func temporalInstanceKey(v interface{}) string {
+	if m, ok := v.(map[string]interface{}); ok {
+		if id, ok := m["instanceId"].(string); ok {
+			return id
+		}
+	}
+	b, _ := ngsilib.JSONMarshal(v)
+	return string(b)
+}
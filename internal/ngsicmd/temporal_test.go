@@ -0,0 +1,1481 @@
+/*
+MIT License
+
+Copyright (c) 2020 Kazuhito Suda
+
+This file is part of NGSI Go
+
+https://github.com/lets-fiware/ngsi-go
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+package ngsicmd
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/cli/v2"
+)
+
+func TestTemporalList(t *testing.T) {
+	ngsi, set, app, buf := setupTest()
+
+	setupAddBroker(t, ngsi, "orion-ld", "https://orion-ld", "ld")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusOK
+	reqRes.Path = "/ngsi-ld/v1/temporal/entities"
+	reqRes.ResHeader = http.Header{"Ngsild-Results-Count": []string{"1"}}
+	reqRes.ResBody = []byte(`[{"id":"urn:ngsi-ld:Sensor:001","type":"Sensor","temperature":[{"type":"Property","value":20,"observedAt":"2020-11-01T00:00:00Z"}]}]`)
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	setupFlagString(set, "host,type,timerel,timeAt")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion-ld", "--type=Sensor", "--timerel=after", "--timeAt=2020-11-01T00:00:00Z"})
+	err := temporalList(c)
+
+	if assert.NoError(t, err) {
+		actual := buf.String()
+		expected := `[{"id":"urn:ngsi-ld:Sensor:001","type":"Sensor","temperature":[{"type":"Property","value":20,"observedAt":"2020-11-01T00:00:00Z"}]}]`
+		assert.Equal(t, expected, actual)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestTemporalListPage(t *testing.T) {
+	ngsi, set, app, buf := setupTest()
+
+	setupAddBroker(t, ngsi, "orion-ld", "https://orion-ld", "ld")
+
+	reqRes1 := MockHTTPReqRes{}
+	reqRes1.Res.StatusCode = http.StatusPartialContent
+	reqRes1.Path = "/ngsi-ld/v1/temporal/entities"
+	reqRes1.ResHeader = http.Header{"Ngsild-Results-Count": []string{"101"}, "Content-Range": []string{"date-time 2020-11-01T00:00:00Z-2020-11-02T00:00:00Z/*"}}
+	reqRes1.ResBody = []byte(`[{"id":"urn:ngsi-ld:Sensor:001","type":"Sensor","temperature":{"type":"Property","values":[[20,"2020-11-01T00:00:00Z"],[21,"2020-11-02T00:00:00Z"]]}}]`)
+	reqRes2 := MockHTTPReqRes{}
+	reqRes2.Res.StatusCode = http.StatusOK
+	reqRes2.Path = "/ngsi-ld/v1/temporal/entities/urn:ngsi-ld:Sensor:001"
+	reqRes2.ResBody = []byte(`{"id":"urn:ngsi-ld:Sensor:001","type":"Sensor","temperature":{"type":"Property","values":[[21,"2020-11-02T00:00:00Z"],[22,"2020-11-03T00:00:00Z"]]}}`)
+	reqRes3 := MockHTTPReqRes{}
+	reqRes3.Res.StatusCode = http.StatusOK
+	reqRes3.Path = "/ngsi-ld/v1/temporal/entities"
+	reqRes3.ResHeader = http.Header{"Ngsild-Results-Count": []string{"101"}}
+	reqRes3.ResBody = []byte(`[{"id":"urn:ngsi-ld:Sensor:002","type":"Sensor"}]`)
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes1)
+	mock.ReqRes = append(mock.ReqRes, reqRes2)
+	mock.ReqRes = append(mock.ReqRes, reqRes3)
+	ngsi.HTTP = mock
+	setupFlagString(set, "host,type")
+	setupFlagBool(set, "lines,temporalValues")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion-ld", "--type=Sensor", "--lines", "--temporalValues"})
+	err := temporalList(c)
+
+	if assert.NoError(t, err) {
+		actual := buf.String()
+		expected := "{\"id\":\"urn:ngsi-ld:Sensor:001\",\"temperature\":{\"type\":\"Property\",\"values\":[[20,\"2020-11-01T00:00:00Z\"],[21,\"2020-11-02T00:00:00Z\"],[22,\"2020-11-03T00:00:00Z\"]]},\"type\":\"Sensor\"}\n{\"id\":\"urn:ngsi-ld:Sensor:002\",\"type\":\"Sensor\"}\n"
+		assert.Equal(t, expected, actual)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestTemporalListCountZero(t *testing.T) {
+	ngsi, set, app, buf := setupTest()
+
+	setupAddBroker(t, ngsi, "orion-ld", "https://orion-ld", "ld")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusOK
+	reqRes.Path = "/ngsi-ld/v1/temporal/entities"
+	reqRes.ResHeader = http.Header{"Ngsild-Results-Count": []string{"0"}}
+	reqRes.ResBody = []byte(`[]`)
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	setupFlagString(set, "host,type")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion-ld", "--type=Sensor"})
+	err := temporalList(c)
+
+	if assert.NoError(t, err) {
+		actual := buf.String()
+		expected := ""
+		assert.Equal(t, expected, actual)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestTemporalListSafeString(t *testing.T) {
+	ngsi, set, app, buf := setupTest()
+
+	setupAddBroker(t, ngsi, "orion-ld", "https://orion-ld", "ld")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusOK
+	reqRes.Path = "/ngsi-ld/v1/temporal/entities"
+	reqRes.ResHeader = http.Header{"Ngsild-Results-Count": []string{"1"}}
+	reqRes.ResBody = []byte(`[{"id":"urn:ngsi-ld:Sensor:001","type":"Sensor","name":[{"type":"Property","value":"%25"}]}]`)
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	setupFlagString(set, "host,type,safeString")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion-ld", "--type=Sensor", "--safeString=on"})
+	err := temporalList(c)
+
+	if assert.NoError(t, err) {
+		actual := buf.String()
+		expected := `[{"id":"urn:ngsi-ld:Sensor:001","name":[{"type":"Property","value":"%"}],"type":"Sensor"}]`
+		assert.Equal(t, expected, actual)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestTemporalListErrorInitCmd(t *testing.T) {
+	_, set, app, _ := setupTest()
+
+	c := cli.NewContext(app, set, nil)
+	err := temporalList(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 1, ngsiErr.ErrNo)
+		assert.Equal(t, "Required host not found", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestTemporalListErrorNewClient(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion-ld", "https://orion-ld", "ld")
+	setupFlagString(set, "host,link")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion-ld", "--link=abc"})
+	err := temporalList(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 2, ngsiErr.ErrNo)
+		assert.Equal(t, "abc not found", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestTemporalListErrorNotLd(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+	setupFlagString(set, "host")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion"})
+	err := temporalList(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 3, ngsiErr.ErrNo)
+		assert.Equal(t, "only available on NGSI-LD", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestTemporalListErrorTemporalQuery(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion-ld", "https://orion-ld", "ld")
+	setupFlagString(set, "host,timerel")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion-ld", "--timerel=after"})
+	err := temporalList(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 4, ngsiErr.ErrNo)
+		assert.Equal(t, "timeAt is required", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestTemporalListErrorHTTP(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion-ld", "https://orion-ld", "ld")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Err = errors.New("http error")
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	setupFlagString(set, "host")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion-ld"})
+	err := temporalList(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 5, ngsiErr.ErrNo)
+		assert.Equal(t, "http error", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestTemporalListErrorHTTPStatus(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion-ld", "https://orion-ld", "ld")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusBadRequest
+	reqRes.Res.Status = "400 Bad Request"
+	reqRes.ResBody = []byte("error")
+	reqRes.Path = "/ngsi-ld/v1/temporal/entities"
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	setupFlagString(set, "host")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion-ld"})
+	err := temporalList(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 6, ngsiErr.ErrNo)
+		assert.Equal(t, "400 Bad Request error", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestTemporalListErrorResultsCount(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion-ld", "https://orion-ld", "ld")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusOK
+	reqRes.Path = "/ngsi-ld/v1/temporal/entities"
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	setupFlagString(set, "host")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion-ld"})
+	err := temporalList(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 7, ngsiErr.ErrNo)
+		assert.Equal(t, "ResultsCount error", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestTemporalListErrorSafeString(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion-ld", "https://orion-ld", "ld")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusOK
+	reqRes.Path = "/ngsi-ld/v1/temporal/entities"
+	reqRes.ResHeader = http.Header{"Ngsild-Results-Count": []string{"1"}}
+	reqRes.ResBody = []byte(`[{"id":"urn:ngsi-ld:Sensor:001","type":"Sensor"}]`)
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	ngsi.JSONConverter = &MockJSONLib{EncodeErr: errors.New("json error"), DecodeErr: errors.New("json error")}
+	setupFlagString(set, "host,safeString")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion-ld", "--safeString=on"})
+	err := temporalList(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 8, ngsiErr.ErrNo)
+		assert.Equal(t, "json error", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestTemporalListErrorLinesUnmarshal(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion-ld", "https://orion-ld", "ld")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusOK
+	reqRes.Path = "/ngsi-ld/v1/temporal/entities"
+	reqRes.ResHeader = http.Header{"Ngsild-Results-Count": []string{"1"}}
+	reqRes.ResBody = []byte(`[{"id":"urn:ngsi-ld:Sensor:001","type":"Sensor"}]`)
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	ngsi.JSONConverter = &MockJSONLib{DecodeErr: errors.New("json error")}
+	setupFlagString(set, "host")
+	setupFlagBool(set, "lines")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion-ld", "--lines"})
+	err := temporalList(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 9, ngsiErr.ErrNo)
+		assert.Equal(t, "json error", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestTemporalListErrorLinesMarshal(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion-ld", "https://orion-ld", "ld")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusOK
+	reqRes.Path = "/ngsi-ld/v1/temporal/entities"
+	reqRes.ResHeader = http.Header{"Ngsild-Results-Count": []string{"1"}}
+	reqRes.ResBody = []byte(`[{"id":"urn:ngsi-ld:Sensor:001","type":"Sensor"}]`)
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	j := ngsi.JSONConverter
+	ngsi.JSONConverter = &MockJSONLib{EncodeErr: errors.New("json error"), Jsonlib: j}
+	setupFlagString(set, "host")
+	setupFlagBool(set, "lines")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion-ld", "--lines"})
+	err := temporalList(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 10, ngsiErr.ErrNo)
+		assert.Equal(t, "json error", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestTemporalRead(t *testing.T) {
+	ngsi, set, app, buf := setupTest()
+
+	setupAddBroker(t, ngsi, "orion-ld", "https://orion-ld", "ld")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusOK
+	reqRes.Path = "/ngsi-ld/v1/temporal/entities/urn:ngsi-ld:Sensor:001"
+	reqRes.ResBody = []byte(`{"id":"urn:ngsi-ld:Sensor:001","type":"Sensor","temperature":{"type":"Property","values":[[20,"2020-11-01T00:00:00Z"]]}}`)
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	setupFlagString(set, "host,id,timerel,timeAt,endTimeAt,timeproperty")
+	setupFlagBool(set, "temporalValues")
+	set.Int("lastN", 0, "doc")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion-ld", "--id=urn:ngsi-ld:Sensor:001", "--timerel=between", "--timeAt=2020-11-01T00:00:00Z", "--endTimeAt=2020-11-02T00:00:00Z", "--timeproperty=observedAt", "--lastN=10", "--temporalValues"})
+	err := temporalRead(c)
+
+	if assert.NoError(t, err) {
+		actual := buf.String()
+		expected := `{"id":"urn:ngsi-ld:Sensor:001","type":"Sensor","temperature":{"type":"Property","values":[[20,"2020-11-01T00:00:00Z"]]}}` + "\n"
+		assert.Equal(t, expected, actual)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestTemporalReadSafeString(t *testing.T) {
+	ngsi, set, app, buf := setupTest()
+
+	setupAddBroker(t, ngsi, "orion-ld", "https://orion-ld", "ld")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusPartialContent
+	reqRes.Path = "/ngsi-ld/v1/temporal/entities/urn:ngsi-ld:Sensor:001"
+	reqRes.ResHeader = http.Header{"Content-Range": []string{"date-time 2020-11-01T00:00:00Z-2020-11-02T00:00:00Z/*"}}
+	reqRes.ResBody = []byte(`{"id":"urn:ngsi-ld:Sensor:001","type":"Sensor","name":[{"type":"Property","value":"%28a%29","instanceId":"urn:1"}]}`)
+	reqRes2 := MockHTTPReqRes{}
+	reqRes2.Res.StatusCode = http.StatusOK
+	reqRes2.Path = "/ngsi-ld/v1/temporal/entities/urn:ngsi-ld:Sensor:001"
+	reqRes2.ResBody = []byte(`{"id":"urn:ngsi-ld:Sensor:001","type":"Sensor","name":[{"type":"Property","value":"%28a%29","instanceId":"urn:1"},{"type":"Property","value":"%28b%29","instanceId":"urn:2"}]}`)
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes, reqRes2)
+	ngsi.HTTP = mock
+	setupFlagString(set, "host,id,safeString")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion-ld", "--id=urn:ngsi-ld:Sensor:001", "--safeString=on"})
+	err := temporalRead(c)
+
+	if assert.NoError(t, err) {
+		actual := buf.String()
+		expected := `{"id":"urn:ngsi-ld:Sensor:001","name":[{"instanceId":"urn:1","type":"Property","value":"(a)"},{"instanceId":"urn:2","type":"Property","value":"(b)"}],"type":"Sensor"}` + "\n"
+		assert.Equal(t, expected, actual)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestTemporalReadErrorInitCmd(t *testing.T) {
+	_, set, app, _ := setupTest()
+
+	c := cli.NewContext(app, set, nil)
+	err := temporalRead(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 1, ngsiErr.ErrNo)
+		assert.Equal(t, "Required host not found", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestTemporalReadErrorNewClient(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion-ld", "https://orion-ld", "ld")
+	setupFlagString(set, "host,link")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion-ld", "--link=abc"})
+	err := temporalRead(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 2, ngsiErr.ErrNo)
+		assert.Equal(t, "abc not found", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestTemporalReadErrorNotLd(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+	setupFlagString(set, "host,id")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", "--id=urn:ngsi-ld:Sensor:001"})
+	err := temporalRead(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 3, ngsiErr.ErrNo)
+		assert.Equal(t, "only available on NGSI-LD", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestTemporalReadErrorTemporalQuery(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion-ld", "https://orion-ld", "ld")
+	setupFlagString(set, "host,id,timerel")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion-ld", "--id=urn:ngsi-ld:Sensor:001", "--timerel=within"})
+	err := temporalRead(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 4, ngsiErr.ErrNo)
+		assert.Equal(t, "error: within (before, after, between)", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestTemporalReadErrorHTTP(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion-ld", "https://orion-ld", "ld")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Err = errors.New("http error")
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	setupFlagString(set, "host,id")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion-ld", "--id=urn:ngsi-ld:Sensor:001"})
+	err := temporalRead(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 5, ngsiErr.ErrNo)
+		assert.Equal(t, "http error", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestTemporalReadErrorHTTPStatus(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion-ld", "https://orion-ld", "ld")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusNotFound
+	reqRes.Res.Status = "404 Not Found"
+	reqRes.ResBody = []byte("error")
+	reqRes.Path = "/ngsi-ld/v1/temporal/entities/urn:ngsi-ld:Sensor:001"
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	setupFlagString(set, "host,id")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion-ld", "--id=urn:ngsi-ld:Sensor:001"})
+	err := temporalRead(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 6, ngsiErr.ErrNo)
+		assert.Equal(t, "404 Not Found error", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestTemporalReadErrorSafeString(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion-ld", "https://orion-ld", "ld")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusOK
+	reqRes.Path = "/ngsi-ld/v1/temporal/entities/urn:ngsi-ld:Sensor:001"
+	reqRes.ResBody = []byte(`{"id":"urn:ngsi-ld:Sensor:001","type":"Sensor"}`)
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	ngsi.JSONConverter = &MockJSONLib{EncodeErr: errors.New("json error"), DecodeErr: errors.New("json error")}
+	setupFlagString(set, "host,id,safeString")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion-ld", "--id=urn:ngsi-ld:Sensor:001", "--safeString=on"})
+	err := temporalRead(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 7, ngsiErr.ErrNo)
+		assert.Equal(t, "json error", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestTemporalCreate(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion-ld", "https://orion-ld", "ld")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusCreated
+	reqRes.Path = "/ngsi-ld/v1/temporal/entities"
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	setupFlagString(set, "host,data")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion-ld", `--data={"id":"urn:ngsi-ld:Sensor:001","type":"Sensor","temperature":[{"type":"Property","value":20,"observedAt":"2020-11-01T00:00:00Z"}]}`})
+	err := temporalCreate(c)
+
+	assert.NoError(t, err)
+}
+
+func TestTemporalCreateSafeString(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion-ld", "https://orion-ld", "ld")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusNoContent
+	reqRes.Path = "/ngsi-ld/v1/temporal/entities"
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	setupFlagString(set, "host,data,safeString")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion-ld", "--safeString=on", `--data={"id":"urn:ngsi-ld:Sensor:001","type":"Sensor"}`})
+	err := temporalCreate(c)
+
+	assert.NoError(t, err)
+}
+
+func TestTemporalCreateErrorInitCmd(t *testing.T) {
+	_, set, app, _ := setupTest()
+
+	c := cli.NewContext(app, set, nil)
+	err := temporalCreate(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 1, ngsiErr.ErrNo)
+		assert.Equal(t, "Required host not found", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestTemporalCreateErrorNewClient(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion-ld", "https://orion-ld", "ld")
+	setupFlagString(set, "host,link")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion-ld", "--link=abc"})
+	err := temporalCreate(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 2, ngsiErr.ErrNo)
+		assert.Equal(t, "abc not found", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestTemporalCreateErrorNotLd(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+	setupFlagString(set, "host")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion"})
+	err := temporalCreate(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 3, ngsiErr.ErrNo)
+		assert.Equal(t, "only available on NGSI-LD", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestTemporalCreateErrorReadAll(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion-ld", "https://orion-ld", "ld")
+	setupFlagString(set, "host")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion-ld"})
+	err := temporalCreate(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 4, ngsiErr.ErrNo)
+		assert.Equal(t, "data is empty", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestTemporalCreateErrorSafeString(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion-ld", "https://orion-ld", "ld")
+	ngsi.JSONConverter = &MockJSONLib{EncodeErr: errors.New("json error"), DecodeErr: errors.New("json error")}
+	setupFlagString(set, "host,data,safeString")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion-ld", "--data={}", "--safeString=on"})
+	err := temporalCreate(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 5, ngsiErr.ErrNo)
+		assert.Equal(t, "json error", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestTemporalCreateErrorHTTP(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion-ld", "https://orion-ld", "ld")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Err = errors.New("http error")
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	setupFlagString(set, "host,data")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion-ld", "--data={}"})
+	err := temporalCreate(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 6, ngsiErr.ErrNo)
+		assert.Equal(t, "http error", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestTemporalCreateErrorHTTPStatus(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion-ld", "https://orion-ld", "ld")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusBadRequest
+	reqRes.Res.Status = "400 Bad Request"
+	reqRes.ResBody = []byte("error")
+	reqRes.Path = "/ngsi-ld/v1/temporal/entities"
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	setupFlagString(set, "host,data")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion-ld", "--data={}"})
+	err := temporalCreate(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 7, ngsiErr.ErrNo)
+		assert.Equal(t, "400 Bad Request error", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestTemporalAppend(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion-ld", "https://orion-ld", "ld")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusNoContent
+	reqRes.Path = "/ngsi-ld/v1/temporal/entities/urn:ngsi-ld:Sensor:001/attrs"
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	setupFlagString(set, "host,id,data")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion-ld", "--id=urn:ngsi-ld:Sensor:001", `--data={"temperature":[{"type":"Property","value":21,"observedAt":"2020-11-01T01:00:00Z"}]}`})
+	err := temporalAppend(c)
+
+	assert.NoError(t, err)
+}
+
+func TestTemporalAppendSafeString(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion-ld", "https://orion-ld", "ld")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusNoContent
+	reqRes.Path = "/ngsi-ld/v1/temporal/entities/urn:ngsi-ld:Sensor:001/attrs"
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	setupFlagString(set, "host,id,data,safeString")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion-ld", "--id=urn:ngsi-ld:Sensor:001", "--safeString=on", `--data={"name":[{"type":"Property","value":"%"}]}`})
+	err := temporalAppend(c)
+
+	assert.NoError(t, err)
+}
+
+func TestTemporalAppendErrorInitCmd(t *testing.T) {
+	_, set, app, _ := setupTest()
+
+	c := cli.NewContext(app, set, nil)
+	err := temporalAppend(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 1, ngsiErr.ErrNo)
+		assert.Equal(t, "Required host not found", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestTemporalAppendErrorNewClient(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion-ld", "https://orion-ld", "ld")
+	setupFlagString(set, "host,link")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion-ld", "--link=abc"})
+	err := temporalAppend(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 2, ngsiErr.ErrNo)
+		assert.Equal(t, "abc not found", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestTemporalAppendErrorNotLd(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+	setupFlagString(set, "host")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion"})
+	err := temporalAppend(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 3, ngsiErr.ErrNo)
+		assert.Equal(t, "only available on NGSI-LD", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestTemporalAppendErrorReadAll(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion-ld", "https://orion-ld", "ld")
+	setupFlagString(set, "host,id")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion-ld", "--id=urn:ngsi-ld:Sensor:001"})
+	err := temporalAppend(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 4, ngsiErr.ErrNo)
+		assert.Equal(t, "data is empty", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestTemporalAppendErrorSafeString(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion-ld", "https://orion-ld", "ld")
+	ngsi.JSONConverter = &MockJSONLib{EncodeErr: errors.New("json error"), DecodeErr: errors.New("json error")}
+	setupFlagString(set, "host,id,data,safeString")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion-ld", "--id=urn:ngsi-ld:Sensor:001", "--data={}", "--safeString=on"})
+	err := temporalAppend(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 5, ngsiErr.ErrNo)
+		assert.Equal(t, "json error", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestTemporalAppendErrorHTTP(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion-ld", "https://orion-ld", "ld")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Err = errors.New("http error")
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	setupFlagString(set, "host,id,data")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion-ld", "--id=urn:ngsi-ld:Sensor:001", "--data={}"})
+	err := temporalAppend(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 6, ngsiErr.ErrNo)
+		assert.Equal(t, "http error", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestTemporalAppendErrorHTTPStatus(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion-ld", "https://orion-ld", "ld")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusNotFound
+	reqRes.Res.Status = "404 Not Found"
+	reqRes.ResBody = []byte("error")
+	reqRes.Path = "/ngsi-ld/v1/temporal/entities/urn:ngsi-ld:Sensor:001/attrs"
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	setupFlagString(set, "host,id,data")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion-ld", "--id=urn:ngsi-ld:Sensor:001", "--data={}"})
+	err := temporalAppend(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 7, ngsiErr.ErrNo)
+		assert.Equal(t, "404 Not Found error", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestTemporalDelete(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion-ld", "https://orion-ld", "ld")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusNoContent
+	reqRes.Path = "/ngsi-ld/v1/temporal/entities/urn:ngsi-ld:Sensor:001"
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	setupFlagString(set, "host,id")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion-ld", "--id=urn:ngsi-ld:Sensor:001"})
+	err := temporalDelete(c)
+
+	assert.NoError(t, err)
+}
+
+func TestTemporalDeleteAttr(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion-ld", "https://orion-ld", "ld")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusNoContent
+	reqRes.Path = "/ngsi-ld/v1/temporal/entities/urn:ngsi-ld:Sensor:001/attrs/temperature"
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	setupFlagString(set, "host,id,attrName")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion-ld", "--id=urn:ngsi-ld:Sensor:001", "--attrName=temperature"})
+	err := temporalDelete(c)
+
+	assert.NoError(t, err)
+}
+
+func TestTemporalDeleteErrorInitCmd(t *testing.T) {
+	_, set, app, _ := setupTest()
+
+	c := cli.NewContext(app, set, nil)
+	err := temporalDelete(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 1, ngsiErr.ErrNo)
+		assert.Equal(t, "Required host not found", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestTemporalDeleteErrorNewClient(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion-ld", "https://orion-ld", "ld")
+	setupFlagString(set, "host,link")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion-ld", "--link=abc"})
+	err := temporalDelete(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 2, ngsiErr.ErrNo)
+		assert.Equal(t, "abc not found", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestTemporalDeleteErrorNotLd(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion", "https://orion", "v2")
+	setupFlagString(set, "host")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion"})
+	err := temporalDelete(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 3, ngsiErr.ErrNo)
+		assert.Equal(t, "only available on NGSI-LD", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestTemporalDeleteErrorHTTP(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion-ld", "https://orion-ld", "ld")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Err = errors.New("http error")
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	setupFlagString(set, "host,id")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion-ld", "--id=urn:ngsi-ld:Sensor:001"})
+	err := temporalDelete(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 4, ngsiErr.ErrNo)
+		assert.Equal(t, "http error", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestTemporalDeleteErrorHTTPStatus(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion-ld", "https://orion-ld", "ld")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusNotFound
+	reqRes.Res.Status = "404 Not Found"
+	reqRes.ResBody = []byte("error")
+	reqRes.Path = "/ngsi-ld/v1/temporal/entities/urn:ngsi-ld:Sensor:001"
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	setupFlagString(set, "host,id")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion-ld", "--id=urn:ngsi-ld:Sensor:001"})
+	err := temporalDelete(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 5, ngsiErr.ErrNo)
+		assert.Equal(t, "404 Not Found error", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestSetTemporalQueryErrorEndTimeAt(t *testing.T) {
+	_, set, app, _ := setupTest()
+
+	setupFlagString(set, "timerel,timeAt")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--timerel=between", "--timeAt=2020-11-01T00:00:00Z"})
+	v := parseOptions(c, nil, nil)
+	err := setTemporalQuery(c, v)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 3, ngsiErr.ErrNo)
+		assert.Equal(t, "endTimeAt is required", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestSetTemporalQueryErrorTimerel(t *testing.T) {
+	_, set, app, _ := setupTest()
+
+	setupFlagString(set, "timeAt")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--timeAt=2020-11-01T00:00:00Z"})
+	v := parseOptions(c, nil, nil)
+	err := setTemporalQuery(c, v)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 4, ngsiErr.ErrNo)
+		assert.Equal(t, "timerel is required", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestSetTemporalQueryErrorTimeproperty(t *testing.T) {
+	_, set, app, _ := setupTest()
+
+	setupFlagString(set, "timeproperty")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--timeproperty=deletedAt"})
+	v := parseOptions(c, nil, nil)
+	err := setTemporalQuery(c, v)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 5, ngsiErr.ErrNo)
+		assert.Equal(t, "error: deletedAt (observedAt, createdAt, modifiedAt)", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestSetTemporalQueryErrorLastN(t *testing.T) {
+	_, set, app, _ := setupTest()
+
+	set.Int("lastN", 0, "doc")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--lastN=0"})
+	v := parseOptions(c, nil, nil)
+	err := setTemporalQuery(c, v)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 6, ngsiErr.ErrNo)
+		assert.Equal(t, "lastN error: 0", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestTemporalReadErrorPartialUnmarshal(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion-ld", "https://orion-ld", "ld")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusPartialContent
+	reqRes.Path = "/ngsi-ld/v1/temporal/entities/urn:ngsi-ld:Sensor:001"
+	reqRes.ResHeader = http.Header{"Content-Range": []string{"date-time 2020-11-01T00:00:00Z-2020-11-02T00:00:00Z/*"}}
+	reqRes.ResBody = []byte(`{"id":"urn:ngsi-ld:Sensor:001","type":"Sensor"}`)
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	ngsi.JSONConverter = &MockJSONLib{DecodeErr: errors.New("json error")}
+	setupFlagString(set, "host,id")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion-ld", "--id=urn:ngsi-ld:Sensor:001"})
+	err := temporalRead(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 8, ngsiErr.ErrNo)
+		assert.Equal(t, "json error", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestTemporalReadErrorReadAll(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion-ld", "https://orion-ld", "ld")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusPartialContent
+	reqRes.Path = "/ngsi-ld/v1/temporal/entities/urn:ngsi-ld:Sensor:001"
+	reqRes.ResHeader = http.Header{"Content-Range": []string{"items 0-1/*"}}
+	reqRes.ResBody = []byte(`{"id":"urn:ngsi-ld:Sensor:001","type":"Sensor"}`)
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	setupFlagString(set, "host,id")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion-ld", "--id=urn:ngsi-ld:Sensor:001"})
+	err := temporalRead(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 9, ngsiErr.ErrNo)
+		assert.Equal(t, "Content-Range error: items 0-1/*", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestTemporalListErrorPartialUnmarshal(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion-ld", "https://orion-ld", "ld")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusPartialContent
+	reqRes.Path = "/ngsi-ld/v1/temporal/entities"
+	reqRes.ResHeader = http.Header{"Ngsild-Results-Count": []string{"1"}, "Content-Range": []string{"date-time 2020-11-01T00:00:00Z-2020-11-02T00:00:00Z/*"}}
+	reqRes.ResBody = []byte(`[{"id":"urn:ngsi-ld:Sensor:001","type":"Sensor"}]`)
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	ngsi.JSONConverter = &MockJSONLib{DecodeErr: errors.New("json error")}
+	setupFlagString(set, "host")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion-ld"})
+	err := temporalList(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 11, ngsiErr.ErrNo)
+		assert.Equal(t, "json error", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestTemporalListErrorReadAll(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion-ld", "https://orion-ld", "ld")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusPartialContent
+	reqRes.Path = "/ngsi-ld/v1/temporal/entities"
+	reqRes.ResHeader = http.Header{"Ngsild-Results-Count": []string{"1"}, "Content-Range": []string{"items 0-1/*"}}
+	reqRes.ResBody = []byte(`[{"id":"urn:ngsi-ld:Sensor:001","type":"Sensor"}]`)
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	setupFlagString(set, "host")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion-ld"})
+	err := temporalList(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 12, ngsiErr.ErrNo)
+		assert.Equal(t, "Content-Range error: items 0-1/*", ngsiErr.Message)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestTemporalReadAllLastN(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker(t, ngsi, "orion-ld", "https://orion-ld", "ld")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusPartialContent
+	reqRes.Path = "/ngsi-ld/v1/temporal/entities/urn:ngsi-ld:Sensor:001"
+	reqRes.ResHeader = http.Header{"Content-Range": []string{"date-time 2020-11-01T00:00:00Z-2020-10-31T00:00:00Z/3"}}
+	reqRes.ResBody = []byte(`{"id":"urn:ngsi-ld:Sensor:001","type":"Sensor","temperature":{"type":"Property","values":[[19,"2020-10-31T00:00:00Z"]]}}`)
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	setupFlagString(set, "host")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion-ld"})
+	client, _ := newClient(ngsi, c, false)
+
+	entity := map[string]interface{}{"id": "urn:ngsi-ld:Sensor:001", "type": "Sensor",
+		"temperature": map[string]interface{}{"type": "Property", "values": []interface{}{[]interface{}{20.0, "2020-11-01T00:00:00Z"}}}}
+	res := &http.Response{StatusCode: http.StatusPartialContent, Header: http.Header{"Content-Range": []string{"date-time 2020-11-02T00:00:00Z-2020-11-01T00:00:00Z/3"}}}
+	v := &url.Values{"lastN": []string{"3"}, "options": []string{"temporalValues"}}
+
+	err := temporalReadAll(ngsi, client, "urn:ngsi-ld:Sensor:001", v, entity, res)
+
+	if assert.NoError(t, err) {
+		expected := []interface{}{[]interface{}{20.0, "2020-11-01T00:00:00Z"}, []interface{}{19.0, "2020-10-31T00:00:00Z"}}
+		assert.Equal(t, expected, entity["temperature"].(map[string]interface{})["values"])
+		assert.Equal(t, "lastN=2&options=temporalValues&timeAt=2020-11-01T00%3A00%3A00Z&timerel=before", client.URL.RawQuery)
+	}
+}
+
+func TestTemporalReadAllError(t *testing.T) {
+	cases := []struct {
+		reqRes  MockHTTPReqRes
+		safe    string
+		decode  bool
+		errNo   int
+		message string
+	}{
+		{reqRes: MockHTTPReqRes{Err: errors.New("http error")}, errNo: 2, message: "http error"},
+		{reqRes: MockHTTPReqRes{Res: http.Response{StatusCode: http.StatusBadRequest, Status: "400 Bad Request"}, ResBody: []byte("error")}, errNo: 3, message: "400 Bad Request error"},
+		{reqRes: MockHTTPReqRes{Res: http.Response{StatusCode: http.StatusOK}, ResBody: []byte("{")}, safe: "on", errNo: 4},
+		{reqRes: MockHTTPReqRes{Res: http.Response{StatusCode: http.StatusOK}, ResBody: []byte("{}")}, decode: true, errNo: 5, message: "json error"},
+	}
+
+	for _, c := range cases {
+		ngsi, set, app, _ := setupTest()
+
+		setupAddBroker(t, ngsi, "orion-ld", "https://orion-ld", "ld")
+
+		mock := NewMockHTTP()
+		mock.ReqRes = append(mock.ReqRes, c.reqRes)
+		ngsi.HTTP = mock
+		if c.decode {
+			ngsi.JSONConverter = &MockJSONLib{DecodeErr: errors.New("json error")}
+		}
+		setupFlagString(set, "host,safeString")
+
+		ctx := cli.NewContext(app, set, nil)
+		_ = set.Parse([]string{"--host=orion-ld", "--safeString=" + c.safe})
+		client, _ := newClient(ngsi, ctx, false)
+
+		res := &http.Response{StatusCode: http.StatusPartialContent, Header: http.Header{"Content-Range": []string{"date-time 2020-11-01T00:00:00Z-2020-11-02T00:00:00Z/*"}}}
+		err := temporalReadAll(ngsi, client, "urn:ngsi-ld:Sensor:001", &url.Values{}, map[string]interface{}{}, res)
+
+		if assert.Error(t, err) {
+			ngsiErr := err.(*ngsiCmdError)
+			assert.Equal(t, c.errNo, ngsiErr.ErrNo)
+			if c.message != "" {
+				assert.Equal(t, c.message, ngsiErr.Message)
+			}
+		}
+	}
+}
+
+func TestTemporalContentRange(t *testing.T) {
+	cases := []struct {
+		s     string
+		first string
+		last  string
+	}{
+		{s: "date-time 2020-08-01T12:05:00Z-2020-08-01T12:07:00Z/3", first: "2020-08-01T12:05:00Z", last: "2020-08-01T12:07:00Z"},
+		{s: "DateTime 2020-08-01T12:07:00.000Z-2020-08-01T12:05:00.000Z/*", first: "2020-08-01T12:05:00.000Z", last: "2020-08-01T12:07:00.000Z"},
+	}
+
+	for _, c := range cases {
+		first, last, err := temporalContentRange(c.s)
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, c.first, first)
+			assert.Equal(t, c.last, last)
+		}
+	}
+}
+
+func TestTemporalContentRangeError(t *testing.T) {
+	_, _, err := temporalContentRange("")
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 1, ngsiErr.ErrNo)
+		assert.Equal(t, "Content-Range error: ", ngsiErr.Message)
+	}
+}
+
+func TestTemporalNextQuery(t *testing.T) {
+	cases := []struct {
+		query    string
+		received int
+		expected string
+	}{
+		{query: "", expected: "timeAt=2020-11-02T00%3A00%3A00Z&timerel=after"},
+		{query: "timerel=between&timeAt=2020-11-01T00:00:00Z&endTimeAt=2020-11-05T00:00:00Z", expected: "endTimeAt=2020-11-05T00%3A00%3A00Z&timeAt=2020-11-02T00%3A00%3A00Z&timerel=between"},
+		{query: "timerel=before&timeAt=2020-11-05T00:00:00Z", expected: "endTimeAt=2020-11-05T00%3A00%3A00Z&timeAt=2020-11-02T00%3A00%3A00Z&timerel=between"},
+		{query: "lastN=10", received: 4, expected: "lastN=6&timeAt=2020-11-01T00%3A00%3A00Z&timerel=before"},
+		{query: "lastN=10&timerel=after&timeAt=2020-10-01T00:00:00Z", received: 4, expected: "endTimeAt=2020-11-01T00%3A00%3A00Z&lastN=6&timeAt=2020-10-01T00%3A00%3A00Z&timerel=between"},
+		{query: "lastN=10", received: 10, expected: ""},
+		{query: "timerel=between&timeAt=2020-11-02T00:00:00Z&endTimeAt=2020-11-05T00:00:00Z", expected: ""},
+	}
+
+	for _, c := range cases {
+		v, _ := url.ParseQuery(c.query)
+		actual := temporalNextQuery(&v, "2020-11-01T00:00:00Z", "2020-11-02T00:00:00Z", c.received)
+
+		if c.expected == "" {
+			assert.Nil(t, actual)
+		} else if assert.NotNil(t, actual) {
+			assert.Equal(t, c.expected, actual.Encode())
+		}
+	}
+}
+
+func TestTemporalMerge(t *testing.T) {
+	dst := map[string]interface{}{
+		"id":   "urn:ngsi-ld:Sensor:001",
+		"type": "Sensor",
+		"name": map[string]interface{}{"type": "Property", "value": "a", "instanceId": "urn:1"},
+	}
+	src := map[string]interface{}{
+		"id":          "urn:ngsi-ld:Sensor:001",
+		"type":        "Sensor",
+		"name":        []interface{}{map[string]interface{}{"type": "Property", "value": "a", "instanceId": "urn:1"}, map[string]interface{}{"type": "Property", "value": "b", "instanceId": "urn:2"}},
+		"temperature": []interface{}{map[string]interface{}{"type": "Property", "value": 20.0}},
+	}
+
+	actual := temporalMerge(dst, src)
+
+	assert.Equal(t, 1, actual)
+	assert.Equal(t, 2, len(dst["name"].([]interface{})))
+	assert.Equal(t, 1, len(dst["temperature"].([]interface{})))
+	assert.Equal(t, 2, temporalCount(dst))
+	assert.Nil(t, temporalInstances("a"))
+}
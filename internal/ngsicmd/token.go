@@ -31,11 +31,26 @@ package ngsicmd
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/lets-fiware/ngsi-go/internal/ngsilib"
 	"github.com/urfave/cli/v2"
 )
 
+type tokenDetail struct {
+	Host         string   `json:"host"`
+	IdmType      string   `json:"idmType"`
+	IdmHost      string   `json:"idmHost"`
+	ClientID     string   `json:"clientId,omitempty"`
+	Username     string   `json:"username,omitempty"`
+	TokenType    string   `json:"tokenType"`
+	Scope        []string `json:"scope"`
+	Expires      string   `json:"expires"`
+	ExpiresIn    int64    `json:"expiresIn"`
+	AccessToken  string   `json:"accessToken"`
+	RefreshToken string   `json:"refreshToken,omitempty"`
+}
+
 func tokenCommand(c *cli.Context) error {
 	const funcName = "tokenCmd"
 
@@ -55,23 +70,103 @@ func tokenCommand(c *cli.Context) error {
 		return &ngsiCmdError{funcName, 3, host + " has no token", err}
 	}
 
-	time := token.Expires - ngsi.TimeLib.NowUnix()
-	if time < 0 {
-		time = 0
+	expiresIn := token.Expires - ngsi.TimeLib.NowUnix()
+	if expiresIn < 0 {
+		expiresIn = 0
 	}
 
 	if c.Bool("verbose") {
-		token.Token.ExpiresIn = time
-		b, err := ngsilib.JSONMarshal(token.Token)
+		detail := tokenDetail{
+			Host:         host,
+			IdmType:      client.Broker.IdmType,
+			IdmHost:      token.IdmHost,
+			ClientID:     token.ClientID,
+			Username:     token.Username,
+			TokenType:    token.Token.TokenType,
+			Scope:        token.Token.Scope,
+			Expires:      time.Unix(token.Expires, 0).Format(time.RFC3339),
+			ExpiresIn:    expiresIn,
+			AccessToken:  token.Token.AccessToken,
+			RefreshToken: token.Token.RefreshToken,
+		}
+		b, err := ngsilib.JSONMarshal(detail)
 		if err != nil {
 			return &ngsiCmdError{funcName, 4, err.Error(), err}
 		}
-		fmt.Fprintln(ngsi.StdWriter, string(b))
+		printJSON(ngsi, b)
+	} else if c.Bool("introspect") {
+		b, err := ngsi.IntrospectToken(client)
+		if err != nil {
+			return &ngsiCmdError{funcName, 5, err.Error(), err}
+		}
+		printJSON(ngsi, b)
 	} else if c.Bool("expires") {
-		fmt.Fprintf(ngsi.StdWriter, "%d\n", time)
+		fmt.Fprintf(ngsi.StdWriter, "%d\n", expiresIn)
 	} else {
 		fmt.Fprintln(ngsi.StdWriter, client.Token)
 	}
 
 	return nil
 }
+
+func tokenList(c *cli.Context) error {
+	const funcName = "tokenList"
+
+	ngsi, err := initCmd(c, funcName, false)
+	if err != nil {
+		return &ngsiCmdError{funcName, 1, err.Error(), err}
+	}
+
+	now := ngsi.TimeLib.NowUnix()
+
+	for _, info := range ngsi.TokenInfoList() {
+		user := info.Username
+		if user == "" {
+			user = info.ClientID
+		}
+		expiresIn := info.Expires - now
+		if expiresIn < 0 {
+			expiresIn = 0
+		}
+		fmt.Fprintf(ngsi.StdWriter, "%s %s %s %d\n", info.Host, user, info.IdmHost, expiresIn)
+	}
+
+	return nil
+}
+
+func tokenRevoke(c *cli.Context) error {
+	const funcName = "tokenRevoke"
+
+	ngsi, err := initCmd(c, funcName, true)
+	if err != nil {
+		return &ngsiCmdError{funcName, 1, err.Error(), err}
+	}
+
+	client, err := ngsi.BrokerClient(ngsi.Host)
+	if err != nil {
+		return &ngsiCmdError{funcName, 2, err.Error(), err}
+	}
+
+	err = ngsi.RevokeToken(client)
+	if err != nil {
+		return &ngsiCmdError{funcName, 3, err.Error(), err}
+	}
+
+	return nil
+}
+
+func tokenClear(c *cli.Context) error {
+	const funcName = "tokenClear"
+
+	ngsi, err := initCmd(c, funcName, false)
+	if err != nil {
+		return &ngsiCmdError{funcName, 1, err.Error(), err}
+	}
+
+	err = ngsi.ClearTokens()
+	if err != nil {
+		return &ngsiCmdError{funcName, 2, err.Error(), err}
+	}
+
+	return nil
+}
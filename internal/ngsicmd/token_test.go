@@ -30,10 +30,14 @@ SOFTWARE.
 package ngsicmd
 
 import (
+	"bytes"
 	"errors"
+	"flag"
 	"net/http"
 	"testing"
+	"time"
 
+	"github.com/lets-fiware/ngsi-go/internal/ngsilib"
 	"github.com/stretchr/testify/assert"
 	"github.com/urfave/cli/v2"
 )
@@ -80,10 +84,12 @@ func TestVersionTokenCommandJSON(t *testing.T) {
 	_ = set.Parse([]string{"--host=orion", "--verbose"})
 	err := tokenCommand(c)
 
-	assert.NoError(t, err)
-	actual := buf.String()
-	expected := "{\"access_token\":\"c312d32a36a8a1df219a807a79323bb31941f462\",\"expires_in\":1156,\"refresh_token\":\"7cb75b47782195839ecbc7c7457f18abed853fe1\",\"scope\":[\"bearer\"],\"token_type\":\"Bearer\"}\n"
-	assert.Equal(t, expected, actual)
+	if assert.NoError(t, err) {
+		actual := buf.String()
+		expires := time.Unix(ngsi.TokenInfoList()[0].Expires, 0).Format(time.RFC3339)
+		expected := "{\"host\":\"orion\",\"idmType\":\"tokenproxy\",\"idmHost\":\"http://orion/token\",\"username\":\"testuser\",\"tokenType\":\"Bearer\",\"scope\":[\"bearer\"],\"expires\":\"" + expires + "\",\"expiresIn\":1156,\"accessToken\":\"c312d32a36a8a1df219a807a79323bb31941f462\",\"refreshToken\":\"7cb75b47782195839ecbc7c7457f18abed853fe1\"}\n"
+		assert.Equal(t, expected, actual)
+	}
 }
 
 func TestVersionTokenCommandJSONExpiresZero(t *testing.T) {
@@ -105,11 +111,12 @@ func TestVersionTokenCommandJSONExpiresZero(t *testing.T) {
 	_ = set.Parse([]string{"--host=orion", "--verbose"})
 	err := tokenCommand(c)
 
-	assert.NoError(t, err)
-	actual := buf.String()
-	expected := "{\"access_token\":\"c312d32a36a8a1df219a807a79323bb31941f462\",\"expires_in\":0,\"refresh_token\":\"7cb75b47782195839ecbc7c7457f18abed853fe1\",\"scope\":[\"bearer\"],\"token_type\":\"Bearer\"}\n"
-	assert.Equal(t, expected, actual)
-
+	if assert.NoError(t, err) {
+		actual := buf.String()
+		expires := time.Unix(ngsi.TokenInfoList()[0].Expires, 0).Format(time.RFC3339)
+		expected := "{\"host\":\"orion\",\"idmType\":\"tokenproxy\",\"idmHost\":\"http://orion/token\",\"username\":\"testuser\",\"tokenType\":\"Bearer\",\"scope\":[\"bearer\"],\"expires\":\"" + expires + "\",\"expiresIn\":0,\"accessToken\":\"c312d32a36a8a1df219a807a79323bb31941f462\",\"refreshToken\":\"7cb75b47782195839ecbc7c7457f18abed853fe1\"}\n"
+		assert.Equal(t, expected, actual)
+	}
 }
 
 func TestVersionTokenCommandExpires(t *testing.T) {
@@ -253,3 +260,201 @@ func TestVersionTokenCommandErrorJSON(t *testing.T) {
 		assert.Error(t, err)
 	}
 }
+
+func TestTokenCommandIntrospect(t *testing.T) {
+	ngsi, set, app, buf := setupTest()
+
+	setupAddBroker2(t, ngsi, "orion", "http://orion", "v2", "password", "http://idm/token", "testuser", "1234")
+	broker := (*ngsi.BrokerList())["orion"]
+	broker.ClientID = "0e2779a5-a12d-41ce-bc8b-b0ba3ab1cc4e"
+	broker.ClientSecret = "a2da7e2a-3e1c-48e1-b8e2-ec2e9d7a1a27"
+
+	reqRes1 := MockHTTPReqRes{}
+	reqRes1.Res.StatusCode = http.StatusOK
+	reqRes1.ResBody = []byte(`{"access_token":"c312d32a36a8a1df219a807a79323bb31941f462","expires_in":1156,"scope":["bearer"],"token_type":"Bearer"}`)
+	reqRes2 := MockHTTPReqRes{}
+	reqRes2.Res.StatusCode = http.StatusOK
+	reqRes2.Path = "/token/introspect"
+	reqRes2.ResBody = []byte(`{"active":true,"client_id":"0e2779a5-a12d-41ce-bc8b-b0ba3ab1cc4e","username":"testuser"}`)
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes1, reqRes2)
+	ngsi.HTTP = mock
+	setupFlagString(set, "host")
+	setupFlagBool(set, "introspect")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", "--introspect"})
+	err := tokenCommand(c)
+
+	if assert.NoError(t, err) {
+		actual := buf.String()
+		expected := "{\"active\":true,\"client_id\":\"0e2779a5-a12d-41ce-bc8b-b0ba3ab1cc4e\",\"username\":\"testuser\"}\n"
+		assert.Equal(t, expected, actual)
+	}
+}
+
+func TestTokenCommandErrorIntrospect(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker2(t, ngsi, "orion", "http://orion", "v2", "tokenproxy", "/token", "testuser", "1234")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusOK
+	reqRes.ResBody = []byte(`{"access_token":"c312d32a36a8a1df219a807a79323bb31941f462","expires_in":1156,"refresh_token":"7cb75b47782195839ecbc7c7457f18abed853fe1","scope":["bearer"],"token_type":"Bearer"}`)
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	setupFlagString(set, "host")
+	setupFlagBool(set, "introspect")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion", "--introspect"})
+	err := tokenCommand(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 5, ngsiErr.ErrNo)
+		assert.Equal(t, "introspection not supported: tokenproxy", ngsiErr.Message)
+	}
+}
+
+func setupTokenCache(t *testing.T) (*ngsilib.NGSI, *flag.FlagSet, *cli.App, *bytes.Buffer) {
+	ngsi, set, app, buf := setupTest()
+	ngsi.TimeLib = &MockTimeLib{unixTime: 1000}
+
+	setupAddBroker2(t, ngsi, "orion", "http://orion", "v2", "tokenproxy", "/token", "testuser", "1234")
+
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusOK
+	reqRes.ResBody = []byte(`{"access_token":"c312d32a36a8a1df219a807a79323bb31941f462","expires_in":1156,"refresh_token":"7cb75b47782195839ecbc7c7457f18abed853fe1","scope":["bearer"],"token_type":"Bearer"}`)
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	setupFlagString(set, "host")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion"})
+	err := tokenCommand(c)
+	assert.NoError(t, err)
+	buf.Reset()
+
+	set = flag.NewFlagSet("test", 0)
+	setupFlagString(set, "config,cacheFile")
+	_ = set.Parse([]string{"--config=", "--cacheFile="})
+
+	return ngsi, set, app, buf
+}
+
+func TestTokenList(t *testing.T) {
+	_, set, app, buf := setupTokenCache(t)
+
+	c := cli.NewContext(app, set, nil)
+	err := tokenList(c)
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, "orion testuser http://orion/token 1156\n", buf.String())
+	}
+}
+
+func TestTokenListErrorInitCmd(t *testing.T) {
+	_, set, app, _ := setupTest()
+
+	setupFlagString(set, "syslog")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--syslog="})
+	err := tokenList(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 1, ngsiErr.ErrNo)
+		assert.Equal(t, "syslog logLevel error", ngsiErr.Message)
+	}
+}
+
+func TestTokenRevoke(t *testing.T) {
+	ngsi, set, app, _ := setupTokenCache(t)
+
+	setupFlagString(set, "host")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion"})
+	err := tokenRevoke(c)
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, 0, len(ngsi.TokenInfoList()))
+	}
+}
+
+func TestTokenRevokeErrorInitCmd(t *testing.T) {
+	_, set, app, _ := setupTest()
+
+	c := cli.NewContext(app, set, nil)
+	err := tokenRevoke(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 1, ngsiErr.ErrNo)
+		assert.Equal(t, "Required host not found", ngsiErr.Message)
+	}
+}
+
+func TestTokenRevokeErrorBrokerClient(t *testing.T) {
+	_, set, app, _ := setupTest()
+
+	setupFlagString(set, "host")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion-ld"})
+	err := tokenRevoke(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 2, ngsiErr.ErrNo)
+		assert.Equal(t, "orion-ld not found", ngsiErr.Message)
+	}
+}
+
+func TestTokenRevokeErrorRevoke(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupAddBroker2(t, ngsi, "orion", "http://orion", "v2", "tokenproxy", "/token", "testuser", "1234")
+	setupFlagString(set, "host")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--host=orion"})
+	err := tokenRevoke(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 3, ngsiErr.ErrNo)
+		assert.Equal(t, "token not found", ngsiErr.Message)
+	}
+}
+
+func TestTokenClear(t *testing.T) {
+	ngsi, set, app, _ := setupTokenCache(t)
+
+	c := cli.NewContext(app, set, nil)
+	err := tokenClear(c)
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, 0, len(ngsi.TokenInfoList()))
+	}
+}
+
+func TestTokenClearErrorInitCmd(t *testing.T) {
+	_, set, app, _ := setupTest()
+
+	setupFlagString(set, "syslog")
+
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--syslog="})
+	err := tokenClear(c)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 1, ngsiErr.ErrNo)
+		assert.Equal(t, "syslog logLevel error", ngsiErr.Message)
+	}
+}
@@ -100,12 +100,19 @@ func typesList(c *cli.Context) error {
 		}
 	}
 
-	if c.IsSet("json") {
+	if outputEnabled(c) {
+		if types == nil {
+			types = []string{}
+		}
+		if err := outputPrint(c, ngsi, types); err != nil {
+			return &ngsiCmdError{funcName, 9, err.Error(), err}
+		}
+	} else if c.IsSet("json") {
 		b, err := ngsilib.JSONMarshal(types)
 		if err != nil {
 			return &ngsiCmdError{funcName, 8, err.Error(), err}
 		}
-		fmt.Fprintln(ngsi.StdWriter, string(b))
+		printJSON(ngsi, b)
 	} else {
 		for _, e := range types {
 			fmt.Fprintln(ngsi.StdWriter, e)
@@ -141,7 +148,14 @@ func typeGet(c *cli.Context) error {
 		return &ngsiCmdError{funcName, 5, fmt.Sprintf("error %s %s", res.Status, string(body)), nil}
 	}
 
-	fmt.Fprintln(ngsi.StdWriter, string(body))
+	if outputEnabled(c) {
+		if err := outputPrint(c, ngsi, body); err != nil {
+			return &ngsiCmdError{funcName, 6, err.Error(), err}
+		}
+		return nil
+	}
+
+	printJSON(ngsi, body)
 
 	return nil
 }
@@ -59,7 +59,11 @@ func cbVersion(c *cli.Context) error {
 		return &ngsiCmdError{funcName, 4, fmt.Sprintf("error %s %s", res.Status, string(body)), nil}
 	}
 
-	fmt.Fprint(ngsi.StdWriter, string(body))
+	if isPretty(ngsi) {
+		printJSON(ngsi, body)
+	} else {
+		fmt.Fprint(ngsi.StdWriter, string(body))
+	}
 
 	return nil
 }
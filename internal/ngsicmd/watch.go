@@ -0,0 +1,186 @@
+/*
+MIT License
+
+Copyright (c) 2020 Kazuhito Suda
+
+This file is part of NGSI Go
+
+https://github.com/lets-fiware/ngsi-go
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+package ngsicmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/lets-fiware/ngsi-go/internal/ngsilib"
+	"github.com/urfave/cli/v2"
+)
+
+const (
+	watchColorRed    = "\x1b[31m"
+	watchColorGreen  = "\x1b[32m"
+	watchColorYellow = "\x1b[33m"
+	watchColorReset  = "\x1b[0m"
+)
+
+// watchTick and watchSignal are variables so that tests can drive the polling loop.
+var watchTick = func(d time.Duration) (<-chan time.Time, func()) {
+	ticker := time.NewTicker(d)
+	return ticker.C, ticker.Stop
+}
+
+var watchSignal = func() (<-chan os.Signal, func()) {
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	return sig, func() { signal.Stop(sig) }
+}
+
+// watchRun polls entities with fetch at the interval and prints changes until interrupted.
+// When prev is nil, the current entities are fetched first.
+func watchRun(c *cli.Context, ngsi *ngsilib.NGSI, prev entitiesRespose, fetch func() (entitiesRespose, error)) error {
+	const funcName = "watchRun"
+
+	interval, err := watchInterval(c.String("interval"))
+	if err != nil {
+		return &ngsiCmdError{funcName, 1, err.Error(), err}
+	}
+
+	if prev == nil {
+		prev, err = fetch()
+		if err != nil {
+			return &ngsiCmdError{funcName, 2, err.Error(), err}
+		}
+	}
+
+	color := watchColor(ngsi.StdWriter)
+
+	tick, stopTick := watchTick(interval)
+	defer stopTick()
+	sig, stopSignal := watchSignal()
+	defer stopSignal()
+
+	for {
+		var now time.Time
+		var ok bool
+		select {
+		case <-sig:
+			return nil
+		case now, ok = <-tick:
+			if !ok {
+				return nil
+			}
+		}
+
+		cur, err := fetch()
+		if err != nil {
+			// a transient error such as a timeout does not stop watching
+			ngsi.Logging(ngsilib.LogErr, fmt.Sprintf("%s\n", err.Error()))
+			continue
+		}
+
+		result := diffCompare(prev, cur, nil)
+		if len(result.OnlySource)+len(result.OnlyDestination)+len(result.Different) > 0 {
+			if err := watchPrint(ngsi.StdWriter, result, now, color); err != nil {
+				return &ngsiCmdError{funcName, 3, err.Error(), err}
+			}
+		}
+		prev = cur
+	}
+}
+
+// watchInterval parses a duration such as 500ms or 1m. A number without a unit is seconds.
+func watchInterval(s string) (time.Duration, error) {
+	const funcName = "watchInterval"
+
+	if s == "" {
+		return 5 * time.Second, nil
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		n, e := strconv.Atoi(s)
+		if e != nil {
+			return 0, &ngsiCmdError{funcName, 1, "interval error: " + s, err}
+		}
+		d = time.Duration(n) * time.Second
+	}
+	if d <= 0 {
+		return 0, &ngsiCmdError{funcName, 2, "interval error: " + s, nil}
+	}
+
+	return d, nil
+}
+
+// watchColor returns true when w is a terminal and NO_COLOR is not set.
+func watchColor(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok || os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// watchPrint prints changes in the format of diff. Entity lines have a timestamp and
+// removed, added and changed lines are colored red, green and yellow.
+func watchPrint(w io.Writer, result *diffEntitiesResult, now time.Time, color bool) error {
+	const funcName = "watchPrint"
+
+	buf := &bytes.Buffer{}
+	if err := diffPrint(buf, result); err != nil {
+		return &ngsiCmdError{funcName, 1, err.Error(), err}
+	}
+
+	timestamp := now.Format(time.RFC3339)
+
+	for _, line := range strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n") {
+		marker := strings.TrimLeft(line, " ")
+		if !strings.HasPrefix(line, " ") {
+			line = timestamp + " " + line
+		}
+		if color && marker != "" {
+			switch marker[0] {
+			case '-':
+				line = watchColorRed + line + watchColorReset
+			case '+':
+				line = watchColorGreen + line + watchColorReset
+			case '~':
+				line = watchColorYellow + line + watchColorReset
+			}
+		}
+		fmt.Fprintln(w, line)
+	}
+
+	return nil
+}
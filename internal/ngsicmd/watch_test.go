@@ -0,0 +1,259 @@
+/*
+MIT License
+
+Copyright (c) 2020 Kazuhito Suda
+
+This file is part of NGSI Go
+
+https://github.com/lets-fiware/ngsi-go
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+package ngsicmd
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/cli/v2"
+)
+
+var testWatchTime = time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC)
+
+// setupWatch makes watchRun poll n times and then return. It returns a function to restore the variables.
+func setupWatch(n int) func() {
+	tick, signal := watchTick, watchSignal
+
+	watchTick = func(d time.Duration) (<-chan time.Time, func()) {
+		ch := make(chan time.Time, n)
+		for i := 0; i < n; i++ {
+			ch <- testWatchTime.Add(time.Duration(i) * d)
+		}
+		close(ch)
+		return ch, func() {}
+	}
+	watchSignal = func() (<-chan os.Signal, func()) {
+		return make(chan os.Signal), func() {}
+	}
+
+	return func() {
+		watchTick, watchSignal = tick, signal
+	}
+}
+
+func TestWatchRun(t *testing.T) {
+	ngsi, set, app, buf := setupTest()
+	defer setupWatch(3)()
+
+	setupFlagString(set, "interval")
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--interval=1s"})
+
+	states := []entitiesRespose{
+		{{"id": "E1", "type": "T", "temperature": 21.0}},
+		{{"id": "E1", "type": "T", "temperature": 22.0}},
+		{{"id": "E1", "type": "T", "temperature": 22.0}, {"id": "E2", "type": "T"}},
+	}
+	n := 0
+	err := watchRun(c, ngsi, states[0], func() (entitiesRespose, error) {
+		n++
+		switch n {
+		case 1:
+			return states[1], nil
+		case 2:
+			return nil, errors.New("fetch error")
+		}
+		return states[2], nil
+	})
+
+	if assert.NoError(t, err) {
+		expected := "2021-01-02T03:04:05Z ~ E1 (T)\n" +
+			"    ~ temperature value: 21 -> 22\n" +
+			"2021-01-02T03:04:07Z + E2 (T)\n"
+		assert.Equal(t, expected, buf.String())
+		assert.Equal(t, 3, n)
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestWatchRunFetchFirst(t *testing.T) {
+	ngsi, set, app, buf := setupTest()
+	defer setupWatch(1)()
+
+	c := cli.NewContext(app, set, nil)
+
+	n := 0
+	err := watchRun(c, ngsi, nil, func() (entitiesRespose, error) {
+		n++
+		if n == 1 {
+			return entitiesRespose{{"id": "E1", "type": "T"}}, nil
+		}
+		return entitiesRespose{}, nil
+	})
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, "2021-01-02T03:04:05Z - E1 (T)\n", buf.String())
+	} else {
+		t.FailNow()
+	}
+}
+
+func TestWatchRunSignal(t *testing.T) {
+	ngsi, set, app, buf := setupTest()
+	tick, signal := watchTick, watchSignal
+	defer func() { watchTick, watchSignal = tick, signal }()
+
+	watchTick = func(d time.Duration) (<-chan time.Time, func()) {
+		return make(chan time.Time), func() {}
+	}
+	watchSignal = func() (<-chan os.Signal, func()) {
+		sig := make(chan os.Signal, 1)
+		sig <- os.Interrupt
+		return sig, func() {}
+	}
+
+	c := cli.NewContext(app, set, nil)
+
+	err := watchRun(c, ngsi, entitiesRespose{}, func() (entitiesRespose, error) {
+		return nil, errors.New("not called")
+	})
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, "", buf.String())
+	}
+}
+
+func TestWatchRunErrorInterval(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	setupFlagString(set, "interval")
+	c := cli.NewContext(app, set, nil)
+	_ = set.Parse([]string{"--interval=abc"})
+
+	err := watchRun(c, ngsi, nil, nil)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 1, ngsiErr.ErrNo)
+		assert.Equal(t, "interval error: abc", ngsiErr.Message)
+	}
+}
+
+func TestWatchRunErrorFetch(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+
+	c := cli.NewContext(app, set, nil)
+
+	err := watchRun(c, ngsi, nil, func() (entitiesRespose, error) {
+		return nil, errors.New("fetch error")
+	})
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 2, ngsiErr.ErrNo)
+		assert.Equal(t, "fetch error", ngsiErr.Message)
+	}
+}
+
+func TestWatchRunErrorPrint(t *testing.T) {
+	ngsi, set, app, _ := setupTest()
+	defer setupWatch(1)()
+
+	c := cli.NewContext(app, set, nil)
+	ngsi.JSONConverter = &MockJSONLib{EncodeErr: errors.New("json error"), Jsonlib: ngsi.JSONConverter}
+
+	err := watchRun(c, ngsi, entitiesRespose{{"id": "E1", "type": "T", "a": 1.0}}, func() (entitiesRespose, error) {
+		return entitiesRespose{{"id": "E1", "type": "T", "a": 2.0}}, nil
+	})
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 3, ngsiErr.ErrNo)
+		assert.Equal(t, "json error", ngsiErr.Message)
+	}
+}
+
+func TestWatchInterval(t *testing.T) {
+	cases := []struct {
+		s        string
+		expected time.Duration
+	}{
+		{"", 5 * time.Second},
+		{"500ms", 500 * time.Millisecond},
+		{"1m", time.Minute},
+		{"10", 10 * time.Second},
+	}
+
+	for _, c := range cases {
+		d, err := watchInterval(c.s)
+		if assert.NoError(t, err) {
+			assert.Equal(t, c.expected, d)
+		}
+	}
+}
+
+func TestWatchIntervalError(t *testing.T) {
+	_, err := watchInterval("0")
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*ngsiCmdError)
+		assert.Equal(t, 2, ngsiErr.ErrNo)
+		assert.Equal(t, "interval error: 0", ngsiErr.Message)
+	}
+}
+
+func TestWatchColor(t *testing.T) {
+	ngsi, _, _, _ := setupTest()
+
+	assert.False(t, watchColor(ngsi.StdWriter))
+
+	f, err := ioutil.TempFile(t.TempDir(), "out")
+	if assert.NoError(t, err) {
+		assert.False(t, watchColor(f))
+		_ = f.Close()
+		assert.False(t, watchColor(f))
+	}
+}
+
+func TestWatchPrintColor(t *testing.T) {
+	ngsi, _, _, buf := setupTest()
+
+	result := &diffEntitiesResult{
+		OnlySource:      []diffEntityKey{{ID: "E1", Type: "T"}},
+		OnlyDestination: []diffEntityKey{{ID: "E2", Type: "T"}},
+		Different:       []diffEntity{{ID: "E3", Type: "T", Attrs: []diffAttr{{Name: "a", Diff: "onlyDestination"}}}},
+	}
+
+	err := watchPrint(ngsi.StdWriter, result, testWatchTime, true)
+
+	if assert.NoError(t, err) {
+		expected := "\x1b[31m2021-01-02T03:04:05Z - E1 (T)\x1b[0m\n" +
+			"\x1b[32m2021-01-02T03:04:05Z + E2 (T)\x1b[0m\n" +
+			"\x1b[33m2021-01-02T03:04:05Z ~ E3 (T)\x1b[0m\n" +
+			"\x1b[32m    + a\x1b[0m\n"
+		assert.Equal(t, expected, buf.String())
+	}
+}
@@ -30,45 +30,76 @@ SOFTWARE.
 package ngsilib
 
 import (
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
+	"io/ioutil"
+	"strconv"
 	"strings"
+	"time"
 )
 
 // Broker is
 type Broker struct {
-	BrokerHost   string `json:"brokerHost,omitempty"`
-	NgsiType     string `json:"ngsiType,omitempty"`
-	APIPath      string `json:"apiPath,omitempty"`
-	IdmType      string `json:"idmType,omitempty"`
-	IdmHost      string `json:"idmHost,omitempty"`
-	Token        string `json:"token,omitempty"`
-	Username     string `json:"username,omitempty"`
-	Password     string `json:"password,omitempty"`
-	ClientID     string `json:"clientId,omitempty"`
-	ClientSecret string `json:"clientSecret,omitempty"`
-	Context      string `json:"context,omitempty"`
-	Tenant       string `json:"tenant,omitempty"`
-	Scope        string `json:"scope,omitempty"`
-	SafeString   string `json:"safeString,omitempty"`
-	XAuthToken   string `json:"xAuthToken,omitempty"`
+	BrokerHost         string            `json:"brokerHost,omitempty"`
+	NgsiType           string            `json:"ngsiType,omitempty"`
+	APIPath            string            `json:"apiPath,omitempty"`
+	IdmType            string            `json:"idmType,omitempty"`
+	IdmHost            string            `json:"idmHost,omitempty"`
+	Token              string            `json:"token,omitempty"`
+	Username           string            `json:"username,omitempty"`
+	Password           string            `json:"password,omitempty"`
+	ClientID           string            `json:"clientId,omitempty"`
+	ClientSecret       string            `json:"clientSecret,omitempty"`
+	IdmScope           string            `json:"idmScope,omitempty"`
+	Audience           string            `json:"audience,omitempty"`
+	Context            string            `json:"context,omitempty"`
+	Tenant             string            `json:"tenant,omitempty"`
+	Scope              string            `json:"scope,omitempty"`
+	SafeString         string            `json:"safeString,omitempty"`
+	XAuthToken         string            `json:"xAuthToken,omitempty"`
+	ServerType         string            `json:"serverType,omitempty"`
+	Proxy              string            `json:"proxy,omitempty"`
+	CACert             string            `json:"caCert,omitempty"`
+	ClientCert         string            `json:"clientCert,omitempty"`
+	ClientKey          string            `json:"clientKey,omitempty"`
+	InsecureSkipVerify string            `json:"insecureSkipVerify,omitempty"`
+	Retry              string            `json:"retry,omitempty"`
+	RetryWait          string            `json:"retryWait,omitempty"`
+	PageSize           string            `json:"pageSize,omitempty"`
+	BatchSize          string            `json:"batchSize,omitempty"`
+	Headers            map[string]string `json:"headers,omitempty"`
 }
 
 const (
-	cBrokerHost        = "brokerHost"
-	cNgsiType          = "ngsiType"
-	cAPIPath           = "apiPath"
-	cIdmType           = "idmType"
-	cIdmHost           = "idmHost"
-	cToken             = "token"
-	cUsername          = "username"
-	cPassword          = "password"
-	cClientID          = "clientId"
-	cClientSecret      = "clientSecret"
-	cContext           = "context"
-	cFiwareService     = "fiwareService"
-	cFiwareServicePath = "fiwareServicePath"
-	cSafeString        = "safeString"
-	cXAuthToken        = "xAuthToken"
+	cBrokerHost         = "brokerHost"
+	cNgsiType           = "ngsiType"
+	cAPIPath            = "apiPath"
+	cIdmType            = "idmType"
+	cIdmHost            = "idmHost"
+	cToken              = "token"
+	cUsername           = "username"
+	cPassword           = "password"
+	cClientID           = "clientId"
+	cClientSecret       = "clientSecret"
+	cIdmScope           = "idmScope"
+	cAudience           = "audience"
+	cContext            = "context"
+	cFiwareService      = "fiwareService"
+	cFiwareServicePath  = "fiwareServicePath"
+	cSafeString         = "safeString"
+	cXAuthToken         = "xAuthToken"
+	cServerType         = "serverType"
+	cProxy              = "proxy"
+	cCACert             = "caCert"
+	cClientCert         = "clientCert"
+	cClientKey          = "clientKey"
+	cInsecureSkipVerify = "insecureSkipVerify"
+	cRetry              = "retry"
+	cRetryWait          = "retryWait"
+	cPageSize           = "pageSize"
+	cBatchSize          = "batchSize"
+	cHeader             = "header"
 )
 
 const (
@@ -76,6 +107,16 @@ const (
 	cKeyrock              = "keyrock"
 	cKeyrocktokenprovider = "keyrocktokenprovider"
 	cTokenproxy           = "tokenproxy"
+	cClientCredentials    = "clientcredentials"
+	cOpenIDConnect        = "oidc"
+	cBasic                = "basic"
+)
+
+const (
+	cServerBroker      = "broker"
+	cServerQuantumLeap = "quantumleap"
+	cServerComet       = "comet"
+	cServerIoTAgent    = "iota"
 )
 
 const (
@@ -91,12 +132,14 @@ const (
 
 var (
 	brokerArgs = []string{cBrokerHost, cNgsiType, cAPIPath,
-		cIdmType, cIdmHost, cToken, cUsername, cPassword, cClientID, cClientSecret,
-		cContext, cFiwareService, cFiwareServicePath, cSafeString, cXAuthToken}
-	idmTypes    = []string{cPasswordCredentials, cKeyrock, cKeyrocktokenprovider, cTokenproxy}
+		cIdmType, cIdmHost, cToken, cUsername, cPassword, cClientID, cClientSecret, cIdmScope, cAudience,
+		cContext, cFiwareService, cFiwareServicePath, cSafeString, cXAuthToken, cServerType, cProxy,
+		cCACert, cClientCert, cClientKey, cInsecureSkipVerify, cRetry, cRetryWait, cPageSize, cBatchSize, cHeader}
+	idmTypes    = []string{cPasswordCredentials, cKeyrock, cKeyrocktokenprovider, cTokenproxy, cClientCredentials, cOpenIDConnect, cBasic}
 	ngsiV2Types = []string{cNgsiV2, cNgsiv2, cV2}
 	ngsiLdTypes = []string{cNgsiLd, cLd}
 	apiPaths    = []string{cPathRoot, cPathV2, cPathNgsiLd}
+	serverTypes = []string{cServerBroker, cServerQuantumLeap, cServerComet, cServerIoTAgent}
 )
 
 func (ngsi *NGSI) checkAllParams(host *Broker) error {
@@ -150,6 +193,34 @@ func (ngsi *NGSI) checkAllParams(host *Broker) error {
 		return &NgsiLibError{funcName, 8, err.Error(), err}
 	}
 
+	if serverType := host.ServerType; serverType != "" {
+		if !Contains(serverTypes, strings.ToLower(serverType)) {
+			return &NgsiLibError{funcName, 9, fmt.Sprintf("serverType error: %s", serverType), nil}
+		}
+	}
+
+	if proxy := host.Proxy; proxy != "" {
+		if !IsHTTP(proxy) {
+			return &NgsiLibError{funcName, 10, fmt.Sprintf("proxy error: %s", proxy), nil}
+		}
+	}
+
+	if (host.ClientCert == "") != (host.ClientKey == "") {
+		return &NgsiLibError{funcName, 11, "clientCert and clientKey are required", nil}
+	}
+
+	if _, err := host.insecureSkipVerify(); err != nil {
+		return &NgsiLibError{funcName, 12, err.Error(), err}
+	}
+
+	if _, _, err := host.retryPolicy(); err != nil {
+		return &NgsiLibError{funcName, 13, err.Error(), err}
+	}
+
+	if _, _, err := host.sizes(); err != nil {
+		return &NgsiLibError{funcName, 14, err.Error(), err}
+	}
+
 	return nil
 }
 
@@ -188,6 +259,13 @@ func checkIdmParams(idmType string, idmHost string, username string, password st
 		return &NgsiLibError{funcName, 2, fmt.Sprintf("idmType error: %s", idmType), nil}
 	}
 
+	if strings.ToLower(idmType) == cBasic {
+		if username == "" {
+			return &NgsiLibError{funcName, 11, "username is needed", nil}
+		}
+		return nil
+	}
+
 	if idmHost == "" {
 		return &NgsiLibError{funcName, 3, "required idmHost not found", nil}
 	}
@@ -206,6 +284,22 @@ func checkIdmParams(idmType string, idmHost string, username string, password st
 		if username == "" && password != "" {
 			return &NgsiLibError{funcName, 6, "username is needed", nil}
 		}
+	case cClientCredentials:
+		if clientID == "" || clientSecret == "" {
+			return &NgsiLibError{funcName, 7, "clientID and clientSecret are needed", nil}
+		}
+	case cOpenIDConnect:
+		if clientID == "" {
+			return &NgsiLibError{funcName, 8, "clientID is needed", nil}
+		}
+		if username == "" {
+			if password != "" {
+				return &NgsiLibError{funcName, 9, "username is needed", nil}
+			}
+			if clientSecret == "" {
+				return &NgsiLibError{funcName, 10, "clientSecret is needed for client credentials", nil}
+			}
+		}
 	}
 	return nil
 }
@@ -253,6 +347,12 @@ func copyBrokerInfo(from *Broker, to *Broker) {
 	if from.ClientSecret != "" && to.ClientSecret == "" {
 		to.ClientSecret = from.ClientSecret
 	}
+	if from.IdmScope != "" && to.IdmScope == "" {
+		to.IdmScope = from.IdmScope
+	}
+	if from.Audience != "" && to.Audience == "" {
+		to.Audience = from.Audience
+	}
 	if from.Context != "" && to.Context == "" {
 		to.Context = from.Context
 	}
@@ -268,6 +368,46 @@ func copyBrokerInfo(from *Broker, to *Broker) {
 	if from.XAuthToken != "" && to.XAuthToken == "" {
 		to.XAuthToken = from.XAuthToken
 	}
+	if from.ServerType != "" && to.ServerType == "" {
+		to.ServerType = from.ServerType
+	}
+	if from.Proxy != "" && to.Proxy == "" {
+		to.Proxy = from.Proxy
+	}
+	if from.CACert != "" && to.CACert == "" {
+		to.CACert = from.CACert
+	}
+	if from.ClientCert != "" && to.ClientCert == "" {
+		to.ClientCert = from.ClientCert
+	}
+	if from.ClientKey != "" && to.ClientKey == "" {
+		to.ClientKey = from.ClientKey
+	}
+	if from.InsecureSkipVerify != "" && to.InsecureSkipVerify == "" {
+		to.InsecureSkipVerify = from.InsecureSkipVerify
+	}
+	if from.Retry != "" && to.Retry == "" {
+		to.Retry = from.Retry
+	}
+	if from.RetryWait != "" && to.RetryWait == "" {
+		to.RetryWait = from.RetryWait
+	}
+	if from.PageSize != "" && to.PageSize == "" {
+		to.PageSize = from.PageSize
+	}
+	if from.BatchSize != "" && to.BatchSize == "" {
+		to.BatchSize = from.BatchSize
+	}
+	if len(from.Headers) > 0 {
+		headers := make(map[string]string)
+		for k, v := range from.Headers {
+			headers[k] = v
+		}
+		for k, v := range to.Headers {
+			headers[k] = v
+		}
+		to.Headers = headers
+	}
 }
 func setBrokerParam(broker *Broker, param map[string]string) error {
 	const funcName = "setBrokerParam"
@@ -296,6 +436,10 @@ func setBrokerParam(broker *Broker, param map[string]string) error {
 			broker.ClientID = value
 		case cClientSecret:
 			broker.ClientSecret = value
+		case cIdmScope:
+			broker.IdmScope = value
+		case cAudience:
+			broker.Audience = value
 		case cContext:
 			broker.Context = value
 		case cFiwareService:
@@ -306,11 +450,70 @@ func setBrokerParam(broker *Broker, param map[string]string) error {
 			broker.SafeString = value
 		case cXAuthToken:
 			broker.XAuthToken = value
+		case cServerType:
+			broker.ServerType = value
+		case cProxy:
+			broker.Proxy = value
+		case cCACert:
+			broker.CACert = value
+		case cClientCert:
+			broker.ClientCert = value
+		case cClientKey:
+			broker.ClientKey = value
+		case cInsecureSkipVerify:
+			broker.InsecureSkipVerify = value
+		case cRetry:
+			broker.Retry = value
+		case cRetryWait:
+			broker.RetryWait = value
+		case cPageSize:
+			broker.PageSize = value
+		case cBatchSize:
+			broker.BatchSize = value
+		case cHeader:
+			if value == "" {
+				broker.Headers = nil
+				break
+			}
+			if err := setBrokerHeaders(broker, value); err != nil {
+				return &NgsiLibError{funcName, 2, err.Error(), err}
+			}
 		}
 	}
 	return nil
 }
 
+// setBrokerHeaders sets headers given as "key=value" separated by newlines to broker. A header value may contain
+// commas. A header with an empty value is removed.
+func setBrokerHeaders(broker *Broker, value string) error {
+	const funcName = "setBrokerHeaders"
+
+	headers := make(map[string]string)
+	for k, v := range broker.Headers {
+		headers[k] = v
+	}
+
+	for _, header := range strings.Split(value, "\n") {
+		kv := strings.SplitN(header, "=", 2)
+		key := strings.TrimSpace(kv[0])
+		if len(kv) != 2 || key == "" || strings.ContainsAny(key, " :\t") {
+			return &NgsiLibError{funcName, 1, fmt.Sprintf("header error: %s", header), nil}
+		}
+		if v := strings.TrimSpace(kv[1]); v != "" {
+			headers[key] = v
+		} else {
+			delete(headers, key)
+		}
+	}
+
+	if len(headers) == 0 {
+		headers = nil
+	}
+	broker.Headers = headers
+
+	return nil
+}
+
 // DeleteItem is ...
 func (ngsi *NGSI) DeleteItem(host string, item string) error {
 	const funcName = "DeleteItem"
@@ -380,3 +583,107 @@ func (info *Broker) xAuthToken() (bool, error) {
 	}
 	return b, nil
 }
+
+func (info *Broker) insecureSkipVerify() (bool, error) {
+	const funcName = "insecureSkipVerify"
+
+	value := info.InsecureSkipVerify
+	b, err := gNGSI.BoolFlag(value)
+	if err != nil {
+		return false, &NgsiLibError{funcName, 1, err.Error(), err}
+	}
+	return b, nil
+}
+
+func (info *Broker) tlsConfig() (*tls.Config, error) {
+	const funcName = "tlsConfig"
+
+	if info.CACert == "" && info.ClientCert == "" && info.InsecureSkipVerify == "" {
+		return nil, nil
+	}
+
+	insecure, err := info.insecureSkipVerify()
+	if err != nil {
+		return nil, &NgsiLibError{funcName, 1, err.Error(), err}
+	}
+
+	config := &tls.Config{InsecureSkipVerify: insecure}
+
+	if info.CACert != "" {
+		b, err := ioutil.ReadFile(info.CACert)
+		if err != nil {
+			return nil, &NgsiLibError{funcName, 2, err.Error(), err}
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(b) {
+			return nil, &NgsiLibError{funcName, 3, "certificate not found in " + info.CACert, nil}
+		}
+		config.RootCAs = pool
+	}
+
+	if info.ClientCert != "" {
+		cert, err := tls.LoadX509KeyPair(info.ClientCert, info.ClientKey)
+		if err != nil {
+			return nil, &NgsiLibError{funcName, 4, err.Error(), err}
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	return config, nil
+}
+
+// retryPolicy returns the number of retries and the initial wait between them.
+func (info *Broker) retryPolicy() (int, time.Duration, error) {
+	const funcName = "retryPolicy"
+
+	retry := 0
+	wait := defaultRetryWait
+
+	if info.Retry != "" {
+		n, err := strconv.Atoi(info.Retry)
+		if err != nil || n < 0 || n > maxRetry {
+			return 0, 0, &NgsiLibError{funcName, 1, fmt.Sprintf("retry error: %s", info.Retry), nil}
+		}
+		retry = n
+	}
+
+	if info.RetryWait != "" {
+		n, err := strconv.Atoi(info.RetryWait)
+		if err != nil || n < 1 || n > maxRetryWait {
+			return 0, 0, &NgsiLibError{funcName, 2, fmt.Sprintf("retryWait error: %s", info.RetryWait), nil}
+		}
+		wait = time.Duration(n) * time.Second
+	}
+
+	return retry, wait, nil
+}
+
+// MaxSize is the maximum number of entities in a request. It is the upper limit of --maxCount, --pageSize and --batchSize.
+const MaxSize = 3000
+
+// sizes returns the number of entities read per request and written per batch request.
+// 0 means that the size is not set.
+func (info *Broker) sizes() (int, int, error) {
+	const funcName = "sizes"
+
+	page := 0
+	batch := 0
+
+	if info.PageSize != "" {
+		n, err := strconv.Atoi(info.PageSize)
+		if err != nil || n < 1 || n > MaxSize {
+			return 0, 0, &NgsiLibError{funcName, 1, fmt.Sprintf("pageSize error: %s", info.PageSize), nil}
+		}
+		page = n
+	}
+
+	if info.BatchSize != "" {
+		n, err := strconv.Atoi(info.BatchSize)
+		if err != nil || n < 1 || n > MaxSize {
+			return 0, 0, &NgsiLibError{funcName, 2, fmt.Sprintf("batchSize error: %s", info.BatchSize), nil}
+		}
+		batch = n
+	}
+
+	return page, batch, nil
+}
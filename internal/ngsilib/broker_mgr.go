@@ -34,16 +34,22 @@ func (ngsi *NGSI) CreateBroker(name string, brokerParam map[string]string) error
 	const funcName = "CreateBroker"
 
 	broker := new(Broker)
-	setBrokerParam(broker, brokerParam)
+	if err := setBrokerParam(broker, brokerParam); err != nil {
+		return &NgsiLibError{funcName, 1, err.Error(), err}
+	}
 
 	if err := ngsi.checkAllParams(broker); err != nil {
-		return &NgsiLibError{funcName, 1, err.Error(), err}
+		return &NgsiLibError{funcName, 2, err.Error(), err}
+	}
+
+	if _, err := broker.tlsConfig(); err != nil {
+		return &NgsiLibError{funcName, 3, err.Error(), err}
 	}
 
 	ngsi.brokerList[name] = broker
 
 	if err := ngsi.saveConfigFile(); err != nil {
-		return &NgsiLibError{funcName, 2, err.Error(), err}
+		return &NgsiLibError{funcName, 4, err.Error(), err}
 	}
 
 	return nil
@@ -60,11 +66,14 @@ func (ngsi *NGSI) UpdateBroker(host string, brokerParam map[string]string) error
 		if err := ngsi.checkAllParams(broker); err != nil {
 			return &NgsiLibError{funcName, 2, err.Error(), err}
 		}
-		if err := ngsi.saveConfigFile(); err != nil {
+		if _, err := broker.tlsConfig(); err != nil {
 			return &NgsiLibError{funcName, 3, err.Error(), err}
 		}
+		if err := ngsi.saveConfigFile(); err != nil {
+			return &NgsiLibError{funcName, 4, err.Error(), err}
+		}
 	} else {
-		return &NgsiLibError{funcName, 4, host + " not found", nil}
+		return &NgsiLibError{funcName, 5, host + " not found", nil}
 	}
 	return nil
 }
@@ -31,6 +31,7 @@ package ngsilib
 
 import (
 	"errors"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -63,13 +64,13 @@ func TestCreateBrokerErrorParam(t *testing.T) {
 	InitBrokerList()
 
 	param := make(map[string]string)
-	param["host"] = "http://orion"
+	param["ngsiType"] = "v2"
 
 	err := ngsi.CreateBroker("orion", param)
 
 	if assert.Error(t, err) {
 		ngsiErr := err.(*NgsiLibError)
-		assert.Equal(t, 1, ngsiErr.ErrNo)
+		assert.Equal(t, 2, ngsiErr.ErrNo)
 		assert.Equal(t, "brokerHost not found", ngsiErr.Message)
 	}
 }
@@ -88,11 +89,50 @@ func TestCreateBrokerErrorSave(t *testing.T) {
 
 	if assert.Error(t, err) {
 		ngsiErr := err.(*NgsiLibError)
-		assert.Equal(t, 2, ngsiErr.ErrNo)
+		assert.Equal(t, 4, ngsiErr.ErrNo)
 		assert.Equal(t, "open error", ngsiErr.Message)
 	}
 }
 
+func TestCreateBrokerErrorHeader(t *testing.T) {
+	ngsi := testNgsiLibInit()
+	fileName := ""
+	ngsi.ConfigFile = &MockIoLib{filename: &fileName}
+
+	InitBrokerList()
+
+	param := make(map[string]string)
+	param["brokerHost"] = "http://orion"
+	param["header"] = "apikey"
+
+	err := ngsi.CreateBroker("orion", param)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*NgsiLibError)
+		assert.Equal(t, 1, ngsiErr.ErrNo)
+		assert.Equal(t, "header error: apikey", ngsiErr.Message)
+	}
+}
+
+func TestCreateBrokerErrorTLSConfig(t *testing.T) {
+	ngsi := testNgsiLibInit()
+	fileName := ""
+	ngsi.ConfigFile = &MockIoLib{filename: &fileName}
+
+	InitBrokerList()
+
+	param := make(map[string]string)
+	param["brokerHost"] = "https://orion"
+	param["caCert"] = filepath.Join(t.TempDir(), "ca.pem")
+
+	err := ngsi.CreateBroker("orion", param)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*NgsiLibError)
+		assert.Equal(t, 3, ngsiErr.ErrNo)
+	}
+}
+
 func TestUpdateBroker(t *testing.T) {
 	ngsi := testNgsiLibInit()
 	fileName := ""
@@ -165,6 +205,29 @@ func TestUpdateBrokerErrorCheckParam(t *testing.T) {
 	}
 }
 
+func TestUpdateBrokerErrorTLSConfig(t *testing.T) {
+	ngsi := testNgsiLibInit()
+	fileName := ""
+	ngsi.ConfigFile = &MockIoLib{filename: &fileName}
+
+	InitBrokerList()
+
+	param := make(map[string]string)
+	param["brokerHost"] = "https://orion"
+
+	err := ngsi.CreateBroker("orion", param)
+	assert.NoError(t, err)
+
+	param = make(map[string]string)
+	param["caCert"] = filepath.Join(t.TempDir(), "ca.pem")
+	err = ngsi.UpdateBroker("orion", param)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*NgsiLibError)
+		assert.Equal(t, 3, ngsiErr.ErrNo)
+	}
+}
+
 func TestUpdateBrokerErrorSave(t *testing.T) {
 	ngsi := testNgsiLibInit()
 	fileName := ""
@@ -187,7 +250,7 @@ func TestUpdateBrokerErrorSave(t *testing.T) {
 
 	if assert.Error(t, err) {
 		ngsiErr := err.(*NgsiLibError)
-		assert.Equal(t, 3, ngsiErr.ErrNo)
+		assert.Equal(t, 4, ngsiErr.ErrNo)
 		assert.Equal(t, "open error", ngsiErr.Message)
 	}
 }
@@ -211,7 +274,7 @@ func TestUpdateBrokerErrorNotFound(t *testing.T) {
 
 	if assert.Error(t, err) {
 		ngsiErr := err.(*NgsiLibError)
-		assert.Equal(t, 4, ngsiErr.ErrNo)
+		assert.Equal(t, 5, ngsiErr.ErrNo)
 		assert.Equal(t, "orion-ld not found", ngsiErr.Message)
 	}
 }
@@ -30,7 +30,17 @@ SOFTWARE.
 package ngsilib
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -237,6 +247,202 @@ func TestCheckAllParamsErrorSafeString(t *testing.T) {
 	}
 }
 
+func TestCheckAllParamsServerType(t *testing.T) {
+	ngsi := testNgsiLibInit()
+	fileName := ""
+	ngsi.ConfigFile = &MockIoLib{filename: &fileName}
+
+	InitBrokerList()
+
+	param := make(map[string]string)
+	param["brokerHost"] = "http://quantumleap"
+	param["serverType"] = "QuantumLeap"
+	err := ngsi.CreateBroker("ql", param)
+	assert.NoError(t, err)
+
+	host := ngsi.brokerList["ql"]
+	err = ngsi.checkAllParams(host)
+
+	assert.NoError(t, err)
+}
+
+func TestCheckAllParamsErrorServerType(t *testing.T) {
+	ngsi := testNgsiLibInit()
+	fileName := ""
+	ngsi.ConfigFile = &MockIoLib{filename: &fileName}
+
+	InitBrokerList()
+
+	param := make(map[string]string)
+	param["brokerHost"] = "http://orion"
+	err := ngsi.CreateBroker("orion", param)
+	assert.NoError(t, err)
+
+	host := ngsi.brokerList["orion"]
+	host.ServerType = "cygnus"
+	err = ngsi.checkAllParams(host)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*NgsiLibError)
+		assert.Equal(t, 9, ngsiErr.ErrNo)
+		assert.Equal(t, "serverType error: cygnus", ngsiErr.Message)
+	}
+}
+
+func TestCheckAllParamsProxy(t *testing.T) {
+	ngsi := testNgsiLibInit()
+	fileName := ""
+	ngsi.ConfigFile = &MockIoLib{filename: &fileName}
+
+	InitBrokerList()
+
+	param := make(map[string]string)
+	param["brokerHost"] = "http://orion"
+	param["ngsiType"] = "v2"
+	param["proxy"] = "http://proxy:8080"
+	err := ngsi.CreateBroker("orion", param)
+	assert.NoError(t, err)
+
+	host := ngsi.brokerList["orion"]
+	err = ngsi.checkAllParams(host)
+
+	assert.NoError(t, err)
+}
+
+func TestCheckAllParamsErrorProxy(t *testing.T) {
+	ngsi := testNgsiLibInit()
+	fileName := ""
+	ngsi.ConfigFile = &MockIoLib{filename: &fileName}
+
+	InitBrokerList()
+
+	param := make(map[string]string)
+	param["brokerHost"] = "http://orion"
+	err := ngsi.CreateBroker("orion", param)
+	assert.NoError(t, err)
+
+	host := ngsi.brokerList["orion"]
+	host.Proxy = "proxy:8080"
+	err = ngsi.checkAllParams(host)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*NgsiLibError)
+		assert.Equal(t, 10, ngsiErr.ErrNo)
+		assert.Equal(t, "proxy error: proxy:8080", ngsiErr.Message)
+	}
+}
+
+func TestCheckAllParamsErrorClientCert(t *testing.T) {
+	ngsi := testNgsiLibInit()
+	fileName := ""
+	ngsi.ConfigFile = &MockIoLib{filename: &fileName}
+
+	InitBrokerList()
+
+	param := make(map[string]string)
+	param["brokerHost"] = "http://orion"
+	err := ngsi.CreateBroker("orion", param)
+	assert.NoError(t, err)
+
+	host := ngsi.brokerList["orion"]
+	host.ClientCert = "/cert/client.pem"
+	err = ngsi.checkAllParams(host)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*NgsiLibError)
+		assert.Equal(t, 11, ngsiErr.ErrNo)
+		assert.Equal(t, "clientCert and clientKey are required", ngsiErr.Message)
+	}
+}
+
+func TestCheckAllParamsErrorInsecureSkipVerify(t *testing.T) {
+	ngsi := testNgsiLibInit()
+	fileName := ""
+	ngsi.ConfigFile = &MockIoLib{filename: &fileName}
+
+	InitBrokerList()
+
+	param := make(map[string]string)
+	param["brokerHost"] = "http://orion"
+	err := ngsi.CreateBroker("orion", param)
+	assert.NoError(t, err)
+
+	host := ngsi.brokerList["orion"]
+	host.InsecureSkipVerify = "none"
+	err = ngsi.checkAllParams(host)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*NgsiLibError)
+		assert.Equal(t, 12, ngsiErr.ErrNo)
+		assert.Equal(t, "unkown parameter: none", ngsiErr.Message)
+	}
+}
+
+func TestCheckAllParamsTLSFileNotRead(t *testing.T) {
+	ngsi := testNgsiLibInit()
+	fileName := ""
+	ngsi.ConfigFile = &MockIoLib{filename: &fileName}
+
+	InitBrokerList()
+
+	param := make(map[string]string)
+	param["brokerHost"] = "http://orion"
+	err := ngsi.CreateBroker("orion", param)
+	assert.NoError(t, err)
+
+	host := ngsi.brokerList["orion"]
+	host.CACert = filepath.Join(t.TempDir(), "ca.pem")
+	err = ngsi.checkAllParams(host)
+
+	assert.NoError(t, err)
+}
+
+func TestCheckAllParamsErrorRetry(t *testing.T) {
+	ngsi := testNgsiLibInit()
+	fileName := ""
+	ngsi.ConfigFile = &MockIoLib{filename: &fileName}
+
+	InitBrokerList()
+
+	param := make(map[string]string)
+	param["brokerHost"] = "http://orion"
+	err := ngsi.CreateBroker("orion", param)
+	assert.NoError(t, err)
+
+	host := ngsi.brokerList["orion"]
+	host.Retry = "-1"
+	err = ngsi.checkAllParams(host)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*NgsiLibError)
+		assert.Equal(t, 13, ngsiErr.ErrNo)
+		assert.Equal(t, "retry error: -1", ngsiErr.Message)
+	}
+}
+
+func TestCheckAllParamsErrorSize(t *testing.T) {
+	ngsi := testNgsiLibInit()
+	fileName := ""
+	ngsi.ConfigFile = &MockIoLib{filename: &fileName}
+
+	InitBrokerList()
+
+	param := make(map[string]string)
+	param["brokerHost"] = "http://orion"
+	err := ngsi.CreateBroker("orion", param)
+	assert.NoError(t, err)
+
+	host := ngsi.brokerList["orion"]
+	host.BatchSize = "0"
+	err = ngsi.checkAllParams(host)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*NgsiLibError)
+		assert.Equal(t, 14, ngsiErr.ErrNo)
+		assert.Equal(t, "batchSize error: 0", ngsiErr.Message)
+	}
+}
+
 func TestGetAPIPath(t *testing.T) {
 	b, a, err := getAPIPath("/,/api")
 
@@ -358,6 +564,45 @@ func TestCheckIdmParamsErrorUserPassword(t *testing.T) {
 	}
 }
 
+func TestCheckIdmParamsClientCredentials(t *testing.T) {
+	err := checkIdmParams(cClientCredentials, "http://keycloak/realms/fiware/protocol/openid-connect/token", "", "", "ngsi-go", "55555555-6666-7777-8888-999999999999")
+
+	assert.NoError(t, err)
+}
+
+func TestCheckIdmParamsOpenIDConnect(t *testing.T) {
+	err := checkIdmParams(cOpenIDConnect, "http://keycloak/realms/fiware", "fiware", "", "ngsi-go", "")
+
+	assert.NoError(t, err)
+}
+
+func TestCheckIdmParamsErrorOAuth2(t *testing.T) {
+	cases := []struct {
+		idmType      string
+		username     string
+		password     string
+		clientID     string
+		clientSecret string
+		errNo        int
+		message      string
+	}{
+		{idmType: cClientCredentials, clientID: "ngsi-go", errNo: 7, message: "clientID and clientSecret are needed"},
+		{idmType: cOpenIDConnect, clientSecret: "1111", errNo: 8, message: "clientID is needed"},
+		{idmType: cOpenIDConnect, password: "1234", clientID: "ngsi-go", errNo: 9, message: "username is needed"},
+		{idmType: cOpenIDConnect, clientID: "ngsi-go", errNo: 10, message: "clientSecret is needed for client credentials"},
+	}
+
+	for _, c := range cases {
+		err := checkIdmParams(c.idmType, "http://keycloak", c.username, c.password, c.clientID, c.clientSecret)
+
+		if assert.Error(t, err) {
+			ngsiErr := err.(*NgsiLibError)
+			assert.Equal(t, c.errNo, ngsiErr.ErrNo)
+			assert.Equal(t, c.message, ngsiErr.Message)
+		}
+	}
+}
+
 func TestExistsBrokerHostTrue(t *testing.T) {
 	ngsi := testNgsiLibInit()
 	fileName := ""
@@ -390,6 +635,22 @@ func TestExistsBrokerHostFalse(t *testing.T) {
 	assert.Equal(t, false, b)
 }
 
+func TestCheckIdmParamsBasic(t *testing.T) {
+	err := checkIdmParams(cBasic, "", "fiware", "", "", "")
+
+	assert.NoError(t, err)
+}
+
+func TestCheckIdmParamsErrorBasic(t *testing.T) {
+	err := checkIdmParams(cBasic, "", "", "1234", "", "")
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*NgsiLibError)
+		assert.Equal(t, 11, ngsiErr.ErrNo)
+		assert.Equal(t, "username is needed", ngsiErr.Message)
+	}
+}
+
 func TestServerInfoArgs(t *testing.T) {
 	ngsi := testNgsiLibInit()
 	args := ngsi.ServerInfoArgs()
@@ -415,6 +676,17 @@ func TestCopyBrokerInfo(t *testing.T) {
 	param[cFiwareServicePath] = "/iot"
 	param[cSafeString] = "off"
 	param[cXAuthToken] = "on"
+	param[cServerType] = "quantumleap"
+	param[cProxy] = "http://proxy:8080"
+	param[cCACert] = "/cert/ca.pem"
+	param[cClientCert] = "/cert/client.pem"
+	param[cClientKey] = "/cert/client-key.pem"
+	param[cInsecureSkipVerify] = "off"
+	param[cRetry] = "3"
+	param[cRetryWait] = "2"
+	param[cPageSize] = "500"
+	param[cBatchSize] = "50"
+	param[cHeader] = "apikey=b2a1c3d4"
 	setBrokerParam(&broker, param)
 
 	broker2 := Broker{}
@@ -442,6 +714,17 @@ func TestCopyBrokerInfo2(t *testing.T) {
 	param[cFiwareServicePath] = "/iot"
 	param[cSafeString] = "off"
 	param[cXAuthToken] = "on"
+	param[cServerType] = "quantumleap"
+	param[cProxy] = "http://proxy:8080"
+	param[cCACert] = "/cert/ca.pem"
+	param[cClientCert] = "/cert/client.pem"
+	param[cClientKey] = "/cert/client-key.pem"
+	param[cInsecureSkipVerify] = "off"
+	param[cRetry] = "3"
+	param[cRetryWait] = "2"
+	param[cPageSize] = "500"
+	param[cBatchSize] = "50"
+	param[cHeader] = "apikey=b2a1c3d4"
 	setBrokerParam(&broker, param)
 
 	broker2 := Broker{}
@@ -453,6 +736,16 @@ func TestCopyBrokerInfo2(t *testing.T) {
 	assert.Equal(t, expected, broker2)
 }
 
+func TestCopyBrokerInfoHeaders(t *testing.T) {
+	broker := Broker{Headers: map[string]string{"apikey": "b2a1c3d4", "X-Gateway": "kong"}}
+	broker2 := Broker{Headers: map[string]string{"apikey": "e5f6a7b8"}}
+
+	copyBrokerInfo(&broker, &broker2)
+
+	assert.Equal(t, map[string]string{"apikey": "e5f6a7b8", "X-Gateway": "kong"}, broker2.Headers)
+	assert.Equal(t, map[string]string{"apikey": "b2a1c3d4", "X-Gateway": "kong"}, broker.Headers)
+}
+
 func TestSetBrokerParam(t *testing.T) {
 	broker := Broker{}
 	param := make(map[string]string)
@@ -471,9 +764,72 @@ func TestSetBrokerParam(t *testing.T) {
 	param[cFiwareServicePath] = "/iot"
 	param[cSafeString] = "off"
 	param[cXAuthToken] = "on"
+	param[cServerType] = "comet"
+	param[cProxy] = "http://proxy:8080"
+	param[cCACert] = "/cert/ca.pem"
+	param[cClientCert] = "/cert/client.pem"
+	param[cClientKey] = "/cert/client-key.pem"
+	param[cInsecureSkipVerify] = "off"
+	param[cRetry] = "3"
+	param[cRetryWait] = "2"
+	param[cPageSize] = "500"
+	param[cBatchSize] = "50"
+	param[cHeader] = "apikey=b2a1c3d4"
 	err := setBrokerParam(&broker, param)
 
-	assert.NoError(t, err)
+	if assert.NoError(t, err) {
+		assert.Equal(t, map[string]string{"apikey": "b2a1c3d4"}, broker.Headers)
+	}
+}
+
+func TestSetBrokerParamHeader(t *testing.T) {
+	broker := Broker{Headers: map[string]string{"apikey": "b2a1c3d4", "X-Gateway": "kong"}}
+
+	err := setBrokerParam(&broker, map[string]string{cHeader: "X-Gateway=\nX-Request-Source = ngsi-go\nAccept=application/json, text/plain"})
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, map[string]string{"apikey": "b2a1c3d4", "X-Request-Source": "ngsi-go", "Accept": "application/json, text/plain"}, broker.Headers)
+	}
+
+	err = setBrokerParam(&broker, map[string]string{cHeader: "apikey=\nX-Request-Source=\nAccept="})
+
+	if assert.NoError(t, err) {
+		assert.Nil(t, broker.Headers)
+	}
+}
+
+func TestSetBrokerParamHeaderClear(t *testing.T) {
+	broker := Broker{Headers: map[string]string{"apikey": "b2a1c3d4"}}
+
+	err := setBrokerParam(&broker, map[string]string{cHeader: ""})
+
+	if assert.NoError(t, err) {
+		assert.Nil(t, broker.Headers)
+	}
+}
+
+func TestSetBrokerParamErrorHeader(t *testing.T) {
+	cases := []struct {
+		header  string
+		message string
+	}{
+		{header: "apikey", message: "header error: apikey"},
+		{header: "=b2a1c3d4", message: "header error: =b2a1c3d4"},
+		{header: "api key=b2a1c3d4", message: "header error: api key=b2a1c3d4"},
+		{header: "apikey:b2a1c3d4=", message: "header error: apikey:b2a1c3d4="},
+	}
+
+	for _, c := range cases {
+		broker := Broker{}
+
+		err := setBrokerParam(&broker, map[string]string{cHeader: c.header})
+
+		if assert.Error(t, err) {
+			ngsiErr := err.(*NgsiLibError)
+			assert.Equal(t, 2, ngsiErr.ErrNo)
+			assert.Equal(t, c.message, ngsiErr.Message)
+		}
+	}
 }
 
 func TestSetBrokerParamError(t *testing.T) {
@@ -719,3 +1075,224 @@ func TestXAuthTokenError(t *testing.T) {
 		assert.Equal(t, b, false)
 	}
 }
+
+func TestInsecureSkipVerifyTrue(t *testing.T) {
+	_ = testNgsiLibInit()
+	info := Broker{InsecureSkipVerify: "on"}
+
+	b, err := info.insecureSkipVerify()
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, true, b)
+	}
+}
+
+func TestInsecureSkipVerifyError(t *testing.T) {
+	_ = testNgsiLibInit()
+	info := Broker{InsecureSkipVerify: "error"}
+
+	b, err := info.insecureSkipVerify()
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*NgsiLibError)
+		assert.Equal(t, 1, ngsiErr.ErrNo)
+		assert.Equal(t, "unkown parameter: error", ngsiErr.Message)
+		assert.Equal(t, false, b)
+	}
+}
+
+func TestTLSConfigNone(t *testing.T) {
+	info := Broker{}
+
+	config, err := info.tlsConfig()
+
+	if assert.NoError(t, err) {
+		assert.Nil(t, config)
+	}
+}
+
+func TestTLSConfig(t *testing.T) {
+	_ = testNgsiLibInit()
+	cert, key := testWriteCert(t)
+	info := Broker{CACert: cert, ClientCert: cert, ClientKey: key, InsecureSkipVerify: "off"}
+
+	config, err := info.tlsConfig()
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, false, config.InsecureSkipVerify)
+		assert.NotNil(t, config.RootCAs)
+		assert.Equal(t, 1, len(config.Certificates))
+	}
+}
+
+func TestTLSConfigErrorInsecureSkipVerify(t *testing.T) {
+	_ = testNgsiLibInit()
+	info := Broker{InsecureSkipVerify: "error"}
+
+	_, err := info.tlsConfig()
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*NgsiLibError)
+		assert.Equal(t, 1, ngsiErr.ErrNo)
+	}
+}
+
+func TestTLSConfigErrorReadCACert(t *testing.T) {
+	_ = testNgsiLibInit()
+	info := Broker{CACert: filepath.Join(t.TempDir(), "ca.pem")}
+
+	_, err := info.tlsConfig()
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*NgsiLibError)
+		assert.Equal(t, 2, ngsiErr.ErrNo)
+	}
+}
+
+func TestTLSConfigErrorCACert(t *testing.T) {
+	_ = testNgsiLibInit()
+	caCert := filepath.Join(t.TempDir(), "ca.pem")
+	_ = ioutil.WriteFile(caCert, []byte("fiware"), 0600)
+	info := Broker{CACert: caCert}
+
+	_, err := info.tlsConfig()
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*NgsiLibError)
+		assert.Equal(t, 3, ngsiErr.ErrNo)
+		assert.Equal(t, "certificate not found in "+caCert, ngsiErr.Message)
+	}
+}
+
+func TestTLSConfigErrorClientCert(t *testing.T) {
+	_ = testNgsiLibInit()
+	cert, _ := testWriteCert(t)
+	info := Broker{ClientCert: cert, ClientKey: cert}
+
+	_, err := info.tlsConfig()
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*NgsiLibError)
+		assert.Equal(t, 4, ngsiErr.ErrNo)
+	}
+}
+
+func testWriteCert(t *testing.T) (string, string) {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+
+	template := x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "ngsi-go"},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &priv.PublicKey, priv)
+	assert.NoError(t, err)
+	keyDer, err := x509.MarshalECPrivateKey(priv)
+	assert.NoError(t, err)
+
+	dir := t.TempDir()
+	cert := filepath.Join(dir, "cert.pem")
+	key := filepath.Join(dir, "key.pem")
+	_ = ioutil.WriteFile(cert, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600)
+	_ = ioutil.WriteFile(key, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600)
+
+	return cert, key
+}
+
+func TestRetryPolicy(t *testing.T) {
+	info := Broker{Retry: "3", RetryWait: "2"}
+
+	retry, wait, err := info.retryPolicy()
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, 3, retry)
+		assert.Equal(t, 2*time.Second, wait)
+	}
+}
+
+func TestRetryPolicyDefault(t *testing.T) {
+	info := Broker{}
+
+	retry, wait, err := info.retryPolicy()
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, 0, retry)
+		assert.Equal(t, 1*time.Second, wait)
+	}
+}
+
+func TestRetryPolicyErrorRetry(t *testing.T) {
+	info := Broker{Retry: "11"}
+
+	_, _, err := info.retryPolicy()
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*NgsiLibError)
+		assert.Equal(t, 1, ngsiErr.ErrNo)
+		assert.Equal(t, "retry error: 11", ngsiErr.Message)
+	}
+}
+
+func TestRetryPolicyErrorRetryWait(t *testing.T) {
+	info := Broker{RetryWait: "0"}
+
+	_, _, err := info.retryPolicy()
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*NgsiLibError)
+		assert.Equal(t, 2, ngsiErr.ErrNo)
+		assert.Equal(t, "retryWait error: 0", ngsiErr.Message)
+	}
+}
+
+func TestSizes(t *testing.T) {
+	info := Broker{PageSize: "1000", BatchSize: "50"}
+
+	page, batch, err := info.sizes()
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, 1000, page)
+		assert.Equal(t, 50, batch)
+	}
+}
+
+func TestSizesDefault(t *testing.T) {
+	info := Broker{}
+
+	page, batch, err := info.sizes()
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, 0, page)
+		assert.Equal(t, 0, batch)
+	}
+}
+
+func TestSizesErrorPageSize(t *testing.T) {
+	info := Broker{PageSize: "3001"}
+
+	_, _, err := info.sizes()
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*NgsiLibError)
+		assert.Equal(t, 1, ngsiErr.ErrNo)
+		assert.Equal(t, "pageSize error: 3001", ngsiErr.Message)
+	}
+}
+
+func TestSizesErrorBatchSize(t *testing.T) {
+	info := Broker{BatchSize: "many"}
+
+	_, _, err := info.sizes()
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*NgsiLibError)
+		assert.Equal(t, 2, ngsiErr.ErrNo)
+		assert.Equal(t, "batchSize error: many", ngsiErr.Message)
+	}
+}
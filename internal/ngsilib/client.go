@@ -39,15 +39,18 @@ import (
 
 // Client is
 type Client struct {
+	Host          string
 	Broker        *Broker
 	URL           *url.URL
 	Headers       map[string]string
 	Token         string
+	BasicAuth     string
 	Tenant        string
 	Scope         string
 	APIPathBefore string
 	APIPathAfter  string
 	NgsiType      int
+	ServerType    int
 	SafeString    bool
 	XAuthToken    bool
 	Link          *string
@@ -59,17 +62,31 @@ const (
 	ngsiLd
 )
 
+const (
+	serverBroker = iota
+	serverQuantumLeap
+	serverComet
+	serverIoTAgent
+)
+
 // InitHeader is ...
 func (client *Client) InitHeader() error {
 	const funcName = "InitHeader"
 	client.Headers = make(map[string]string)
 
+	if client.Broker != nil {
+		for key, value := range client.Broker.Headers {
+			client.Headers[key] = value
+		}
+	}
 	if client.Token != "" {
 		if client.XAuthToken {
 			client.Headers["X-Auth-Token"] = client.Token
 		} else {
 			client.Headers["Authorization"] = "Bearer " + client.Token
 		}
+	} else if client.BasicAuth != "" {
+		client.Headers["Authorization"] = client.BasicAuth
 	}
 	if client.Tenant != "" {
 		if err := client.CheckTenant(client.Tenant); err != nil {
@@ -94,6 +111,40 @@ func (client *Client) InitHeader() error {
 	return nil
 }
 
+// Clone returns a copy of client that can be used concurrently with client.
+func (client *Client) Clone() *Client {
+	c := *client
+	if client.URL != nil {
+		u := *client.URL
+		c.URL = &u
+	}
+	c.Headers = make(map[string]string, len(client.Headers))
+	for key, value := range client.Headers {
+		c.Headers[key] = value
+	}
+	return &c
+}
+
+// PageSize returns the number of entities read per request configured in the broker, or size if not set.
+func (client *Client) PageSize(size int) int {
+	if client.Broker != nil {
+		if page, _, err := client.Broker.sizes(); err == nil && page > 0 {
+			return page
+		}
+	}
+	return size
+}
+
+// BatchSize returns the number of entities written per batch request configured in the broker, or size if not set.
+func (client *Client) BatchSize(size int) int {
+	if client.Broker != nil {
+		if _, batch, err := client.Broker.sizes(); err == nil && batch > 0 {
+			return batch
+		}
+	}
+	return size
+}
+
 // SetHeaders is ...
 func (client *Client) SetHeaders(headers map[string]string) {
 	if headers != nil {
@@ -126,8 +177,10 @@ func (client *Client) SetContentType() {
 
 // SetPath is ...
 func (client *Client) SetPath(path string) {
-	if path != "/version" {
-		if client.NgsiType == ngsiLd {
+	if path != "/version" && client.ServerType != serverIoTAgent {
+		if client.ServerType == serverComet {
+			path = "/STH/v1" + path
+		} else if client.NgsiType == ngsiLd {
 			path = "/ngsi-ld/v1" + path
 		} else {
 			path = "/v2" + path
@@ -161,6 +214,21 @@ func (client *Client) IsNgsiLd() bool {
 	return client.NgsiType == ngsiLd
 }
 
+// IsQuantumLeap is
+func (client *Client) IsQuantumLeap() bool {
+	return client.ServerType == serverQuantumLeap
+}
+
+// IsComet is
+func (client *Client) IsComet() bool {
+	return client.ServerType == serverComet
+}
+
+// IsIoTAgent is
+func (client *Client) IsIoTAgent() bool {
+	return client.ServerType == serverIoTAgent
+}
+
 // ResultsCount is ...
 func (client *Client) ResultsCount(res *http.Response) (int, error) {
 	if client.IsNgsiLd() {
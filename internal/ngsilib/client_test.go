@@ -73,6 +73,32 @@ func TestInitHeaderAuthorization(t *testing.T) {
 	}
 }
 
+func TestInitHeaderBasicAuth(t *testing.T) {
+	client := &Client{URL: &url.URL{}, Headers: map[string]string{}}
+	client.BasicAuth = "Basic Zml3YXJlOjEyMzQ="
+
+	err := client.InitHeader()
+	actual := client.Headers["Authorization"]
+	expected := "Basic Zml3YXJlOjEyMzQ="
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, expected, actual)
+	}
+}
+
+func TestInitHeaderBrokerHeaders(t *testing.T) {
+	client := &Client{URL: &url.URL{}, Headers: map[string]string{}}
+	client.Broker = &Broker{Headers: map[string]string{"apikey": "b2a1c3d4", "Authorization": "Bearer 1111"}}
+	client.Token = "000000000000000000000"
+
+	err := client.InitHeader()
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, "b2a1c3d4", client.Headers["apikey"])
+		assert.Equal(t, "Bearer 000000000000000000000", client.Headers["Authorization"])
+	}
+}
+
 func TestInitHeaderTenant(t *testing.T) {
 	client := &Client{URL: &url.URL{}, Headers: map[string]string{}}
 	client.XAuthToken = false
@@ -169,6 +195,20 @@ func TestSetHeadersNil(t *testing.T) {
 	client.SetHeaders(nil)
 }
 
+func TestClone(t *testing.T) {
+	u, _ := url.Parse("http://orion/v2/entities")
+	client := &Client{URL: u, Headers: map[string]string{"Fiware-Service": "iot"}, Tenant: "iot"}
+
+	actual := client.Clone()
+	actual.SetPath("/types")
+	actual.SetHeader("Accept", "application/json")
+
+	assert.Equal(t, "iot", actual.Tenant)
+	assert.Equal(t, "/v2/entities", client.URL.Path)
+	assert.Equal(t, map[string]string{"Fiware-Service": "iot"}, client.Headers)
+	assert.Equal(t, map[string]string{"Fiware-Service": "iot", "Accept": "application/json"}, actual.Headers)
+}
+
 func TestSetHeaders(t *testing.T) {
 	client := &Client{URL: &url.URL{}, Headers: map[string]string{}}
 	client.NgsiType = ngsiV2
@@ -271,6 +311,44 @@ func TestSetPathV2APIPath(t *testing.T) {
 	assert.Equal(t, expected, actual)
 }
 
+func TestSetPathComet(t *testing.T) {
+	client := &Client{URL: &url.URL{}, Headers: map[string]string{}}
+	client.NgsiType = ngsiV2
+	client.ServerType = serverComet
+
+	client.SetPath("/contextEntities")
+
+	actual := client.URL.Path
+	expected := "/STH/v1/contextEntities"
+	assert.Equal(t, expected, actual)
+}
+
+func TestSetPathIoTAgent(t *testing.T) {
+	client := &Client{URL: &url.URL{}, Headers: map[string]string{}}
+	client.NgsiType = ngsiV2
+	client.ServerType = serverIoTAgent
+	client.APIPathBefore = "/"
+	client.APIPathAfter = "/iota"
+
+	client.SetPath("/iot/devices")
+
+	actual := client.URL.Path
+	expected := "/iota/iot/devices"
+	assert.Equal(t, expected, actual)
+}
+
+func TestSetPathQuantumLeap(t *testing.T) {
+	client := &Client{URL: &url.URL{}, Headers: map[string]string{}}
+	client.NgsiType = ngsiV2
+	client.ServerType = serverQuantumLeap
+
+	client.SetPath("/entities")
+
+	actual := client.URL.Path
+	expected := "/v2/entities"
+	assert.Equal(t, expected, actual)
+}
+
 func TestIsSafeStringFalse(t *testing.T) {
 	client := &Client{URL: &url.URL{}, Headers: map[string]string{}}
 	client.SafeString = false
@@ -325,6 +403,60 @@ func TestIsNgsiLDStringTrue(t *testing.T) {
 	assert.Equal(t, expected, actual)
 }
 
+func TestIsIoTAgentTrue(t *testing.T) {
+	client := &Client{URL: &url.URL{}, Headers: map[string]string{}}
+	client.ServerType = serverIoTAgent
+
+	actual := client.IsIoTAgent()
+	expected := true
+	assert.Equal(t, expected, actual)
+}
+
+func TestIsIoTAgentFalse(t *testing.T) {
+	client := &Client{URL: &url.URL{}, Headers: map[string]string{}}
+	client.ServerType = serverBroker
+
+	actual := client.IsIoTAgent()
+	expected := false
+	assert.Equal(t, expected, actual)
+}
+
+func TestIsQuantumLeapTrue(t *testing.T) {
+	client := &Client{URL: &url.URL{}, Headers: map[string]string{}}
+	client.ServerType = serverQuantumLeap
+
+	actual := client.IsQuantumLeap()
+	expected := true
+	assert.Equal(t, expected, actual)
+}
+
+func TestIsQuantumLeapFalse(t *testing.T) {
+	client := &Client{URL: &url.URL{}, Headers: map[string]string{}}
+	client.ServerType = serverBroker
+
+	actual := client.IsQuantumLeap()
+	expected := false
+	assert.Equal(t, expected, actual)
+}
+
+func TestIsCometTrue(t *testing.T) {
+	client := &Client{URL: &url.URL{}, Headers: map[string]string{}}
+	client.ServerType = serverComet
+
+	actual := client.IsComet()
+	expected := true
+	assert.Equal(t, expected, actual)
+}
+
+func TestIsCometFalse(t *testing.T) {
+	client := &Client{URL: &url.URL{}, Headers: map[string]string{}}
+	client.ServerType = serverQuantumLeap
+
+	actual := client.IsComet()
+	expected := false
+	assert.Equal(t, expected, actual)
+}
+
 func TestResultsCountV2(t *testing.T) {
 	client := &Client{URL: &url.URL{}, Headers: map[string]string{}}
 	client.NgsiType = ngsiV2
@@ -430,3 +562,27 @@ func TestCheckScopeError(t *testing.T) {
 		assert.Equal(t, "error FIWARE ServicePath: fiware", ngsiErr.Message)
 	}
 }
+
+func TestPageSize(t *testing.T) {
+	client := &Client{Broker: &Broker{PageSize: "500"}}
+
+	assert.Equal(t, 500, client.PageSize(100))
+}
+
+func TestPageSizeDefault(t *testing.T) {
+	client := &Client{Broker: &Broker{PageSize: "0"}}
+
+	assert.Equal(t, 100, client.PageSize(100))
+}
+
+func TestBatchSize(t *testing.T) {
+	client := &Client{Broker: &Broker{BatchSize: "50"}}
+
+	assert.Equal(t, 50, client.BatchSize(100))
+}
+
+func TestBatchSizeDefault(t *testing.T) {
+	client := &Client{}
+
+	assert.Equal(t, 100, client.BatchSize(100))
+}
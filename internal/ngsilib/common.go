@@ -31,10 +31,12 @@ package ngsilib
 
 import (
 	"encoding/json"
+	"errors"
 	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"syscall"
 	"time"
 )
 
@@ -60,11 +62,15 @@ type IoLib interface {
 	Getenv(key string) string
 	FilePathAbs(path string) (string, error)
 	FilePathJoin(elem ...string) string
+	Lock() error
+	Unlock() error
+	Rename(oldpath, newpath string) error
 }
 
 type ioLib struct {
 	file     *os.File
 	fileName *string
+	lock     *os.File
 }
 
 func (io *ioLib) Open() (err error) {
@@ -131,6 +137,56 @@ func (io *ioLib) FilePathJoin(elem ...string) string {
 	return filepath.Join(elem...)
 }
 
+const (
+	lockRetry = 100
+	lockWait  = 50 * time.Millisecond
+)
+
+// Lock takes an exclusive flock on a lock file next to the file so that concurrent processes update
+// the file exclusively. The kernel releases the lock when a process is killed, so no stale lock is left.
+func (io *ioLib) Lock() error {
+	f, err := os.OpenFile(*io.fileName+".lock", os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+
+	for i := 0; ; i++ {
+		err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+		if err == nil {
+			io.lock = f
+			return nil
+		}
+		if err != syscall.EWOULDBLOCK {
+			_ = f.Close()
+			return err
+		}
+		if i >= lockRetry {
+			_ = f.Close()
+			return errors.New(*io.fileName + " is locked")
+		}
+		time.Sleep(lockWait)
+	}
+}
+
+// Unlock releases the lock. The lock file is kept, as removing it would let another process lock
+// a new file while a waiter still holds the old one.
+func (io *ioLib) Unlock() error {
+	if io.lock == nil {
+		return nil
+	}
+	f := io.lock
+	io.lock = nil
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_UN); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func (io *ioLib) Rename(oldpath, newpath string) error {
+	return os.Rename(oldpath, newpath)
+}
+
 // FileLib is ...
 type FileLib interface {
 	Open(path string) error
@@ -204,6 +260,7 @@ func (j *jsonLib) Encode(w io.Writer, v interface{}) error {
 type TimeLib interface {
 	Now() time.Time
 	NowUnix() int64
+	Sleep(d time.Duration)
 }
 
 type timeLib struct{}
@@ -215,3 +272,7 @@ func (t *timeLib) Now() time.Time {
 func (t *timeLib) NowUnix() int64 {
 	return time.Now().Unix()
 }
+
+func (t *timeLib) Sleep(d time.Duration) {
+	time.Sleep(d)
+}
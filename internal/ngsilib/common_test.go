@@ -31,6 +31,9 @@ package ngsilib
 
 import (
 	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -173,6 +176,76 @@ func TestFilePathJoin(t *testing.T) {
 	assert.Equal(t, "/home", s)
 }
 
+func TestLock(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "cache")
+	io := ioLib{fileName: &filename}
+
+	err := io.Lock()
+	assert.NoError(t, err)
+	_, err = os.Stat(filename + ".lock")
+	assert.NoError(t, err)
+
+	err = io.Unlock()
+	assert.NoError(t, err)
+	assert.Nil(t, io.lock)
+}
+
+func TestLockLeftFile(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "cache")
+	io := ioLib{fileName: &filename}
+
+	_ = ioutil.WriteFile(filename+".lock", nil, 0600)
+
+	err := io.Lock()
+	assert.NoError(t, err)
+
+	err = io.Unlock()
+	assert.NoError(t, err)
+}
+
+func TestLockRelock(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "cache")
+	io1 := ioLib{fileName: &filename}
+	io2 := ioLib{fileName: &filename}
+
+	err := io1.Lock()
+	assert.NoError(t, err)
+	err = io1.Unlock()
+	assert.NoError(t, err)
+
+	err = io2.Lock()
+	assert.NoError(t, err)
+	err = io2.Unlock()
+	assert.NoError(t, err)
+}
+
+func TestLockError(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "dir", "cache")
+	io := ioLib{fileName: &filename}
+
+	err := io.Lock()
+
+	assert.Error(t, err)
+}
+
+func TestUnlockNotLocked(t *testing.T) {
+	io := ioLib{}
+
+	err := io.Unlock()
+
+	assert.NoError(t, err)
+}
+
+func TestRename(t *testing.T) {
+	dir := t.TempDir()
+	io := ioLib{}
+	_ = ioutil.WriteFile(filepath.Join(dir, "old"), nil, 0600)
+
+	err := io.Rename(filepath.Join(dir, "old"), filepath.Join(dir, "new"))
+
+	assert.NoError(t, err)
+}
+
 func TestFileLibOpen(t *testing.T) {
 	f := &fileLib{}
 	err := f.Open("???")
@@ -275,3 +348,9 @@ func TestTimeLibNowUnix(t *testing.T) {
 
 	_ = time.NowUnix()
 }
+
+func TestTimeLibSleep(t *testing.T) {
+	time := &timeLib{}
+
+	time.Sleep(0)
+}
@@ -46,6 +46,7 @@ type Settings struct {
 	Tenant          string `json:"tenant"`
 	Scope           string `json:"scope"`
 	Token           string `json:"token"`
+	Pretty          bool   `json:"pretty"`
 }
 
 // NgsiConfig is ...
@@ -0,0 +1,366 @@
+/*
+MIT License
+
+Copyright (c) 2020 Kazuhito Suda
+
+This file is part of NGSI Go
+
+https://github.com/lets-fiware/ngsi-go
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+package ngsilib
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+const (
+	credentialFileName    = "ngsi-go-credentials.json"
+	credentialPassphrase  = "NGSI_GO_PASSPHRASE"
+	credentialIterations  = 100000
+	credentialCheckString = "ngsi-go"
+)
+
+// credentialStore is the file which holds passwords encrypted with AES-GCM. The key is derived
+// from a master passphrase with PBKDF2-SHA256. Check is an encrypted known string to find a wrong
+// passphrase before a password is added.
+type credentialStore struct {
+	Version     int               `json:"version"`
+	Salt        string            `json:"salt"`
+	Iterations  int               `json:"iterations"`
+	Check       string            `json:"check"`
+	Credentials map[string]string `json:"credentials"`
+}
+
+// CredentialKey returns the key of a password in the credential store, which is username@idmURL.
+func CredentialKey(client *Client) (string, error) {
+	const funcName = "CredentialKey"
+
+	if client.Broker.IdmType == "" || (client.Broker.IdmHost == "" && strings.ToLower(client.Broker.IdmType) != cBasic) {
+		return "", &NgsiLibError{funcName, 1, "idmType and idmHost not found", nil}
+	}
+	if client.Broker.Username == "" {
+		return "", &NgsiLibError{funcName, 2, "username not found", nil}
+	}
+
+	return client.Broker.Username + "@" + client.idmURL(), nil
+}
+
+// GetCredential returns a password in the credential store. The passphrase is asked only
+// when the password is found.
+func (ngsi *NGSI) GetCredential(client *Client) (string, bool, error) {
+	const funcName = "GetCredential"
+
+	key, err := CredentialKey(client)
+	if err != nil {
+		return "", false, nil
+	}
+	if _, err := ngsi.credentialFile(); err != nil {
+		return "", false, nil
+	}
+
+	store, err := ngsi.loadCredentialStore()
+	if err != nil {
+		return "", false, &NgsiLibError{funcName, 1, err.Error(), err}
+	}
+
+	s, ok := store.Credentials[key]
+	if !ok {
+		return "", false, nil
+	}
+
+	k, err := ngsi.credentialCipherKey(store)
+	if err != nil {
+		return "", false, &NgsiLibError{funcName, 2, err.Error(), err}
+	}
+
+	password, err := credentialDecrypt(k, s)
+	if err != nil {
+		return "", false, &NgsiLibError{funcName, 3, err.Error(), err}
+	}
+
+	return password, true, nil
+}
+
+// SetCredential encrypts a password and saves it in the credential store.
+func (ngsi *NGSI) SetCredential(client *Client, password string) error {
+	const funcName = "SetCredential"
+
+	key, err := CredentialKey(client)
+	if err != nil {
+		return &NgsiLibError{funcName, 1, err.Error(), err}
+	}
+
+	store, err := ngsi.loadCredentialStore()
+	if err != nil {
+		return &NgsiLibError{funcName, 2, err.Error(), err}
+	}
+
+	k, err := ngsi.credentialCipherKey(store)
+	if err != nil {
+		return &NgsiLibError{funcName, 3, err.Error(), err}
+	}
+
+	s, err := credentialEncrypt(k, password)
+	if err != nil {
+		return &NgsiLibError{funcName, 4, err.Error(), err}
+	}
+	store.Credentials[key] = s
+
+	if err := ngsi.saveCredentialStore(store); err != nil {
+		return &NgsiLibError{funcName, 5, err.Error(), err}
+	}
+
+	return nil
+}
+
+// DeleteCredential deletes a password from the credential store.
+func (ngsi *NGSI) DeleteCredential(client *Client) error {
+	const funcName = "DeleteCredential"
+
+	key, err := CredentialKey(client)
+	if err != nil {
+		return &NgsiLibError{funcName, 1, err.Error(), err}
+	}
+
+	store, err := ngsi.loadCredentialStore()
+	if err != nil {
+		return &NgsiLibError{funcName, 2, err.Error(), err}
+	}
+
+	if _, ok := store.Credentials[key]; !ok {
+		return &NgsiLibError{funcName, 3, key + " not found", nil}
+	}
+	delete(store.Credentials, key)
+
+	if err := ngsi.saveCredentialStore(store); err != nil {
+		return &NgsiLibError{funcName, 4, err.Error(), err}
+	}
+
+	return nil
+}
+
+// CredentialList returns the keys of passwords in the credential store.
+func (ngsi *NGSI) CredentialList() ([]string, error) {
+	const funcName = "CredentialList"
+
+	store, err := ngsi.loadCredentialStore()
+	if err != nil {
+		return nil, &NgsiLibError{funcName, 1, err.Error(), err}
+	}
+
+	keys := []string{}
+	for k := range store.Credentials {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys, nil
+}
+
+// credentialFile returns the credential store, which is in the same directory as the config file.
+func (ngsi *NGSI) credentialFile() (IoLib, error) {
+	const funcName = "credentialFile"
+
+	io := ngsi.CredFile
+
+	if io.FileName() == nil {
+		config := ngsi.ConfigFile.FileName()
+		if config == nil || *config == "" {
+			return nil, &NgsiLibError{funcName, 1, "credential store not available without config file", nil}
+		}
+		s := filepath.Join(filepath.Dir(*config), credentialFileName)
+		io.SetFileName(&s)
+	}
+
+	return io, nil
+}
+
+func (ngsi *NGSI) loadCredentialStore() (*credentialStore, error) {
+	const funcName = "loadCredentialStore"
+
+	store := &credentialStore{Version: 1, Credentials: make(map[string]string)}
+
+	io, err := ngsi.credentialFile()
+	if err != nil {
+		return nil, &NgsiLibError{funcName, 1, err.Error(), err}
+	}
+
+	if !existsFile(io, *io.FileName()) {
+		return store, nil
+	}
+
+	if err := io.Open(); err != nil {
+		return nil, &NgsiLibError{funcName, 2, err.Error(), err}
+	}
+	defer io.Close()
+
+	if err := io.Decode(store); err != nil {
+		return nil, &NgsiLibError{funcName, 3, err.Error(), err}
+	}
+	if store.Credentials == nil {
+		store.Credentials = make(map[string]string)
+	}
+
+	return store, nil
+}
+
+func (ngsi *NGSI) saveCredentialStore(store *credentialStore) error {
+	const funcName = "saveCredentialStore"
+
+	io, err := ngsi.credentialFile()
+	if err != nil {
+		return &NgsiLibError{funcName, 1, err.Error(), err}
+	}
+
+	if err := io.OpenFile(oWRONLY|oCREATE, 0600); err != nil {
+		return &NgsiLibError{funcName, 2, err.Error(), err}
+	}
+	defer io.Close()
+
+	if err := io.Truncate(0); err != nil {
+		return &NgsiLibError{funcName, 3, err.Error(), err}
+	}
+
+	if err := io.Encode(store); err != nil {
+		return &NgsiLibError{funcName, 4, err.Error(), err}
+	}
+
+	return nil
+}
+
+// credentialPassphrase returns the master passphrase from NGSI_GO_PASSPHRASE or the terminal.
+func (ngsi *NGSI) credentialPassphrase() (string, error) {
+	const funcName = "credentialPassphrase"
+
+	if s := ngsi.CredFile.Getenv(credentialPassphrase); s != "" {
+		return s, nil
+	}
+
+	if ngsi.TermLib.IsTerminal() {
+		s, err := ngsi.TermLib.ReadPassword("Passphrase: ")
+		if err != nil {
+			return "", &NgsiLibError{funcName, 1, err.Error(), err}
+		}
+		if s != "" {
+			return s, nil
+		}
+	}
+
+	return "", &NgsiLibError{funcName, 2, "passphrase is required (set " + credentialPassphrase + ")", nil}
+}
+
+// credentialCipherKey derives the key from the passphrase. A new store gets a salt and a check value.
+func (ngsi *NGSI) credentialCipherKey(store *credentialStore) ([]byte, error) {
+	const funcName = "credentialCipherKey"
+
+	passphrase, err := ngsi.credentialPassphrase()
+	if err != nil {
+		return nil, &NgsiLibError{funcName, 1, err.Error(), err}
+	}
+
+	if store.Salt == "" {
+		salt := make([]byte, 16)
+		if _, err := rand.Read(salt); err != nil {
+			return nil, &NgsiLibError{funcName, 2, err.Error(), err}
+		}
+		store.Salt = base64.StdEncoding.EncodeToString(salt)
+		store.Iterations = credentialIterations
+		key := pbkdf2.Key([]byte(passphrase), salt, store.Iterations, 32, sha256.New)
+		store.Check, err = credentialEncrypt(key, credentialCheckString)
+		if err != nil {
+			return nil, &NgsiLibError{funcName, 3, err.Error(), err}
+		}
+		return key, nil
+	}
+
+	salt, err := base64.StdEncoding.DecodeString(store.Salt)
+	if err != nil {
+		return nil, &NgsiLibError{funcName, 4, err.Error(), err}
+	}
+	key := pbkdf2.Key([]byte(passphrase), salt, store.Iterations, 32, sha256.New)
+
+	if s, err := credentialDecrypt(key, store.Check); err != nil || s != credentialCheckString {
+		return nil, &NgsiLibError{funcName, 5, "passphrase is incorrect", nil}
+	}
+
+	return key, nil
+}
+
+func credentialEncrypt(key []byte, plaintext string) (string, error) {
+	const funcName = "credentialEncrypt"
+
+	gcm, err := credentialGCM(key)
+	if err != nil {
+		return "", &NgsiLibError{funcName, 1, err.Error(), err}
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", &NgsiLibError{funcName, 2, err.Error(), err}
+	}
+
+	b := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
+
+	return base64.StdEncoding.EncodeToString(b), nil
+}
+
+func credentialDecrypt(key []byte, s string) (string, error) {
+	const funcName = "credentialDecrypt"
+
+	gcm, err := credentialGCM(key)
+	if err != nil {
+		return "", &NgsiLibError{funcName, 1, err.Error(), err}
+	}
+
+	b, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return "", &NgsiLibError{funcName, 2, err.Error(), err}
+	}
+	if len(b) < gcm.NonceSize() {
+		return "", &NgsiLibError{funcName, 3, "credential data error", nil}
+	}
+
+	plaintext, err := gcm.Open(nil, b[:gcm.NonceSize()], b[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", &NgsiLibError{funcName, 4, err.Error(), err}
+	}
+
+	return string(plaintext), nil
+}
+
+func credentialGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,357 @@
+/*
+MIT License
+
+Copyright (c) 2020 Kazuhito Suda
+
+This file is part of NGSI Go
+
+https://github.com/lets-fiware/ngsi-go
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+package ngsilib
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func setupCredentialStore(t *testing.T, passphrase ...string) *NGSI {
+	ngsi := testNgsiLibInit()
+
+	config := filepath.Join(t.TempDir(), configFileName)
+	ngsi.ConfigFile.SetFileName(&config)
+	ngsi.CredFile = &ioLib{}
+	ngsi.TermLib = &MockTermLib{Terminal: true, Password: passphrase}
+
+	return ngsi
+}
+
+func TestCredentialKey(t *testing.T) {
+	client := &Client{Broker: &Broker{BrokerHost: "http://orion", IdmType: "keyrock", IdmHost: "/oauth2/token", Username: "fiware"}}
+
+	actual, err := CredentialKey(client)
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, "fiware@http://orion/oauth2/token", actual)
+	}
+}
+
+func TestCredentialKeyBasic(t *testing.T) {
+	client := &Client{Broker: &Broker{BrokerHost: "http://orion", IdmType: "basic", Username: "fiware"}}
+
+	actual, err := CredentialKey(client)
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, "fiware@http://orion", actual)
+	}
+}
+
+func TestCredentialKeyError(t *testing.T) {
+	cases := []struct {
+		broker  *Broker
+		errNo   int
+		message string
+	}{
+		{broker: &Broker{BrokerHost: "http://orion"}, errNo: 1, message: "idmType and idmHost not found"},
+		{broker: &Broker{BrokerHost: "http://orion", IdmType: "keyrock", IdmHost: "http://keyrock"}, errNo: 2, message: "username not found"},
+	}
+
+	for _, c := range cases {
+		_, err := CredentialKey(&Client{Broker: c.broker})
+
+		if assert.Error(t, err) {
+			ngsiErr := err.(*NgsiLibError)
+			assert.Equal(t, c.errNo, ngsiErr.ErrNo)
+			assert.Equal(t, c.message, ngsiErr.Message)
+		}
+	}
+}
+
+func TestCredential(t *testing.T) {
+	ngsi := setupCredentialStore(t, "secret", "secret", "secret", "secret")
+	client := &Client{Broker: &Broker{BrokerHost: "http://orion", IdmType: "keyrock", IdmHost: "http://keyrock", Username: "fiware"}}
+	client2 := &Client{Broker: &Broker{BrokerHost: "http://orion", IdmType: "keyrock", IdmHost: "http://keyrock", Username: "admin"}}
+
+	err := ngsi.SetCredential(client, "1234")
+	assert.NoError(t, err)
+	err = ngsi.SetCredential(client2, "5678")
+	assert.NoError(t, err)
+
+	b, _ := ioutil.ReadFile(*ngsi.CredFile.FileName())
+	assert.NotContains(t, string(b), "1234")
+
+	list, err := ngsi.CredentialList()
+	if assert.NoError(t, err) {
+		assert.Equal(t, []string{"admin@http://keyrock", "fiware@http://keyrock"}, list)
+	}
+
+	password, ok, err := ngsi.GetCredential(client)
+	if assert.NoError(t, err) {
+		assert.Equal(t, true, ok)
+		assert.Equal(t, "1234", password)
+	}
+
+	err = ngsi.DeleteCredential(client)
+	assert.NoError(t, err)
+
+	_, ok, err = ngsi.GetCredential(client)
+	if assert.NoError(t, err) {
+		assert.Equal(t, false, ok)
+	}
+
+	password, ok, err = ngsi.GetCredential(client2)
+	if assert.NoError(t, err) {
+		assert.Equal(t, true, ok)
+		assert.Equal(t, "5678", password)
+	}
+
+	assert.Equal(t, []string{"Passphrase: ", "Passphrase: ", "Passphrase: ", "Passphrase: "}, ngsi.TermLib.(*MockTermLib).Prompts)
+}
+
+func TestCredentialEnv(t *testing.T) {
+	ngsi := setupCredentialStore(t)
+	client := &Client{Broker: &Broker{BrokerHost: "http://orion", IdmType: "keyrock", IdmHost: "http://keyrock", Username: "fiware"}}
+
+	os.Setenv(credentialPassphrase, "secret")
+	defer os.Unsetenv(credentialPassphrase)
+
+	err := ngsi.SetCredential(client, "1234")
+	assert.NoError(t, err)
+
+	password, ok, err := ngsi.GetCredential(client)
+	if assert.NoError(t, err) {
+		assert.Equal(t, true, ok)
+		assert.Equal(t, "1234", password)
+	}
+}
+
+func TestGetCredentialNotFound(t *testing.T) {
+	ngsi := testNgsiLibInit()
+	client := &Client{Broker: &Broker{BrokerHost: "http://orion", IdmType: "keyrock", IdmHost: "http://keyrock", Username: "fiware"}}
+
+	_, ok, err := ngsi.GetCredential(client)
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, false, ok)
+	}
+
+	_, ok, err = ngsi.GetCredential(&Client{Broker: &Broker{}})
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, false, ok)
+	}
+}
+
+func TestGetCredentialError(t *testing.T) {
+	client := &Client{Broker: &Broker{BrokerHost: "http://orion", IdmType: "keyrock", IdmHost: "http://keyrock", Username: "fiware"}}
+
+	ngsi := setupCredentialStore(t, "secret", "wrong")
+	_ = ngsi.SetCredential(client, "1234")
+
+	_, _, err := ngsi.GetCredential(client)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*NgsiLibError)
+		assert.Equal(t, 2, ngsiErr.ErrNo)
+		assert.Equal(t, "passphrase is incorrect", ngsiErr.Message)
+	}
+
+	ngsi.CredFile = &MockIoLib{DecodeErr: errors.New("decode error")}
+	ngsi.CredFile.SetFileName(ngsi.ConfigFile.FileName())
+
+	_, _, err = ngsi.GetCredential(client)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*NgsiLibError)
+		assert.Equal(t, 1, ngsiErr.ErrNo)
+		assert.Equal(t, "decode error", ngsiErr.Message)
+	}
+}
+
+func TestGetCredentialErrorDecrypt(t *testing.T) {
+	client := &Client{Broker: &Broker{BrokerHost: "http://orion", IdmType: "keyrock", IdmHost: "http://keyrock", Username: "fiware"}}
+	ngsi := setupCredentialStore(t, "secret", "secret")
+
+	store := &credentialStore{Credentials: map[string]string{}}
+	key, _ := ngsi.credentialCipherKey(store)
+	check, _ := credentialEncrypt(key, credentialCheckString)
+	store.Check = check
+	store.Credentials["fiware@http://keyrock"] = "AAAA"
+	_ = ngsi.saveCredentialStore(store)
+
+	_, _, err := ngsi.GetCredential(client)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*NgsiLibError)
+		assert.Equal(t, 3, ngsiErr.ErrNo)
+		assert.Equal(t, "credential data error", ngsiErr.Message)
+	}
+}
+
+func TestSetCredentialError(t *testing.T) {
+	client := &Client{Broker: &Broker{BrokerHost: "http://orion", IdmType: "keyrock", IdmHost: "http://keyrock", Username: "fiware"}}
+
+	cases := []struct {
+		client  *Client
+		config  string
+		io      IoLib
+		term    *MockTermLib
+		errNo   int
+		message string
+	}{
+		{client: &Client{Broker: &Broker{}}, config: "config", io: &MockIoLib{}, term: &MockTermLib{}, errNo: 1, message: "idmType and idmHost not found"},
+		{client: client, config: "", io: &MockIoLib{}, term: &MockTermLib{}, errNo: 2, message: "credential store not available without config file"},
+		{client: client, config: "config", io: &MockIoLib{OpenErr: errors.New("open error")}, term: &MockTermLib{}, errNo: 2, message: "open error"},
+		{client: client, config: "config", io: &MockIoLib{StatErr: errors.New("not found")}, term: &MockTermLib{}, errNo: 3, message: "passphrase is required (set NGSI_GO_PASSPHRASE)"},
+		{client: client, config: "config", io: &MockIoLib{StatErr: errors.New("not found")}, term: &MockTermLib{Terminal: true, Err: errors.New("read error")}, errNo: 3, message: "read error"},
+		{client: client, config: "config", io: &MockIoLib{StatErr: errors.New("not found"), OpenErr: errors.New("open error")}, term: &MockTermLib{Terminal: true, Password: []string{"secret"}}, errNo: 5, message: "open error"},
+		{client: client, config: "config", io: &MockIoLib{StatErr: errors.New("not found"), TruncateErr: errors.New("truncate error")}, term: &MockTermLib{Terminal: true, Password: []string{"secret"}}, errNo: 5, message: "truncate error"},
+		{client: client, config: "config", io: &MockIoLib{StatErr: errors.New("not found"), EncodeErr: errors.New("encode error")}, term: &MockTermLib{Terminal: true, Password: []string{"secret"}}, errNo: 5, message: "encode error"},
+	}
+
+	for _, c := range cases {
+		ngsi := testNgsiLibInit()
+		ngsi.ConfigFile.SetFileName(&c.config)
+		ngsi.CredFile = c.io
+		ngsi.TermLib = c.term
+
+		err := ngsi.SetCredential(c.client, "1234")
+
+		if assert.Error(t, err) {
+			ngsiErr := err.(*NgsiLibError)
+			assert.Equal(t, c.errNo, ngsiErr.ErrNo)
+			assert.Equal(t, c.message, ngsiErr.Message)
+		}
+	}
+}
+
+func TestDeleteCredentialError(t *testing.T) {
+	client := &Client{Broker: &Broker{BrokerHost: "http://orion", IdmType: "keyrock", IdmHost: "http://keyrock", Username: "fiware"}}
+
+	cases := []struct {
+		client  *Client
+		io      IoLib
+		errNo   int
+		message string
+	}{
+		{client: &Client{Broker: &Broker{}}, io: &MockIoLib{}, errNo: 1, message: "idmType and idmHost not found"},
+		{client: client, io: &MockIoLib{OpenErr: errors.New("open error")}, errNo: 2, message: "open error"},
+		{client: client, io: &MockIoLib{StatErr: errors.New("not found")}, errNo: 3, message: "fiware@http://keyrock not found"},
+	}
+
+	for _, c := range cases {
+		ngsi := testNgsiLibInit()
+		config := "config"
+		ngsi.ConfigFile.SetFileName(&config)
+		ngsi.CredFile = c.io
+
+		err := ngsi.DeleteCredential(c.client)
+
+		if assert.Error(t, err) {
+			ngsiErr := err.(*NgsiLibError)
+			assert.Equal(t, c.errNo, ngsiErr.ErrNo)
+			assert.Equal(t, c.message, ngsiErr.Message)
+		}
+	}
+}
+
+func TestDeleteCredentialErrorSave(t *testing.T) {
+	client := &Client{Broker: &Broker{BrokerHost: "http://orion", IdmType: "keyrock", IdmHost: "http://keyrock", Username: "fiware"}}
+	ngsi := setupCredentialStore(t, "secret")
+	_ = ngsi.SetCredential(client, "1234")
+
+	name := *ngsi.CredFile.FileName()
+	_ = os.Chmod(name, 0400)
+	defer os.Chmod(name, 0600)
+	if f, err := os.OpenFile(name, os.O_WRONLY, 0); err == nil {
+		f.Close()
+		t.Skip("file permission is not effective")
+	}
+
+	err := ngsi.DeleteCredential(client)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*NgsiLibError)
+		assert.Equal(t, 4, ngsiErr.ErrNo)
+	}
+}
+
+func TestCredentialListError(t *testing.T) {
+	ngsi := testNgsiLibInit()
+
+	_, err := ngsi.CredentialList()
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*NgsiLibError)
+		assert.Equal(t, 1, ngsiErr.ErrNo)
+		assert.Equal(t, "credential store not available without config file", ngsiErr.Message)
+	}
+}
+
+func TestCredentialCipherKeyError(t *testing.T) {
+	ngsi := testNgsiLibInit()
+	ngsi.TermLib = &MockTermLib{Terminal: true, Password: []string{"secret"}}
+
+	_, err := ngsi.credentialCipherKey(&credentialStore{Salt: "@"})
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*NgsiLibError)
+		assert.Equal(t, 4, ngsiErr.ErrNo)
+		assert.Equal(t, "illegal base64 data at input byte 0", ngsiErr.Message)
+	}
+}
+
+func TestCredentialDecryptError(t *testing.T) {
+	key := make([]byte, 32)
+
+	_, err := credentialDecrypt(make([]byte, 5), "")
+	if assert.Error(t, err) {
+		assert.Equal(t, 1, err.(*NgsiLibError).ErrNo)
+	}
+
+	_, err = credentialDecrypt(key, "@")
+	if assert.Error(t, err) {
+		assert.Equal(t, 2, err.(*NgsiLibError).ErrNo)
+	}
+
+	s, _ := credentialEncrypt(make([]byte, 16), "1234")
+	_, err = credentialDecrypt(key, s)
+	if assert.Error(t, err) {
+		assert.Equal(t, 4, err.(*NgsiLibError).ErrNo)
+		assert.Equal(t, "cipher: message authentication failed", err.Error())
+	}
+}
+
+func TestCredentialEncryptError(t *testing.T) {
+	_, err := credentialEncrypt(make([]byte, 5), "1234")
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*NgsiLibError)
+		assert.Equal(t, 1, ngsiErr.ErrNo)
+		assert.Equal(t, "crypto/aes: invalid key size 5", ngsiErr.Message)
+	}
+}
@@ -42,6 +42,7 @@ import (
 type MockTimeLib struct {
 	dateTime string
 	unixTime int64
+	sleep    []time.Duration
 }
 
 func (t *MockTimeLib) Now() time.Time {
@@ -54,6 +55,10 @@ func (t *MockTimeLib) NowUnix() int64 {
 	return t.unixTime
 }
 
+func (t *MockTimeLib) Sleep(d time.Duration) {
+	t.sleep = append(t.sleep, d)
+}
+
 //
 // MockJSONLIB
 //
@@ -79,7 +84,38 @@ func (j *MockJSONLib) Encode(w io.Writer, v interface{}) error {
 
 func testNgsiLibInit() *NGSI {
 	gNGSI = nil
-	return NewNGSI()
+	ngsi := NewNGSI()
+	ngsi.TermLib = &MockTermLib{}
+	ngsi.CacheTmpFile = &MockIoLib{}
+	return ngsi
+}
+
+//
+// MockTermLib
+//
+
+type MockTermLib struct {
+	Terminal bool
+	Password []string
+	Err      error
+	Prompts  []string
+}
+
+func (t *MockTermLib) IsTerminal() bool {
+	return t.Terminal
+}
+
+func (t *MockTermLib) ReadPassword(prompt string) (string, error) {
+	t.Prompts = append(t.Prompts, prompt)
+	if t.Err != nil {
+		return "", t.Err
+	}
+	if len(t.Password) == 0 {
+		return "", nil
+	}
+	s := t.Password[0]
+	t.Password = t.Password[1:]
+	return s, nil
 }
 
 //
@@ -100,6 +136,8 @@ type MockIoLib struct {
 	MkdirErr     error
 	DecodeErr    error
 	Env          string
+	LockErr      error
+	RenameErr    error
 }
 
 func (io *MockIoLib) Open() (err error) {
@@ -162,6 +200,18 @@ func (io *MockIoLib) FilePathJoin(elem ...string) string {
 	return strings.Join(elem, "/")
 }
 
+func (io *MockIoLib) Lock() error {
+	return io.LockErr
+}
+
+func (io *MockIoLib) Unlock() error {
+	return nil
+}
+
+func (io *MockIoLib) Rename(oldpath, newpath string) error {
+	return io.RenameErr
+}
+
 func NewMockHTTP() *MockHTTP {
 	m := MockHTTP{}
 	return &m
@@ -169,8 +219,9 @@ func NewMockHTTP() *MockHTTP {
 
 // MockHTTPReqRes is ...
 type MockHTTP struct {
-	index  int
-	ReqRes []MockHTTPReqRes
+	index      int
+	ReqRes     []MockHTTPReqRes
+	idempotent bool
 }
 
 type MockHTTPReqRes struct {
@@ -185,15 +236,16 @@ type MockHTTPReqRes struct {
 
 // MockHTTPRequest is ...
 type MockHTTPRequest interface {
-	Request(method string, url *url.URL, headers map[string]string, body interface{}) (*http.Response, []byte, error)
+	Request(method string, url *url.URL, headers map[string]string, body interface{}, idempotent bool) (*http.Response, []byte, error)
 }
 
 // Request is ...
-func (h *MockHTTP) Request(method string, url *url.URL, headers map[string]string, body interface{}) (*http.Response, []byte, error) {
+func (h *MockHTTP) Request(method string, url *url.URL, headers map[string]string, body interface{}, idempotent bool) (*http.Response, []byte, error) {
 	const funcName = "Request"
 
 	r := h.ReqRes[h.index]
 	h.index++
+	h.idempotent = idempotent
 
 	if r.Err != nil {
 		return nil, nil, r.Err
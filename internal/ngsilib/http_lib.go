@@ -31,86 +31,334 @@ package ngsilib
 
 import (
 	"bytes"
+	"fmt"
 	"io"
 	"io/ioutil"
+	"math/rand"
+	"net"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
 // HTTPGet is ...
 func (client *Client) HTTPGet() (*http.Response, []byte, error) {
-	return client.HTTP.Request(http.MethodGet, client.URL, client.Headers, nil)
+	return client.HTTP.Request(http.MethodGet, client.URL, client.Headers, nil, true)
 }
 
 // HTTPPost is ...
 func (client *Client) HTTPPost(body interface{}) (*http.Response, []byte, error) {
-	return client.HTTP.Request(http.MethodPost, client.URL, client.Headers, body)
+	return client.HTTP.Request(http.MethodPost, client.URL, client.Headers, body, false)
+}
+
+// HTTPPostIdempotent sends a POST request which has the same effect when it is sent again,
+// e.g. a batch upsert, so that it is retried on a transient error.
+func (client *Client) HTTPPostIdempotent(body interface{}) (*http.Response, []byte, error) {
+	return client.HTTP.Request(http.MethodPost, client.URL, client.Headers, body, true)
 }
 
 // HTTPPut is ...
 func (client *Client) HTTPPut(body interface{}) (*http.Response, []byte, error) {
-	return client.HTTP.Request(http.MethodPut, client.URL, client.Headers, body)
+	return client.HTTP.Request(http.MethodPut, client.URL, client.Headers, body, true)
 }
 
 // HTTPPatch is ...
 func (client *Client) HTTPPatch(body interface{}) (*http.Response, []byte, error) {
-	return client.HTTP.Request(http.MethodPatch, client.URL, client.Headers, body)
+	return client.HTTP.Request(http.MethodPatch, client.URL, client.Headers, body, false)
 }
 
 // HTTPDelete is
 func (client *Client) HTTPDelete() (*http.Response, []byte, error) {
-	return client.HTTP.Request(http.MethodDelete, client.URL, client.Headers, nil)
+	return client.HTTP.Request(http.MethodDelete, client.URL, client.Headers, nil, true)
 }
 
 // HTTPRequest is ...
 type HTTPRequest interface {
-	Request(method string, url *url.URL, headers map[string]string, body interface{}) (*http.Response, []byte, error)
+	Request(method string, url *url.URL, headers map[string]string, body interface{}, idempotent bool) (*http.Response, []byte, error)
+}
+
+type httpRequest struct {
+	mutex   sync.Mutex
+	client  *http.Client
+	hosts   map[string]*Broker
+	clients map[string]*http.Client
 }
-type httpRequest struct{}
 
 // NewHTTPRequet is ...
 func NewHTTPRequet() HTTPRequest {
 	return &httpRequest{}
 }
 
-func (r *httpRequest) Request(method string, url *url.URL, headers map[string]string, body interface{}) (*http.Response, []byte, error) {
-	const funcName = "httpRequest"
+const (
+	maxRetry         = 10
+	maxRetryWait     = 60
+	defaultRetryWait = 1 * time.Second
+	maxBackoff       = 60 * time.Second
+	maxRetryAfter    = 600 * time.Second
+)
+
+// Request sends a request. A request which fails with a connection error or
+// a transient status code is retried with exponential backoff when the caller
+// tells that it is idempotent.
+func (r *httpRequest) Request(method string, url *url.URL, headers map[string]string, body interface{}, idempotent bool) (*http.Response, []byte, error) {
+	retry, wait := r.retryPolicy(url.Host)
+	if !idempotent {
+		retry = 0
+	}
+
+	for attempt := 1; ; attempt++ {
+		res, b, transient, err := r.request(method, url, headers, body)
+		if attempt > retry {
+			return res, b, err
+		}
+
+		var reason string
+		if err != nil {
+			if !transient {
+				return res, b, err
+			}
+			reason = err.Error()
+		} else {
+			if !isRetryableStatus(res.StatusCode) {
+				return res, b, err
+			}
+			reason = res.Status
+		}
+
+		d := retryBackoff(wait, attempt, res)
+		gNGSI.Logging(LogInfo, fmt.Sprintf("%s %s: %s, retry %d/%d in %v\n", method, url.String(), reason, attempt, retry, d))
+		gNGSI.TimeLib.Sleep(d)
+	}
+}
 
-	client := &http.Client{Timeout: time.Duration(60 * time.Second)}
+// request sends a request once. transient is true when the request failed
+// while communicating with the server.
+func (r *httpRequest) request(method string, url *url.URL, headers map[string]string, body interface{}) (res *http.Response, b []byte, transient bool, err error) {
+	const funcName = "httpRequest"
 
 	var reader io.Reader
-	var err error
 
 	switch method {
 	case http.MethodPost, http.MethodPut, http.MethodPatch:
 		reader, err = newReader(body)
 		if err != nil {
-			return nil, nil, &NgsiLibError{funcName, 1, err.Error(), err}
+			return nil, nil, false, &NgsiLibError{funcName, 1, err.Error(), err}
 		}
 	}
 
 	req, err := http.NewRequest(method, url.String(), reader)
 	if err != nil {
-		return nil, nil, &NgsiLibError{funcName, 2, err.Error(), err}
+		return nil, nil, false, &NgsiLibError{funcName, 2, err.Error(), err}
 	}
 
 	for k, v := range headers {
 		req.Header.Add(k, v)
 	}
 
+	client, err := r.httpClient(req.URL.Host)
+	if err != nil {
+		return nil, nil, false, &NgsiLibError{funcName, 3, err.Error(), err}
+	}
+
 	resp, err := client.Do(req)
 	if err != nil {
-		return nil, nil, &NgsiLibError{funcName, 3, err.Error(), err}
+		return nil, nil, true, &NgsiLibError{funcName, 4, err.Error(), err}
 	}
 	defer resp.Body.Close()
 
-	b, err := ioutil.ReadAll(resp.Body)
+	b, err = ioutil.ReadAll(resp.Body)
 	if err != nil {
-		return nil, nil, &NgsiLibError{funcName, 4, err.Error(), err}
+		return nil, nil, true, &NgsiLibError{funcName, 5, err.Error(), err}
+	}
+	return resp, b, false, nil
+}
+
+// retryPolicy returns the number of retries and the initial wait for host.
+// The global settings take precedence over the broker settings.
+func (r *httpRequest) retryPolicy(host string) (int, time.Duration) {
+	if gNGSI == nil {
+		return 0, defaultRetryWait
+	}
+
+	retry, wait := 0, defaultRetryWait
+
+	r.mutex.Lock()
+	broker, ok := r.hosts[host]
+	r.mutex.Unlock()
+
+	if ok {
+		if n, w, err := broker.retryPolicy(); err == nil {
+			retry, wait = n, w
+		}
+	}
+
+	if gNGSI.Retry >= 0 {
+		retry = gNGSI.Retry
+	}
+	if gNGSI.RetryWait > 0 {
+		wait = gNGSI.RetryWait
+	}
+
+	return retry, wait
+}
+
+func isRetryableStatus(code int) bool {
+	switch code {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// retryBackoff returns the wait before the next attempt. It honours the Retry-After
+// header and otherwise doubles wait on each attempt with jitter.
+func retryBackoff(wait time.Duration, attempt int, res *http.Response) time.Duration {
+	if res != nil {
+		if d, ok := retryAfter(res.Header.Get("Retry-After")); ok {
+			return d
+		}
+	}
+
+	d := wait
+	for i := 1; i < attempt && d < maxBackoff; i++ {
+		d *= 2
+	}
+	if d > maxBackoff {
+		d = maxBackoff
+	}
+
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+// retryAfter parses the value of the Retry-After header which is either
+// delay-seconds or an HTTP-date.
+func retryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+
+	var d time.Duration
+	if n, err := strconv.Atoi(value); err == nil {
+		d = time.Duration(n) * time.Second
+	} else if t, err := http.ParseTime(value); err == nil {
+		d = t.Sub(gNGSI.TimeLib.Now())
+	} else {
+		return 0, false
+	}
+
+	if d < 0 {
+		d = 0
+	}
+	if d > maxRetryAfter {
+		d = maxRetryAfter
+	}
+	return d, true
+}
+
+// httpClient returns an http client for host. Clients are cached so that
+// connections are reused across requests, e.g. paginated calls.
+// A host with broker specific settings such as a proxy or TLS has its own client.
+func (r *httpRequest) httpClient(host string) (*http.Client, error) {
+	const funcName = "httpClient"
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	broker, ok := r.hosts[host]
+	if !ok {
+		if r.client == nil {
+			r.client = newHTTPClient(newHTTPTransport())
+		}
+		return r.client, nil
+	}
+
+	if client, ok := r.clients[host]; ok {
+		return client, nil
+	}
+
+	transport := newHTTPTransport()
+
+	if broker.Proxy != "" {
+		u, err := url.Parse(broker.Proxy)
+		if err != nil {
+			return nil, &NgsiLibError{funcName, 1, err.Error(), err}
+		}
+		transport.Proxy = http.ProxyURL(u)
+	}
+
+	tlsConfig, err := broker.tlsConfig()
+	if err != nil {
+		return nil, &NgsiLibError{funcName, 2, err.Error(), err}
+	}
+	transport.TLSClientConfig = tlsConfig
+
+	client := newHTTPClient(transport)
+	if r.clients == nil {
+		r.clients = make(map[string]*http.Client)
+	}
+	r.clients[host] = client
+
+	return client, nil
+}
+
+func (r *httpRequest) setHost(host string, broker *Broker) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.hosts == nil {
+		r.hosts = make(map[string]*Broker)
+	}
+	r.hosts[host] = broker
+	delete(r.clients, host)
+}
+
+func newHTTPTransport() *http.Transport {
+	maxIdleConns := 100
+	idleTimeout := 90 * time.Second
+	keepAlive := true
+
+	if gNGSI != nil {
+		maxIdleConns = gNGSI.MaxIdleConns
+		idleTimeout = gNGSI.IdleTimeout
+		keepAlive = gNGSI.KeepAlive
+	}
+
+	return &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		MaxIdleConns:          maxIdleConns,
+		MaxIdleConnsPerHost:   maxIdleConns,
+		IdleConnTimeout:       idleTimeout,
+		DisableKeepAlives:     !keepAlive,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+}
+
+func newHTTPClient(transport *http.Transport) *http.Client {
+	timeout := 60 * time.Second
+	if gNGSI != nil {
+		timeout = gNGSI.Timeout
+	}
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
+
+// setHTTPConfig applies the proxy, TLS and retry settings of broker to requests
+// to host when the default http request is used.
+func setHTTPConfig(h HTTPRequest, host string, broker *Broker) {
+	if broker.Proxy == "" && broker.CACert == "" && broker.ClientCert == "" && broker.InsecureSkipVerify == "" &&
+		broker.Retry == "" && broker.RetryWait == "" {
+		return
+	}
+	if r, ok := h.(*httpRequest); ok {
+		r.setHost(host, broker)
 	}
-	return resp, b, nil
 }
 
 func newReader(v interface{}) (io.Reader, error) {
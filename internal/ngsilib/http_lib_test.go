@@ -30,12 +30,17 @@ SOFTWARE.
 package ngsilib
 
 import (
+	"bytes"
+	"encoding/pem"
 	"fmt"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"path/filepath"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -124,7 +129,7 @@ func TestRequest(t *testing.T) {
 
 	r := NewHTTPRequet()
 	u, _ := url.Parse(ts.URL)
-	res, _, err := r.Request("GET", u, nil, nil)
+	res, _, err := r.Request("GET", u, nil, nil, true)
 	if assert.NoError(t, err) {
 		assert.Equal(t, http.StatusOK, res.StatusCode)
 	}
@@ -137,7 +142,7 @@ func TestRequestHeaders(t *testing.T) {
 	r := NewHTTPRequet()
 	u, _ := url.Parse(ts.URL)
 	headers := map[string]string{"Fiware-Service": "fiware"}
-	res, _, err := r.Request("GET", u, headers, nil)
+	res, _, err := r.Request("GET", u, headers, nil, true)
 	if assert.NoError(t, err) {
 		assert.Equal(t, http.StatusOK, res.StatusCode)
 	}
@@ -149,7 +154,7 @@ func TestRequestErrorNewReader(t *testing.T) {
 
 	r := NewHTTPRequet()
 	u, _ := url.Parse(ts.URL)
-	_, _, err := r.Request("POST", u, nil, 1)
+	_, _, err := r.Request("POST", u, nil, 1, false)
 	if assert.Error(t, err) {
 		ngsiErr := err.(*NgsiLibError)
 		assert.Equal(t, 1, ngsiErr.ErrNo)
@@ -164,7 +169,7 @@ func TestRequestErrorNewRequest(t *testing.T) {
 	r := NewHTTPRequet()
 	u, _ := url.Parse(ts.URL)
 	u.Host = ":\n"
-	_, _, err := r.Request("GET", u, nil, nil)
+	_, _, err := r.Request("GET", u, nil, nil, true)
 	if assert.Error(t, err) {
 		ngsiErr := err.(*NgsiLibError)
 		assert.Equal(t, 2, ngsiErr.ErrNo)
@@ -179,10 +184,10 @@ func TestRequestErrorDo(t *testing.T) {
 	r := NewHTTPRequet()
 	u, _ := url.Parse(ts.URL)
 	u.Host = ""
-	_, _, err := r.Request("GET", u, nil, nil)
+	_, _, err := r.Request("GET", u, nil, nil, true)
 	if assert.Error(t, err) {
 		ngsiErr := err.(*NgsiLibError)
-		assert.Equal(t, 3, ngsiErr.ErrNo)
+		assert.Equal(t, 4, ngsiErr.ErrNo)
 		assert.Equal(t, "Get \"http:\": http: no Host in request URL", ngsiErr.Message)
 	}
 }
@@ -193,14 +198,426 @@ func TestRequestErrorReadAll(t *testing.T) {
 
 	r := NewHTTPRequet()
 	u, _ := url.Parse(ts.URL + "/error")
-	_, _, err := r.Request("POST", u, nil, "")
+	_, _, err := r.Request("POST", u, nil, "", false)
 	if assert.Error(t, err) {
 		ngsiErr := err.(*NgsiLibError)
-		assert.Equal(t, 4, ngsiErr.ErrNo)
+		assert.Equal(t, 5, ngsiErr.ErrNo)
 		assert.Equal(t, "unexpected EOF", ngsiErr.Message)
 	}
 }
 
+func TestRequestReuseClient(t *testing.T) {
+	ts := httptest.NewServer(Route())
+	defer ts.Close()
+
+	r := &httpRequest{}
+	u, _ := url.Parse(ts.URL)
+	_, _, err := r.Request("GET", u, nil, nil, true)
+	assert.NoError(t, err)
+	client := r.client
+
+	_, _, err = r.Request("GET", u, nil, nil, true)
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, client, r.client)
+	}
+}
+
+func TestRequestTimeout(t *testing.T) {
+	ngsi := testNgsiLibInit()
+	ngsi.Timeout = 10 * time.Millisecond
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+	}))
+	defer ts.Close()
+
+	r := &httpRequest{}
+	u, _ := url.Parse(ts.URL)
+	_, _, err := r.Request("GET", u, nil, nil, true)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*NgsiLibError)
+		assert.Equal(t, 4, ngsiErr.ErrNo)
+		assert.Equal(t, time.Duration(10*time.Millisecond), r.client.Timeout)
+	}
+}
+
+func TestRequestTransport(t *testing.T) {
+	ngsi := testNgsiLibInit()
+	ngsi.MaxIdleConns = 10
+	ngsi.IdleTimeout = 30 * time.Second
+	ngsi.KeepAlive = false
+
+	r := &httpRequest{}
+	client, _ := r.httpClient("orion:1026")
+	transport := client.Transport.(*http.Transport)
+
+	assert.Equal(t, 10, transport.MaxIdleConns)
+	assert.Equal(t, 10, transport.MaxIdleConnsPerHost)
+	assert.Equal(t, 30*time.Second, transport.IdleConnTimeout)
+	assert.Equal(t, true, transport.DisableKeepAlives)
+}
+
+func TestRequestProxy(t *testing.T) {
+	proxied := ""
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = r.URL.String()
+	}))
+	defer proxy.Close()
+
+	r := &httpRequest{}
+	setHTTPConfig(r, "orion:1026", &Broker{Proxy: proxy.URL})
+	u, _ := url.Parse("http://orion:1026/version")
+	res, _, err := r.Request("GET", u, nil, nil, true)
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, "http://orion:1026/version", proxied)
+	}
+}
+
+func TestRequestErrorHTTPClient(t *testing.T) {
+	r := &httpRequest{}
+	setHTTPConfig(r, "orion:1026", &Broker{Proxy: "http://proxy:8080\n"})
+	u, _ := url.Parse("http://orion:1026/version")
+	_, _, err := r.Request("GET", u, nil, nil, true)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*NgsiLibError)
+		assert.Equal(t, 3, ngsiErr.ErrNo)
+	}
+}
+
+func TestHTTPClientCache(t *testing.T) {
+	r := &httpRequest{}
+	setHTTPConfig(r, "orion:1026", &Broker{InsecureSkipVerify: "on"})
+
+	client1, err := r.httpClient("orion:1026")
+	assert.NoError(t, err)
+	client2, err := r.httpClient("orion:1026")
+	assert.NoError(t, err)
+	client3, err := r.httpClient("keyrock:3000")
+	assert.NoError(t, err)
+
+	assert.Equal(t, client1, client2)
+	assert.NotEqual(t, client1, client3)
+	assert.Equal(t, true, client1.Transport.(*http.Transport).TLSClientConfig.InsecureSkipVerify)
+}
+
+func TestHTTPClientErrorProxy(t *testing.T) {
+	r := &httpRequest{}
+	setHTTPConfig(r, "orion:1026", &Broker{Proxy: "http://proxy:8080\n"})
+
+	_, err := r.httpClient("orion:1026")
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*NgsiLibError)
+		assert.Equal(t, 1, ngsiErr.ErrNo)
+	}
+}
+
+func TestHTTPClientErrorTLS(t *testing.T) {
+	_ = testNgsiLibInit()
+
+	r := &httpRequest{}
+	setHTTPConfig(r, "orion:1026", &Broker{InsecureSkipVerify: "none"})
+
+	_, err := r.httpClient("orion:1026")
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*NgsiLibError)
+		assert.Equal(t, 2, ngsiErr.ErrNo)
+	}
+}
+
+func TestRequestTLS(t *testing.T) {
+	_ = testNgsiLibInit()
+
+	ts := httptest.NewTLSServer(Route())
+	defer ts.Close()
+
+	dir := t.TempDir()
+	caCert := filepath.Join(dir, "ca.pem")
+	_ = ioutil.WriteFile(caCert, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ts.Certificate().Raw}), 0600)
+
+	r := &httpRequest{}
+	u, _ := url.Parse(ts.URL)
+	setHTTPConfig(r, u.Host, &Broker{CACert: caCert})
+	res, _, err := r.Request("GET", u, nil, nil, true)
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+	}
+}
+
+func TestRequestTLSErrorUnknownAuthority(t *testing.T) {
+	_ = testNgsiLibInit()
+
+	ts := httptest.NewTLSServer(Route())
+	defer ts.Close()
+
+	r := &httpRequest{}
+	u, _ := url.Parse(ts.URL)
+	_, _, err := r.Request("GET", u, nil, nil, true)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*NgsiLibError)
+		assert.Equal(t, 4, ngsiErr.ErrNo)
+	}
+}
+
+func testRetryServer(codes []int, header map[string]string, count *int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		code := codes[len(codes)-1]
+		if *count < len(codes) {
+			code = codes[*count]
+		}
+		*count++
+		for k, v := range header {
+			w.Header().Set(k, v)
+		}
+		w.WriteHeader(code)
+	}))
+}
+
+func TestRequestRetry(t *testing.T) {
+	ngsi := testNgsiLibInit()
+	ngsi.Retry = 3
+	timeLib := &MockTimeLib{}
+	ngsi.TimeLib = timeLib
+	buf := &bytes.Buffer{}
+	ngsi.LogWriter = &LogWriter{buf, LogInfo}
+
+	count := 0
+	ts := testRetryServer([]int{503, 502, 200}, nil, &count)
+	defer ts.Close()
+
+	r := &httpRequest{}
+	u, _ := url.Parse(ts.URL + "/v2/entities")
+	res, _, err := r.Request("GET", u, nil, nil, true)
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, 3, count)
+		assert.Equal(t, 2, len(timeLib.sleep))
+		assert.True(t, timeLib.sleep[0] >= 500*time.Millisecond && timeLib.sleep[0] <= 1*time.Second)
+		assert.True(t, timeLib.sleep[1] >= 1*time.Second && timeLib.sleep[1] <= 2*time.Second)
+		assert.Contains(t, buf.String(), "503 Service Unavailable, retry 1/3")
+		assert.Contains(t, buf.String(), "502 Bad Gateway, retry 2/3")
+	}
+}
+
+func TestRequestRetryAfter(t *testing.T) {
+	ngsi := testNgsiLibInit()
+	ngsi.Retry = 1
+	timeLib := &MockTimeLib{}
+	ngsi.TimeLib = timeLib
+
+	count := 0
+	ts := testRetryServer([]int{429, 204}, map[string]string{"Retry-After": "5"}, &count)
+	defer ts.Close()
+
+	r := &httpRequest{}
+	u, _ := url.Parse(ts.URL + "/v2/entities/urn:ngsi-ld:Product:001")
+	res, _, err := r.Request("DELETE", u, nil, nil, true)
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, http.StatusNoContent, res.StatusCode)
+		assert.Equal(t, 2, count)
+		assert.Equal(t, []time.Duration{5 * time.Second}, timeLib.sleep)
+	}
+}
+
+func TestRequestRetryExhausted(t *testing.T) {
+	ngsi := testNgsiLibInit()
+	ngsi.Retry = 2
+	timeLib := &MockTimeLib{}
+	ngsi.TimeLib = timeLib
+
+	count := 0
+	ts := testRetryServer([]int{503}, nil, &count)
+	defer ts.Close()
+
+	r := &httpRequest{}
+	u, _ := url.Parse(ts.URL + "/v2/op/update")
+	res, _, err := r.Request("POST", u, nil, `{"actionType":"append","entities":[]}`, true)
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, http.StatusServiceUnavailable, res.StatusCode)
+		assert.Equal(t, 3, count)
+		assert.Equal(t, 2, len(timeLib.sleep))
+	}
+}
+
+func TestRequestRetryBroker(t *testing.T) {
+	ngsi := testNgsiLibInit()
+	timeLib := &MockTimeLib{}
+	ngsi.TimeLib = timeLib
+
+	count := 0
+	ts := testRetryServer([]int{504, 200}, nil, &count)
+	defer ts.Close()
+
+	r := &httpRequest{}
+	u, _ := url.Parse(ts.URL + "/v2/entities")
+	setHTTPConfig(r, u.Host, &Broker{Retry: "1", RetryWait: "4"})
+	res, _, err := r.Request("GET", u, nil, nil, true)
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, 2, count)
+		assert.True(t, timeLib.sleep[0] >= 2*time.Second && timeLib.sleep[0] <= 4*time.Second)
+	}
+}
+
+func TestRequestRetryNotIdempotent(t *testing.T) {
+	ngsi := testNgsiLibInit()
+	ngsi.Retry = 3
+	timeLib := &MockTimeLib{}
+	ngsi.TimeLib = timeLib
+
+	count := 0
+	ts := testRetryServer([]int{503}, nil, &count)
+	defer ts.Close()
+
+	r := &httpRequest{}
+	u, _ := url.Parse(ts.URL + "/v2/entities")
+	res, _, err := r.Request("POST", u, nil, "{}", false)
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, http.StatusServiceUnavailable, res.StatusCode)
+		assert.Equal(t, 1, count)
+		assert.Equal(t, 0, len(timeLib.sleep))
+	}
+}
+
+func TestRequestRetryErrorDo(t *testing.T) {
+	ngsi := testNgsiLibInit()
+	ngsi.Retry = 2
+	timeLib := &MockTimeLib{}
+	ngsi.TimeLib = timeLib
+
+	ts := httptest.NewServer(Route())
+	u, _ := url.Parse(ts.URL + "/v2/entities")
+	ts.Close()
+
+	r := &httpRequest{}
+	_, _, err := r.Request("GET", u, nil, nil, true)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*NgsiLibError)
+		assert.Equal(t, 4, ngsiErr.ErrNo)
+		assert.Equal(t, 2, len(timeLib.sleep))
+	}
+}
+
+func TestRequestRetryErrorNewReader(t *testing.T) {
+	ngsi := testNgsiLibInit()
+	ngsi.Retry = 2
+	timeLib := &MockTimeLib{}
+	ngsi.TimeLib = timeLib
+
+	r := &httpRequest{}
+	u, _ := url.Parse("http://orion/v2/entities/urn:ngsi-ld:Product:001/attrs")
+	_, _, err := r.Request("PUT", u, nil, 1, true)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*NgsiLibError)
+		assert.Equal(t, 1, ngsiErr.ErrNo)
+		assert.Equal(t, 0, len(timeLib.sleep))
+	}
+}
+
+func TestRetryPolicyHTTPRequest(t *testing.T) {
+	ngsi := testNgsiLibInit()
+	ngsi.RetryWait = 3 * time.Second
+
+	r := &httpRequest{}
+	r.setHost("orion:1026", &Broker{Retry: "5", RetryWait: "10"})
+
+	retry, wait := r.retryPolicy("orion:1026")
+
+	assert.Equal(t, 5, retry)
+	assert.Equal(t, 3*time.Second, wait)
+}
+
+func TestRetryPolicyHTTPRequestNoNGSI(t *testing.T) {
+	gNGSI = nil
+
+	r := &httpRequest{}
+	retry, wait := r.retryPolicy("orion:1026")
+
+	assert.Equal(t, 0, retry)
+	assert.Equal(t, 1*time.Second, wait)
+}
+
+func TestIsRetryableStatus(t *testing.T) {
+	for _, code := range []int{429, 502, 503, 504} {
+		assert.Equal(t, true, isRetryableStatus(code))
+	}
+	for _, code := range []int{200, 400, 404, 500} {
+		assert.Equal(t, false, isRetryableStatus(code))
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+	d := retryBackoff(1*time.Second, 3, nil)
+	assert.True(t, d >= 2*time.Second && d <= 4*time.Second)
+
+	d = retryBackoff(10*time.Second, 10, nil)
+	assert.True(t, d >= 30*time.Second && d <= 60*time.Second)
+}
+
+func TestRetryBackoffRetryAfter(t *testing.T) {
+	_ = testNgsiLibInit()
+
+	res := &http.Response{Header: http.Header{}}
+	res.Header.Set("Retry-After", "7")
+
+	d := retryBackoff(1*time.Second, 1, res)
+
+	assert.Equal(t, 7*time.Second, d)
+}
+
+func TestRetryAfter(t *testing.T) {
+	ngsi := testNgsiLibInit()
+	ngsi.TimeLib = &MockTimeLib{dateTime: "2015-10-21T07:28:00.000Z"}
+
+	cases := []struct {
+		value    string
+		expected time.Duration
+		ok       bool
+	}{
+		{value: "", expected: 0, ok: false},
+		{value: "120", expected: 120 * time.Second, ok: true},
+		{value: "-5", expected: 0, ok: true},
+		{value: "3600", expected: 600 * time.Second, ok: true},
+		{value: "Wed, 21 Oct 2015 07:28:30 GMT", expected: 30 * time.Second, ok: true},
+		{value: "tomorrow", expected: 0, ok: false},
+	}
+
+	for _, c := range cases {
+		d, ok := retryAfter(c.value)
+		assert.Equal(t, c.expected, d, c.value)
+		assert.Equal(t, c.ok, ok, c.value)
+	}
+}
+
+func TestSetHTTPConfigNotDefault(t *testing.T) {
+	m := &MockHTTP{}
+
+	setHTTPConfig(m, "orion:1026", &Broker{Proxy: "http://proxy:8080"})
+}
+
+func TestSetHTTPConfigNoSettings(t *testing.T) {
+	r := &httpRequest{}
+
+	setHTTPConfig(r, "orion:1026", &Broker{BrokerHost: "http://orion:1026"})
+
+	assert.Equal(t, 0, len(r.hosts))
+}
+
 func TestNewReaderString(t *testing.T) {
 	s := "tset data"
 
@@ -0,0 +1,118 @@
+/*
+MIT License
+
+Copyright (c) 2020 Kazuhito Suda
+
+This file is part of NGSI Go
+
+https://github.com/lets-fiware/ngsi-go
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+package ngsilib
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// HTTPServerLib is ...
+type HTTPServerLib interface {
+	Listen(addr string) error
+	Serve(handler http.Handler) error
+	Shutdown() error
+}
+
+// httpServerLib is used from the goroutine which serves and from the one which shuts it down,
+// so the server is guarded by a mutex. Listen binds the port before Serve, so that requests
+// sent in between, e.g. the first notification of a subscription, wait in the listen queue.
+type httpServerLib struct {
+	mutex    sync.Mutex
+	server   *http.Server
+	listener net.Listener
+	closed   bool
+}
+
+// NewHTTPServerLib is ...
+func NewHTTPServerLib() HTTPServerLib {
+	return &httpServerLib{}
+}
+
+func (s *httpServerLib) Listen(addr string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.closed {
+		return nil
+	}
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	s.listener = l
+	s.server = &http.Server{Addr: addr}
+	return nil
+}
+
+func (s *httpServerLib) Serve(handler http.Handler) error {
+	s.mutex.Lock()
+	if s.closed {
+		s.mutex.Unlock()
+		return nil
+	}
+	if s.listener == nil {
+		s.mutex.Unlock()
+		return errors.New("not listening")
+	}
+	server := s.server
+	server.Handler = handler
+	l := s.listener
+	s.mutex.Unlock()
+
+	err := server.Serve(l)
+	if err == http.ErrServerClosed {
+		return nil
+	}
+	return err
+}
+
+func (s *httpServerLib) Shutdown() error {
+	s.mutex.Lock()
+	s.closed = true
+	server := s.server
+	l := s.listener
+	s.mutex.Unlock()
+
+	if server == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err := server.Shutdown(ctx)
+	// the listener is not closed by Shutdown when Serve has not been called
+	_ = l.Close()
+	return err
+}
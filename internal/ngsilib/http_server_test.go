@@ -0,0 +1,124 @@
+/*
+MIT License
+
+Copyright (c) 2020 Kazuhito Suda
+
+This file is part of NGSI Go
+
+https://github.com/lets-fiware/ngsi-go
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+package ngsilib
+
+import (
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewHTTPServerLib(t *testing.T) {
+	actual := NewHTTPServerLib()
+
+	assert.NotEqual(t, nil, actual)
+}
+
+func TestHTTPServerLib(t *testing.T) {
+	s := &httpServerLib{}
+
+	err := s.Listen("127.0.0.1:0")
+	assert.NoError(t, err)
+	addr := s.listener.Addr().String()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- s.Serve(http.NotFoundHandler())
+	}()
+
+	res, err := http.Get("http://" + addr)
+	if assert.NoError(t, err) {
+		res.Body.Close()
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+	}
+
+	err = s.Shutdown()
+	assert.NoError(t, err)
+
+	err = <-done
+	assert.NoError(t, err)
+}
+
+func TestHTTPServerLibShutdownNotStarted(t *testing.T) {
+	s := &httpServerLib{}
+
+	err := s.Shutdown()
+
+	assert.NoError(t, err)
+}
+
+func TestHTTPServerLibShutdownNotServed(t *testing.T) {
+	s := &httpServerLib{}
+
+	err := s.Listen("127.0.0.1:0")
+	assert.NoError(t, err)
+	addr := s.listener.Addr().String()
+
+	err = s.Shutdown()
+	assert.NoError(t, err)
+
+	_, err = net.Dial("tcp", addr)
+	assert.Error(t, err)
+
+	err = s.Serve(http.NotFoundHandler())
+	assert.NoError(t, err)
+}
+
+func TestHTTPServerLibListenAfterShutdown(t *testing.T) {
+	s := &httpServerLib{}
+
+	err := s.Shutdown()
+	assert.NoError(t, err)
+
+	err = s.Listen("127.0.0.1:0")
+	assert.NoError(t, err)
+	err = s.Serve(http.NotFoundHandler())
+	assert.NoError(t, err)
+}
+
+func TestHTTPServerLibErrorListen(t *testing.T) {
+	s := &httpServerLib{}
+
+	err := s.Listen("127.0.0.1:-1")
+
+	assert.Error(t, err)
+}
+
+func TestHTTPServerLibErrorNotListening(t *testing.T) {
+	s := &httpServerLib{}
+
+	err := s.Serve(http.NotFoundHandler())
+
+	if assert.Error(t, err) {
+		assert.Equal(t, "not listening", err.Error())
+	}
+}
@@ -53,6 +53,7 @@ func (ngsi *NGSI) NewClient(name string, cmdFlags *CmdFlags, isHTTPVerb bool) (c
 
 		broker, ok := ngsi.brokerList[host]
 		if ok {
+			client.Host = host
 			client.Broker = broker
 			host = client.Broker.BrokerHost
 			if host == "" {
@@ -134,6 +135,18 @@ func (ngsi *NGSI) NewClient(name string, cmdFlags *CmdFlags, isHTTPVerb bool) (c
 				client.NgsiType = ngsiLd
 			}
 		}
+		setHTTPConfig(client.HTTP, client.URL.Host, client.Broker)
+
+		switch strings.ToLower(client.Broker.ServerType) {
+		case cServerQuantumLeap:
+			client.ServerType = serverQuantumLeap
+		case cServerComet:
+			client.ServerType = serverComet
+		case cServerIoTAgent:
+			client.ServerType = serverIoTAgent
+		default:
+			client.ServerType = serverBroker
+		}
 	}
 
 	token := ""
@@ -147,6 +160,12 @@ func (ngsi *NGSI) NewClient(name string, cmdFlags *CmdFlags, isHTTPVerb bool) (c
 	}
 	if token != "" {
 		client.Token = token
+	} else if strings.ToLower(client.Broker.IdmType) == cBasic {
+		auth, err := basicAuthorization(client)
+		if err != nil {
+			return nil, &NgsiLibError{funcName, 13, err.Error(), err}
+		}
+		client.BasicAuth = auth
 	} else if client.Broker.IdmType != "" {
 		token, err := ngsi.GetToken(client)
 		if err != nil {
@@ -214,3 +233,23 @@ func parseURL(url string) (string, string, string) {
 	}
 	return host, path, query
 }
+
+// BrokerClient returns a client which has broker information of host. Unlike NewClient, it never gets a token.
+func (ngsi *NGSI) BrokerClient(host string) (*Client, error) {
+	const funcName = "BrokerClient"
+
+	broker, ok := ngsi.brokerList[host]
+	if !ok {
+		return nil, &NgsiLibError{funcName, 1, host + " not found", nil}
+	}
+	b := *broker
+	if b.BrokerHost != "" && !IsHTTP(b.BrokerHost) {
+		broker1, ok := ngsi.brokerList[b.BrokerHost]
+		if !ok {
+			return nil, &NgsiLibError{funcName, 2, b.BrokerHost + " not found", nil}
+		}
+		copyBrokerInfo(broker1, &b)
+	}
+
+	return &Client{Host: host, Broker: &b}, nil
+}
@@ -134,6 +134,84 @@ func TestNewClientNgsiTypeV2(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestNewClientServerTypeQuantumLeap(t *testing.T) {
+	ngsi := testNgsiLibInit()
+	fileName := ""
+	ngsi.ConfigFile = &MockIoLib{filename: &fileName}
+
+	InitBrokerList()
+
+	broker := &Broker{BrokerHost: "http://quantumleap/", ServerType: "quantumleap"}
+	ngsi.brokerList["ql"] = broker
+
+	flags := &CmdFlags{}
+
+	client, err := ngsi.NewClient("ql", flags, false)
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, true, client.IsQuantumLeap())
+	}
+}
+
+func TestNewClientServerTypeComet(t *testing.T) {
+	ngsi := testNgsiLibInit()
+	fileName := ""
+	ngsi.ConfigFile = &MockIoLib{filename: &fileName}
+
+	InitBrokerList()
+
+	broker := &Broker{BrokerHost: "http://comet/", ServerType: "comet"}
+	ngsi.brokerList["comet"] = broker
+
+	flags := &CmdFlags{}
+
+	client, err := ngsi.NewClient("comet", flags, false)
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, true, client.IsComet())
+	}
+}
+
+func TestNewClientServerTypeIoTAgent(t *testing.T) {
+	ngsi := testNgsiLibInit()
+	fileName := ""
+	ngsi.ConfigFile = &MockIoLib{filename: &fileName}
+
+	InitBrokerList()
+
+	broker := &Broker{BrokerHost: "http://iota:4041/", ServerType: "iota"}
+	ngsi.brokerList["iota"] = broker
+
+	flags := &CmdFlags{}
+
+	client, err := ngsi.NewClient("iota", flags, false)
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, true, client.IsIoTAgent())
+	}
+}
+
+func TestNewClientProxy(t *testing.T) {
+	ngsi := testNgsiLibInit()
+	fileName := ""
+	ngsi.ConfigFile = &MockIoLib{filename: &fileName}
+	r := &httpRequest{}
+	ngsi.HTTP = r
+
+	InitBrokerList()
+
+	broker := &Broker{BrokerHost: "http://orion:1026/", NgsiType: "v2", Proxy: "http://proxy:8080"}
+	ngsi.brokerList["orion"] = broker
+
+	flags := &CmdFlags{}
+
+	_, err := ngsi.NewClient("orion", flags, false)
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, broker, r.hosts["orion:1026"])
+	}
+}
+
 func TestNewClientToken(t *testing.T) {
 	ngsi := testNgsiLibInit()
 	fileName := ""
@@ -176,6 +254,26 @@ func TestNewClientIdmType(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestNewClientBasic(t *testing.T) {
+	ngsi := testNgsiLibInit()
+	fileName := ""
+	ngsi.ConfigFile = &MockIoLib{filename: &fileName}
+
+	InitBrokerList()
+
+	broker := &Broker{BrokerHost: "http://orion/", IdmType: cBasic, Username: "fiware", Password: "1234", Headers: map[string]string{"apikey": "b2a1c3d4"}}
+	ngsi.brokerList["orion"] = broker
+
+	flags := &CmdFlags{}
+
+	client, err := ngsi.NewClient("orion", flags, false)
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, "Basic Zml3YXJlOjEyMzQ=", client.Headers["Authorization"])
+		assert.Equal(t, "b2a1c3d4", client.Headers["apikey"])
+	}
+}
+
 func TestNewClientSafeString(t *testing.T) {
 	ngsi := testNgsiLibInit()
 	fileName := ""
@@ -385,6 +483,27 @@ func TestNewClientErrorIdmType(t *testing.T) {
 	}
 }
 
+func TestNewClientErrorBasic(t *testing.T) {
+	ngsi := testNgsiLibInit()
+	fileName := ""
+	ngsi.ConfigFile = &MockIoLib{filename: &fileName}
+
+	InitBrokerList()
+
+	broker := &Broker{BrokerHost: "http://orion/", IdmType: cBasic}
+	ngsi.brokerList["orion"] = broker
+
+	flags := &CmdFlags{}
+
+	_, err := ngsi.NewClient("orion", flags, false)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*NgsiLibError)
+		assert.Equal(t, 13, ngsiErr.ErrNo)
+		assert.Equal(t, "username is required", ngsiErr.Message)
+	}
+}
+
 func TestNewClientErrorSafeString(t *testing.T) {
 	ngsi := testNgsiLibInit()
 	fileName := ""
@@ -554,3 +673,44 @@ func TestParseURLHostPathQuery(t *testing.T) {
 	assert.Equal(t, "/v2/entities", path)
 	assert.Equal(t, "options=keyValues", query)
 }
+
+func TestBrokerClient(t *testing.T) {
+	ngsi := testNgsiLibInit()
+	ngsi.brokerList = make(BrokerList)
+	ngsi.brokerList["orion"] = &Broker{BrokerHost: "http://orion", IdmType: "keyrock", IdmHost: "http://keyrock", Username: "fiware"}
+	ngsi.brokerList["orion-alias"] = &Broker{BrokerHost: "orion", Username: "admin"}
+
+	client, err := ngsi.BrokerClient("orion-alias")
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, "orion-alias", client.Host)
+		assert.Equal(t, "keyrock", client.Broker.IdmType)
+		assert.Equal(t, "admin", client.Broker.Username)
+		assert.Equal(t, "", ngsi.brokerList["orion-alias"].IdmType)
+	}
+}
+
+func TestBrokerClientError(t *testing.T) {
+	ngsi := testNgsiLibInit()
+	ngsi.brokerList = make(BrokerList)
+	ngsi.brokerList["orion-alias"] = &Broker{BrokerHost: "orion"}
+
+	cases := []struct {
+		host    string
+		errNo   int
+		message string
+	}{
+		{host: "orion-ld", errNo: 1, message: "orion-ld not found"},
+		{host: "orion-alias", errNo: 2, message: "orion not found"},
+	}
+
+	for _, c := range cases {
+		_, err := ngsi.BrokerClient(c.host)
+
+		if assert.Error(t, err) {
+			ngsiErr := err.(*NgsiLibError)
+			assert.Equal(t, c.errNo, ngsiErr.ErrNo)
+			assert.Equal(t, c.message, ngsiErr.Message)
+		}
+	}
+}
@@ -43,10 +43,13 @@ type NGSI struct {
 	brokerList  BrokerList
 	tokenList   tokenInfoList
 	contextList ContextsInfo
+	oidcConfigs map[string]map[string]interface{}
 
 	LogLevel      int
 	ConfigFile    IoLib
 	CacheFile     IoLib
+	CacheTmpFile  IoLib
+	CredFile      IoLib
 	StdReader     io.Reader
 	StdWriter     io.Writer
 	LogWriter     io.Writer
@@ -57,13 +60,20 @@ type NGSI struct {
 	Margin        int64
 	Maxsize       int
 	Timeout       time.Duration
+	MaxIdleConns  int
+	IdleTimeout   time.Duration
+	KeepAlive     bool
+	Retry         int
+	RetryWait     time.Duration
 	PreviousArgs  *Settings
 	Updated       bool
 	HTTP          HTTPRequest
+	HTTPServer    HTTPServerLib
 	Stderr        *os.File
 	OsType        string
 	SyslogLib     SyslogLib
 	TimeLib       TimeLib
+	TermLib       TermLib
 	BatchFlag     *bool
 }
 
@@ -85,11 +95,18 @@ func NewNGSI() *NGSI {
 		gNGSI = &NGSI{}
 		gNGSI.InitLog(os.Stdin, os.Stdout, os.Stderr)
 		gNGSI.HTTP = &httpRequest{}
+		gNGSI.HTTPServer = &httpServerLib{}
 		gNGSI.Margin = 180
-		gNGSI.Timeout = 60
+		gNGSI.Timeout = 60 * time.Second
+		gNGSI.MaxIdleConns = 100
+		gNGSI.IdleTimeout = 90 * time.Second
+		gNGSI.KeepAlive = true
+		gNGSI.Retry = -1 // use the retry setting of a broker
 		gNGSI.Maxsize = 100
 		gNGSI.ConfigFile = &ioLib{}
 		gNGSI.CacheFile = &ioLib{}
+		gNGSI.CacheTmpFile = &ioLib{}
+		gNGSI.CredFile = &ioLib{}
 		gNGSI.JSONConverter = &jsonLib{}
 		gNGSI.FileReader = &fileLib{}
 		gNGSI.Stderr = os.Stderr
@@ -97,6 +114,7 @@ func NewNGSI() *NGSI {
 		gNGSI.SyslogLib = &syslogLib{}
 		gNGSI.PreviousArgs = &Settings{UsePreviousArgs: true}
 		gNGSI.TimeLib = &timeLib{}
+		gNGSI.TermLib = &termLib{}
 		gNGSI.brokerList = make(BrokerList)
 		gNGSI.contextList = make(ContextsInfo)
 		gNGSI.contextList["etsi"] = "https://uri.etsi.org/ngsi-ld/v1/ngsi-ld-core-context-v1.3.jsonld"
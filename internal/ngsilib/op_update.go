@@ -60,5 +60,9 @@ func (client *Client) OpUpdate(entities interface{}, actionType string, keyValue
 		return nil, nil, &NgsiLibError{funcName, 1, "json.Marshal error", err}
 	}
 
-	return client.HTTPPost(b)
+	// append_strict fails and delete deletes other entities when they are sent again
+	if actionType == "append_strict" || actionType == "delete" {
+		return client.HTTPPost(b)
+	}
+	return client.HTTPPostIdempotent(b)
 }
@@ -54,7 +54,27 @@ func TestOpUpdate(t *testing.T) {
 
 	_, _, err := client.OpUpdate(entities, actionType, keyValues, safeString)
 
-	assert.NoError(t, err)
+	if assert.NoError(t, err) {
+		assert.Equal(t, true, mock.idempotent)
+	}
+}
+
+func TestOpUpdateNotIdempotent(t *testing.T) {
+	testNgsiLibInit()
+
+	for _, actionType := range []string{"append_strict", "delete"} {
+		reqRes := MockHTTPReqRes{}
+		reqRes.Res.StatusCode = http.StatusOK
+		mock := NewMockHTTP()
+		mock.ReqRes = append(mock.ReqRes, reqRes)
+		client := &Client{HTTP: mock, URL: &url.URL{}, Headers: map[string]string{}}
+
+		_, _, err := client.OpUpdate(`[]`, actionType, false, false)
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, false, mock.idempotent, actionType)
+		}
+	}
 }
 
 func TestOpUpdateKeyValues(t *testing.T) {
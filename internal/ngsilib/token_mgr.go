@@ -38,21 +38,46 @@ import (
 	"net/http"
 	"net/url"
 	"path/filepath"
+	"sort"
+	"strings"
 )
 
 // Token is ...
 type Token struct {
-	AccessToken  string   `json:"access_token"`
-	ExpiresIn    int64    `json:"expires_in"`
-	RefreshToken string   `json:"refresh_token"`
-	Scope        []string `json:"scope"`
-	TokenType    string   `json:"token_type"`
+	AccessToken  string     `json:"access_token"`
+	ExpiresIn    int64      `json:"expires_in"`
+	RefreshToken string     `json:"refresh_token"`
+	Scope        TokenScope `json:"scope"`
+	TokenType    string     `json:"token_type"`
+}
+
+// TokenScope is the scope of a token. An identity manager returns it as a space-separated
+// string (RFC 6749 section 5.1) or as an array of strings.
+type TokenScope []string
+
+// UnmarshalJSON accepts both a space-separated string and an array of strings.
+func (scope *TokenScope) UnmarshalJSON(b []byte) error {
+	var a []string
+	if err := json.Unmarshal(b, &a); err == nil {
+		*scope = a
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	*scope = strings.Fields(s)
+	return nil
 }
 
 // TokenInfo is ...
 type TokenInfo struct {
-	Expires int64 `json:"expires"`
-	Token   Token `json:"token"`
+	Host     string `json:"host,omitempty"`
+	IdmHost  string `json:"idmHost,omitempty"`
+	ClientID string `json:"clientId,omitempty"`
+	Username string `json:"username,omitempty"`
+	Expires  int64  `json:"expires"`
+	Token    Token  `json:"token"`
 }
 
 type tokenInfoList map[string]TokenInfo
@@ -112,21 +137,35 @@ func initTokenList(io IoLib) error {
 		return nil
 	}
 
-	if existsFile(io, *io.FileName()) {
-		err := io.Open()
-		if err != nil {
-			return &NgsiLibError{funcName, 1, err.Error(), err}
-		}
-		defer io.Close()
+	list, err := loadTokenList(io)
+	if err != nil {
+		return &NgsiLibError{funcName, 1, err.Error(), err}
+	}
+	gNGSI.tokenList = list
+
+	return nil
+}
 
-		tokens := tokens{}
-		io.Decode(&tokens)
+func loadTokenList(io IoLib) (tokenInfoList, error) {
+	const funcName = "loadTokenList"
 
-		gNGSI.tokenList = tokens.Tokens
-	} else {
-		gNGSI.tokenList = make(tokenInfoList)
+	if !existsFile(io, *io.FileName()) {
+		return make(tokenInfoList), nil
 	}
-	return nil
+
+	err := io.Open()
+	if err != nil {
+		return nil, &NgsiLibError{funcName, 1, err.Error(), err}
+	}
+	defer io.Close()
+
+	tokens := tokens{}
+	io.Decode(&tokens)
+
+	if tokens.Tokens == nil {
+		tokens.Tokens = make(tokenInfoList)
+	}
+	return tokens.Tokens, nil
 }
 
 // TokenList is ...
@@ -142,6 +181,23 @@ func (ngsi *NGSI) TokenList() string {
 	return list
 }
 
+// TokenInfoList returns cached tokens sorted by host and user.
+func (ngsi *NGSI) TokenInfoList() []TokenInfo {
+	list := []TokenInfo{}
+
+	for _, v := range ngsi.tokenList {
+		list = append(list, v)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Host != list[j].Host {
+			return list[i].Host < list[j].Host
+		}
+		return list[i].Username+list[i].ClientID < list[j].Username+list[j].ClientID
+	})
+
+	return list
+}
+
 // TokenInfo is ...
 func (ngsi *NGSI) TokenInfo(client *Client) (*TokenInfo, error) {
 	const funcName = "TokenInfo"
@@ -169,6 +225,14 @@ func (ngsi *NGSI) GetToken(client *Client) (string, error) {
 			gNGSI.Logging(LogDebug, accessToken+"\n")
 			return accessToken, nil
 		}
+
+		if token.RefreshToken != "" {
+			accessToken, err := refreshToken(ngsi, client, token.RefreshToken)
+			if err == nil {
+				return accessToken, nil
+			}
+			gNGSI.Logging(LogInfo, err.Error()+"\n")
+		}
 	}
 
 	return getToken(ngsi, client)
@@ -180,26 +244,36 @@ func getToken(ngsi *NGSI, client *Client) (string, error) {
 	ngsi.Logging(LogInfo, funcName)
 
 	var data string
-	headers := make(map[string]string)
-	u, _ := url.Parse(client.idmURL())
-	idm := Client{URL: u, Headers: headers, HTTP: ngsi.HTTP}
+	idm := newIdmClient(ngsi, client)
 
-	username, err := getUserName(client)
-	if err != nil {
-		return "", &NgsiLibError{funcName, 1, err.Error(), err}
-	}
-	password, err := getPassword(client)
-	if err != nil {
-		return "", &NgsiLibError{funcName, 2, err.Error(), err}
+	broker := client.Broker
+	idmType := strings.ToLower(broker.IdmType)
+
+	if idmType == cOpenIDConnect {
+		tokenURL, err := discoverEndpoint(ngsi, client, "token_endpoint")
+		if err != nil {
+			return "", &NgsiLibError{funcName, 7, err.Error(), err}
+		}
+		idm.URL = tokenURL
 	}
 
-	broker := client.Broker
+	var username, password string
+	var err error
+	if !isClientCredentials(broker) {
+		username, err = getUserName(client)
+		if err != nil {
+			return "", &NgsiLibError{funcName, 1, err.Error(), err}
+		}
+		password, err = getPassword(client)
+		if err != nil {
+			return "", &NgsiLibError{funcName, 2, err.Error(), err}
+		}
+	}
 
-	switch broker.IdmType {
+	switch idmType {
 	case cKeyrock:
 		idm.SetHeader(cContentType, cAppXWwwFormUrlencoded)
-		auth := fmt.Sprintf("%s:%s", broker.ClientID, broker.ClientSecret)
-		idm.SetHeader("Authorization", fmt.Sprintf("Basic %s", base64.StdEncoding.EncodeToString([]byte(auth))))
+		idm.SetHeader("Authorization", keyrockAuthorization(broker))
 		data = fmt.Sprintf("grant_type=password&username=%s&password=%s", username, password)
 	case cPasswordCredentials:
 		idm.SetHeader(cContentType, cAppXWwwFormUrlencoded)
@@ -210,6 +284,9 @@ func getToken(ngsi *NGSI, client *Client) (string, error) {
 	case cTokenproxy:
 		idm.SetHeader(cContentType, cAppJSON)
 		data = fmt.Sprintf("{\"username\": \"%s\", \"password\": \"%s\"}", username, password)
+	case cClientCredentials, cOpenIDConnect:
+		idm.SetHeader(cContentType, cAppXWwwFormUrlencoded)
+		data = oauth2Params(broker, username, password)
 	default:
 		return "", &NgsiLibError{funcName, 3, "unkown idm type: " + broker.IdmType, nil}
 	}
@@ -224,44 +301,434 @@ func getToken(ngsi *NGSI, client *Client) (string, error) {
 
 	var token Token
 
-	if broker.IdmType == cKeyrocktokenprovider {
+	if idmType == cKeyrocktokenprovider {
 		r := fmt.Sprintf(`{"access_token":"%s", "expires_in":%d}`, string(body), client.getExpiresIn())
 		json.Unmarshal([]byte(r), &token)
 	} else {
 		json.Unmarshal(body, &token)
 	}
 
+	err = storeTokenInfo(ngsi, client, &token)
+	if err != nil {
+		return "", &NgsiLibError{funcName, 6, err.Error(), err}
+	}
+	return token.AccessToken, nil
+}
+
+// refreshToken gets a new token with grant_type=refresh_token, so that the password is not sent again.
+func refreshToken(ngsi *NGSI, client *Client, refresh string) (string, error) {
+	const funcName = "refreshToken"
+
+	ngsi.Logging(LogInfo, funcName+"\n")
+
+	var data string
+	idm := newIdmClient(ngsi, client)
+
+	broker := client.Broker
+
+	switch strings.ToLower(broker.IdmType) {
+	case cKeyrock:
+		idm.SetHeader(cContentType, cAppXWwwFormUrlencoded)
+		idm.SetHeader("Authorization", keyrockAuthorization(broker))
+		data = fmt.Sprintf("grant_type=refresh_token&refresh_token=%s", refresh)
+	case cPasswordCredentials:
+		idm.SetHeader(cContentType, cAppXWwwFormUrlencoded)
+		data = fmt.Sprintf("grant_type=refresh_token&refresh_token=%s&client_id=%s&client_secret=%s", refresh, broker.ClientID, broker.ClientSecret)
+	case cOpenIDConnect:
+		tokenURL, err := discoverEndpoint(ngsi, client, "token_endpoint")
+		if err != nil {
+			return "", &NgsiLibError{funcName, 6, err.Error(), err}
+		}
+		idm.URL = tokenURL
+		fallthrough
+	case cClientCredentials:
+		idm.SetHeader(cContentType, cAppXWwwFormUrlencoded)
+		v := url.Values{}
+		v.Set("grant_type", "refresh_token")
+		v.Set("refresh_token", refresh)
+		v.Set("client_id", broker.ClientID)
+		if broker.ClientSecret != "" {
+			v.Set("client_secret", broker.ClientSecret)
+		}
+		data = v.Encode()
+	default:
+		return "", &NgsiLibError{funcName, 1, "refresh token not supported: " + broker.IdmType, nil}
+	}
+
+	res, body, err := idm.HTTPPost(data)
+	if err != nil {
+		return "", &NgsiLibError{funcName, 2, err.Error(), err}
+	}
+	if res.StatusCode != http.StatusOK {
+		return "", &NgsiLibError{funcName, 3, fmt.Sprintf("error %s %s", res.Status, string(body)), nil}
+	}
+
+	var token Token
+	if err := json.Unmarshal(body, &token); err != nil || token.AccessToken == "" {
+		return "", &NgsiLibError{funcName, 4, "access token not found", err}
+	}
+	if token.RefreshToken == "" {
+		token.RefreshToken = refresh
+	}
+
+	err = storeTokenInfo(ngsi, client, &token)
+	if err != nil {
+		return "", &NgsiLibError{funcName, 5, err.Error(), err}
+	}
+	return token.AccessToken, nil
+}
+
+// RevokeToken revokes the cached token of client at the identity manager and removes it from the cache.
+func (ngsi *NGSI) RevokeToken(client *Client) error {
+	const funcName = "RevokeToken"
+
+	hash := getHash(client)
+	info, ok := ngsi.tokenList[hash]
+	if !ok {
+		return &NgsiLibError{funcName, 1, "token not found", nil}
+	}
+
+	if err := revokeToken(ngsi, client, &info.Token); err != nil {
+		return &NgsiLibError{funcName, 2, err.Error(), err}
+	}
+
+	err := updateTokenList(ngsi, func(list tokenInfoList) {
+		delete(list, hash)
+	})
+	if err != nil {
+		return &NgsiLibError{funcName, 3, err.Error(), err}
+	}
+	return nil
+}
+
+// ClearTokens removes all tokens from the cache. The tokens are not revoked.
+func (ngsi *NGSI) ClearTokens() error {
+	const funcName = "ClearTokens"
+
+	err := updateTokenList(ngsi, func(list tokenInfoList) {
+		for k := range list {
+			delete(list, k)
+		}
+	})
+	if err != nil {
+		return &NgsiLibError{funcName, 1, err.Error(), err}
+	}
+	return nil
+}
+
+// revokeToken revokes a token with OAuth 2.0 Token Revocation (RFC 7009). The refresh token is revoked if it exists,
+// since it revokes the access token as well. Identity managers which have no revocation endpoint are skipped.
+func revokeToken(ngsi *NGSI, client *Client, token *Token) error {
+	const funcName = "revokeToken"
+
+	ngsi.Logging(LogInfo, funcName+"\n")
+
+	idm := newIdmClient(ngsi, client)
+	broker := client.Broker
+
+	switch strings.ToLower(broker.IdmType) {
+	case cOpenIDConnect:
+		revokeURL, err := discoverEndpoint(ngsi, client, "revocation_endpoint")
+		if err != nil {
+			return &NgsiLibError{funcName, 1, err.Error(), err}
+		}
+		idm.URL = revokeURL
+	case cKeyrock, cPasswordCredentials, cClientCredentials:
+		if idm.URL == nil || !strings.HasSuffix(idm.URL.Path, "/token") {
+			return nil
+		}
+		u := *idm.URL
+		u.Path = strings.TrimSuffix(u.Path, "/token") + "/revoke"
+		idm.URL = &u
+	default:
+		return nil
+	}
+
+	v := url.Values{}
+	if token.RefreshToken != "" {
+		v.Set("token", token.RefreshToken)
+		v.Set("token_type_hint", "refresh_token")
+	} else {
+		v.Set("token", token.AccessToken)
+		v.Set("token_type_hint", "access_token")
+	}
+	if strings.ToLower(broker.IdmType) == cKeyrock {
+		idm.SetHeader("Authorization", keyrockAuthorization(broker))
+	} else {
+		v.Set("client_id", broker.ClientID)
+		if broker.ClientSecret != "" {
+			v.Set("client_secret", broker.ClientSecret)
+		}
+	}
+	idm.SetHeader(cContentType, cAppXWwwFormUrlencoded)
+
+	res, body, err := idm.HTTPPost(v.Encode())
+	if err != nil {
+		return &NgsiLibError{funcName, 2, err.Error(), err}
+	}
+	if res.StatusCode != http.StatusOK {
+		return &NgsiLibError{funcName, 3, fmt.Sprintf("error %s %s", res.Status, string(body)), nil}
+	}
+	return nil
+}
+
+// IntrospectToken gets information about the cached token of client from the identity manager.
+func (ngsi *NGSI) IntrospectToken(client *Client) ([]byte, error) {
+	const funcName = "IntrospectToken"
+
+	hash := getHash(client)
+	info, ok := ngsi.tokenList[hash]
+	if !ok {
+		return nil, &NgsiLibError{funcName, 1, "token not found", nil}
+	}
+
+	body, err := introspectToken(ngsi, client, &info.Token)
+	if err != nil {
+		return nil, &NgsiLibError{funcName, 2, err.Error(), err}
+	}
+	return body, nil
+}
+
+// introspectToken gets the user information of an access token from /user of Keyrock, or the state of an access token
+// with OAuth 2.0 Token Introspection (RFC 7662) from other identity managers.
+func introspectToken(ngsi *NGSI, client *Client, token *Token) ([]byte, error) {
+	const funcName = "introspectToken"
+
+	ngsi.Logging(LogInfo, funcName+"\n")
+
+	idm := newIdmClient(ngsi, client)
+	broker := client.Broker
+
+	idmType := strings.ToLower(broker.IdmType)
+
+	switch idmType {
+	case cKeyrock, cPasswordCredentials, cClientCredentials:
+		if idm.URL == nil {
+			return nil, &NgsiLibError{funcName, 1, "url error: " + client.idmURL(), nil}
+		}
+		u := *idm.URL
+		if idmType == cKeyrock {
+			u.Path = strings.TrimSuffix(u.Path, "/oauth2/token") + "/user"
+			idm.SetQuery(&url.Values{"access_token": []string{token.AccessToken}})
+		} else {
+			// e.g. /protocol/openid-connect/token/introspect of Keycloak
+			u.Path = strings.TrimSuffix(u.Path, "/") + "/introspect"
+		}
+		idm.URL = &u
+	case cOpenIDConnect:
+		introspectURL, err := discoverEndpoint(ngsi, client, "introspection_endpoint")
+		if err != nil {
+			return nil, &NgsiLibError{funcName, 2, err.Error(), err}
+		}
+		idm.URL = introspectURL
+	default:
+		return nil, &NgsiLibError{funcName, 3, "introspection not supported: " + broker.IdmType, nil}
+	}
+
+	var res *http.Response
+	var body []byte
+	var err error
+
+	if idmType == cKeyrock {
+		res, body, err = idm.HTTPGet()
+	} else {
+		v := url.Values{}
+		v.Set("token", token.AccessToken)
+		v.Set("token_type_hint", "access_token")
+		v.Set("client_id", broker.ClientID)
+		if broker.ClientSecret != "" {
+			v.Set("client_secret", broker.ClientSecret)
+		}
+		idm.SetHeader(cContentType, cAppXWwwFormUrlencoded)
+		res, body, err = idm.HTTPPost(v.Encode())
+	}
+	if err != nil {
+		return nil, &NgsiLibError{funcName, 4, err.Error(), err}
+	}
+	if res.StatusCode != http.StatusOK {
+		return nil, &NgsiLibError{funcName, 5, fmt.Sprintf("error %s %s", res.Status, string(body)), nil}
+	}
+	return body, nil
+}
+
+func newIdmClient(ngsi *NGSI, client *Client) *Client {
+	headers := make(map[string]string)
+	u, _ := url.Parse(client.idmURL())
+	idm := &Client{URL: u, Headers: headers, HTTP: ngsi.HTTP}
+	if u != nil {
+		setHTTPConfig(idm.HTTP, u.Host, client.Broker)
+	}
+	return idm
+}
+
+// isClientCredentials returns true if a token is got with client credentials, i.e. without username and password.
+func isClientCredentials(broker *Broker) bool {
+	switch strings.ToLower(broker.IdmType) {
+	case cClientCredentials:
+		return true
+	case cOpenIDConnect:
+		return broker.Username == ""
+	}
+	return false
+}
+
+// oauth2Params returns form data of client credentials grant or password grant with the scope and the audience.
+func oauth2Params(broker *Broker, username, password string) string {
+	v := url.Values{}
+	if username == "" {
+		v.Set("grant_type", "client_credentials")
+	} else {
+		v.Set("grant_type", "password")
+		v.Set("username", username)
+		v.Set("password", password)
+	}
+	v.Set("client_id", broker.ClientID)
+	if broker.ClientSecret != "" {
+		v.Set("client_secret", broker.ClientSecret)
+	}
+	if broker.IdmScope != "" {
+		v.Set("scope", broker.IdmScope)
+	}
+	if broker.Audience != "" {
+		v.Set("audience", broker.Audience)
+	}
+	return v.Encode()
+}
+
+// discoverEndpoint gets an endpoint such as token_endpoint from the OpenID Provider Configuration of the issuer in idmHost.
+func discoverEndpoint(ngsi *NGSI, client *Client, name string) (*url.URL, error) {
+	const funcName = "discoverEndpoint"
+
+	u, err := url.Parse(strings.TrimSuffix(client.idmURL(), "/") + "/.well-known/openid-configuration")
+	if err != nil {
+		return nil, &NgsiLibError{funcName, 1, err.Error(), err}
+	}
+	idm := &Client{URL: u, Headers: make(map[string]string), HTTP: ngsi.HTTP}
+	setHTTPConfig(idm.HTTP, u.Host, client.Broker)
+
+	// the configuration is cached so that it is fetched once per issuer
+	config, ok := ngsi.oidcConfigs[u.String()]
+	if !ok {
+		res, body, err := idm.HTTPGet()
+		if err != nil {
+			return nil, &NgsiLibError{funcName, 2, err.Error(), err}
+		}
+		if res.StatusCode != http.StatusOK {
+			return nil, &NgsiLibError{funcName, 3, fmt.Sprintf("error %s %s", res.Status, string(body)), nil}
+		}
+
+		_ = json.Unmarshal(body, &config)
+		if ngsi.oidcConfigs == nil {
+			ngsi.oidcConfigs = make(map[string]map[string]interface{})
+		}
+		ngsi.oidcConfigs[u.String()] = config
+	}
+
+	endpoint, _ := config[name].(string)
+	if endpoint == "" {
+		return nil, &NgsiLibError{funcName, 4, name + " not found", nil}
+	}
+
+	endpointURL, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, &NgsiLibError{funcName, 5, err.Error(), err}
+	}
+	setHTTPConfig(idm.HTTP, endpointURL.Host, client.Broker)
+
+	return endpointURL, nil
+}
+
+func keyrockAuthorization(broker *Broker) string {
+	auth := fmt.Sprintf("%s:%s", broker.ClientID, broker.ClientSecret)
+	return fmt.Sprintf("Basic %s", base64.StdEncoding.EncodeToString([]byte(auth)))
+}
+
+// basicAuthorization returns an Authorization header of HTTP Basic authentication with the username and the password
+// of the broker.
+func basicAuthorization(client *Client) (string, error) {
+	const funcName = "basicAuthorization"
+
+	username, err := getUserName(client)
+	if err != nil {
+		return "", &NgsiLibError{funcName, 1, err.Error(), err}
+	}
+	password, err := getPassword(client)
+	if err != nil {
+		return "", &NgsiLibError{funcName, 2, err.Error(), err}
+	}
+	auth := fmt.Sprintf("%s:%s", username, password)
+	return fmt.Sprintf("Basic %s", base64.StdEncoding.EncodeToString([]byte(auth))), nil
+}
+
+// storeTokenInfo caches the token. Tokens which have a refresh token are kept after expiration.
+func storeTokenInfo(ngsi *NGSI, client *Client, token *Token) error {
+	const funcName = "storeTokenInfo"
+
 	client.storeToken(token.AccessToken)
 
-	var tokenInfo TokenInfo
 	utime := ngsi.TimeLib.NowUnix()
 
 	hash := getHash(client)
-	tokenInfo.Expires = utime + token.ExpiresIn
-	tokenInfo.Token = token
-
-	newTokenList := make(tokenInfoList)
-	newTokenList[hash] = tokenInfo
+	tokenInfo := TokenInfo{
+		Host:     client.Host,
+		IdmHost:  client.idmURL(),
+		ClientID: client.Broker.ClientID,
+		Username: client.Broker.Username,
+		Expires:  utime + token.ExpiresIn,
+		Token:    *token,
+	}
 
-	for k, v := range ngsi.tokenList {
-		if v.Expires > utime+gNGSI.Margin {
-			newTokenList[k] = v
+	err := updateTokenList(ngsi, func(list tokenInfoList) {
+		for k, v := range list {
+			if !(v.Expires > utime+gNGSI.Margin || v.Token.RefreshToken != "") {
+				delete(list, k)
+			}
 		}
+		list[hash] = tokenInfo
+	})
+	if err != nil {
+		return &NgsiLibError{funcName, 1, err.Error(), err}
 	}
+	return nil
+}
+
+// updateTokenList updates the token list with the lock of the cache file. The list is reloaded from the
+// cache file before update, so that tokens stored by other processes are not lost.
+func updateTokenList(ngsi *NGSI, update func(list tokenInfoList)) error {
+	const funcName = "updateTokenList"
 
-	ngsi.tokenList = newTokenList
+	cacheFile := ngsi.CacheFile
+	file := *cacheFile.FileName()
+
+	if file == "" {
+		if ngsi.tokenList == nil {
+			ngsi.tokenList = make(tokenInfoList)
+		}
+		update(ngsi.tokenList)
+		return nil
+	}
 
-	tokens := make(map[string]interface{})
-	tokens["tokens"] = ngsi.tokenList
+	if err := cacheFile.Lock(); err != nil {
+		return &NgsiLibError{funcName, 1, err.Error(), err}
+	}
+	defer func() { _ = cacheFile.Unlock() }()
 
-	err = saveToken(*ngsi.CacheFile.FileName(), &token)
+	list, err := loadTokenList(cacheFile)
 	if err != nil {
-		return "", &NgsiLibError{funcName, 6, err.Error(), err}
+		return &NgsiLibError{funcName, 2, err.Error(), err}
 	}
-	return token.AccessToken, nil
+
+	update(list)
+	ngsi.tokenList = list
+
+	if err := saveToken(file, list); err != nil {
+		return &NgsiLibError{funcName, 3, err.Error(), err}
+	}
+	return nil
 }
 
-func saveToken(file string, token *Token) error {
+// saveToken writes the token list into a temporary file and renames it to the cache file.
+func saveToken(file string, list tokenInfoList) error {
 	const funcName = "saveToken"
 
 	gNGSI.Logging(LogInfo, funcName+"\n")
@@ -270,28 +737,37 @@ func saveToken(file string, token *Token) error {
 		return nil
 	}
 
-	cacheFile := gNGSI.CacheFile
+	tmpFile := gNGSI.CacheTmpFile
+
+	tmp := file + ".tmp"
+	tmpFile.SetFileName(&tmp)
 
-	err := cacheFile.OpenFile(oWRONLY|oCREATE, 0600)
+	err := tmpFile.OpenFile(oWRONLY|oCREATE, 0600)
 	if err != nil {
 		return &NgsiLibError{funcName, 1, err.Error() + " " + file, err}
 	}
-	defer cacheFile.Close()
 
-	if err := cacheFile.Truncate(0); err != nil {
+	if err := tmpFile.Truncate(0); err != nil {
+		tmpFile.Close()
 		return &NgsiLibError{funcName, 2, err.Error(), err}
 	}
 
-	err = cacheFile.Encode(&token)
+	err = tmpFile.Encode(&tokens{Tokens: list})
+	tmpFile.Close()
 	if err != nil {
 		return &NgsiLibError{funcName, 3, err.Error(), err}
 	}
 
+	if err := tmpFile.Rename(tmp, file); err != nil {
+		return &NgsiLibError{funcName, 4, err.Error(), err}
+	}
+
 	return nil
 }
 
+// getHash returns a key of the token cache, which identifies a broker alias, an identity manager, a client and a user.
 func getHash(client *Client) string {
-	s := client.Broker.BrokerHost + client.Broker.Username
+	s := strings.Join([]string{client.Host, client.Broker.BrokerHost, client.idmURL(), client.Broker.ClientID, client.Broker.Username}, "\x00")
 	r := sha1.Sum([]byte(s))
 	return hex.EncodeToString(r[:])
 }
@@ -306,12 +782,32 @@ func getUserName(client *Client) (string, error) {
 	return s, nil
 }
 
+// getPassword returns the password in the config file, the credential store or the terminal in this order.
 func getPassword(client *Client) (string, error) {
 	const funcName = "getPassword"
 
 	s := client.Broker.Password
-	if s == "" {
-		return "", &NgsiLibError{funcName, 1, "password is required", nil}
+	if s != "" {
+		return s, nil
 	}
-	return s, nil
+
+	s, ok, err := gNGSI.GetCredential(client)
+	if err != nil {
+		return "", &NgsiLibError{funcName, 2, err.Error(), err}
+	}
+	if ok {
+		return s, nil
+	}
+
+	if gNGSI.TermLib.IsTerminal() {
+		s, err = gNGSI.TermLib.ReadPassword(fmt.Sprintf("Password for %s: ", client.Broker.Username))
+		if err != nil {
+			return "", &NgsiLibError{funcName, 3, err.Error(), err}
+		}
+		if s != "" {
+			return s, nil
+		}
+	}
+
+	return "", &NgsiLibError{funcName, 1, "password is required", nil}
 }
@@ -31,8 +31,11 @@ package ngsilib
 
 import (
 	"bytes"
+	"encoding/json"
 	"errors"
 	"net/http"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -159,7 +162,7 @@ func TestTokenInfo(t *testing.T) {
 	ngsi.tokenList = tokenInfoList{}
 	ngsi.tokenList["token1"] = TokenInfo{}
 	ngsi.tokenList["token2"] = TokenInfo{}
-	ngsi.tokenList["583a5c111b603ff8925585f48503e343403115f9"] = TokenInfo{}
+	ngsi.tokenList["b030abf88086cb8c650fcf34a02a75e170061753"] = TokenInfo{}
 
 	client := &Client{Broker: &Broker{BrokerHost: "http://orion/", Username: "fiware"}}
 
@@ -215,7 +218,7 @@ func TestNgsiGetTokenExpires(t *testing.T) {
 	ngsi.TimeLib = &MockTimeLib{unixTime: 0}
 	ngsi.tokenList["token1"] = TokenInfo{}
 	ngsi.tokenList["token2"] = TokenInfo{}
-	ngsi.tokenList["583a5c111b603ff8925585f48503e343403115f9"] = TokenInfo{Expires: 3600}
+	ngsi.tokenList["b030abf88086cb8c650fcf34a02a75e170061753"] = TokenInfo{Expires: 3600}
 
 	client := &Client{Broker: &Broker{BrokerHost: "http://orion/", Username: "fiware"}}
 
@@ -224,6 +227,64 @@ func TestNgsiGetTokenExpires(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestNgsiGetTokenRefresh(t *testing.T) {
+	ngsi := testNgsiLibInit()
+	ngsi.tokenList = tokenInfoList{}
+	filename := ""
+	ngsi.CacheFile = &MockIoLib{filename: &filename}
+	ngsi.LogWriter = &bytes.Buffer{}
+	ngsi.TimeLib = &MockTimeLib{unixTime: 1000}
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusOK
+	reqRes.ReqData = []byte("grant_type=refresh_token&refresh_token=refresh1")
+	reqRes.ResBody = []byte(`{"access_token":"access2","expires_in":3600,"refresh_token":"refresh2"}`)
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+
+	client := &Client{Broker: &Broker{BrokerHost: "http://orion/", IdmType: cKeyrock, IdmHost: "http://idm", Username: "fiware", ClientID: "0000", ClientSecret: "1111"}}
+	hash := getHash(client)
+	ngsi.tokenList[hash] = TokenInfo{Expires: 0, Token: Token{AccessToken: "access1", RefreshToken: "refresh1"}}
+
+	actual, err := ngsi.GetToken(client)
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, "access2", actual)
+		assert.Equal(t, int64(4600), ngsi.tokenList[hash].Expires)
+		assert.Equal(t, "refresh2", ngsi.tokenList[hash].Token.RefreshToken)
+	}
+}
+
+func TestNgsiGetTokenRefreshFallback(t *testing.T) {
+	ngsi := testNgsiLibInit()
+	ngsi.tokenList = tokenInfoList{}
+	filename := ""
+	ngsi.CacheFile = &MockIoLib{filename: &filename}
+	ngsi.LogWriter = &bytes.Buffer{}
+	ngsi.TimeLib = &MockTimeLib{unixTime: 1000}
+	reqRes1 := MockHTTPReqRes{}
+	reqRes1.Res.StatusCode = http.StatusBadRequest
+	reqRes1.ReqData = []byte("grant_type=refresh_token&refresh_token=refresh1&client_id=0000&client_secret=1111")
+	reqRes2 := MockHTTPReqRes{}
+	reqRes2.Res.StatusCode = http.StatusOK
+	reqRes2.ReqData = []byte("grant_type=password&username=fiware&password=1234&client_id=0000&client_secret=1111")
+	reqRes2.ResBody = []byte(`{"access_token":"access2","expires_in":3600,"refresh_token":"refresh2"}`)
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes1, reqRes2)
+	ngsi.HTTP = mock
+
+	client := &Client{Broker: &Broker{BrokerHost: "http://orion/", IdmType: cPasswordCredentials, IdmHost: "http://idm", Username: "fiware", Password: "1234", ClientID: "0000", ClientSecret: "1111"}}
+	hash := getHash(client)
+	ngsi.tokenList[hash] = TokenInfo{Expires: 0, Token: Token{AccessToken: "access1", RefreshToken: "refresh1"}}
+
+	actual, err := ngsi.GetToken(client)
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, "access2", actual)
+		assert.Equal(t, "refresh2", ngsi.tokenList[hash].Token.RefreshToken)
+	}
+}
+
 func TestNgsiGetTokenNotFound(t *testing.T) {
 	ngsi := testNgsiLibInit()
 	ngsi.tokenList = tokenInfoList{}
@@ -367,6 +428,85 @@ func TestGetTokenKeyrock(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestGetTokenClientCredentials(t *testing.T) {
+	ngsi := testNgsiLibInit()
+	ngsi.tokenList = tokenInfoList{}
+	filename := ""
+	ngsi.CacheFile = &MockIoLib{filename: &filename}
+	ngsi.LogWriter = &bytes.Buffer{}
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusOK
+	reqRes.Path = "/realms/fiware/protocol/openid-connect/token"
+	reqRes.ReqData = []byte("audience=orion&client_id=ngsi-go&client_secret=1111&grant_type=client_credentials&scope=openid")
+	reqRes.ResBody = []byte(`{"access_token":"access","expires_in":300,"scope":"openid profile"}`)
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+
+	client := &Client{Broker: &Broker{BrokerHost: "http://orion/", IdmType: cClientCredentials, IdmHost: "http://keycloak/realms/fiware/protocol/openid-connect/token", ClientID: "ngsi-go", ClientSecret: "1111", IdmScope: "openid", Audience: "orion"}}
+
+	actual, err := getToken(ngsi, client)
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, "access", actual)
+		info, ok := ngsi.tokenList[getHash(client)]
+		assert.Equal(t, true, ok)
+		assert.Equal(t, TokenScope{"openid", "profile"}, info.Token.Scope)
+	}
+}
+
+func TestGetTokenOpenIDConnect(t *testing.T) {
+	ngsi := testNgsiLibInit()
+	ngsi.tokenList = tokenInfoList{}
+	filename := ""
+	ngsi.CacheFile = &MockIoLib{filename: &filename}
+	ngsi.LogWriter = &bytes.Buffer{}
+	reqRes1 := MockHTTPReqRes{}
+	reqRes1.Res.StatusCode = http.StatusOK
+	reqRes1.Path = "/realms/fiware/.well-known/openid-configuration"
+	reqRes1.ResBody = []byte(`{"issuer":"http://keycloak/realms/fiware","token_endpoint":"http://keycloak/realms/fiware/protocol/openid-connect/token"}`)
+	reqRes2 := MockHTTPReqRes{}
+	reqRes2.Res.StatusCode = http.StatusOK
+	reqRes2.Path = "/realms/fiware/protocol/openid-connect/token"
+	reqRes2.ReqData = []byte("client_id=ngsi-go&grant_type=password&password=1234&username=fiware")
+	reqRes2.ResBody = []byte(`{"access_token":"access","expires_in":300,"refresh_token":"refresh","scope":"openid email"}`)
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes1, reqRes2)
+	ngsi.HTTP = mock
+
+	client := &Client{Broker: &Broker{BrokerHost: "http://orion/", IdmType: "OIDC", IdmHost: "http://keycloak/realms/fiware/", Username: "fiware", Password: "1234", ClientID: "ngsi-go"}}
+
+	actual, err := getToken(ngsi, client)
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, "access", actual)
+		assert.Equal(t, "refresh", ngsi.tokenList[getHash(client)].Token.RefreshToken)
+		assert.Equal(t, TokenScope{"openid", "email"}, ngsi.tokenList[getHash(client)].Token.Scope)
+	}
+}
+
+func TestGetTokenErrorDiscovery(t *testing.T) {
+	ngsi := testNgsiLibInit()
+	ngsi.tokenList = tokenInfoList{}
+	ngsi.CacheFile = &MockIoLib{}
+	ngsi.LogWriter = &bytes.Buffer{}
+	reqRes := MockHTTPReqRes{}
+	reqRes.Err = errors.New("http error")
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+
+	client := &Client{Broker: &Broker{BrokerHost: "http://orion/", IdmType: cOpenIDConnect, IdmHost: "http://keycloak/realms/fiware", ClientID: "ngsi-go", ClientSecret: "1111"}}
+
+	_, err := getToken(ngsi, client)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*NgsiLibError)
+		assert.Equal(t, 7, ngsiErr.ErrNo)
+		assert.Equal(t, "http error", ngsiErr.Message)
+	}
+}
+
 func TestGetTokenErrorUsername(t *testing.T) {
 	ngsi := testNgsiLibInit()
 	ngsi.tokenList = tokenInfoList{}
@@ -487,7 +627,8 @@ func TestGetTokenErrorSave(t *testing.T) {
 	ngsi := testNgsiLibInit()
 	ngsi.tokenList = tokenInfoList{}
 	filename := "cache-file"
-	ngsi.CacheFile = &MockIoLib{filename: &filename, EncodeErr: errors.New("encode error")}
+	ngsi.CacheFile = &MockIoLib{filename: &filename}
+	ngsi.CacheTmpFile = &MockIoLib{EncodeErr: errors.New("encode error")}
 	ngsi.LogWriter = &bytes.Buffer{}
 	reqRes := MockHTTPReqRes{}
 	reqRes.Res.StatusCode = http.StatusOK
@@ -508,33 +649,258 @@ func TestGetTokenErrorSave(t *testing.T) {
 	}
 }
 
-func TestSaveToken(t *testing.T) {
+func TestRefreshToken(t *testing.T) {
 	ngsi := testNgsiLibInit()
+	ngsi.tokenList = tokenInfoList{}
+	filename := ""
+	ngsi.CacheFile = &MockIoLib{filename: &filename}
+	ngsi.LogWriter = &bytes.Buffer{}
+	ngsi.TimeLib = &MockTimeLib{unixTime: 1000}
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusOK
+	reqRes.ResBody = []byte(`{"access_token":"access2","expires_in":3600}`)
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+	ngsi.tokenList["expired"] = TokenInfo{Expires: 0}
+	ngsi.tokenList["refresh"] = TokenInfo{Expires: 0, Token: Token{RefreshToken: "refresh"}}
+
+	client := &Client{Broker: &Broker{BrokerHost: "http://orion/", IdmType: cKeyrock, IdmHost: "http://idm", Username: "fiware", ClientID: "0000", ClientSecret: "1111"}}
+
+	actual, err := refreshToken(ngsi, client, "refresh1")
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, "access2", actual)
+		assert.Equal(t, "refresh1", ngsi.tokenList[getHash(client)].Token.RefreshToken)
+		assert.Equal(t, 2, len(ngsi.tokenList))
+		_, ok := ngsi.tokenList["refresh"]
+		assert.Equal(t, true, ok)
+	}
+}
+
+func TestRefreshTokenScopeString(t *testing.T) {
+	ngsi := testNgsiLibInit()
+	ngsi.tokenList = tokenInfoList{}
+	filename := ""
+	ngsi.CacheFile = &MockIoLib{filename: &filename}
+	ngsi.LogWriter = &bytes.Buffer{}
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusOK
+	reqRes.ResBody = []byte(`{"access_token":"access2","expires_in":300,"refresh_token":"refresh2","scope":"openid profile"}`)
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+
+	client := &Client{Broker: &Broker{BrokerHost: "http://orion/", IdmType: cKeyrock, IdmHost: "http://idm", Username: "fiware", ClientID: "0000", ClientSecret: "1111"}}
+
+	actual, err := refreshToken(ngsi, client, "refresh1")
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, "access2", actual)
+		assert.Equal(t, TokenScope{"openid", "profile"}, ngsi.tokenList[getHash(client)].Token.Scope)
+	}
+}
+
+func TestRefreshTokenOpenIDConnect(t *testing.T) {
+	ngsi := testNgsiLibInit()
+	ngsi.tokenList = tokenInfoList{}
+	filename := ""
+	ngsi.CacheFile = &MockIoLib{filename: &filename}
+	ngsi.LogWriter = &bytes.Buffer{}
+	reqRes1 := MockHTTPReqRes{}
+	reqRes1.Res.StatusCode = http.StatusOK
+	reqRes1.ResBody = []byte(`{"token_endpoint":"http://keycloak/realms/fiware/protocol/openid-connect/token"}`)
+	reqRes2 := MockHTTPReqRes{}
+	reqRes2.Res.StatusCode = http.StatusOK
+	reqRes2.Path = "/realms/fiware/protocol/openid-connect/token"
+	reqRes2.ReqData = []byte("client_id=ngsi-go&client_secret=1111&grant_type=refresh_token&refresh_token=refresh1")
+	reqRes2.ResBody = []byte(`{"access_token":"access2","expires_in":300,"refresh_token":"refresh2"}`)
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes1, reqRes2)
+	ngsi.HTTP = mock
+
+	client := &Client{Broker: &Broker{BrokerHost: "http://orion/", IdmType: cOpenIDConnect, IdmHost: "http://keycloak/realms/fiware", ClientID: "ngsi-go", ClientSecret: "1111"}}
+
+	actual, err := refreshToken(ngsi, client, "refresh1")
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, "access2", actual)
+		assert.Equal(t, "refresh2", ngsi.tokenList[getHash(client)].Token.RefreshToken)
+	}
+}
+
+func TestRefreshTokenErrorDiscovery(t *testing.T) {
+	ngsi := testNgsiLibInit()
+	ngsi.tokenList = tokenInfoList{}
 	ngsi.CacheFile = &MockIoLib{}
 	ngsi.LogWriter = &bytes.Buffer{}
-	token := &Token{}
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusNotFound
+	reqRes.Res.Status = "404 Not Found"
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+
+	client := &Client{Broker: &Broker{BrokerHost: "http://orion/", IdmType: cOpenIDConnect, IdmHost: "http://keycloak/realms/fiware", ClientID: "ngsi-go", ClientSecret: "1111"}}
+
+	_, err := refreshToken(ngsi, client, "refresh1")
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*NgsiLibError)
+		assert.Equal(t, 6, ngsiErr.ErrNo)
+		assert.Equal(t, "error 404 Not Found ", ngsiErr.Message)
+	}
+}
+
+func TestDiscoverEndpointCache(t *testing.T) {
+	ngsi := testNgsiLibInit()
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusOK
+	reqRes.Path = "/realms/fiware/.well-known/openid-configuration"
+	reqRes.ResBody = []byte(`{"token_endpoint":"http://keycloak/realms/fiware/protocol/openid-connect/token","revocation_endpoint":"http://keycloak/realms/fiware/protocol/openid-connect/revoke"}`)
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+
+	client := &Client{Broker: &Broker{BrokerHost: "http://orion/", IdmType: cOpenIDConnect, IdmHost: "http://keycloak/realms/fiware"}}
+
+	tokenURL, err := discoverEndpoint(ngsi, client, "token_endpoint")
+	assert.NoError(t, err)
+	revokeURL, err := discoverEndpoint(ngsi, client, "revocation_endpoint")
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, "http://keycloak/realms/fiware/protocol/openid-connect/token", tokenURL.String())
+		assert.Equal(t, "http://keycloak/realms/fiware/protocol/openid-connect/revoke", revokeURL.String())
+		assert.Equal(t, 1, mock.index)
+	}
+}
+
+func TestDiscoverEndpointError(t *testing.T) {
+	cases := []struct {
+		idmHost string
+		reqRes  MockHTTPReqRes
+		errNo   int
+		message string
+	}{
+		{idmHost: "http://keycloak/%zz", errNo: 1, message: "parse \"http://keycloak/%zz/.well-known/openid-configuration\": invalid URL escape \"%zz\""},
+		{idmHost: "http://keycloak", reqRes: MockHTTPReqRes{Err: errors.New("http error")}, errNo: 2, message: "http error"},
+		{idmHost: "http://keycloak", reqRes: MockHTTPReqRes{Res: http.Response{StatusCode: http.StatusNotFound, Status: "404 Not Found"}, ResBody: []byte("error")}, errNo: 3, message: "error 404 Not Found error"},
+		{idmHost: "http://keycloak", reqRes: MockHTTPReqRes{Res: http.Response{StatusCode: http.StatusOK}, ResBody: []byte("{}")}, errNo: 4, message: "token_endpoint not found"},
+		{idmHost: "http://keycloak", reqRes: MockHTTPReqRes{Res: http.Response{StatusCode: http.StatusOK}, ResBody: []byte(`{"token_endpoint":"http://keycloak/%zz"}`)}, errNo: 5, message: "parse \"http://keycloak/%zz\": invalid URL escape \"%zz\""},
+	}
+
+	for _, c := range cases {
+		ngsi := testNgsiLibInit()
+		mock := NewMockHTTP()
+		mock.ReqRes = append(mock.ReqRes, c.reqRes)
+		ngsi.HTTP = mock
+
+		client := &Client{Broker: &Broker{BrokerHost: "http://orion/", IdmType: cOpenIDConnect, IdmHost: c.idmHost}}
+
+		_, err := discoverEndpoint(ngsi, client, "token_endpoint")
+
+		if assert.Error(t, err) {
+			ngsiErr := err.(*NgsiLibError)
+			assert.Equal(t, c.errNo, ngsiErr.ErrNo)
+			assert.Equal(t, c.message, ngsiErr.Message)
+		}
+	}
+}
+
+func TestRefreshTokenError(t *testing.T) {
+	cases := []struct {
+		idmType string
+		reqRes  MockHTTPReqRes
+		io      *MockIoLib
+		errNo   int
+		message string
+	}{
+		{idmType: cTokenproxy, errNo: 1, message: "refresh token not supported: tokenproxy"},
+		{idmType: cKeyrock, reqRes: MockHTTPReqRes{Err: errors.New("http error")}, errNo: 2, message: "http error"},
+		{idmType: cKeyrock, reqRes: MockHTTPReqRes{Res: http.Response{StatusCode: http.StatusBadRequest, Status: "400 Bad Request"}, ResBody: []byte("error")}, errNo: 3, message: "error 400 Bad Request error"},
+		{idmType: cKeyrock, reqRes: MockHTTPReqRes{Res: http.Response{StatusCode: http.StatusOK}, ResBody: []byte("{}")}, errNo: 4, message: "access token not found"},
+		{idmType: cKeyrock, reqRes: MockHTTPReqRes{Res: http.Response{StatusCode: http.StatusOK}, ResBody: []byte(`{"access_token":"access"}`)}, io: &MockIoLib{EncodeErr: errors.New("encode error")}, errNo: 5, message: "encode error"},
+	}
+
+	for _, c := range cases {
+		ngsi := testNgsiLibInit()
+		ngsi.tokenList = tokenInfoList{}
+		filename := "cache-file"
+		if c.io == nil {
+			c.io = &MockIoLib{}
+		}
+		c.io.SetFileName(&filename)
+		ngsi.CacheFile = c.io
+		ngsi.CacheTmpFile = &MockIoLib{EncodeErr: c.io.EncodeErr}
+		ngsi.LogWriter = &bytes.Buffer{}
+		mock := NewMockHTTP()
+		mock.ReqRes = append(mock.ReqRes, c.reqRes)
+		ngsi.HTTP = mock
+
+		client := &Client{Broker: &Broker{BrokerHost: "http://orion/", IdmType: c.idmType, IdmHost: "http://idm", Username: "fiware"}}
+
+		_, err := refreshToken(ngsi, client, "refresh")
+
+		if assert.Error(t, err) {
+			ngsiErr := err.(*NgsiLibError)
+			assert.Equal(t, c.errNo, ngsiErr.ErrNo)
+			assert.Equal(t, c.message, ngsiErr.Message)
+		}
+	}
+}
+
+func TestTokenScopeUnmarshalJSON(t *testing.T) {
+	cases := []struct {
+		data     string
+		expected TokenScope
+	}{
+		{data: `{"scope":"openid profile"}`, expected: TokenScope{"openid", "profile"}},
+		{data: `{"scope":["bearer"]}`, expected: TokenScope{"bearer"}},
+		{data: `{"scope":""}`, expected: TokenScope{}},
+		{data: `{}`, expected: nil},
+	}
+
+	for _, c := range cases {
+		var token Token
+		err := json.Unmarshal([]byte(c.data), &token)
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, c.expected, token.Scope)
+		}
+	}
+}
+
+func TestTokenScopeUnmarshalJSONError(t *testing.T) {
+	var token Token
+	err := json.Unmarshal([]byte(`{"scope":1}`), &token)
+
+	assert.Error(t, err)
+}
+
+func TestSaveToken(t *testing.T) {
+	ngsi := testNgsiLibInit()
+	ngsi.CacheTmpFile = &MockIoLib{}
+	ngsi.LogWriter = &bytes.Buffer{}
 
-	err := saveToken("cache-file", token)
+	err := saveToken("cache-file", tokenInfoList{})
 	assert.NoError(t, err)
 }
 
 func TestSaveTokenNoFileName(t *testing.T) {
 	ngsi := testNgsiLibInit()
-	ngsi.CacheFile = &MockIoLib{}
+	ngsi.CacheTmpFile = &MockIoLib{}
 	ngsi.LogWriter = &bytes.Buffer{}
-	token := &Token{}
 
-	err := saveToken("", token)
+	err := saveToken("", tokenInfoList{})
 	assert.NoError(t, err)
 }
 
 func TestSaveTokenErrorOpenFile(t *testing.T) {
 	ngsi := testNgsiLibInit()
-	ngsi.CacheFile = &MockIoLib{OpenErr: errors.New("open error")}
+	ngsi.CacheTmpFile = &MockIoLib{OpenErr: errors.New("open error")}
 	ngsi.LogWriter = &bytes.Buffer{}
-	token := &Token{}
 
-	err := saveToken("cache-file", token)
+	err := saveToken("cache-file", tokenInfoList{})
 	if assert.Error(t, err) {
 		ngsiErr := err.(*NgsiLibError)
 		assert.Equal(t, 1, ngsiErr.ErrNo)
@@ -544,11 +910,10 @@ func TestSaveTokenErrorOpenFile(t *testing.T) {
 
 func TestSaveTokenErrorTruncate(t *testing.T) {
 	ngsi := testNgsiLibInit()
-	ngsi.CacheFile = &MockIoLib{TruncateErr: errors.New("truncate error")}
+	ngsi.CacheTmpFile = &MockIoLib{TruncateErr: errors.New("truncate error")}
 	ngsi.LogWriter = &bytes.Buffer{}
-	token := &Token{}
 
-	err := saveToken("cache-file", token)
+	err := saveToken("cache-file", tokenInfoList{})
 	if assert.Error(t, err) {
 		ngsiErr := err.(*NgsiLibError)
 		assert.Equal(t, 2, ngsiErr.ErrNo)
@@ -558,11 +923,10 @@ func TestSaveTokenErrorTruncate(t *testing.T) {
 
 func TestSaveTokenErrorEncode(t *testing.T) {
 	ngsi := testNgsiLibInit()
-	ngsi.CacheFile = &MockIoLib{EncodeErr: errors.New("encode error")}
+	ngsi.CacheTmpFile = &MockIoLib{EncodeErr: errors.New("encode error")}
 	ngsi.LogWriter = &bytes.Buffer{}
-	token := &Token{}
 
-	err := saveToken("cache-file", token)
+	err := saveToken("cache-file", tokenInfoList{})
 	if assert.Error(t, err) {
 		ngsiErr := err.(*NgsiLibError)
 		assert.Equal(t, 3, ngsiErr.ErrNo)
@@ -570,16 +934,391 @@ func TestSaveTokenErrorEncode(t *testing.T) {
 	}
 }
 
+func TestSaveTokenErrorRename(t *testing.T) {
+	ngsi := testNgsiLibInit()
+	ngsi.CacheTmpFile = &MockIoLib{RenameErr: errors.New("rename error")}
+	ngsi.LogWriter = &bytes.Buffer{}
+
+	err := saveToken("cache-file", tokenInfoList{})
+	if assert.Error(t, err) {
+		ngsiErr := err.(*NgsiLibError)
+		assert.Equal(t, 4, ngsiErr.ErrNo)
+		assert.Equal(t, "rename error", ngsiErr.Message)
+	}
+}
+
+func TestUpdateTokenListMerge(t *testing.T) {
+	ngsi := testNgsiLibInit()
+	ngsi.LogWriter = &bytes.Buffer{}
+	filename := filepath.Join(t.TempDir(), cacheFileName)
+	ngsi.CacheFile = &ioLib{}
+	ngsi.CacheFile.SetFileName(&filename)
+	ngsi.CacheTmpFile = &ioLib{}
+	ngsi.tokenList = tokenInfoList{}
+
+	err := updateTokenList(ngsi, func(list tokenInfoList) { list["token1"] = TokenInfo{Host: "orion"} })
+	assert.NoError(t, err)
+
+	// another process which has loaded the cache file before token1 was stored
+	ngsi.tokenList = tokenInfoList{}
+	err = updateTokenList(ngsi, func(list tokenInfoList) { list["token2"] = TokenInfo{Host: "orion-ld"} })
+	assert.NoError(t, err)
+
+	list, err := loadTokenList(ngsi.CacheFile)
+	if assert.NoError(t, err) {
+		assert.Equal(t, tokenInfoList{"token1": TokenInfo{Host: "orion"}, "token2": TokenInfo{Host: "orion-ld"}}, list)
+		assert.Equal(t, list, ngsi.tokenList)
+	}
+	_, err = os.Stat(filename + ".tmp")
+	assert.Error(t, err)
+}
+
+func TestUpdateTokenListError(t *testing.T) {
+	cases := []struct {
+		io      *MockIoLib
+		errNo   int
+		message string
+	}{
+		{io: &MockIoLib{LockErr: errors.New("lock error")}, errNo: 1, message: "lock error"},
+		{io: &MockIoLib{OpenErr: errors.New("open error")}, errNo: 2, message: "open error"},
+		{io: &MockIoLib{StatErr: errors.New("not found"), EncodeErr: errors.New("encode error")}, errNo: 3, message: "encode error"},
+	}
+
+	for _, c := range cases {
+		ngsi := testNgsiLibInit()
+		ngsi.LogWriter = &bytes.Buffer{}
+		filename := "cache-file"
+		c.io.SetFileName(&filename)
+		ngsi.CacheFile = c.io
+		ngsi.CacheTmpFile = &MockIoLib{EncodeErr: c.io.EncodeErr}
+
+		err := updateTokenList(ngsi, func(list tokenInfoList) {})
+
+		if assert.Error(t, err) {
+			ngsiErr := err.(*NgsiLibError)
+			assert.Equal(t, c.errNo, ngsiErr.ErrNo)
+			assert.Equal(t, c.message, ngsiErr.Message)
+		}
+	}
+}
+
+func TestTokenInfoList(t *testing.T) {
+	ngsi := testNgsiLibInit()
+	ngsi.tokenList = tokenInfoList{}
+	ngsi.tokenList["token1"] = TokenInfo{Host: "orion-ld", Username: "fiware"}
+	ngsi.tokenList["token2"] = TokenInfo{Host: "orion", Username: "fiware"}
+	ngsi.tokenList["token3"] = TokenInfo{Host: "orion", Username: "admin"}
+
+	actual := ngsi.TokenInfoList()
+
+	expected := []TokenInfo{{Host: "orion", Username: "admin"}, {Host: "orion", Username: "fiware"}, {Host: "orion-ld", Username: "fiware"}}
+	assert.Equal(t, expected, actual)
+}
+
+func TestRevokeToken(t *testing.T) {
+	ngsi := testNgsiLibInit()
+	ngsi.LogWriter = &bytes.Buffer{}
+	filename := ""
+	ngsi.CacheFile = &MockIoLib{filename: &filename}
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusOK
+	reqRes.Path = "/oauth2/revoke"
+	reqRes.ReqData = []byte("token=refresh&token_type_hint=refresh_token")
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+
+	client := &Client{Host: "orion", Broker: &Broker{BrokerHost: "http://orion/", IdmType: cKeyrock, IdmHost: "http://keyrock/oauth2/token", Username: "fiware", ClientID: "0000", ClientSecret: "1111"}}
+	ngsi.tokenList = tokenInfoList{}
+	ngsi.tokenList[getHash(client)] = TokenInfo{Token: Token{AccessToken: "access", RefreshToken: "refresh"}}
+	ngsi.tokenList["token1"] = TokenInfo{}
+
+	err := ngsi.RevokeToken(client)
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, 1, len(ngsi.tokenList))
+	}
+}
+
+func TestRevokeTokenError(t *testing.T) {
+	cases := []struct {
+		cached  bool
+		reqRes  MockHTTPReqRes
+		io      *MockIoLib
+		errNo   int
+		message string
+	}{
+		{cached: false, errNo: 1, message: "token not found"},
+		{cached: true, reqRes: MockHTTPReqRes{Err: errors.New("http error")}, errNo: 2, message: "http error"},
+		{cached: true, reqRes: MockHTTPReqRes{Res: http.Response{StatusCode: http.StatusOK}}, io: &MockIoLib{LockErr: errors.New("lock error")}, errNo: 3, message: "lock error"},
+	}
+
+	for _, c := range cases {
+		ngsi := testNgsiLibInit()
+		ngsi.LogWriter = &bytes.Buffer{}
+		filename := "cache-file"
+		if c.io == nil {
+			c.io = &MockIoLib{}
+		}
+		c.io.SetFileName(&filename)
+		ngsi.CacheFile = c.io
+		mock := NewMockHTTP()
+		mock.ReqRes = append(mock.ReqRes, c.reqRes)
+		ngsi.HTTP = mock
+
+		client := &Client{Host: "orion", Broker: &Broker{BrokerHost: "http://orion/", IdmType: cPasswordCredentials, IdmHost: "http://keycloak/token", Username: "fiware", ClientID: "0000", ClientSecret: "1111"}}
+		ngsi.tokenList = tokenInfoList{}
+		if c.cached {
+			ngsi.tokenList[getHash(client)] = TokenInfo{Token: Token{AccessToken: "access"}}
+		}
+
+		err := ngsi.RevokeToken(client)
+
+		if assert.Error(t, err) {
+			ngsiErr := err.(*NgsiLibError)
+			assert.Equal(t, c.errNo, ngsiErr.ErrNo)
+			assert.Equal(t, c.message, ngsiErr.Message)
+		}
+	}
+}
+
+func TestClearTokens(t *testing.T) {
+	ngsi := testNgsiLibInit()
+	ngsi.LogWriter = &bytes.Buffer{}
+	filename := ""
+	ngsi.CacheFile = &MockIoLib{filename: &filename}
+	ngsi.tokenList = tokenInfoList{}
+	ngsi.tokenList["token1"] = TokenInfo{}
+	ngsi.tokenList["token2"] = TokenInfo{}
+
+	err := ngsi.ClearTokens()
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, 0, len(ngsi.tokenList))
+	}
+}
+
+func TestClearTokensError(t *testing.T) {
+	ngsi := testNgsiLibInit()
+	ngsi.LogWriter = &bytes.Buffer{}
+	filename := "cache-file"
+	ngsi.CacheFile = &MockIoLib{filename: &filename, LockErr: errors.New("lock error")}
+
+	err := ngsi.ClearTokens()
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*NgsiLibError)
+		assert.Equal(t, 1, ngsiErr.ErrNo)
+		assert.Equal(t, "lock error", ngsiErr.Message)
+	}
+}
+
+func TestRevokeTokenOAuth2(t *testing.T) {
+	cases := []struct {
+		broker  *Broker
+		reqRes  []MockHTTPReqRes
+		request int
+	}{
+		{broker: &Broker{IdmType: cTokenproxy, IdmHost: "http://tokenproxy/token"}, request: 0},
+		{broker: &Broker{IdmType: cClientCredentials, IdmHost: "http://keycloak/auth"}, request: 0},
+		{broker: &Broker{IdmType: cClientCredentials, IdmHost: "http://keycloak/protocol/openid-connect/token", ClientID: "ngsi-go"},
+			reqRes: []MockHTTPReqRes{{Res: http.Response{StatusCode: http.StatusOK}, Path: "/protocol/openid-connect/revoke", ReqData: []byte("client_id=ngsi-go&token=access&token_type_hint=access_token")}}, request: 1},
+		{broker: &Broker{IdmType: cOpenIDConnect, IdmHost: "http://keycloak/realms/fiware", ClientID: "ngsi-go", ClientSecret: "1111"},
+			reqRes: []MockHTTPReqRes{
+				{Res: http.Response{StatusCode: http.StatusOK}, ResBody: []byte(`{"revocation_endpoint":"http://keycloak/realms/fiware/protocol/openid-connect/revoke"}`)},
+				{Res: http.Response{StatusCode: http.StatusOK}, Path: "/realms/fiware/protocol/openid-connect/revoke", ReqData: []byte("client_id=ngsi-go&client_secret=1111&token=access&token_type_hint=access_token")},
+			}, request: 2},
+	}
+
+	for _, c := range cases {
+		ngsi := testNgsiLibInit()
+		ngsi.LogWriter = &bytes.Buffer{}
+		mock := NewMockHTTP()
+		mock.ReqRes = c.reqRes
+		ngsi.HTTP = mock
+
+		c.broker.BrokerHost = "http://orion/"
+		client := &Client{Broker: c.broker}
+
+		err := revokeToken(ngsi, client, &Token{AccessToken: "access"})
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, c.request, mock.index)
+		}
+	}
+}
+
+func TestRevokeTokenErrorOAuth2(t *testing.T) {
+	cases := []struct {
+		idmType string
+		reqRes  MockHTTPReqRes
+		errNo   int
+		message string
+	}{
+		{idmType: cOpenIDConnect, reqRes: MockHTTPReqRes{Res: http.Response{StatusCode: http.StatusOK}, ResBody: []byte("{}")}, errNo: 1, message: "revocation_endpoint not found"},
+		{idmType: cKeyrock, reqRes: MockHTTPReqRes{Res: http.Response{StatusCode: http.StatusBadRequest, Status: "400 Bad Request"}, ResBody: []byte("error")}, errNo: 3, message: "error 400 Bad Request error"},
+	}
+
+	for _, c := range cases {
+		ngsi := testNgsiLibInit()
+		ngsi.LogWriter = &bytes.Buffer{}
+		mock := NewMockHTTP()
+		mock.ReqRes = append(mock.ReqRes, c.reqRes)
+		ngsi.HTTP = mock
+
+		client := &Client{Broker: &Broker{BrokerHost: "http://orion/", IdmType: c.idmType, IdmHost: "http://idm/oauth2/token"}}
+
+		err := revokeToken(ngsi, client, &Token{AccessToken: "access"})
+
+		if assert.Error(t, err) {
+			ngsiErr := err.(*NgsiLibError)
+			assert.Equal(t, c.errNo, ngsiErr.ErrNo)
+			assert.Equal(t, c.message, ngsiErr.Message)
+		}
+	}
+}
+
+func TestIntrospectToken(t *testing.T) {
+	ngsi := testNgsiLibInit()
+	ngsi.LogWriter = &bytes.Buffer{}
+	reqRes := MockHTTPReqRes{}
+	reqRes.Res.StatusCode = http.StatusOK
+	reqRes.Path = "/user"
+	reqRes.ResBody = []byte(`{"id":"admin","username":"fiware"}`)
+	mock := NewMockHTTP()
+	mock.ReqRes = append(mock.ReqRes, reqRes)
+	ngsi.HTTP = mock
+
+	client := &Client{Host: "orion", Broker: &Broker{BrokerHost: "http://orion/", IdmType: cKeyrock, IdmHost: "http://keyrock/oauth2/token", Username: "fiware", ClientID: "0000", ClientSecret: "1111"}}
+	ngsi.tokenList = tokenInfoList{}
+	ngsi.tokenList[getHash(client)] = TokenInfo{Token: Token{AccessToken: "access"}}
+
+	actual, err := ngsi.IntrospectToken(client)
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, `{"id":"admin","username":"fiware"}`, string(actual))
+	}
+}
+
+func TestIntrospectTokenError(t *testing.T) {
+	cases := []struct {
+		cached  bool
+		errNo   int
+		message string
+	}{
+		{cached: false, errNo: 1, message: "token not found"},
+		{cached: true, errNo: 2, message: "introspection not supported: tokenproxy"},
+	}
+
+	for _, c := range cases {
+		ngsi := testNgsiLibInit()
+		ngsi.LogWriter = &bytes.Buffer{}
+
+		client := &Client{Host: "orion", Broker: &Broker{BrokerHost: "http://orion/", IdmType: cTokenproxy, IdmHost: "http://tokenproxy/token", Username: "fiware"}}
+		ngsi.tokenList = tokenInfoList{}
+		if c.cached {
+			ngsi.tokenList[getHash(client)] = TokenInfo{Token: Token{AccessToken: "access"}}
+		}
+
+		_, err := ngsi.IntrospectToken(client)
+
+		if assert.Error(t, err) {
+			ngsiErr := err.(*NgsiLibError)
+			assert.Equal(t, c.errNo, ngsiErr.ErrNo)
+			assert.Equal(t, c.message, ngsiErr.Message)
+		}
+	}
+}
+
+func TestIntrospectTokenOAuth2(t *testing.T) {
+	cases := []struct {
+		broker *Broker
+		reqRes []MockHTTPReqRes
+	}{
+		{broker: &Broker{IdmType: cPasswordCredentials, IdmHost: "http://idm/oauth2/token", ClientID: "ngsi-go", ClientSecret: "1111"},
+			reqRes: []MockHTTPReqRes{{Res: http.Response{StatusCode: http.StatusOK}, Path: "/oauth2/token/introspect", ReqData: []byte("client_id=ngsi-go&client_secret=1111&token=access&token_type_hint=access_token"), ResBody: []byte(`{"active":true}`)}}},
+		{broker: &Broker{IdmType: cClientCredentials, IdmHost: "http://keycloak/realms/fiware/protocol/openid-connect/token", ClientID: "ngsi-go", ClientSecret: "1111"},
+			reqRes: []MockHTTPReqRes{{Res: http.Response{StatusCode: http.StatusOK}, Path: "/realms/fiware/protocol/openid-connect/token/introspect", ReqData: []byte("client_id=ngsi-go&client_secret=1111&token=access&token_type_hint=access_token"), ResBody: []byte(`{"active":true}`)}}},
+		{broker: &Broker{IdmType: cOpenIDConnect, IdmHost: "http://keycloak/realms/fiware", ClientID: "ngsi-go"},
+			reqRes: []MockHTTPReqRes{
+				{Res: http.Response{StatusCode: http.StatusOK}, ResBody: []byte(`{"introspection_endpoint":"http://keycloak/realms/fiware/protocol/openid-connect/token/introspect"}`)},
+				{Res: http.Response{StatusCode: http.StatusOK}, Path: "/realms/fiware/protocol/openid-connect/token/introspect", ReqData: []byte("client_id=ngsi-go&token=access&token_type_hint=access_token"), ResBody: []byte(`{"active":true}`)},
+			}},
+	}
+
+	for _, c := range cases {
+		ngsi := testNgsiLibInit()
+		ngsi.LogWriter = &bytes.Buffer{}
+		mock := NewMockHTTP()
+		mock.ReqRes = c.reqRes
+		ngsi.HTTP = mock
+
+		c.broker.BrokerHost = "http://orion/"
+		client := &Client{Broker: c.broker}
+
+		actual, err := introspectToken(ngsi, client, &Token{AccessToken: "access"})
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, `{"active":true}`, string(actual))
+		}
+	}
+}
+
+func TestIntrospectTokenErrorOAuth2(t *testing.T) {
+	cases := []struct {
+		idmType string
+		idmHost string
+		reqRes  MockHTTPReqRes
+		errNo   int
+		message string
+	}{
+		{idmType: cKeyrock, idmHost: "http://%zz", errNo: 1, message: "url error: http://%zz"},
+		{idmType: cClientCredentials, idmHost: "http://%zz", errNo: 1, message: "url error: http://%zz"},
+		{idmType: cOpenIDConnect, reqRes: MockHTTPReqRes{Res: http.Response{StatusCode: http.StatusOK}, ResBody: []byte("{}")}, errNo: 2, message: "introspection_endpoint not found"},
+		{idmType: cKeyrocktokenprovider, errNo: 3, message: "introspection not supported: keyrocktokenprovider"},
+		{idmType: cKeyrock, reqRes: MockHTTPReqRes{Err: errors.New("http error")}, errNo: 4, message: "http error"},
+		{idmType: cClientCredentials, reqRes: MockHTTPReqRes{Res: http.Response{StatusCode: http.StatusUnauthorized, Status: "401 Unauthorized"}, ResBody: []byte("error")}, errNo: 5, message: "error 401 Unauthorized error"},
+	}
+
+	for _, c := range cases {
+		ngsi := testNgsiLibInit()
+		ngsi.LogWriter = &bytes.Buffer{}
+		mock := NewMockHTTP()
+		mock.ReqRes = append(mock.ReqRes, c.reqRes)
+		ngsi.HTTP = mock
+
+		if c.idmHost == "" {
+			c.idmHost = "http://idm/oauth2/token"
+		}
+		client := &Client{Broker: &Broker{BrokerHost: "http://orion/", IdmType: c.idmType, IdmHost: c.idmHost}}
+
+		_, err := introspectToken(ngsi, client, &Token{AccessToken: "access"})
+
+		if assert.Error(t, err) {
+			ngsiErr := err.(*NgsiLibError)
+			assert.Equal(t, c.errNo, ngsiErr.ErrNo)
+			assert.Equal(t, c.message, ngsiErr.Message)
+		}
+	}
+}
+
 func TestGetHash(t *testing.T) {
 	client := &Client{Broker: &Broker{BrokerHost: "http://orion/", Username: "fiware"}}
 
 	actual := getHash(client)
-	expected := "583a5c111b603ff8925585f48503e343403115f9"
+	expected := "b030abf88086cb8c650fcf34a02a75e170061753"
 
 	assert.Equal(t, expected, actual)
 
 }
 
+func TestGetHashClientID(t *testing.T) {
+	client := &Client{Broker: &Broker{BrokerHost: "http://orion/", ClientID: "ngsi-go"}}
+	client2 := &Client{Broker: &Broker{BrokerHost: "http://orion/", ClientID: "ngsi-go2"}}
+
+	assert.NotEqual(t, getHash(client), getHash(client2))
+}
+
 func TestGetUserName(t *testing.T) {
 	client := &Client{Broker: &Broker{Username: "fiware"}}
 
@@ -591,6 +1330,41 @@ func TestGetUserName(t *testing.T) {
 
 }
 
+func TestBasicAuthorization(t *testing.T) {
+	_ = testNgsiLibInit()
+	client := &Client{Broker: &Broker{IdmType: cBasic, Username: "fiware", Password: "1234"}}
+
+	actual, err := basicAuthorization(client)
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, "Basic Zml3YXJlOjEyMzQ=", actual)
+	}
+}
+
+func TestBasicAuthorizationError(t *testing.T) {
+	cases := []struct {
+		username string
+		errNo    int
+		message  string
+	}{
+		{username: "", errNo: 1, message: "username is required"},
+		{username: "fiware", errNo: 2, message: "password is required"},
+	}
+
+	for _, c := range cases {
+		_ = testNgsiLibInit()
+		client := &Client{Broker: &Broker{BrokerHost: "http://orion", IdmType: cBasic, Username: c.username}}
+
+		_, err := basicAuthorization(client)
+
+		if assert.Error(t, err) {
+			ngsiErr := err.(*NgsiLibError)
+			assert.Equal(t, c.errNo, ngsiErr.ErrNo)
+			assert.Equal(t, c.message, ngsiErr.Message)
+		}
+	}
+}
+
 func TestGetPassword(t *testing.T) {
 	client := &Client{Broker: &Broker{Password: "12345"}}
 
@@ -601,3 +1375,56 @@ func TestGetPassword(t *testing.T) {
 	}
 
 }
+
+func TestGetPasswordCredential(t *testing.T) {
+	ngsi := setupCredentialStore(t, "secret", "secret")
+	client := &Client{Broker: &Broker{IdmType: "keyrock", IdmHost: "http://keyrock", Username: "fiware"}}
+	_ = ngsi.SetCredential(client, "1234")
+
+	actual, err := getPassword(client)
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, "1234", actual)
+	}
+}
+
+func TestGetPasswordTerminal(t *testing.T) {
+	ngsi := testNgsiLibInit()
+	ngsi.TermLib = &MockTermLib{Terminal: true, Password: []string{"1234"}}
+	client := &Client{Broker: &Broker{IdmType: "keyrock", IdmHost: "http://keyrock", Username: "fiware"}}
+
+	actual, err := getPassword(client)
+
+	if assert.NoError(t, err) {
+		assert.Equal(t, "1234", actual)
+		assert.Equal(t, []string{"Password for fiware: "}, ngsi.TermLib.(*MockTermLib).Prompts)
+	}
+}
+
+func TestGetPasswordErrorCredential(t *testing.T) {
+	ngsi := setupCredentialStore(t, "secret", "wrong")
+	client := &Client{Broker: &Broker{IdmType: "keyrock", IdmHost: "http://keyrock", Username: "fiware"}}
+	_ = ngsi.SetCredential(client, "1234")
+
+	_, err := getPassword(client)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*NgsiLibError)
+		assert.Equal(t, 2, ngsiErr.ErrNo)
+		assert.Equal(t, "passphrase is incorrect", ngsiErr.Message)
+	}
+}
+
+func TestGetPasswordErrorTerminal(t *testing.T) {
+	ngsi := testNgsiLibInit()
+	ngsi.TermLib = &MockTermLib{Terminal: true, Err: errors.New("read error")}
+	client := &Client{Broker: &Broker{IdmType: "keyrock", IdmHost: "http://keyrock", Username: "fiware"}}
+
+	_, err := getPassword(client)
+
+	if assert.Error(t, err) {
+		ngsiErr := err.(*NgsiLibError)
+		assert.Equal(t, 3, ngsiErr.ErrNo)
+		assert.Equal(t, "read error", ngsiErr.Message)
+	}
+}